@@ -1,159 +1,674 @@
 package main
 
 import (
+	"cmp"
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
+	"maps"
 	"os"
 	"os/signal"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
+	"time"
 
 	"github.com/oulman/tfc-agent-autoscaler/internal/config"
 	"github.com/oulman/tfc-agent-autoscaler/internal/ecs"
 	"github.com/oulman/tfc-agent-autoscaler/internal/health"
 	"github.com/oulman/tfc-agent-autoscaler/internal/metrics"
+	"github.com/oulman/tfc-agent-autoscaler/internal/notify"
 	"github.com/oulman/tfc-agent-autoscaler/internal/scaler"
 	"github.com/oulman/tfc-agent-autoscaler/internal/tfc"
 )
 
+// Build information, set at link time with
+// -ldflags "-X main.version=... -X main.commit=... -X main.date=...".
+var (
+	version = "dev"
+	commit  = "unknown"
+	date    = "unknown"
+)
+
 func main() {
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	if len(os.Args) > 1 && (os.Args[1] == "--version" || os.Args[1] == "-version") {
+		fmt.Printf("autoscaler %s (commit %s, built %s)\n", version, commit, date)
+		return
+	}
+
+	// Logging starts as JSON at Info so config errors are logged, then
+	// follows LOG_LEVEL and LOG_FORMAT once the config is loaded.
+	level := new(slog.LevelVar)
+	logger := newLogger(config.LogFormatJSON, level)
+	logger.Info("starting autoscaler", "version", version, "commit", commit, "date", date)
 
 	cfg, err := config.Load()
 	if err != nil {
 		logger.Error("failed to load config", "error", err)
 		os.Exit(1)
 	}
+	level.Set(cfg.LogLevel)
+	logger = newLogger(cfg.LogFormat, level)
+	logger.Info("effective configuration", "config", cfg)
+	warnAgentLimit(logger, cfg)
+	if cfg.HealthPauseControl && !strings.HasPrefix(cfg.HealthAddr, "unix:") {
+		logger.Warn("HEALTH_PAUSE_CONTROL exposes unauthenticated POST /pause on a TCP listener; anyone who can reach it can stop scaling",
+			"health_addr", cfg.HealthAddr,
+		)
+	}
 
 	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
 	defer cancel()
 
-	tfcClient, err := tfc.New(cfg.TFCToken, cfg.TFCAddress, cfg.TFCAgentPoolID)
+	m := metrics.New(
+		metrics.WithNamespace(cfg.MetricsNamespace),
+		metrics.WithConstLabels(cfg.MetricsLabels),
+		metrics.WithRuntimeMetrics(cfg.RuntimeMetrics),
+	)
+
+	notifier := newScaleNotifier(cfg, logger, m)
+
+	code := run(ctx, logger, cfg, m, notifier)
+	if notifier != nil {
+		// Let notifications of the last scaling actions, such as the drain
+		// on shutdown, finish before exiting.
+		notifier.Wait()
+	}
+	if code != 0 {
+		os.Exit(code)
+	}
+}
+
+// run starts the scalers the config describes and returns the process exit
+// code once they have stopped.
+func run(ctx context.Context, logger *slog.Logger, cfg config.Config, m *metrics.Metrics, notifier *notify.Webhook) int {
+	if len(cfg.Pools) > 0 {
+		return runMultiPool(ctx, logger, cfg, m, notifier)
+	}
+
+	// Services sharing the pool share the client, so its rate limiting is
+	// counted under "default".
+	tfcClient, err := newTFCClient(ctx, cfg, logger, cfg.TFCToken, cfg.TFCAgentPoolID, m.ForService("default"))
 	if err != nil {
 		logger.Error("failed to create TFC client", "error", err)
-		os.Exit(1)
+		return 1
+	}
+	if cfg.TFCAgentPoolName != "" {
+		logger.Info("resolved agent pool", "name", cfg.TFCAgentPoolName, "agent_pool_id", tfcClient.AgentPoolID())
 	}
 
-	m := metrics.New()
-
-	if cfg.SpotService != nil {
-		runDualService(ctx, logger, cfg, tfcClient, m)
-	} else {
-		runSingleService(ctx, logger, cfg, tfcClient, m)
+	if len(cfg.ServiceList()) > 0 {
+		return runServices(ctx, logger, cfg, tfcClient, m, notifier)
 	}
+	return runSingleService(ctx, logger, cfg, tfcClient, m, notifier)
 }
 
-func runSingleService(ctx context.Context, logger *slog.Logger, cfg config.Config, tfcClient *tfc.Client, m *metrics.Metrics) {
-	ecsClient, err := ecs.New(ctx, cfg.ECSCluster, cfg.ECSService)
+func runSingleService(ctx context.Context, logger *slog.Logger, cfg config.Config, tfcClient *tfc.Client, m *metrics.Metrics, notifier *notify.Webhook) int {
+	// The health server stops with the scaler, even when the scaler fails.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	ecsClient, err := ecs.New(ctx, cfg.ECSCluster, cfg.ECSService, ecsOptions(cfg)...)
 	if err != nil {
 		logger.Error("failed to create ECS client", "error", err)
-		os.Exit(1)
+		return 1
+	}
+	if err := preflight(ctx, cfg, tfcClient, ecsClient); err != nil {
+		logger.Error("preflight check failed", "error", err)
+		return 1
 	}
 
+	var client scaler.TFCClient = tfcClient
+	if cfg.FilterServiceAgents {
+		client = tfc.NewServiceView(tfcClient, tfc.RunTypeAll, taskIPsFetcher(ecsClient),
+			tfc.WithAgentNamePrefix(cfg.AgentNamePrefix),
+		)
+	}
+
+	paused := newPauseFlag(cfg)
+	trigger := make(chan struct{}, 1)
 	s := scaler.New("default",
-		tfcClient,
+		client,
 		ecsClient,
 		cfg.MinAgents,
 		cfg.MaxAgents,
 		cfg.PollInterval,
 		cfg.CooldownPeriod,
 		logger,
+		scalerOptions(cfg, trigger, scaler.NewBudget(cfg.TotalMaxAgents), paused, notifier)...,
 	)
 	s.SetMetrics(m.ForService("default"))
 
-	healthSrv := health.NewServer(cfg.HealthAddr, health.NewChannelProbe(s.Ready()), health.WithMetricsHandler(m.Handler()))
-	go func() {
-		if err := healthSrv.Run(ctx); err != nil {
-			logger.Error("health server error", "error", err)
-		}
-	}()
+	watchReload(ctx, logger, cfg, func(next config.Config) {
+		s.UpdateSettings(scalerSettings(next, next.MinAgents, next.MaxAgents, next.CooldownPeriod))
+	})
 
-	if err := s.Run(ctx); err != nil {
-		if errors.Is(err, context.Canceled) {
-			logger.Info("autoscaler stopped", "reason", err)
-		} else {
-			logger.Error("autoscaler failed", "error", err)
-		}
+	states := map[string]health.StateFunc{"default": scalerState(s)}
+	healthSrv := health.NewServer(cfg.HealthAddr, health.NewNamedProbe("default", health.NewChannelProbe(s.Ready())), healthOptions(cfg, m, states, paused, trigger)...)
+	waitHealth := runHealthServer(ctx, logger, healthSrv)
+
+	err = s.Run(ctx)
+	cancel()
+	waitHealth()
+	switch {
+	case err == nil:
+	case errors.Is(err, context.Canceled):
+		logger.Info("autoscaler stopped", "reason", err)
+	default:
+		logger.Error("autoscaler failed", "error", err)
+		return 1
 	}
+	return 0
 }
 
-func runDualService(ctx context.Context, logger *slog.Logger, cfg config.Config, tfcClient *tfc.Client, m *metrics.Metrics) {
-	regularECS, err := ecs.New(ctx, cfg.ECSCluster, cfg.ECSService)
-	if err != nil {
-		logger.Error("failed to create regular ECS client", "error", err)
-		os.Exit(1)
+func runServices(ctx context.Context, logger *slog.Logger, cfg config.Config, tfcClient *tfc.Client, m *metrics.Metrics, notifier *notify.Webhook) int {
+	// A scaler that fails stops the others so the process can exit non-zero.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	services := cfg.ServiceList()
+	ecsClients := make([]*ecs.Client, len(services))
+	for i, service := range services {
+		ecsClient, err := ecs.New(ctx, cfg.ECSCluster, service.ECSService, ecsOptions(cfg)...)
+		if err != nil {
+			logger.Error("failed to create ECS client", "service", service.Name, "error", err)
+			return 1
+		}
+		ecsClients[i] = ecsClient
+	}
+	if err := preflight(ctx, cfg, tfcClient, ecsClients...); err != nil {
+		logger.Error("preflight check failed", "error", err)
+		return 1
 	}
 
-	spotECS, err := ecs.New(ctx, cfg.ECSCluster, cfg.SpotService.ECSService)
+	views, err := serviceViews(tfcClient, services, func(i int) tfc.TaskIPsFunc {
+		return taskIPsFetcher(ecsClients[i])
+	})
 	if err != nil {
-		logger.Error("failed to create spot ECS client", "error", err)
-		os.Exit(1)
+		logger.Error("failed to build service views", "error", err)
+		return 1
 	}
 
-	regularView := tfc.NewServiceView(tfcClient, tfc.RunTypeApply, taskIPsFetcher(regularECS))
-	spotView := tfc.NewServiceView(tfcClient, tfc.RunTypePlan, taskIPsFetcher(spotECS))
+	budget := scaler.NewBudget(cfg.TotalMaxAgents)
+	paused := newPauseFlag(cfg)
 
-	regularScaler := scaler.New("regular",
-		regularView,
-		regularECS,
-		cfg.MinAgents,
-		cfg.MaxAgents,
-		cfg.PollInterval,
-		cfg.CooldownPeriod,
-		logger,
-	)
-	regularScaler.SetMetrics(m.ForService("regular"))
+	scalers := make([]*scaler.Scaler, len(services))
+	triggers := make([]chan struct{}, len(services))
+	for i := range services {
+		triggers[i] = make(chan struct{}, 1)
+	}
 
-	spotScaler := scaler.New("spot",
-		spotView,
-		spotECS,
-		cfg.SpotService.MinAgents,
-		cfg.SpotService.MaxAgents,
-		cfg.PollInterval,
-		cfg.CooldownPeriod,
-		logger,
-	)
-	spotScaler.SetMetrics(m.ForService("spot"))
+	// Spot capacity lost to interruptions is made up on the first non-spot
+	// service right away rather than at its next poll.
+	var onSpotInterruption func(n int)
+	if target := compensationTarget(services); cfg.SpotInterruptionCompensation && target >= 0 {
+		onSpotInterruption = func(n int) {
+			scalers[target].Compensate(n)
+			select {
+			case triggers[target] <- struct{}{}:
+			default:
+			}
+		}
+	}
 
-	probe := health.NewCompositeProbe(
-		health.NewChannelProbe(regularScaler.Ready()),
-		health.NewChannelProbe(spotScaler.Ready()),
-	)
+	probes := make([]health.ReadinessProbe, len(services))
+	states := make(map[string]health.StateFunc, len(services))
+	names := make([]string, len(services))
+	runners := make([]runner, len(services))
+	sendTriggers := make([]chan<- struct{}, len(services))
+	for i, service := range services {
+		opts := scalerOptions(cfg, triggers[i], budget, paused, notifier)
+		if service.Spot {
+			opts = append(opts, scaler.WithSpotInterruptions(onSpotInterruption))
+		}
+		s := scaler.New(service.Name,
+			views[i],
+			ecsClients[i],
+			service.MinAgents,
+			service.MaxAgents,
+			cfg.PollInterval,
+			service.CooldownPeriod,
+			logger,
+			opts...,
+		)
+		s.SetMetrics(m.ForService(service.Name))
+
+		scalers[i] = s
+		probes[i] = health.NewNamedProbe(service.Name, health.NewChannelProbe(s.Ready()))
+		states[service.Name] = scalerState(s)
+		names[i] = service.Name
+		runners[i] = s
+		sendTriggers[i] = triggers[i]
+	}
+
+	watchReload(ctx, logger, cfg, func(next config.Config) {
+		nextServices := next.ServiceList()
+		for i, s := range scalers {
+			name := services[i].Name
+			idx := slices.IndexFunc(nextServices, func(service config.ServiceConfig) bool { return service.Name == name })
+			if idx < 0 {
+				logger.Warn("service removed from config, keeping current settings", "service", name)
+				continue
+			}
+			service := nextServices[idx]
+			s.UpdateSettings(scalerSettings(next, service.MinAgents, service.MaxAgents, service.CooldownPeriod))
+		}
+	})
+
+	healthSrv := health.NewServer(cfg.HealthAddr, health.NewCompositeProbe(probes...), healthOptions(cfg, m, states, paused, sendTriggers...)...)
+	waitHealth := runHealthServer(ctx, logger, healthSrv)
+
+	failed := runScalers(ctx, cancel, logger, names, runners)
+	cancel()
+	waitHealth()
+	if failed {
+		return 1
+	}
+	return 0
+}
+
+// serviceViews builds a ServiceView for each service sharing the agent pool,
+// filtering agents by the IPs taskIPs(i) returns for service i and pending runs
+// by the service's run type. An agent matching several services counts only
+// for the first of them.
+func serviceViews(client tfc.ServiceViewClient, services []config.ServiceConfig, taskIPs func(i int) tfc.TaskIPsFunc) ([]*tfc.ServiceView, error) {
+	views := make([]*tfc.ServiceView, len(services))
+	for i, service := range services {
+		runType, err := tfc.ParseRunType(service.RunType)
+		if err != nil {
+			return nil, fmt.Errorf("service %q: %w", service.Name, err)
+		}
+		views[i] = tfc.NewServiceView(client, runType, taskIPs(i),
+			tfc.WithAgentNamePrefix(service.AgentNamePrefix),
+			tfc.WithPrecedence(views[:i]...),
+		)
+	}
+	return views, nil
+}
+
+// compensationTarget returns the index of the service that makes up for spot
+// interruptions, the first non-spot service, or -1 when there is none.
+func compensationTarget(services []config.ServiceConfig) int {
+	return slices.IndexFunc(services, func(service config.ServiceConfig) bool { return !service.Spot })
+}
+
+func runMultiPool(ctx context.Context, logger *slog.Logger, cfg config.Config, m *metrics.Metrics, notifier *notify.Webhook) int {
+	// A scaler that fails stops the others so the process can exit non-zero.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
-	healthSrv := health.NewServer(cfg.HealthAddr, probe, health.WithMetricsHandler(m.Handler()))
+	scalers := make([]*scaler.Scaler, 0, len(cfg.Pools))
+	probes := make([]health.ReadinessProbe, 0, len(cfg.Pools))
+	states := make(map[string]health.StateFunc, len(cfg.Pools))
+	triggers := make([]chan<- struct{}, 0, len(cfg.Pools))
+	budget := scaler.NewBudget(cfg.TotalMaxAgents)
+	paused := newPauseFlag(cfg)
+
+	for _, pool := range cfg.Pools {
+		// Each pool only contains its own agents, so the client is already
+		// scoped to the service and needs no ServiceView filtering.
+		tfcClient, err := newTFCClient(ctx, cfg, logger.With("pool", pool.Name), cmp.Or(pool.Token, cfg.TFCToken), pool.AgentPoolID, m.ForService(pool.Name))
+		if err != nil {
+			logger.Error("failed to create TFC client", "pool", pool.Name, "error", err)
+			return 1
+		}
+
+		ecsClient, err := ecs.New(ctx, cfg.ECSCluster, pool.ECSService, ecsOptions(cfg)...)
+		if err != nil {
+			logger.Error("failed to create ECS client", "pool", pool.Name, "error", err)
+			return 1
+		}
+		if err := preflight(ctx, cfg, tfcClient, ecsClient); err != nil {
+			logger.Error("preflight check failed", "pool", pool.Name, "error", err)
+			return 1
+		}
+
+		trigger := make(chan struct{}, 1)
+		s := scaler.New(pool.Name,
+			tfcClient,
+			ecsClient,
+			pool.MinAgents,
+			pool.MaxAgents,
+			cfg.PollInterval,
+			cfg.CooldownPeriod,
+			logger,
+			scalerOptions(cfg, trigger, budget, paused, notifier)...,
+		)
+		s.SetMetrics(m.ForService(pool.Name))
+
+		scalers = append(scalers, s)
+		states[pool.Name] = scalerState(s)
+		triggers = append(triggers, trigger)
+		probes = append(probes, health.NewNamedProbe(pool.Name, health.NewChannelProbe(s.Ready())))
+	}
+
+	watchReload(ctx, logger, cfg, func(next config.Config) {
+		for i, s := range scalers {
+			name := cfg.Pools[i].Name
+			idx := slices.IndexFunc(next.Pools, func(p config.PoolConfig) bool { return p.Name == name })
+			if idx < 0 {
+				logger.Warn("pool removed from config, keeping current settings", "pool", name)
+				continue
+			}
+			pool := next.Pools[idx]
+			s.UpdateSettings(scalerSettings(next, pool.MinAgents, pool.MaxAgents, next.CooldownPeriod))
+		}
+	})
+
+	healthSrv := health.NewServer(cfg.HealthAddr, health.NewCompositeProbe(probes...), healthOptions(cfg, m, states, paused, triggers...)...)
+	waitHealth := runHealthServer(ctx, logger, healthSrv)
+
+	names := make([]string, len(cfg.Pools))
+	runners := make([]runner, len(scalers))
+	for i, s := range scalers {
+		names[i] = cfg.Pools[i].Name
+		runners[i] = s
+	}
+	failed := runScalers(ctx, cancel, logger, names, runners)
+	cancel()
+	waitHealth()
+	if failed {
+		return 1
+	}
+	return 0
+}
+
+// runHealthServer runs srv in the background until ctx ends. The returned
+// function waits for srv to shut down, which takes at most SHUTDOWN_TIMEOUT
+// once ctx has ended.
+func runHealthServer(ctx context.Context, logger *slog.Logger, srv *health.Server) func() {
+	done := make(chan struct{})
 	go func() {
-		if err := healthSrv.Run(ctx); err != nil {
+		defer close(done)
+		if err := srv.Run(ctx); err != nil {
 			logger.Error("health server error", "error", err)
 		}
 	}()
+	return func() { <-done }
+}
 
+// runner is the part of *scaler.Scaler runScalers uses.
+type runner interface {
+	Run(ctx context.Context) error
+}
+
+// runScalers runs each named scaler until it stops. A scaler that fails calls
+// cancel so the others stop too; it reports whether any scaler failed.
+func runScalers(ctx context.Context, cancel context.CancelFunc, logger *slog.Logger, names []string, scalers []runner) bool {
+	var failed atomic.Bool
 	var wg sync.WaitGroup
-	wg.Add(2)
+	wg.Add(len(scalers))
 
-	go func() {
-		defer wg.Done()
-		if err := regularScaler.Run(ctx); err != nil {
-			if errors.Is(err, context.Canceled) {
-				logger.Info("regular scaler stopped", "reason", err)
-			} else {
-				logger.Error("regular scaler failed", "error", err)
+	for i, s := range scalers {
+		name := names[i]
+		go func() {
+			defer wg.Done()
+			if err := s.Run(ctx); err != nil {
+				if errors.Is(err, context.Canceled) {
+					logger.Info("scaler stopped", "scaler", name, "reason", err)
+				} else {
+					logger.Error("scaler failed", "scaler", name, "error", err)
+					failed.Store(true)
+					cancel()
+				}
 			}
-		}
-	}()
+		}()
+	}
+
+	wg.Wait()
+	return failed.Load()
+}
+
+// watchReload re-runs config.Load on SIGHUP and passes the result to apply.
+// Changes to settings that apply does not pick up are logged and ignored; a
+// config that fails to load leaves the running settings untouched.
+func watchReload(ctx context.Context, logger *slog.Logger, cfg config.Config, apply func(config.Config)) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
 
 	go func() {
-		defer wg.Done()
-		if err := spotScaler.Run(ctx); err != nil {
-			if errors.Is(err, context.Canceled) {
-				logger.Info("spot scaler stopped", "reason", err)
-			} else {
-				logger.Error("spot scaler failed", "error", err)
+		defer signal.Stop(hup)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hup:
 			}
+
+			next, err := config.Load()
+			if err != nil {
+				logger.Error("config reload failed, keeping current settings", "error", err)
+				continue
+			}
+			if changed := cfg.ImmutableChanges(next); len(changed) > 0 {
+				logger.Warn("ignoring config changes that require a restart", "keys", changed)
+			}
+			if changed := cfg.UnappliedChanges(next); len(changed) > 0 {
+				logger.Warn("ignoring config changes that are not applied on reload, restart to apply them", "fields", changed)
+			}
+			apply(next)
+			logger.Info("config reloaded")
 		}
 	}()
+}
 
-	wg.Wait()
+// warnAgentLimit logs a warning for each scaler whose maximum exceeds
+// TFC_AGENT_LIMIT. The scalers clamp their maximum to the limit, so tasks
+// beyond it are never started.
+func warnAgentLimit(logger *slog.Logger, cfg config.Config) {
+	if cfg.TFCAgentLimit <= 0 {
+		return
+	}
+	maxAgents := map[string]int{}
+	switch {
+	case len(cfg.Pools) > 0:
+		for _, pool := range cfg.Pools {
+			maxAgents[pool.Name] = pool.MaxAgents
+		}
+	case len(cfg.ServiceList()) > 0:
+		for _, service := range cfg.ServiceList() {
+			maxAgents[service.Name] = service.MaxAgents
+		}
+	default:
+		maxAgents["default"] = cfg.MaxAgents
+	}
+	for _, name := range slices.Sorted(maps.Keys(maxAgents)) {
+		if maxAgents[name] > cfg.TFCAgentLimit {
+			logger.Warn("max agents exceeds TFC agent limit, clamping",
+				"scaler", name,
+				"max_agents", maxAgents[name],
+				"agent_limit", cfg.TFCAgentLimit,
+			)
+		}
+	}
+}
+
+func scalerSettings(cfg config.Config, minAgents, maxAgents int, cooldown time.Duration) scaler.Settings {
+	return scaler.Settings{
+		MinAgents:    minAgents,
+		MaxAgents:    maxAgents,
+		PollInterval: cfg.PollInterval,
+		Cooldown:     cooldown,
+	}
+}
+
+// newLogger returns a logger writing to stdout in the given LOG_FORMAT.
+func newLogger(format string, level slog.Leveler) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: level}
+	if format == config.LogFormatText {
+		return slog.New(slog.NewTextHandler(os.Stdout, opts))
+	}
+	return slog.New(slog.NewJSONHandler(os.Stdout, opts))
+}
+
+// newTFCClient creates a TFC client for the agent pool, first looking the pool
+// up by TFC_AGENT_POOL_NAME when agentPoolID is empty.
+func newTFCClient(ctx context.Context, cfg config.Config, logger *slog.Logger, token, agentPoolID string, sm *metrics.ServiceMetrics) (*tfc.Client, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ReconcileTimeout)
+	defer cancel()
+
+	return tfc.New(ctx, token, cfg.TFCAddress, agentPoolID,
+		tfc.WithOrganization(cfg.TFCOrg),
+		tfc.WithAgentPoolName(cfg.TFCAgentPoolName),
+		tfc.WithRetry(cfg.TFCMaxRetries, cfg.TFCRetryBaseDelay),
+		tfc.WithWorkspaceTags(cfg.WorkspaceTags),
+		tfc.WithWorkspaceIDs(cfg.WorkspaceIDs),
+		tfc.WithLogger(logger),
+		tfc.WithAgentLimit(cfg.TFCAgentLimit),
+		tfc.WithRunConcurrency(cfg.TFCRunConcurrency),
+		tfc.WithPendingStatuses(cfg.PlanPendingStatuses, cfg.ApplyPendingStatuses),
+		tfc.WithSpeculativeRuns(cfg.IncludeSpeculative),
+		tfc.WithPoolQueue(cfg.UsePoolQueue),
+		tfc.WithWorkspaceConcurrency(cfg.TFCWorkspaceConcurrency),
+		tfc.WithPageSize(cfg.TFCPageSize),
+		tfc.WithUserAgent(cmp.Or(cfg.TFCUserAgent, tfc.DefaultUserAgent+"/"+version)),
+		tfc.WithRateLimitHook(sm.RecordTFCRateLimited),
+	)
+}
+
+// preflight makes one authenticated call through each client before the
+// scalers start, so credential, permission, and naming mistakes stop startup
+// with a clear error instead of surfacing as repeated reconcile failures.
+func preflight(ctx context.Context, cfg config.Config, tfcClient *tfc.Client, ecsClients ...*ecs.Client) error {
+	ctx, cancel := context.WithTimeout(ctx, cfg.ReconcileTimeout)
+	defer cancel()
+
+	if err := tfcClient.Validate(ctx); err != nil {
+		return fmt.Errorf("validating TFC access: %w", err)
+	}
+	for _, c := range ecsClients {
+		if err := c.Validate(ctx); err != nil {
+			return fmt.Errorf("validating ECS access: %w", err)
+		}
+	}
+	return nil
+}
+
+func ecsOptions(cfg config.Config) []ecs.Option {
+	return []ecs.Option{
+		ecs.WithRegion(cfg.ECSRegion),
+		ecs.WithAssumeRole(cfg.AWSAssumeRoleARN, cfg.AWSAssumeRoleExternalID),
+		ecs.WithProtectionBatchSize(cfg.TaskProtectionBatchSize),
+		ecs.WithTaskIPCache(cfg.TaskIPCacheTTL),
+	}
+}
+
+func scalerOptions(cfg config.Config, trigger <-chan struct{}, budget *scaler.Budget, paused *atomic.Bool, notifier *notify.Webhook) []scaler.Option {
+	opts := []scaler.Option{
+		scaler.WithPause(paused),
+		scaler.WithMaxScaleDownStep(cfg.MaxScaleDownStep),
+		scaler.WithMaxScaleUpStep(cfg.MaxScaleUpStep),
+		scaler.WithWarmIdle(cfg.WarmIdle),
+		scaler.WithScaleDownIdleThreshold(cfg.ScaleDownIdleThreshold),
+		scaler.WithScaleDownFactor(cfg.ScaleDownFactor),
+		scaler.WithSmoothingAlpha(cfg.SmoothingAlpha),
+		scaler.WithTargetBusyRatio(cfg.TargetBusyRatio),
+		scaler.WithRunWeights(cfg.PlanRunWeight, cfg.ApplyRunWeight),
+		scaler.WithRunStatusWeights(cfg.RunStatusWeights),
+		scaler.WithPollJitter(cfg.PollJitter),
+		scaler.WithReconcileTimeout(cfg.ReconcileTimeout),
+		scaler.WithFailureBackoffMax(cfg.ReconcileBackoffMax),
+		scaler.WithReadyAfterSuccesses(cfg.ReadyAfterSuccesses),
+		scaler.WithMaxConsecutiveFailures(cfg.MaxConsecutiveFailures),
+		scaler.WithECSWriteBreaker(cfg.ECSWriteBreakerThreshold, cfg.ECSWriteBreakerCooldown),
+		scaler.WithDryRun(cfg.DryRun),
+		scaler.WithDrainOnShutdown(cfg.DrainOnShutdown),
+		scaler.WithDrainTimeout(cfg.ShutdownTimeout),
+		scaler.WithTaskProtection(cfg.TaskProtectionEnabled),
+		scaler.WithCorrelation(taskCorrelation(cfg)),
+		scaler.WithIdleGuard(cfg.IdleGuardEnabled),
+		scaler.WithBusyFloor(cfg.BusyFloorEnabled),
+		scaler.WithTaskProtectionExpiry(cfg.TaskProtectionExpiry),
+		scaler.WithMaxTaskAge(cfg.MaxTaskAge),
+		scaler.WithProtectNewestIdle(cfg.ProtectNewestIdle),
+		scaler.WithMaxQueueWait(cfg.MaxQueueWait),
+		scaler.WithRunPollInterval(cfg.RunPollInterval),
+		scaler.WithScaleUpStabilization(cfg.ScaleUpStabilization),
+		scaler.WithScaleToZeroGrace(cfg.ScaleToZeroGrace),
+		scaler.WithStartupDelay(cfg.StartupDelay),
+		scaler.WithPlacementStallThreshold(cfg.PlacementStallReconciles),
+		scaler.WithOrphanTaskThreshold(cfg.OrphanTaskReconciles),
+		scaler.WithReconcileTrigger(trigger),
+		scaler.WithBudget(budget),
+		scaler.WithBusinessHours(businessHours(cfg)),
+	}
+	if notifier != nil {
+		opts = append(opts, scaler.WithNotifier(notifier))
+	}
+	if cfg.CostMode {
+		opts = append(opts, scaler.WithScaleDeadband(cfg.ScaleDeadband, cfg.ScaleDeadbandPercent))
+	}
+	return opts
+}
+
+// newScaleNotifier returns the webhook notified of each scaling action, or
+// nil when SCALE_WEBHOOK_URL is unset. Failed deliveries are counted under
+// the service that scaled.
+func newScaleNotifier(cfg config.Config, logger *slog.Logger, m *metrics.Metrics) *notify.Webhook {
+	if cfg.ScaleWebhookURL == "" {
+		return nil
+	}
+	return notify.NewWebhook(cfg.ScaleWebhookURL, cfg.ScaleWebhookTimeout, logger,
+		notify.WithFailureHook(func(service string) {
+			m.ForService(service).RecordWebhookFailure()
+		}),
+	)
+}
+
+func taskCorrelation(cfg config.Config) scaler.Correlation {
+	if cfg.TaskCorrelation == config.TaskCorrelationName {
+		return scaler.CorrelateByName
+	}
+	return scaler.CorrelateByIP
+}
+
+func businessHours(cfg config.Config) *scaler.BusinessHours {
+	if cfg.BusinessHours == nil {
+		return nil
+	}
+	return &scaler.BusinessHours{
+		MinAgents: cfg.BusinessHours.MinAgents,
+		Start:     cfg.BusinessHours.Start,
+		End:       cfg.BusinessHours.End,
+		Location:  cfg.BusinessHours.Location,
+	}
+}
+
+func healthOptions(cfg config.Config, m *metrics.Metrics, states map[string]health.StateFunc, paused *atomic.Bool, triggers ...chan<- struct{}) []health.ServerOption {
+	opts := []health.ServerOption{
+		health.WithMetricsHandler(m.Handler()),
+		health.WithBuildInfo(health.BuildInfo{Version: version, Commit: commit, Date: date}),
+		health.WithConfig(cfg.Redacted()),
+		health.WithShutdownTimeout(cfg.ShutdownTimeout),
+	}
+	if cfg.HealthReadyDetails {
+		opts = append(opts, health.WithReadyDetails())
+	}
+	if cfg.HealthReconcileTrigger {
+		opts = append(opts, health.WithReconcileTrigger(triggers...))
+	}
+	if cfg.HealthDebugState {
+		opts = append(opts, health.WithDebugState(states))
+	}
+	if cfg.HealthPauseControl {
+		opts = append(opts, health.WithPauseControl(paused))
+	}
+	return opts
+}
+
+// newPauseFlag returns the pause flag shared by all scalers and the health
+// server, set when the autoscaler starts paused.
+func newPauseFlag(cfg config.Config) *atomic.Bool {
+	paused := new(atomic.Bool)
+	paused.Store(cfg.Paused)
+	return paused
+}
+
+func scalerState(s *scaler.Scaler) health.StateFunc {
+	return func() any { return s.State() }
 }
 
 func taskIPsFetcher(ecsClient *ecs.Client) tfc.TaskIPsFunc {
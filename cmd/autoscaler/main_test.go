@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/oulman/tfc-agent-autoscaler/internal/config"
+	"github.com/oulman/tfc-agent-autoscaler/internal/health"
+	"github.com/oulman/tfc-agent-autoscaler/internal/tfc"
+)
+
+// runnerFunc adapts a function to runner.
+type runnerFunc func(ctx context.Context) error
+
+func (f runnerFunc) Run(ctx context.Context) error {
+	return f(ctx)
+}
+
+// untilCanceled runs until ctx is canceled, like a healthy scaler.
+func untilCanceled(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestRunScalersStopsOthersOnFailure(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan bool, 1)
+	go func() {
+		done <- runScalers(ctx, cancel, slog.New(slog.NewTextHandler(io.Discard, nil)),
+			[]string{"a", "b", "c"},
+			[]runner{
+				runnerFunc(untilCanceled),
+				runnerFunc(func(context.Context) error { return tfc.ErrPoolNotFound }),
+				runnerFunc(untilCanceled),
+			},
+		)
+	}()
+
+	select {
+	case failed := <-done:
+		if !failed {
+			t.Error("runScalers reported no failure, want failure so the process exits non-zero")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("other pool scalers kept running after one failed")
+	}
+}
+
+func TestRunScalersCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	failed := runScalers(ctx, cancel, slog.New(slog.NewTextHandler(io.Discard, nil)),
+		[]string{"a", "b"},
+		[]runner{runnerFunc(untilCanceled), runnerFunc(untilCanceled)},
+	)
+	if failed {
+		t.Error("runScalers reported failure after cancellation, want none")
+	}
+}
+
+func TestRunHealthServerWaitsForShutdown(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	srv := health.NewServer("127.0.0.1:0", health.NewChannelProbe(make(chan struct{})))
+	wait := runHealthServer(ctx, slog.New(slog.NewTextHandler(io.Discard, nil)), srv)
+
+	done := make(chan struct{})
+	go func() {
+		wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("wait returned while the health server was still running")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("wait did not return after the health server shut down")
+	}
+}
+
+// fakeViewClient is a tfc.ServiceViewClient with a fixed pool.
+type fakeViewClient struct {
+	agents []tfc.AgentInfo
+	counts tfc.PendingRunCounts
+}
+
+func (f fakeViewClient) GetAgentDetails(context.Context) ([]tfc.AgentInfo, error) {
+	return f.agents, nil
+}
+
+func (f fakeViewClient) GetPendingRunsByType(context.Context) (tfc.PendingRunCounts, error) {
+	return f.counts, nil
+}
+
+func (f fakeViewClient) GetPendingRunsDetailed(context.Context) (tfc.PendingRunDetail, error) {
+	return tfc.PendingRunDetail{PendingRunCounts: f.counts}, nil
+}
+
+func (f fakeViewClient) GetPendingRunsByWorkspace(context.Context) ([]tfc.WorkspacePendingRuns, error) {
+	return nil, nil
+}
+
+func (f fakeViewClient) GetPoolLimit(context.Context) (int, error) {
+	return 0, nil
+}
+
+func TestServiceViews(t *testing.T) {
+	client := fakeViewClient{
+		agents: []tfc.AgentInfo{
+			{ID: "a1", Name: "regular-1", IP: "10.0.0.1", Status: "busy"},
+			{ID: "a2", Name: "regular-2", IP: "10.0.0.2", Status: "idle"},
+			{ID: "a3", Name: "spot-1", IP: "10.0.1.1", Status: "busy"},
+			// A recycled regular IP now used by a spot agent.
+			{ID: "a4", Name: "spot-2", IP: "10.0.0.2", Status: "idle"},
+		},
+		counts: tfc.PendingRunCounts{PlanPending: 4, ApplyPending: 2},
+	}
+	taskIPs := []map[string]bool{
+		{"10.0.0.1": true, "10.0.0.2": true},
+		{"10.0.1.1": true},
+	}
+
+	cfg := config.Config{
+		ECSService:      "tfc-agent",
+		MaxAgents:       10,
+		AgentNamePrefix: "regular-",
+		SpotService:     &config.ServiceConfig{ECSService: "tfc-agent-spot", MaxAgents: 5, AgentNamePrefix: "spot-"},
+	}
+	services := cfg.ServiceList()
+	views, err := serviceViews(client, services, func(i int) tfc.TaskIPsFunc {
+		return func(context.Context) (map[string]bool, error) { return taskIPs[i], nil }
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(views) != 2 {
+		t.Fatalf("got %d views, want 2", len(views))
+	}
+
+	tests := []struct {
+		name        string
+		wantPending int
+		wantBusy    int
+		wantIdle    int
+	}{
+		{name: "regular", wantPending: 2, wantBusy: 1, wantIdle: 1},
+		{name: "spot", wantPending: 4, wantBusy: 1, wantIdle: 0},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if services[i].Name != tt.name {
+				t.Fatalf("service %d is %q, want %q", i, services[i].Name, tt.name)
+			}
+			pending, err := views[i].GetPendingRuns(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if pending != tt.wantPending {
+				t.Errorf("pending runs: got %d, want %d", pending, tt.wantPending)
+			}
+			busy, idle, _, err := views[i].GetAgentPoolStatus(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if busy != tt.wantBusy || idle != tt.wantIdle {
+				t.Errorf("busy/idle: got %d/%d, want %d/%d", busy, idle, tt.wantBusy, tt.wantIdle)
+			}
+		})
+	}
+
+	if got := compensationTarget(services); got != 0 {
+		t.Errorf("compensationTarget: got %d, want 0", got)
+	}
+}
+
+func TestServiceViewsUnknownRunType(t *testing.T) {
+	services := []config.ServiceConfig{{Name: "x", ECSService: "agents", RunType: "destroy"}}
+	_, err := serviceViews(fakeViewClient{}, services, func(int) tfc.TaskIPsFunc { return nil })
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestCompensationTargetAllSpot(t *testing.T) {
+	services := []config.ServiceConfig{{Name: "a", Spot: true}, {Name: "b", Spot: true}}
+	if got := compensationTarget(services); got != -1 {
+		t.Errorf("compensationTarget: got %d, want -1", got)
+	}
+}
@@ -2,17 +2,59 @@
 package config
 
 import (
+	"encoding/json"
 	"fmt"
+	"log/slog"
+	"maps"
+	"math"
+	"net/url"
 	"os"
+	"reflect"
+	"regexp"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
+
+	"github.com/oulman/tfc-agent-autoscaler/internal/tfc"
 )
 
-// ServiceConfig holds ECS service name and agent count bounds.
+// ServiceConfig holds ECS service name, agent count bounds, and per-service
+// scaling overrides.
 type ServiceConfig struct {
-	ECSService string
-	MinAgents  int
-	MaxAgents  int
+	// Name labels the service's logs, metrics, and health output.
+	Name            string
+	ECSService      string
+	MinAgents       int
+	MaxAgents       int
+	AgentNamePrefix string
+	// CooldownPeriod defaults to the global COOLDOWN_PERIOD when unset.
+	CooldownPeriod time.Duration
+	// RunType is the run type the service's agents handle, "plan" or "apply".
+	RunType string
+	// Spot marks a Fargate Spot service, watched for interruptions.
+	Spot bool
+}
+
+// BusinessHoursConfig raises the minimum agent count during a weekday window.
+type BusinessHoursConfig struct {
+	MinAgents int
+	// Start and End are wall-clock offsets from midnight; End before Start
+	// wraps past midnight.
+	Start    time.Duration
+	End      time.Duration
+	Location *time.Location
+}
+
+// PoolConfig maps a TFC agent pool to the ECS service running its agents.
+type PoolConfig struct {
+	Name        string
+	AgentPoolID string
+	ECSService  string
+	MinAgents   int
+	MaxAgents   int
+	// Token is a pool-scoped TFC token; empty uses TFC_TOKEN.
+	Token string
 }
 
 // Config holds all configuration for the autoscaler.
@@ -20,17 +62,218 @@ type Config struct {
 	TFCToken       string
 	TFCAddress     string
 	TFCAgentPoolID string
-	TFCOrg         string
-	ECSCluster     string
-	ECSService     string
-	PollInterval   time.Duration
-	MinAgents      int
-	MaxAgents      int
-	CooldownPeriod time.Duration
-	HealthAddr     string
-	SpotService    *ServiceConfig // nil = single-service mode
+	// TFCAgentPoolName is resolved to TFCAgentPoolID in TFCOrg at startup
+	// when the ID is not set.
+	TFCAgentPoolName string
+	TFCOrg           string
+	ECSCluster       string
+	ECSService       string
+	PollInterval     time.Duration
+	MinAgents        int
+	MaxAgents        int
+	CooldownPeriod   time.Duration
+	HealthAddr       string
+	SpotService      *ServiceConfig  // nil = single-service mode
+	Services         []ServiceConfig // ECS_SERVICES; nil = ECS_SERVICE/ECS_SPOT_SERVICE
+	Pools            []PoolConfig    // empty = single-pool mode
+
+	// RunPollInterval is how often pending runs are listed; reconciles in
+	// between reuse the last count (0 = every reconcile).
+	RunPollInterval time.Duration
+	// MaxScaleDownStep caps agents removed per reconcile; 0 means unlimited.
+	MaxScaleDownStep int
+	// MaxScaleUpStep caps agents added per reconcile; 0 means unlimited.
+	MaxScaleUpStep int
+	// AgentNamePrefix restricts dual-service mode's regular service, or the
+	// single service with FilterServiceAgents, to agents whose name starts
+	// with this prefix, in addition to task IP matching.
+	AgentNamePrefix string
+	// FilterServiceAgents makes single-service mode count only agents running
+	// on ECS_SERVICE's tasks instead of every agent in the pool.
+	FilterServiceAgents bool
+	// ReconcileTimeout bounds a single reconcile cycle.
+	ReconcileTimeout time.Duration
+	// ReconcileBackoffMax caps the poll interval backoff after consecutive
+	// reconcile failures (0 = disabled).
+	ReconcileBackoffMax time.Duration
+	// ReadyAfterSuccesses is how many consecutive successful reconciles are
+	// needed before the autoscaler reports ready.
+	ReadyAfterSuccesses int
+	// MaxConsecutiveFailures exits the autoscaler after this many reconciles
+	// in a row fail (0 = never).
+	MaxConsecutiveFailures int
+	// ECSWriteBreakerThreshold skips ECS writes after this many fail in a row
+	// (0 = disabled).
+	ECSWriteBreakerThreshold int
+	// ECSWriteBreakerCooldown is how long ECS writes are skipped once the
+	// breaker opens.
+	ECSWriteBreakerCooldown time.Duration
+	// PollJitter randomizes each poll interval by up to ±PollJitter of its length.
+	PollJitter float64
+	// WarmIdle is the number of spare idle agents kept ahead of demand.
+	WarmIdle int
+	// ScaleDownIdleThreshold blocks scale-down until idle agents exceed it.
+	ScaleDownIdleThreshold int
+	// ScaleDownFactor is the fraction of each computed scale-down applied per reconcile.
+	ScaleDownFactor float64
+	// SmoothingAlpha weights the latest pending demand in its moving average (1 = no smoothing).
+	SmoothingAlpha float64
+	// TargetBusyRatio switches to target tracking on the busy/total agent ratio (0 = additive formula).
+	TargetBusyRatio float64
+	// PlacementStallReconciles is how many consecutive reconciles the ECS
+	// running count may trail desired before stalls are reported (0 = disabled).
+	PlacementStallReconciles int
+	// OrphanTaskReconciles is how many consecutive reconciles running tasks
+	// may outnumber registered agents before it is reported (0 = disabled).
+	OrphanTaskReconciles int
+	// PlanRunWeight is the number of agents reserved per pending plan run.
+	PlanRunWeight float64
+	// ApplyRunWeight is the number of agents reserved per pending apply run.
+	ApplyRunWeight float64
+	// RunStatusWeights overrides the run weights for pending runs in the
+	// given run statuses (nil = weight by run type only).
+	RunStatusWeights map[string]float64
+	// DryRun logs scaling decisions without modifying ECS.
+	DryRun bool
+	// DrainOnShutdown scales services to their minimum when the process stops.
+	DrainOnShutdown bool
+	// ShutdownTimeout bounds the wait for in-flight health server requests
+	// and the drain on shutdown (0 = 5s for requests and 30s for the drain).
+	ShutdownTimeout time.Duration
+	// HealthReadyDetails makes /readyz return per-probe JSON.
+	HealthReadyDetails bool
+	// HealthReconcileTrigger enables POST /reconcile on the health server.
+	HealthReconcileTrigger bool
+	// HealthPauseControl enables POST /pause and POST /resume on the health server.
+	HealthPauseControl bool
+	// Paused starts the autoscaler without making scaling changes.
+	Paused bool
+	// HealthDebugState enables GET /debug/state on the health server.
+	HealthDebugState bool
+	// LogLevel is the minimum level of log records written.
+	LogLevel slog.Level
+	// LogFormat is the log output format, LogFormatJSON or LogFormatText.
+	LogFormat string
+	// MetricsNamespace prefixes every Prometheus metric name (empty = no prefix).
+	MetricsNamespace string
+	// MetricsLabels are constant labels added to every Prometheus metric.
+	MetricsLabels map[string]string
+	// RuntimeMetrics exposes the go_* and process_* collectors on /metrics.
+	RuntimeMetrics bool
+	// TaskProtectionEnabled marks busy tasks scale-in protected before scale-down.
+	TaskProtectionEnabled bool
+	// TaskCorrelation is how agents are matched to ECS tasks, TaskCorrelationIP
+	// or TaskCorrelationName.
+	TaskCorrelation string
+	// IdleGuardEnabled caps each scale-down at the number of idle agents.
+	IdleGuardEnabled bool
+	// BusyFloorEnabled keeps scale-down at or above busy agents plus WarmIdle.
+	BusyFloorEnabled bool
+	// TaskProtectionExpiry is how long busy tasks stay scale-in protected.
+	TaskProtectionExpiry time.Duration
+	// TaskProtectionBatchSize is the number of tasks per task protection API call.
+	TaskProtectionBatchSize int
+	// MaxTaskAge recycles idle tasks running longer than this (0 = disabled).
+	MaxTaskAge time.Duration
+	// ProtectNewestIdle keeps the most recently started idle task protected
+	// during scale-down so older idle tasks are stopped first.
+	ProtectNewestIdle bool
+	// MaxQueueWait forces a scale-up once the oldest pending run has waited
+	// longer than this (0 = disabled).
+	MaxQueueWait time.Duration
+	// ScaleUpStabilization is how long demand must exceed the current desired
+	// count before scaling up (0 = disabled).
+	ScaleUpStabilization time.Duration
+	// StartupDelay is how long each scaler waits before its first reconcile.
+	StartupDelay time.Duration
+	// ScaleWebhookURL receives a JSON POST for each applied scaling action (empty = disabled).
+	ScaleWebhookURL string
+	// ScaleWebhookTimeout bounds each scale webhook request.
+	ScaleWebhookTimeout time.Duration
+	// ScaleToZeroGrace is how long the pool must be idle with no demand before
+	// the last agent is removed when MinAgents is 0 (0 = disabled).
+	ScaleToZeroGrace time.Duration
+	// CostMode skips scaling changes within the scale deadband to save
+	// UpdateService calls and task churn.
+	CostMode bool
+	// ScaleDeadband is the largest change in desired count CostMode leaves
+	// unapplied, and ScaleDeadbandPercent the same as a percentage of the
+	// current desired count. Only one is set.
+	ScaleDeadband        int
+	ScaleDeadbandPercent float64
+	// TaskIPCacheTTL is how long ECS task IP lookups are reused (0 = disabled).
+	TaskIPCacheTTL time.Duration
+	// TFCMaxRetries is the number of retries for transient TFC API errors.
+	TFCMaxRetries int
+	// TFCRetryBaseDelay is the initial backoff between TFC API retries.
+	TFCRetryBaseDelay time.Duration
+	// TFCWorkspaceConcurrency is how many workspaces have their pending runs counted at once.
+	TFCWorkspaceConcurrency int
+	// TFCPageSize is the page size of TFC agent listings.
+	TFCPageSize int
+	// TFCUserAgent overrides the User-Agent sent on TFC API requests (empty = autoscaler name and version).
+	TFCUserAgent string
+	// ECSRegion overrides the AWS region used for ECS calls.
+	ECSRegion string
+	// AWSAssumeRoleARN is an IAM role assumed for ECS calls (cross-account clusters).
+	AWSAssumeRoleARN string
+	// AWSAssumeRoleExternalID is passed when assuming AWSAssumeRoleARN.
+	AWSAssumeRoleExternalID string
+	// WorkspaceTags limits pending run counts to workspaces with any of these tags.
+	WorkspaceTags []string
+	// WorkspaceIDs limits pending run counts to these pool workspaces.
+	WorkspaceIDs []string
+	// PlanPendingStatuses are the run statuses counted as pending plan demand (nil = default).
+	PlanPendingStatuses []string
+	// ApplyPendingStatuses are the run statuses counted as pending apply demand (nil = default).
+	ApplyPendingStatuses []string
+	// IncludeSpeculative counts speculative (plan-only) runs as pending demand.
+	IncludeSpeculative bool
+	// UsePoolQueue counts pending runs from the organization's run listing
+	// filtered by agent pool instead of listing runs per workspace.
+	UsePoolQueue bool
+	// SpotInterruptionCompensation scales up the regular service by the number
+	// of spot tasks lost to interruptions (dual-service mode only).
+	SpotInterruptionCompensation bool
+	// TFCAgentLimit is the organization's agent limit; MAX_AGENTS is clamped to it (0 = unknown).
+	TFCAgentLimit int
+	// TFCRunConcurrency is the organization's run concurrency limit; MAX_AGENTS is clamped to it (0 = unknown).
+	TFCRunConcurrency int
+	// TotalMaxAgents caps the combined desired count of all services (0 = unlimited).
+	TotalMaxAgents int
+	// BusinessHours raises MinAgents during a weekday window (nil = disabled).
+	BusinessHours *BusinessHoursConfig
 }
 
+// Agent-to-task correlation strategies accepted by TASK_CORRELATION.
+const (
+	TaskCorrelationIP   = "ip"
+	TaskCorrelationName = "name"
+)
+
+// Log formats accepted by LOG_FORMAT.
+const (
+	LogFormatJSON = "json"
+	LogFormatText = "text"
+)
+
+// ECS accepts task protection expiry between 1 minute and 48 hours.
+const (
+	minTaskProtectionExpiry = time.Minute
+	maxTaskProtectionExpiry = 2880 * time.Minute
+)
+
+// ECS accepts at most 10 tasks per task protection call.
+const maxTaskProtectionBatchSize = 10
+
+// prometheusNamePattern matches valid Prometheus metric name prefixes and
+// label names.
+var prometheusNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// reservedMetricsLabels are the labels the autoscaler's own metrics use,
+// which METRICS_LABELS cannot override.
+var reservedMetricsLabels = []string{"service", "direction", "reason", "result", "status"}
+
 // Load reads configuration from environment variables.
 func Load() (Config, error) {
 	return load(os.LookupEnv)
@@ -65,12 +308,106 @@ func lookupInt(lookup lookupFn, key string, dest *int) error {
 	return nil
 }
 
+func lookupFloat(lookup lookupFn, key string, dest *float64) error {
+	v, ok := lookup(key)
+	if !ok || v == "" {
+		return nil
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return fmt.Errorf("invalid %s %q: %w", key, v, err)
+	}
+	*dest = f
+	return nil
+}
+
+func lookupBool(lookup lookupFn, key string, dest *bool) error {
+	v, ok := lookup(key)
+	if !ok || v == "" {
+		return nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return fmt.Errorf("invalid %s %q: %w", key, v, err)
+	}
+	*dest = b
+	return nil
+}
+
 func lookupString(lookup lookupFn, key string, dest *string) {
 	if v, ok := lookup(key); ok && v != "" {
 		*dest = v
 	}
 }
 
+// lookupLogLevel parses a log level name: debug, info, warn, or error, in any
+// case.
+func lookupLogLevel(lookup lookupFn, key string, dest *slog.Level) error {
+	v, ok := lookup(key)
+	if !ok || v == "" {
+		return nil
+	}
+	switch strings.ToLower(v) {
+	case "debug":
+		*dest = slog.LevelDebug
+	case "info":
+		*dest = slog.LevelInfo
+	case "warn":
+		*dest = slog.LevelWarn
+	case "error":
+		*dest = slog.LevelError
+	default:
+		return fmt.Errorf("invalid %s %q: must be debug, info, warn, or error", key, v)
+	}
+	return nil
+}
+
+// lookupDeadband parses a scale deadband given either as an agent count
+// ("2") or as a percentage of the current desired count ("20%"). Setting one
+// clears the other.
+func lookupDeadband(lookup lookupFn, key string, count *int, percent *float64) error {
+	v, ok := lookup(key)
+	if !ok || v == "" {
+		return nil
+	}
+	if p, isPercent := strings.CutSuffix(v, "%"); isPercent {
+		f, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil {
+			return fmt.Errorf("invalid %s %q: %w", key, v, err)
+		}
+		if f <= 0 || f >= 100 {
+			return fmt.Errorf("%s (%s) must be a percentage between 0 and 100", key, v)
+		}
+		*count, *percent = 0, f
+		return nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return fmt.Errorf("invalid %s %q: want an agent count or a percentage", key, v)
+	}
+	if n < 0 {
+		return fmt.Errorf("%s (%d) cannot be negative", key, n)
+	}
+	*count, *percent = n, 0
+	return nil
+}
+
+// lookupList splits a comma-separated value, trimming whitespace and
+// dropping empty entries. It returns nil when the variable is unset.
+func lookupList(lookup lookupFn, key string) []string {
+	v, ok := lookup(key)
+	if !ok {
+		return nil
+	}
+	var items []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 // load is the internal implementation that accepts a lookup function for testability.
 func load(lookup lookupFn) (Config, error) {
 	cfg := Config{
@@ -80,20 +417,64 @@ func load(lookup lookupFn) (Config, error) {
 		MaxAgents:      10,
 		CooldownPeriod: 60 * time.Second,
 		HealthAddr:     ":8080",
+		LogFormat:      LogFormatJSON,
+
+		TaskProtectionExpiry: 120 * time.Minute,
+		TFCMaxRetries:        2,
+		TFCRetryBaseDelay:    500 * time.Millisecond,
+		ReconcileTimeout:     30 * time.Second,
+		ReconcileBackoffMax:  5 * time.Minute,
+		ReadyAfterSuccesses:  1,
+		PlanRunWeight:        1,
+		ApplyRunWeight:       1,
+		ScaleDownFactor:      1,
+		SmoothingAlpha:       1,
+		IncludeSpeculative:   true,
+		IdleGuardEnabled:     true,
+		BusyFloorEnabled:     true,
+		RuntimeMetrics:       true,
+
+		PlacementStallReconciles: 6,
+		OrphanTaskReconciles:     6,
+		TaskProtectionBatchSize:  maxTaskProtectionBatchSize,
+		TaskProtectionEnabled:    true,
+		TaskCorrelation:          TaskCorrelationIP,
+		TFCWorkspaceConcurrency:  8,
+		TFCPageSize:              tfc.DefaultPageSize,
+		ECSWriteBreakerCooldown:  5 * time.Minute,
+		ScaleWebhookTimeout:      5 * time.Second,
+		ScaleDeadband:            1,
 	}
 
 	required := []struct {
 		dest *string
 		key  string
 	}{
-		{&cfg.TFCToken, "TFC_TOKEN"},
 		{&cfg.TFCAgentPoolID, "TFC_AGENT_POOL_ID"},
 		{&cfg.TFCOrg, "TFC_ORG"},
 		{&cfg.ECSCluster, "ECS_CLUSTER"},
 		{&cfg.ECSService, "ECS_SERVICE"},
 	}
 
+	if err := loadToken(lookup, &cfg); err != nil {
+		return Config{}, err
+	}
+
+	// In multi-pool mode the pool ID and service come from TFC_POOLS, and
+	// in multi-service mode the services come from ECS_SERVICES. The pool
+	// ID may also be looked up from TFC_AGENT_POOL_NAME.
+	poolsJSON, multiPool := lookup("TFC_POOLS")
+	multiPool = multiPool && poolsJSON != ""
+	servicesJSON, multiService := lookup("ECS_SERVICES")
+	multiService = multiService && servicesJSON != ""
+	lookupString(lookup, "TFC_AGENT_POOL_NAME", &cfg.TFCAgentPoolName)
+
 	for _, r := range required {
+		if ((multiPool || cfg.TFCAgentPoolName != "") && r.key == "TFC_AGENT_POOL_ID") ||
+			((multiPool || multiService) && r.key == "ECS_SERVICE") {
+			lookupString(lookup, r.key, r.dest)
+			continue
+		}
 		v, ok := lookup(r.key)
 		if !ok || v == "" {
 			return Config{}, fmt.Errorf("required environment variable %s is not set", r.key)
@@ -103,13 +484,104 @@ func load(lookup lookupFn) (Config, error) {
 
 	lookupString(lookup, "TFE_ADDRESS", &cfg.TFCAddress)
 	lookupString(lookup, "HEALTH_ADDR", &cfg.HealthAddr)
+	if err := lookupLogLevel(lookup, "LOG_LEVEL", &cfg.LogLevel); err != nil {
+		return Config{}, err
+	}
+	lookupString(lookup, "LOG_FORMAT", &cfg.LogFormat)
+	lookupString(lookup, "AGENT_NAME_PREFIX", &cfg.AgentNamePrefix)
+	lookupString(lookup, "TASK_CORRELATION", &cfg.TaskCorrelation)
+	lookupString(lookup, "TFC_USER_AGENT", &cfg.TFCUserAgent)
+	lookupString(lookup, "METRICS_NAMESPACE", &cfg.MetricsNamespace)
+	lookupString(lookup, "SCALE_WEBHOOK_URL", &cfg.ScaleWebhookURL)
+	cfg.WorkspaceTags = lookupList(lookup, "WORKSPACE_TAGS")
+	cfg.WorkspaceIDs = lookupList(lookup, "WORKSPACE_IDS")
+	cfg.PlanPendingStatuses = lookupList(lookup, "PLAN_PENDING_STATUSES")
+	cfg.ApplyPendingStatuses = lookupList(lookup, "APPLY_PENDING_STATUSES")
+	lookupString(lookup, "ECS_REGION", &cfg.ECSRegion)
+	lookupString(lookup, "AWS_ASSUME_ROLE_ARN", &cfg.AWSAssumeRoleARN)
+	lookupString(lookup, "AWS_ASSUME_ROLE_EXTERNAL_ID", &cfg.AWSAssumeRoleExternalID)
 
 	if err := lookupDuration(lookup, "POLL_INTERVAL", &cfg.PollInterval); err != nil {
 		return Config{}, err
 	}
+	if err := lookupDuration(lookup, "RUN_POLL_INTERVAL", &cfg.RunPollInterval); err != nil {
+		return Config{}, err
+	}
 	if err := lookupDuration(lookup, "COOLDOWN_PERIOD", &cfg.CooldownPeriod); err != nil {
 		return Config{}, err
 	}
+	if err := lookupDuration(lookup, "RECONCILE_TIMEOUT", &cfg.ReconcileTimeout); err != nil {
+		return Config{}, err
+	}
+	if err := lookupDuration(lookup, "RECONCILE_BACKOFF_MAX", &cfg.ReconcileBackoffMax); err != nil {
+		return Config{}, err
+	}
+	if err := lookupInt(lookup, "READY_AFTER_SUCCESSES", &cfg.ReadyAfterSuccesses); err != nil {
+		return Config{}, err
+	}
+	if err := lookupInt(lookup, "MAX_CONSECUTIVE_FAILURES", &cfg.MaxConsecutiveFailures); err != nil {
+		return Config{}, err
+	}
+	if err := lookupInt(lookup, "ECS_WRITE_BREAKER_THRESHOLD", &cfg.ECSWriteBreakerThreshold); err != nil {
+		return Config{}, err
+	}
+	if err := lookupDuration(lookup, "ECS_WRITE_BREAKER_COOLDOWN", &cfg.ECSWriteBreakerCooldown); err != nil {
+		return Config{}, err
+	}
+	if err := lookupDuration(lookup, "TASK_PROTECTION_EXPIRY", &cfg.TaskProtectionExpiry); err != nil {
+		return Config{}, err
+	}
+	if err := lookupInt(lookup, "TASK_PROTECTION_BATCH_SIZE", &cfg.TaskProtectionBatchSize); err != nil {
+		return Config{}, err
+	}
+	if err := lookupDuration(lookup, "MAX_TASK_AGE", &cfg.MaxTaskAge); err != nil {
+		return Config{}, err
+	}
+	if err := lookupDuration(lookup, "MAX_QUEUE_WAIT", &cfg.MaxQueueWait); err != nil {
+		return Config{}, err
+	}
+	if err := lookupDuration(lookup, "SCALE_UP_STABILIZATION", &cfg.ScaleUpStabilization); err != nil {
+		return Config{}, err
+	}
+	if err := lookupDuration(lookup, "SCALE_TO_ZERO_GRACE", &cfg.ScaleToZeroGrace); err != nil {
+		return Config{}, err
+	}
+	if err := lookupBool(lookup, "COST_MODE", &cfg.CostMode); err != nil {
+		return Config{}, err
+	}
+	if err := lookupDeadband(lookup, "SCALE_DEADBAND", &cfg.ScaleDeadband, &cfg.ScaleDeadbandPercent); err != nil {
+		return Config{}, err
+	}
+	if err := lookupDuration(lookup, "STARTUP_DELAY", &cfg.StartupDelay); err != nil {
+		return Config{}, err
+	}
+	if err := lookupDuration(lookup, "SCALE_WEBHOOK_TIMEOUT", &cfg.ScaleWebhookTimeout); err != nil {
+		return Config{}, err
+	}
+	if err := lookupDuration(lookup, "TASK_IP_CACHE_TTL", &cfg.TaskIPCacheTTL); err != nil {
+		return Config{}, err
+	}
+	if err := lookupDuration(lookup, "TFC_RETRY_BASE_DELAY", &cfg.TFCRetryBaseDelay); err != nil {
+		return Config{}, err
+	}
+	if err := lookupInt(lookup, "TFC_AGENT_LIMIT", &cfg.TFCAgentLimit); err != nil {
+		return Config{}, err
+	}
+	if err := lookupInt(lookup, "TFC_RUN_CONCURRENCY", &cfg.TFCRunConcurrency); err != nil {
+		return Config{}, err
+	}
+	if err := lookupInt(lookup, "TOTAL_MAX_AGENTS", &cfg.TotalMaxAgents); err != nil {
+		return Config{}, err
+	}
+	if err := lookupInt(lookup, "TFC_MAX_RETRIES", &cfg.TFCMaxRetries); err != nil {
+		return Config{}, err
+	}
+	if err := lookupInt(lookup, "TFC_WORKSPACE_CONCURRENCY", &cfg.TFCWorkspaceConcurrency); err != nil {
+		return Config{}, err
+	}
+	if err := lookupInt(lookup, "TFC_PAGE_SIZE", &cfg.TFCPageSize); err != nil {
+		return Config{}, err
+	}
 	if err := lookupInt(lookup, "MIN_AGENTS", &cfg.MinAgents); err != nil {
 		return Config{}, err
 	}
@@ -117,17 +589,718 @@ func load(lookup lookupFn) (Config, error) {
 		return Config{}, err
 	}
 
+	if err := lookupInt(lookup, "MAX_SCALE_DOWN_STEP", &cfg.MaxScaleDownStep); err != nil {
+		return Config{}, err
+	}
+	if err := lookupInt(lookup, "MAX_SCALE_UP_STEP", &cfg.MaxScaleUpStep); err != nil {
+		return Config{}, err
+	}
+	if err := lookupInt(lookup, "WARM_IDLE", &cfg.WarmIdle); err != nil {
+		return Config{}, err
+	}
+	if err := lookupInt(lookup, "SCALE_DOWN_IDLE_THRESHOLD", &cfg.ScaleDownIdleThreshold); err != nil {
+		return Config{}, err
+	}
+	if err := lookupInt(lookup, "PLACEMENT_STALL_RECONCILES", &cfg.PlacementStallReconciles); err != nil {
+		return Config{}, err
+	}
+	if err := lookupInt(lookup, "ORPHAN_TASK_RECONCILES", &cfg.OrphanTaskReconciles); err != nil {
+		return Config{}, err
+	}
+	if err := lookupFloat(lookup, "SCALE_DOWN_FACTOR", &cfg.ScaleDownFactor); err != nil {
+		return Config{}, err
+	}
+	if err := lookupFloat(lookup, "SMOOTHING_ALPHA", &cfg.SmoothingAlpha); err != nil {
+		return Config{}, err
+	}
+	if err := lookupFloat(lookup, "TARGET_BUSY_RATIO", &cfg.TargetBusyRatio); err != nil {
+		return Config{}, err
+	}
+	if err := lookupFloat(lookup, "POLL_JITTER", &cfg.PollJitter); err != nil {
+		return Config{}, err
+	}
+	if err := lookupFloat(lookup, "PLAN_RUN_WEIGHT", &cfg.PlanRunWeight); err != nil {
+		return Config{}, err
+	}
+	if err := lookupFloat(lookup, "APPLY_RUN_WEIGHT", &cfg.ApplyRunWeight); err != nil {
+		return Config{}, err
+	}
+	if err := lookupBool(lookup, "DRY_RUN", &cfg.DryRun); err != nil {
+		return Config{}, err
+	}
+	if err := lookupBool(lookup, "DRAIN_ON_SHUTDOWN", &cfg.DrainOnShutdown); err != nil {
+		return Config{}, err
+	}
+	if err := lookupDuration(lookup, "SHUTDOWN_TIMEOUT", &cfg.ShutdownTimeout); err != nil {
+		return Config{}, err
+	}
+	if err := lookupBool(lookup, "HEALTH_READY_DETAILS", &cfg.HealthReadyDetails); err != nil {
+		return Config{}, err
+	}
+	if err := lookupBool(lookup, "HEALTH_RECONCILE_TRIGGER", &cfg.HealthReconcileTrigger); err != nil {
+		return Config{}, err
+	}
+	if err := lookupBool(lookup, "HEALTH_PAUSE_CONTROL", &cfg.HealthPauseControl); err != nil {
+		return Config{}, err
+	}
+	if err := lookupBool(lookup, "PAUSED", &cfg.Paused); err != nil {
+		return Config{}, err
+	}
+	if err := lookupBool(lookup, "HEALTH_DEBUG_STATE", &cfg.HealthDebugState); err != nil {
+		return Config{}, err
+	}
+	if err := lookupBool(lookup, "INCLUDE_SPECULATIVE", &cfg.IncludeSpeculative); err != nil {
+		return Config{}, err
+	}
+	if err := lookupBool(lookup, "USE_POOL_QUEUE", &cfg.UsePoolQueue); err != nil {
+		return Config{}, err
+	}
+	if err := lookupBool(lookup, "TASK_PROTECTION_ENABLED", &cfg.TaskProtectionEnabled); err != nil {
+		return Config{}, err
+	}
+	if err := lookupBool(lookup, "SPOT_INTERRUPTION_COMPENSATION", &cfg.SpotInterruptionCompensation); err != nil {
+		return Config{}, err
+	}
+	if err := lookupBool(lookup, "IDLE_GUARD_ENABLED", &cfg.IdleGuardEnabled); err != nil {
+		return Config{}, err
+	}
+	if err := lookupBool(lookup, "BUSY_FLOOR_ENABLED", &cfg.BusyFloorEnabled); err != nil {
+		return Config{}, err
+	}
+	if err := lookupBool(lookup, "FILTER_SERVICE_AGENTS", &cfg.FilterServiceAgents); err != nil {
+		return Config{}, err
+	}
+	if err := lookupBool(lookup, "PROTECT_NEWEST_IDLE_TASK", &cfg.ProtectNewestIdle); err != nil {
+		return Config{}, err
+	}
+	if err := lookupBool(lookup, "ENABLE_RUNTIME_METRICS", &cfg.RuntimeMetrics); err != nil {
+		return Config{}, err
+	}
+
 	if cfg.MinAgents > cfg.MaxAgents {
 		return Config{}, fmt.Errorf("MIN_AGENTS (%d) cannot be greater than MAX_AGENTS (%d)", cfg.MinAgents, cfg.MaxAgents)
 	}
+	if cfg.TaskCorrelation != TaskCorrelationIP && cfg.TaskCorrelation != TaskCorrelationName {
+		return Config{}, fmt.Errorf("TASK_CORRELATION (%q) must be %q or %q", cfg.TaskCorrelation, TaskCorrelationIP, TaskCorrelationName)
+	}
+	if !cfg.IdleGuardEnabled && !cfg.TaskProtectionEnabled {
+		return Config{}, fmt.Errorf("IDLE_GUARD_ENABLED and TASK_PROTECTION_ENABLED cannot both be false: busy tasks would be unprotected during scale-down")
+	}
+	if cfg.MaxScaleDownStep < 0 {
+		return Config{}, fmt.Errorf("MAX_SCALE_DOWN_STEP (%d) cannot be negative", cfg.MaxScaleDownStep)
+	}
+	if cfg.MaxScaleUpStep < 0 {
+		return Config{}, fmt.Errorf("MAX_SCALE_UP_STEP (%d) cannot be negative", cfg.MaxScaleUpStep)
+	}
+	if cfg.ReconcileTimeout <= 0 {
+		return Config{}, fmt.Errorf("RECONCILE_TIMEOUT (%s) must be positive", cfg.ReconcileTimeout)
+	}
+	if cfg.ReconcileBackoffMax < 0 {
+		return Config{}, fmt.Errorf("RECONCILE_BACKOFF_MAX (%s) cannot be negative", cfg.ReconcileBackoffMax)
+	}
+	if cfg.RunPollInterval != 0 && cfg.RunPollInterval < cfg.PollInterval {
+		return Config{}, fmt.Errorf("RUN_POLL_INTERVAL (%s) must be 0 or at least POLL_INTERVAL (%s)", cfg.RunPollInterval, cfg.PollInterval)
+	}
+	if cfg.ReadyAfterSuccesses < 1 {
+		return Config{}, fmt.Errorf("READY_AFTER_SUCCESSES (%d) must be at least 1", cfg.ReadyAfterSuccesses)
+	}
+	if cfg.MaxConsecutiveFailures < 0 {
+		return Config{}, fmt.Errorf("MAX_CONSECUTIVE_FAILURES (%d) cannot be negative", cfg.MaxConsecutiveFailures)
+	}
+	if cfg.ECSWriteBreakerThreshold < 0 {
+		return Config{}, fmt.Errorf("ECS_WRITE_BREAKER_THRESHOLD (%d) cannot be negative", cfg.ECSWriteBreakerThreshold)
+	}
+	if cfg.ECSWriteBreakerCooldown <= 0 {
+		return Config{}, fmt.Errorf("ECS_WRITE_BREAKER_COOLDOWN (%s) must be positive", cfg.ECSWriteBreakerCooldown)
+	}
+	if cfg.WarmIdle < 0 {
+		return Config{}, fmt.Errorf("WARM_IDLE (%d) cannot be negative", cfg.WarmIdle)
+	}
+	if cfg.ScaleDownIdleThreshold < 0 {
+		return Config{}, fmt.Errorf("SCALE_DOWN_IDLE_THRESHOLD (%d) cannot be negative", cfg.ScaleDownIdleThreshold)
+	}
+	if cfg.PlacementStallReconciles < 0 {
+		return Config{}, fmt.Errorf("PLACEMENT_STALL_RECONCILES (%d) cannot be negative", cfg.PlacementStallReconciles)
+	}
+	if cfg.OrphanTaskReconciles < 0 {
+		return Config{}, fmt.Errorf("ORPHAN_TASK_RECONCILES (%d) cannot be negative", cfg.OrphanTaskReconciles)
+	}
+	if !(cfg.ScaleDownFactor > 0 && cfg.ScaleDownFactor <= 1) {
+		return Config{}, fmt.Errorf("SCALE_DOWN_FACTOR (%g) must be greater than 0 and at most 1", cfg.ScaleDownFactor)
+	}
+	if !(cfg.SmoothingAlpha > 0 && cfg.SmoothingAlpha <= 1) {
+		return Config{}, fmt.Errorf("SMOOTHING_ALPHA (%g) must be greater than 0 and at most 1", cfg.SmoothingAlpha)
+	}
+	if cfg.TargetBusyRatio != 0 && !(cfg.TargetBusyRatio > 0 && cfg.TargetBusyRatio <= 1) {
+		return Config{}, fmt.Errorf("TARGET_BUSY_RATIO (%g) must be greater than 0 and at most 1", cfg.TargetBusyRatio)
+	}
+	if !(cfg.PollJitter >= 0 && cfg.PollJitter < 1) {
+		return Config{}, fmt.Errorf("POLL_JITTER (%g) must be at least 0 and less than 1", cfg.PollJitter)
+	}
+	if !validRunWeight(cfg.PlanRunWeight) {
+		return Config{}, fmt.Errorf("PLAN_RUN_WEIGHT (%g) must be a positive finite number", cfg.PlanRunWeight)
+	}
+	if !validRunWeight(cfg.ApplyRunWeight) {
+		return Config{}, fmt.Errorf("APPLY_RUN_WEIGHT (%g) must be a positive finite number", cfg.ApplyRunWeight)
+	}
+	if cfg.TFCAgentLimit < 0 {
+		return Config{}, fmt.Errorf("TFC_AGENT_LIMIT (%d) cannot be negative", cfg.TFCAgentLimit)
+	}
+	if cfg.TFCRunConcurrency < 0 {
+		return Config{}, fmt.Errorf("TFC_RUN_CONCURRENCY (%d) cannot be negative", cfg.TFCRunConcurrency)
+	}
+	if cfg.TotalMaxAgents < 0 {
+		return Config{}, fmt.Errorf("TOTAL_MAX_AGENTS (%d) cannot be negative", cfg.TotalMaxAgents)
+	}
+	if cfg.TFCMaxRetries < 0 {
+		return Config{}, fmt.Errorf("TFC_MAX_RETRIES (%d) cannot be negative", cfg.TFCMaxRetries)
+	}
+	if cfg.TFCRetryBaseDelay <= 0 {
+		return Config{}, fmt.Errorf("TFC_RETRY_BASE_DELAY (%s) must be positive", cfg.TFCRetryBaseDelay)
+	}
+	if cfg.TFCWorkspaceConcurrency < 1 {
+		return Config{}, fmt.Errorf("TFC_WORKSPACE_CONCURRENCY (%d) must be at least 1", cfg.TFCWorkspaceConcurrency)
+	}
+	if cfg.TFCPageSize < 1 || cfg.TFCPageSize > tfc.MaxPageSize {
+		return Config{}, fmt.Errorf("TFC_PAGE_SIZE (%d) must be between 1 and %d", cfg.TFCPageSize, tfc.MaxPageSize)
+	}
+	if cfg.AWSAssumeRoleExternalID != "" && cfg.AWSAssumeRoleARN == "" {
+		return Config{}, fmt.Errorf("AWS_ASSUME_ROLE_EXTERNAL_ID requires AWS_ASSUME_ROLE_ARN")
+	}
+	if err := validateRunStatuses("PLAN_PENDING_STATUSES", cfg.PlanPendingStatuses); err != nil {
+		return Config{}, err
+	}
+	if err := validateRunStatuses("APPLY_PENDING_STATUSES", cfg.ApplyPendingStatuses); err != nil {
+		return Config{}, err
+	}
+	if cfg.LogFormat != LogFormatJSON && cfg.LogFormat != LogFormatText {
+		return Config{}, fmt.Errorf("LOG_FORMAT (%q) must be %q or %q", cfg.LogFormat, LogFormatJSON, LogFormatText)
+	}
+	if cfg.HealthAddr == "unix:" {
+		return Config{}, fmt.Errorf("HEALTH_ADDR (%q) must include a socket path", cfg.HealthAddr)
+	}
+	metricsLabels, err := parseMetricsLabels(lookupList(lookup, "METRICS_LABELS"))
+	if err != nil {
+		return Config{}, err
+	}
+	cfg.MetricsLabels = metricsLabels
+	runStatusWeights, err := parseRunStatusWeights(lookupList(lookup, "RUN_STATUS_WEIGHTS"))
+	if err != nil {
+		return Config{}, err
+	}
+	cfg.RunStatusWeights = runStatusWeights
+	if cfg.MetricsNamespace != "" && !prometheusNamePattern.MatchString(cfg.MetricsNamespace) {
+		return Config{}, fmt.Errorf("METRICS_NAMESPACE (%q) must start with a letter or underscore and contain only letters, digits, and underscores", cfg.MetricsNamespace)
+	}
+	if cfg.MaxTaskAge < 0 {
+		return Config{}, fmt.Errorf("MAX_TASK_AGE (%s) cannot be negative", cfg.MaxTaskAge)
+	}
+	if cfg.MaxQueueWait < 0 {
+		return Config{}, fmt.Errorf("MAX_QUEUE_WAIT (%s) cannot be negative", cfg.MaxQueueWait)
+	}
+	if cfg.ScaleUpStabilization < 0 {
+		return Config{}, fmt.Errorf("SCALE_UP_STABILIZATION (%s) cannot be negative", cfg.ScaleUpStabilization)
+	}
+	if cfg.ScaleToZeroGrace < 0 {
+		return Config{}, fmt.Errorf("SCALE_TO_ZERO_GRACE (%s) cannot be negative", cfg.ScaleToZeroGrace)
+	}
+	if cfg.StartupDelay < 0 {
+		return Config{}, fmt.Errorf("STARTUP_DELAY (%s) cannot be negative", cfg.StartupDelay)
+	}
+	if cfg.ShutdownTimeout < 0 {
+		return Config{}, fmt.Errorf("SHUTDOWN_TIMEOUT (%s) cannot be negative", cfg.ShutdownTimeout)
+	}
+	if cfg.ScaleWebhookURL != "" {
+		u, err := url.Parse(cfg.ScaleWebhookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return Config{}, fmt.Errorf("SCALE_WEBHOOK_URL (%q) must be an http or https URL", cfg.ScaleWebhookURL)
+		}
+	}
+	if cfg.ScaleWebhookTimeout <= 0 {
+		return Config{}, fmt.Errorf("SCALE_WEBHOOK_TIMEOUT (%s) must be positive", cfg.ScaleWebhookTimeout)
+	}
+	if cfg.TaskIPCacheTTL < 0 {
+		return Config{}, fmt.Errorf("TASK_IP_CACHE_TTL (%s) cannot be negative", cfg.TaskIPCacheTTL)
+	}
+	if cfg.TaskProtectionExpiry < minTaskProtectionExpiry || cfg.TaskProtectionExpiry > maxTaskProtectionExpiry {
+		return Config{}, fmt.Errorf("TASK_PROTECTION_EXPIRY (%s) must be between %s and %s",
+			cfg.TaskProtectionExpiry, minTaskProtectionExpiry, maxTaskProtectionExpiry)
+	}
+	if cfg.TaskProtectionBatchSize < 1 || cfg.TaskProtectionBatchSize > maxTaskProtectionBatchSize {
+		return Config{}, fmt.Errorf("TASK_PROTECTION_BATCH_SIZE (%d) must be between 1 and %d",
+			cfg.TaskProtectionBatchSize, maxTaskProtectionBatchSize)
+	}
 
 	if err := loadSpotConfig(lookup, &cfg); err != nil {
 		return Config{}, err
 	}
+	if err := loadBusinessHours(lookup, &cfg); err != nil {
+		return Config{}, err
+	}
+
+	if cfg.TFCAgentPoolName != "" && cfg.TFCAgentPoolID != "" {
+		return Config{}, fmt.Errorf("TFC_AGENT_POOL_NAME cannot be combined with TFC_AGENT_POOL_ID")
+	}
+	if multiPool {
+		if cfg.SpotService != nil {
+			return Config{}, fmt.Errorf("TFC_POOLS cannot be combined with ECS_SPOT_SERVICE")
+		}
+		if cfg.TFCAgentPoolName != "" {
+			return Config{}, fmt.Errorf("TFC_POOLS cannot be combined with TFC_AGENT_POOL_NAME")
+		}
+		pools, err := parsePools(poolsJSON, cfg.MinAgents, cfg.MaxAgents)
+		if err != nil {
+			return Config{}, err
+		}
+		cfg.Pools = pools
+	}
+
+	if multiService {
+		if multiPool {
+			return Config{}, fmt.Errorf("ECS_SERVICES cannot be combined with TFC_POOLS")
+		}
+		if cfg.SpotService != nil {
+			return Config{}, fmt.Errorf("ECS_SERVICES cannot be combined with ECS_SPOT_SERVICE")
+		}
+		services, err := parseServices(servicesJSON, cfg.MinAgents, cfg.MaxAgents, cfg.CooldownPeriod)
+		if err != nil {
+			return Config{}, err
+		}
+		cfg.Services = services
+	}
+
+	if cfg.TotalMaxAgents > 0 && cfg.TotalMaxAgents < cfg.totalMinAgents() {
+		return Config{}, fmt.Errorf("TOTAL_MAX_AGENTS (%d) cannot be less than the combined minimum agents (%d)",
+			cfg.TotalMaxAgents, cfg.totalMinAgents())
+	}
 
 	return cfg, nil
 }
 
+// totalMinAgents returns the sum of the minimum agents of every service.
+func (c Config) totalMinAgents() int {
+	var total int
+	if len(c.Pools) > 0 {
+		for _, pool := range c.Pools {
+			total += pool.MinAgents
+		}
+		return total
+	}
+	if services := c.ServiceList(); len(services) > 0 {
+		for _, service := range services {
+			total += service.MinAgents
+		}
+		return total
+	}
+	return c.MinAgents
+}
+
+// ServiceList returns the ECS services sharing the agent pool: ECS_SERVICES
+// when set, otherwise the dual-service pair of ECS_SERVICE as "regular",
+// handling apply runs, and ECS_SPOT_SERVICE as "spot", handling plan runs. It
+// returns nil in single-service and multi-pool modes.
+func (c Config) ServiceList() []ServiceConfig {
+	if len(c.Services) > 0 {
+		return c.Services
+	}
+	if c.SpotService == nil {
+		return nil
+	}
+	spot := *c.SpotService
+	spot.Name, spot.RunType, spot.Spot = "spot", "plan", true
+	return []ServiceConfig{
+		{
+			Name:            "regular",
+			ECSService:      c.ECSService,
+			MinAgents:       c.MinAgents,
+			MaxAgents:       c.MaxAgents,
+			AgentNamePrefix: c.AgentNamePrefix,
+			CooldownPeriod:  c.CooldownPeriod,
+			RunType:         "apply",
+		},
+		spot,
+	}
+}
+
+// validateRunStatuses returns an error naming the first entry of statuses
+// that is not a known TFC run status.
+func validateRunStatuses(key string, statuses []string) error {
+	for _, status := range statuses {
+		if !tfc.IsRunStatus(status) {
+			return fmt.Errorf("%s contains unknown run status %q", key, status)
+		}
+	}
+	return nil
+}
+
+func validRunWeight(w float64) bool {
+	return w > 0 && !math.IsInf(w, 1)
+}
+
+// poolJSON is the TFC_POOLS wire format. Bounds are pointers so omitted
+// values can fall back to MIN_AGENTS/MAX_AGENTS.
+type poolJSON struct {
+	Name        string `json:"name"`
+	AgentPoolID string `json:"agent_pool_id"`
+	ECSService  string `json:"ecs_service"`
+	MinAgents   *int   `json:"min_agents"`
+	MaxAgents   *int   `json:"max_agents"`
+	Token       string `json:"token"`
+}
+
+// serviceJSON is the ECS_SERVICES wire format. Bounds are pointers so omitted
+// values can fall back to MIN_AGENTS/MAX_AGENTS.
+type serviceJSON struct {
+	Name            string `json:"name"`
+	ECSService      string `json:"ecs_service"`
+	RunType         string `json:"run_type"`
+	MinAgents       *int   `json:"min_agents"`
+	MaxAgents       *int   `json:"max_agents"`
+	CooldownPeriod  string `json:"cooldown_period"`
+	AgentNamePrefix string `json:"agent_name_prefix"`
+	Spot            bool   `json:"spot"`
+}
+
+// parseMetricsLabels parses METRICS_LABELS entries of the form key=value.
+func parseMetricsLabels(items []string) (map[string]string, error) {
+	if len(items) == 0 {
+		return nil, nil
+	}
+	labels := make(map[string]string, len(items))
+	for _, item := range items {
+		key, value, ok := strings.Cut(item, "=")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if !ok || key == "" || value == "" {
+			return nil, fmt.Errorf("METRICS_LABELS entry %q must be key=value", item)
+		}
+		if !prometheusNamePattern.MatchString(key) || strings.HasPrefix(key, "__") {
+			return nil, fmt.Errorf("METRICS_LABELS key %q is not a valid label name", key)
+		}
+		if slices.Contains(reservedMetricsLabels, key) {
+			return nil, fmt.Errorf("METRICS_LABELS key %q is reserved", key)
+		}
+		if _, dup := labels[key]; dup {
+			return nil, fmt.Errorf("METRICS_LABELS key %q is set more than once", key)
+		}
+		labels[key] = value
+	}
+	return labels, nil
+}
+
+// parseRunStatusWeights parses RUN_STATUS_WEIGHTS entries of the form
+// status=weight.
+func parseRunStatusWeights(items []string) (map[string]float64, error) {
+	if len(items) == 0 {
+		return nil, nil
+	}
+	weights := make(map[string]float64, len(items))
+	for _, item := range items {
+		status, value, ok := strings.Cut(item, "=")
+		status, value = strings.TrimSpace(status), strings.TrimSpace(value)
+		if !ok || status == "" || value == "" {
+			return nil, fmt.Errorf("RUN_STATUS_WEIGHTS entry %q must be status=weight", item)
+		}
+		if !tfc.IsRunStatus(status) {
+			return nil, fmt.Errorf("RUN_STATUS_WEIGHTS contains unknown run status %q", status)
+		}
+		if _, dup := weights[status]; dup {
+			return nil, fmt.Errorf("RUN_STATUS_WEIGHTS status %q is set more than once", status)
+		}
+		weight, err := strconv.ParseFloat(value, 64)
+		if err != nil || !validRunWeight(weight) {
+			return nil, fmt.Errorf("RUN_STATUS_WEIGHTS weight for %q (%q) must be a positive finite number", status, value)
+		}
+		weights[status] = weight
+	}
+	return weights, nil
+}
+
+// parsePools decodes and validates the TFC_POOLS JSON list.
+func parsePools(raw string, defaultMin, defaultMax int) ([]PoolConfig, error) {
+	var entries []poolJSON
+	if err := json.Unmarshal([]byte(raw), &entries); err != nil {
+		return nil, fmt.Errorf("invalid TFC_POOLS: %w", err)
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("TFC_POOLS must contain at least one pool")
+	}
+
+	pools := make([]PoolConfig, 0, len(entries))
+	seen := make(map[string]bool, len(entries))
+	for i, e := range entries {
+		if e.AgentPoolID == "" {
+			return nil, fmt.Errorf("TFC_POOLS[%d]: agent_pool_id is required", i)
+		}
+		if e.ECSService == "" {
+			return nil, fmt.Errorf("TFC_POOLS[%d]: ecs_service is required", i)
+		}
+
+		pool := PoolConfig{
+			Name:        e.Name,
+			AgentPoolID: e.AgentPoolID,
+			ECSService:  e.ECSService,
+			MinAgents:   defaultMin,
+			MaxAgents:   defaultMax,
+			Token:       e.Token,
+		}
+		if pool.Name == "" {
+			pool.Name = pool.AgentPoolID
+		}
+		if e.MinAgents != nil {
+			pool.MinAgents = *e.MinAgents
+		}
+		if e.MaxAgents != nil {
+			pool.MaxAgents = *e.MaxAgents
+		}
+
+		if seen[pool.Name] {
+			return nil, fmt.Errorf("TFC_POOLS[%d]: duplicate pool name %q", i, pool.Name)
+		}
+		seen[pool.Name] = true
+
+		if pool.MinAgents > pool.MaxAgents {
+			return nil, fmt.Errorf("TFC_POOLS[%d]: min_agents (%d) cannot be greater than max_agents (%d)", i, pool.MinAgents, pool.MaxAgents)
+		}
+
+		pools = append(pools, pool)
+	}
+
+	return pools, nil
+}
+
+// parseServices decodes and validates the ECS_SERVICES JSON list.
+func parseServices(raw string, defaultMin, defaultMax int, defaultCooldown time.Duration) ([]ServiceConfig, error) {
+	var entries []serviceJSON
+	if err := json.Unmarshal([]byte(raw), &entries); err != nil {
+		return nil, fmt.Errorf("invalid ECS_SERVICES: %w", err)
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("ECS_SERVICES must contain at least one service")
+	}
+
+	services := make([]ServiceConfig, 0, len(entries))
+	names := make(map[string]bool, len(entries))
+	runTypes := make(map[string]string, len(entries))
+	for i, e := range entries {
+		if e.ECSService == "" {
+			return nil, fmt.Errorf("ECS_SERVICES[%d]: ecs_service is required", i)
+		}
+		if _, err := tfc.ParseRunType(e.RunType); err != nil {
+			return nil, fmt.Errorf("ECS_SERVICES[%d]: run_type: %w", i, err)
+		}
+
+		service := ServiceConfig{
+			Name:            e.Name,
+			ECSService:      e.ECSService,
+			MinAgents:       defaultMin,
+			MaxAgents:       defaultMax,
+			AgentNamePrefix: e.AgentNamePrefix,
+			CooldownPeriod:  defaultCooldown,
+			RunType:         e.RunType,
+			Spot:            e.Spot,
+		}
+		if service.Name == "" {
+			service.Name = service.ECSService
+		}
+		if e.MinAgents != nil {
+			service.MinAgents = *e.MinAgents
+		}
+		if e.MaxAgents != nil {
+			service.MaxAgents = *e.MaxAgents
+		}
+		if e.CooldownPeriod != "" {
+			d, err := time.ParseDuration(e.CooldownPeriod)
+			if err != nil {
+				return nil, fmt.Errorf("ECS_SERVICES[%d]: invalid cooldown_period: %w", i, err)
+			}
+			service.CooldownPeriod = d
+		}
+
+		if names[service.Name] {
+			return nil, fmt.Errorf("ECS_SERVICES[%d]: duplicate service name %q", i, service.Name)
+		}
+		names[service.Name] = true
+		// Services sharing a run type would each scale for all of its runs.
+		if other, dup := runTypes[service.RunType]; dup {
+			return nil, fmt.Errorf("ECS_SERVICES[%d]: run_type %q is already handled by %q", i, service.RunType, other)
+		}
+		runTypes[service.RunType] = service.Name
+
+		if service.MinAgents > service.MaxAgents {
+			return nil, fmt.Errorf("ECS_SERVICES[%d]: min_agents (%d) cannot be greater than max_agents (%d)", i, service.MinAgents, service.MaxAgents)
+		}
+		if service.CooldownPeriod < 0 {
+			return nil, fmt.Errorf("ECS_SERVICES[%d]: cooldown_period (%s) cannot be negative", i, service.CooldownPeriod)
+		}
+
+		services = append(services, service)
+	}
+
+	return services, nil
+}
+
+// ImmutableChanges returns the environment variables whose values differ
+// between c and next but cannot be applied without a restart.
+func (c Config) ImmutableChanges(next Config) []string {
+	var changed []string
+	check := func(key string, differs bool) {
+		if differs {
+			changed = append(changed, key)
+		}
+	}
+
+	check("TFC_TOKEN", c.TFCToken != next.TFCToken)
+	check("TFE_ADDRESS", c.TFCAddress != next.TFCAddress)
+	check("TFC_AGENT_POOL_ID", c.TFCAgentPoolID != next.TFCAgentPoolID)
+	check("TFC_AGENT_POOL_NAME", c.TFCAgentPoolName != next.TFCAgentPoolName)
+	check("TFC_ORG", c.TFCOrg != next.TFCOrg)
+	check("ECS_CLUSTER", c.ECSCluster != next.ECSCluster)
+	check("ECS_SERVICE", c.ECSService != next.ECSService)
+	check("ECS_SPOT_SERVICE", spotServiceName(c) != spotServiceName(next))
+	check("TFC_POOLS", !slices.EqualFunc(c.Pools, next.Pools, func(a, b PoolConfig) bool {
+		return a.Name == b.Name && a.AgentPoolID == b.AgentPoolID && a.ECSService == b.ECSService &&
+			a.Token == b.Token
+	}))
+	check("ECS_SERVICES", !slices.EqualFunc(c.Services, next.Services, func(a, b ServiceConfig) bool {
+		return a.Name == b.Name && a.ECSService == b.ECSService && a.RunType == b.RunType &&
+			a.AgentNamePrefix == b.AgentNamePrefix && a.Spot == b.Spot
+	}))
+	check("HEALTH_ADDR", c.HealthAddr != next.HealthAddr)
+	check("METRICS_NAMESPACE", c.MetricsNamespace != next.MetricsNamespace)
+	check("METRICS_LABELS", !maps.Equal(c.MetricsLabels, next.MetricsLabels))
+	check("TOTAL_MAX_AGENTS", c.TotalMaxAgents != next.TotalMaxAgents)
+
+	return changed
+}
+
+// UnappliedChanges returns the names of the Config fields that differ between
+// c and next but that reload neither applies to running scalers (the agent
+// bounds, poll interval, and cooldowns) nor reports through ImmutableChanges.
+// Such changes take effect only after a restart.
+func (c Config) UnappliedChanges(next Config) []string {
+	a, b := reflect.ValueOf(c.withoutReloadFields()), reflect.ValueOf(next.withoutReloadFields())
+	var changed []string
+	for i := range a.NumField() {
+		if !reflect.DeepEqual(a.Field(i).Interface(), b.Field(i).Interface()) {
+			changed = append(changed, a.Type().Field(i).Name)
+		}
+	}
+	return changed
+}
+
+// withoutReloadFields returns c with the fields reload applies, and those
+// ImmutableChanges compares, cleared.
+func (c Config) withoutReloadFields() Config {
+	c.MinAgents, c.MaxAgents = 0, 0
+	c.PollInterval, c.CooldownPeriod = 0, 0
+	c.TFCToken, c.TFCAddress, c.TFCAgentPoolID, c.TFCAgentPoolName, c.TFCOrg = "", "", "", "", ""
+	c.ECSCluster, c.ECSService = "", ""
+	c.HealthAddr, c.MetricsNamespace, c.MetricsLabels = "", "", nil
+	c.TotalMaxAgents = 0
+	// Every pool and service field is either a bound or part of the pool's
+	// or service's identity.
+	c.Pools, c.Services = nil, nil
+	if c.SpotService != nil {
+		spot := ServiceConfig{AgentNamePrefix: c.SpotService.AgentNamePrefix}
+		c.SpotService = nil
+		if spot != (ServiceConfig{}) {
+			c.SpotService = &spot
+		}
+	}
+	if c.BusinessHours != nil && c.BusinessHours.Location != nil {
+		// Loaded locations carry lookup caches, so compare them by name.
+		hours := *c.BusinessHours
+		hours.Location = time.FixedZone(hours.Location.String(), 0)
+		c.BusinessHours = &hours
+	}
+	return c
+}
+
+func spotServiceName(c Config) string {
+	if c.SpotService == nil {
+		return ""
+	}
+	return c.SpotService.ECSService
+}
+
+// redactedValue replaces secrets in Redacted output.
+const redactedValue = "REDACTED"
+
+// redact masks a secret, leaving empty values visible as unset.
+func redact(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return redactedValue
+}
+
+// Redacted returns a copy of c with the TFC tokens and the AWS assume-role
+// external ID masked, safe to log or serve.
+func (c Config) Redacted() Config {
+	c.TFCToken = redact(c.TFCToken)
+	c.AWSAssumeRoleExternalID = redact(c.AWSAssumeRoleExternalID)
+	if c.Pools != nil {
+		pools := slices.Clone(c.Pools)
+		for i := range pools {
+			pools[i].Token = redact(pools[i].Token)
+		}
+		c.Pools = pools
+	}
+	return c
+}
+
+// plainConfig drops Config's methods so it can be encoded without recursing.
+type plainConfig Config
+
+// MarshalJSON encodes the redacted configuration.
+func (c Config) MarshalJSON() ([]byte, error) {
+	return json.Marshal(plainConfig(c.Redacted()))
+}
+
+// String returns the redacted configuration as JSON.
+func (c Config) String() string {
+	data, err := c.MarshalJSON()
+	if err != nil {
+		return fmt.Sprintf("config: %v", err)
+	}
+	return string(data)
+}
+
+// LogValue implements slog.LogValuer so logging a Config never emits secrets.
+func (c Config) LogValue() slog.Value {
+	return slog.AnyValue(plainConfig(c.Redacted()))
+}
+
+// loadToken sets the TFC token from TFC_TOKEN or, when the secret is mounted
+// as a file, from the whitespace-trimmed contents of TFC_TOKEN_FILE. Exactly
+// one of the two must be set.
+func loadToken(lookup lookupFn, cfg *Config) error {
+	token, hasToken := lookup("TFC_TOKEN")
+	hasToken = hasToken && token != ""
+	path, hasFile := lookup("TFC_TOKEN_FILE")
+	hasFile = hasFile && path != ""
+
+	switch {
+	case hasToken && hasFile:
+		return fmt.Errorf("TFC_TOKEN and TFC_TOKEN_FILE are mutually exclusive")
+	case hasToken:
+		cfg.TFCToken = token
+		return nil
+	case !hasFile:
+		return fmt.Errorf("required environment variable TFC_TOKEN or TFC_TOKEN_FILE is not set")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading TFC_TOKEN_FILE: %w", err)
+	}
+	cfg.TFCToken = strings.TrimSpace(string(data))
+	if cfg.TFCToken == "" {
+		return fmt.Errorf("TFC_TOKEN_FILE %q is empty", path)
+	}
+	return nil
+}
+
 func loadSpotConfig(lookup lookupFn, cfg *Config) error {
 	v, ok := lookup("ECS_SPOT_SERVICE")
 	if !ok || v == "" {
@@ -135,9 +1308,10 @@ func loadSpotConfig(lookup lookupFn, cfg *Config) error {
 	}
 
 	spot := &ServiceConfig{
-		ECSService: v,
-		MinAgents:  0,
-		MaxAgents:  10,
+		ECSService:     v,
+		MinAgents:      0,
+		MaxAgents:      10,
+		CooldownPeriod: cfg.CooldownPeriod,
 	}
 
 	if err := lookupInt(lookup, "SPOT_MIN_AGENTS", &spot.MinAgents); err != nil {
@@ -146,6 +1320,10 @@ func loadSpotConfig(lookup lookupFn, cfg *Config) error {
 	if err := lookupInt(lookup, "SPOT_MAX_AGENTS", &spot.MaxAgents); err != nil {
 		return err
 	}
+	lookupString(lookup, "SPOT_AGENT_NAME_PREFIX", &spot.AgentNamePrefix)
+	if err := lookupDuration(lookup, "SPOT_COOLDOWN_PERIOD", &spot.CooldownPeriod); err != nil {
+		return err
+	}
 
 	if spot.MinAgents > spot.MaxAgents {
 		return fmt.Errorf("SPOT_MIN_AGENTS (%d) cannot be greater than SPOT_MAX_AGENTS (%d)", spot.MinAgents, spot.MaxAgents)
@@ -154,3 +1332,65 @@ func loadSpotConfig(lookup lookupFn, cfg *Config) error {
 	cfg.SpotService = spot
 	return nil
 }
+
+// businessHoursKeys are the variables configuring BusinessHours.
+var businessHoursKeys = []string{"BUSINESS_HOURS_MIN", "BUSINESS_HOURS_START", "BUSINESS_HOURS_END", "BUSINESS_HOURS_TZ"}
+
+// loadBusinessHours sets cfg.BusinessHours when BUSINESS_HOURS_MIN,
+// BUSINESS_HOURS_START, and BUSINESS_HOURS_END are all set. Setting only
+// some of the variables is an error rather than silently disabling the
+// schedule. BUSINESS_HOURS_TZ defaults to UTC.
+func loadBusinessHours(lookup lookupFn, cfg *Config) error {
+	var set []string
+	for _, key := range businessHoursKeys {
+		if v, ok := lookup(key); ok && v != "" {
+			set = append(set, key)
+		}
+	}
+	if len(set) == 0 {
+		return nil
+	}
+	for _, key := range businessHoursKeys[:3] {
+		if !slices.Contains(set, key) {
+			return fmt.Errorf("%s is required when %s is set", key, strings.Join(set, ", "))
+		}
+	}
+
+	hours := &BusinessHoursConfig{Location: time.UTC}
+	if err := lookupInt(lookup, "BUSINESS_HOURS_MIN", &hours.MinAgents); err != nil {
+		return err
+	}
+	if hours.MinAgents < 0 || hours.MinAgents > cfg.MaxAgents {
+		return fmt.Errorf("BUSINESS_HOURS_MIN (%d) must be between 0 and MAX_AGENTS (%d)", hours.MinAgents, cfg.MaxAgents)
+	}
+
+	var err error
+	if hours.Start, err = lookupTimeOfDay(lookup, "BUSINESS_HOURS_START"); err != nil {
+		return err
+	}
+	if hours.End, err = lookupTimeOfDay(lookup, "BUSINESS_HOURS_END"); err != nil {
+		return err
+	}
+	if hours.Start == hours.End {
+		return fmt.Errorf("BUSINESS_HOURS_START and BUSINESS_HOURS_END cannot be equal")
+	}
+
+	if tz, ok := lookup("BUSINESS_HOURS_TZ"); ok && tz != "" {
+		if hours.Location, err = time.LoadLocation(tz); err != nil {
+			return fmt.Errorf("invalid BUSINESS_HOURS_TZ %q: %w", tz, err)
+		}
+	}
+
+	cfg.BusinessHours = hours
+	return nil
+}
+
+// lookupTimeOfDay parses an "HH:MM" wall-clock time as an offset from midnight.
+func lookupTimeOfDay(lookup lookupFn, key string) (time.Duration, error) {
+	v, _ := lookup(key)
+	t, err := time.Parse("15:04", v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: want HH:MM", key, v)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
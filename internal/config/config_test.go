@@ -1,6 +1,14 @@
 package config
 
 import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 )
@@ -22,17 +30,18 @@ func TestLoad(t *testing.T) {
 				"ECS_SERVICE":       "tfc-agent",
 			},
 			want: Config{
-				TFCToken:       "test-token",
-				TFCAddress:     "https://app.terraform.io",
-				TFCAgentPoolID: "apool-123",
-				TFCOrg:         "my-org",
-				ECSCluster:     "my-cluster",
-				ECSService:     "tfc-agent",
-				PollInterval:   10 * time.Second,
-				MinAgents:      0,
-				MaxAgents:      10,
-				CooldownPeriod: 60 * time.Second,
-				HealthAddr:     ":8080",
+				TFCToken:             "test-token",
+				TFCAddress:           "https://app.terraform.io",
+				TFCAgentPoolID:       "apool-123",
+				TFCOrg:               "my-org",
+				ECSCluster:           "my-cluster",
+				ECSService:           "tfc-agent",
+				PollInterval:         10 * time.Second,
+				MinAgents:            0,
+				MaxAgents:            10,
+				CooldownPeriod:       60 * time.Second,
+				HealthAddr:           ":8080",
+				TaskProtectionExpiry: 120 * time.Minute,
 			},
 		},
 		{
@@ -51,17 +60,18 @@ func TestLoad(t *testing.T) {
 				"HEALTH_ADDR":       ":9090",
 			},
 			want: Config{
-				TFCToken:       "test-token",
-				TFCAddress:     "https://tfe.example.com",
-				TFCAgentPoolID: "apool-456",
-				TFCOrg:         "other-org",
-				ECSCluster:     "prod-cluster",
-				ECSService:     "tfc-agent-prod",
-				PollInterval:   30 * time.Second,
-				MinAgents:      2,
-				MaxAgents:      20,
-				CooldownPeriod: 120 * time.Second,
-				HealthAddr:     ":9090",
+				TFCToken:             "test-token",
+				TFCAddress:           "https://tfe.example.com",
+				TFCAgentPoolID:       "apool-456",
+				TFCOrg:               "other-org",
+				ECSCluster:           "prod-cluster",
+				ECSService:           "tfc-agent-prod",
+				PollInterval:         30 * time.Second,
+				MinAgents:            2,
+				MaxAgents:            20,
+				CooldownPeriod:       120 * time.Second,
+				HealthAddr:           ":9090",
+				TaskProtectionExpiry: 120 * time.Minute,
 			},
 		},
 		{
@@ -140,6 +150,193 @@ func TestLoad(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "max scale down step",
+			env: map[string]string{
+				"TFC_TOKEN":           "test-token",
+				"TFC_AGENT_POOL_ID":   "apool-123",
+				"TFC_ORG":             "my-org",
+				"ECS_CLUSTER":         "my-cluster",
+				"ECS_SERVICE":         "tfc-agent",
+				"MAX_SCALE_DOWN_STEP": "3",
+			},
+			want: Config{
+				TFCToken:             "test-token",
+				TFCAddress:           "https://app.terraform.io",
+				TFCAgentPoolID:       "apool-123",
+				TFCOrg:               "my-org",
+				ECSCluster:           "my-cluster",
+				ECSService:           "tfc-agent",
+				PollInterval:         10 * time.Second,
+				MinAgents:            0,
+				MaxAgents:            10,
+				CooldownPeriod:       60 * time.Second,
+				HealthAddr:           ":8080",
+				TaskProtectionExpiry: 120 * time.Minute,
+				MaxScaleDownStep:     3,
+			},
+		},
+		{
+			name: "negative MAX_SCALE_DOWN_STEP",
+			env: map[string]string{
+				"TFC_TOKEN":           "test-token",
+				"TFC_AGENT_POOL_ID":   "apool-123",
+				"TFC_ORG":             "my-org",
+				"ECS_CLUSTER":         "my-cluster",
+				"ECS_SERVICE":         "tfc-agent",
+				"MAX_SCALE_DOWN_STEP": "-1",
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid MAX_SCALE_DOWN_STEP",
+			env: map[string]string{
+				"TFC_TOKEN":           "test-token",
+				"TFC_AGENT_POOL_ID":   "apool-123",
+				"TFC_ORG":             "my-org",
+				"ECS_CLUSTER":         "my-cluster",
+				"ECS_SERVICE":         "tfc-agent",
+				"MAX_SCALE_DOWN_STEP": "lots",
+			},
+			wantErr: true,
+		},
+		{
+			name: "max scale up step",
+			env: map[string]string{
+				"TFC_TOKEN":         "test-token",
+				"TFC_AGENT_POOL_ID": "apool-123",
+				"TFC_ORG":           "my-org",
+				"ECS_CLUSTER":       "my-cluster",
+				"ECS_SERVICE":       "tfc-agent",
+				"MAX_SCALE_UP_STEP": "5",
+			},
+			want: Config{
+				TFCToken:             "test-token",
+				TFCAddress:           "https://app.terraform.io",
+				TFCAgentPoolID:       "apool-123",
+				TFCOrg:               "my-org",
+				ECSCluster:           "my-cluster",
+				ECSService:           "tfc-agent",
+				PollInterval:         10 * time.Second,
+				MinAgents:            0,
+				MaxAgents:            10,
+				CooldownPeriod:       60 * time.Second,
+				HealthAddr:           ":8080",
+				TaskProtectionExpiry: 120 * time.Minute,
+				MaxScaleUpStep:       5,
+			},
+		},
+		{
+			name: "negative MAX_SCALE_UP_STEP",
+			env: map[string]string{
+				"TFC_TOKEN":         "test-token",
+				"TFC_AGENT_POOL_ID": "apool-123",
+				"TFC_ORG":           "my-org",
+				"ECS_CLUSTER":       "my-cluster",
+				"ECS_SERVICE":       "tfc-agent",
+				"MAX_SCALE_UP_STEP": "-2",
+			},
+			wantErr: true,
+		},
+		{
+			name: "dry run enabled",
+			env: map[string]string{
+				"TFC_TOKEN":         "test-token",
+				"TFC_AGENT_POOL_ID": "apool-123",
+				"TFC_ORG":           "my-org",
+				"ECS_CLUSTER":       "my-cluster",
+				"ECS_SERVICE":       "tfc-agent",
+				"DRY_RUN":           "true",
+			},
+			want: Config{
+				TFCToken:             "test-token",
+				TFCAddress:           "https://app.terraform.io",
+				TFCAgentPoolID:       "apool-123",
+				TFCOrg:               "my-org",
+				ECSCluster:           "my-cluster",
+				ECSService:           "tfc-agent",
+				PollInterval:         10 * time.Second,
+				MinAgents:            0,
+				MaxAgents:            10,
+				CooldownPeriod:       60 * time.Second,
+				HealthAddr:           ":8080",
+				TaskProtectionExpiry: 120 * time.Minute,
+				DryRun:               true,
+			},
+		},
+		{
+			name: "invalid DRY_RUN",
+			env: map[string]string{
+				"TFC_TOKEN":         "test-token",
+				"TFC_AGENT_POOL_ID": "apool-123",
+				"TFC_ORG":           "my-org",
+				"ECS_CLUSTER":       "my-cluster",
+				"ECS_SERVICE":       "tfc-agent",
+				"DRY_RUN":           "maybe",
+			},
+			wantErr: true,
+		},
+		{
+			name: "task protection expiry",
+			env: map[string]string{
+				"TFC_TOKEN":              "test-token",
+				"TFC_AGENT_POOL_ID":      "apool-123",
+				"TFC_ORG":                "my-org",
+				"ECS_CLUSTER":            "my-cluster",
+				"ECS_SERVICE":            "tfc-agent",
+				"TASK_PROTECTION_EXPIRY": "4h",
+			},
+			want: Config{
+				TFCToken:             "test-token",
+				TFCAddress:           "https://app.terraform.io",
+				TFCAgentPoolID:       "apool-123",
+				TFCOrg:               "my-org",
+				ECSCluster:           "my-cluster",
+				ECSService:           "tfc-agent",
+				PollInterval:         10 * time.Second,
+				MinAgents:            0,
+				MaxAgents:            10,
+				CooldownPeriod:       60 * time.Second,
+				HealthAddr:           ":8080",
+				TaskProtectionExpiry: 4 * time.Hour,
+			},
+		},
+		{
+			name: "TASK_PROTECTION_EXPIRY below minimum",
+			env: map[string]string{
+				"TFC_TOKEN":              "test-token",
+				"TFC_AGENT_POOL_ID":      "apool-123",
+				"TFC_ORG":                "my-org",
+				"ECS_CLUSTER":            "my-cluster",
+				"ECS_SERVICE":            "tfc-agent",
+				"TASK_PROTECTION_EXPIRY": "30s",
+			},
+			wantErr: true,
+		},
+		{
+			name: "TASK_PROTECTION_EXPIRY above maximum",
+			env: map[string]string{
+				"TFC_TOKEN":              "test-token",
+				"TFC_AGENT_POOL_ID":      "apool-123",
+				"TFC_ORG":                "my-org",
+				"ECS_CLUSTER":            "my-cluster",
+				"ECS_SERVICE":            "tfc-agent",
+				"TASK_PROTECTION_EXPIRY": "49h",
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid TASK_PROTECTION_EXPIRY",
+			env: map[string]string{
+				"TFC_TOKEN":              "test-token",
+				"TFC_AGENT_POOL_ID":      "apool-123",
+				"TFC_ORG":                "my-org",
+				"ECS_CLUSTER":            "my-cluster",
+				"ECS_SERVICE":            "tfc-agent",
+				"TASK_PROTECTION_EXPIRY": "forever",
+			},
+			wantErr: true,
+		},
 		{
 			name: "spot service enabled",
 			env: map[string]string{
@@ -153,21 +350,23 @@ func TestLoad(t *testing.T) {
 				"SPOT_MAX_AGENTS":   "20",
 			},
 			want: Config{
-				TFCToken:       "test-token",
-				TFCAddress:     "https://app.terraform.io",
-				TFCAgentPoolID: "apool-123",
-				TFCOrg:         "my-org",
-				ECSCluster:     "my-cluster",
-				ECSService:     "tfc-agent",
-				PollInterval:   10 * time.Second,
-				MinAgents:      0,
-				MaxAgents:      10,
-				CooldownPeriod: 60 * time.Second,
-				HealthAddr:     ":8080",
+				TFCToken:             "test-token",
+				TFCAddress:           "https://app.terraform.io",
+				TFCAgentPoolID:       "apool-123",
+				TFCOrg:               "my-org",
+				ECSCluster:           "my-cluster",
+				ECSService:           "tfc-agent",
+				PollInterval:         10 * time.Second,
+				MinAgents:            0,
+				MaxAgents:            10,
+				CooldownPeriod:       60 * time.Second,
+				HealthAddr:           ":8080",
+				TaskProtectionExpiry: 120 * time.Minute,
 				SpotService: &ServiceConfig{
-					ECSService: "tfc-agent-spot",
-					MinAgents:  1,
-					MaxAgents:  20,
+					ECSService:     "tfc-agent-spot",
+					MinAgents:      1,
+					MaxAgents:      20,
+					CooldownPeriod: 60 * time.Second,
 				},
 			},
 		},
@@ -182,21 +381,23 @@ func TestLoad(t *testing.T) {
 				"ECS_SPOT_SERVICE":  "tfc-agent-spot",
 			},
 			want: Config{
-				TFCToken:       "test-token",
-				TFCAddress:     "https://app.terraform.io",
-				TFCAgentPoolID: "apool-123",
-				TFCOrg:         "my-org",
-				ECSCluster:     "my-cluster",
-				ECSService:     "tfc-agent",
-				PollInterval:   10 * time.Second,
-				MinAgents:      0,
-				MaxAgents:      10,
-				CooldownPeriod: 60 * time.Second,
-				HealthAddr:     ":8080",
+				TFCToken:             "test-token",
+				TFCAddress:           "https://app.terraform.io",
+				TFCAgentPoolID:       "apool-123",
+				TFCOrg:               "my-org",
+				ECSCluster:           "my-cluster",
+				ECSService:           "tfc-agent",
+				PollInterval:         10 * time.Second,
+				MinAgents:            0,
+				MaxAgents:            10,
+				CooldownPeriod:       60 * time.Second,
+				HealthAddr:           ":8080",
+				TaskProtectionExpiry: 120 * time.Minute,
 				SpotService: &ServiceConfig{
-					ECSService: "tfc-agent-spot",
-					MinAgents:  0,
-					MaxAgents:  10,
+					ECSService:     "tfc-agent-spot",
+					MinAgents:      0,
+					MaxAgents:      10,
+					CooldownPeriod: 60 * time.Second,
 				},
 			},
 		},
@@ -251,7 +452,9 @@ func TestLoad(t *testing.T) {
 				got.ECSCluster != tt.want.ECSCluster || got.ECSService != tt.want.ECSService ||
 				got.PollInterval != tt.want.PollInterval || got.MinAgents != tt.want.MinAgents ||
 				got.MaxAgents != tt.want.MaxAgents || got.CooldownPeriod != tt.want.CooldownPeriod ||
-				got.HealthAddr != tt.want.HealthAddr {
+				got.HealthAddr != tt.want.HealthAddr || got.MaxScaleDownStep != tt.want.MaxScaleDownStep ||
+				got.MaxScaleUpStep != tt.want.MaxScaleUpStep || got.DryRun != tt.want.DryRun ||
+				got.TaskProtectionExpiry != tt.want.TaskProtectionExpiry {
 				t.Errorf("got %+v, want %+v", got, tt.want)
 			}
 			if (got.SpotService == nil) != (tt.want.SpotService == nil) {
@@ -265,3 +468,2824 @@ func TestLoad(t *testing.T) {
 		})
 	}
 }
+
+// withRequired returns the required environment variables merged with extra.
+func withRequired(extra map[string]string) map[string]string {
+	env := map[string]string{
+		"TFC_TOKEN":         "test-token",
+		"TFC_AGENT_POOL_ID": "apool-123",
+		"TFC_ORG":           "my-org",
+		"ECS_CLUSTER":       "my-cluster",
+		"ECS_SERVICE":       "tfc-agent",
+	}
+	for k, v := range extra {
+		env[k] = v
+	}
+	return env
+}
+
+// loadEnv calls load with a lookup backed by env.
+func loadEnv(env map[string]string) (Config, error) {
+	return load(func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	})
+}
+
+func TestLoadTFCRetry(t *testing.T) {
+	tests := []struct {
+		name           string
+		env            map[string]string
+		wantMaxRetries int
+		wantBaseDelay  time.Duration
+		wantErr        bool
+	}{
+		{
+			name:           "defaults",
+			env:            withRequired(nil),
+			wantMaxRetries: 2,
+			wantBaseDelay:  500 * time.Millisecond,
+		},
+		{
+			name: "overridden",
+			env: withRequired(map[string]string{
+				"TFC_MAX_RETRIES":      "5",
+				"TFC_RETRY_BASE_DELAY": "1s",
+			}),
+			wantMaxRetries: 5,
+			wantBaseDelay:  time.Second,
+		},
+		{
+			name:           "retries disabled",
+			env:            withRequired(map[string]string{"TFC_MAX_RETRIES": "0"}),
+			wantMaxRetries: 0,
+			wantBaseDelay:  500 * time.Millisecond,
+		},
+		{
+			name:    "negative TFC_MAX_RETRIES",
+			env:     withRequired(map[string]string{"TFC_MAX_RETRIES": "-1"}),
+			wantErr: true,
+		},
+		{
+			name:    "zero TFC_RETRY_BASE_DELAY",
+			env:     withRequired(map[string]string{"TFC_RETRY_BASE_DELAY": "0s"}),
+			wantErr: true,
+		},
+		{
+			name:    "invalid TFC_RETRY_BASE_DELAY",
+			env:     withRequired(map[string]string{"TFC_RETRY_BASE_DELAY": "soon"}),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadEnv(tt.env)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.TFCMaxRetries != tt.wantMaxRetries {
+				t.Errorf("TFCMaxRetries: got %d, want %d", got.TFCMaxRetries, tt.wantMaxRetries)
+			}
+			if got.TFCRetryBaseDelay != tt.wantBaseDelay {
+				t.Errorf("TFCRetryBaseDelay: got %v, want %v", got.TFCRetryBaseDelay, tt.wantBaseDelay)
+			}
+		})
+	}
+}
+
+func TestLoadPools(t *testing.T) {
+	multiPoolBase := map[string]string{
+		"TFC_TOKEN":   "test-token",
+		"TFC_ORG":     "my-org",
+		"ECS_CLUSTER": "my-cluster",
+	}
+	withPools := func(pools string, extra map[string]string) map[string]string {
+		env := map[string]string{"TFC_POOLS": pools}
+		for k, v := range multiPoolBase {
+			env[k] = v
+		}
+		for k, v := range extra {
+			env[k] = v
+		}
+		return env
+	}
+
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    []PoolConfig
+		wantErr bool
+	}{
+		{
+			name: "unset keeps single-pool mode",
+			env:  withRequired(nil),
+			want: nil,
+		},
+		{
+			name: "multiple pools",
+			env: withPools(`[
+				{"name":"team-a","agent_pool_id":"apool-a","ecs_service":"agents-a","min_agents":1,"max_agents":5},
+				{"name":"team-b","agent_pool_id":"apool-b","ecs_service":"agents-b","max_agents":20}
+			]`, nil),
+			want: []PoolConfig{
+				{Name: "team-a", AgentPoolID: "apool-a", ECSService: "agents-a", MinAgents: 1, MaxAgents: 5},
+				{Name: "team-b", AgentPoolID: "apool-b", ECSService: "agents-b", MinAgents: 0, MaxAgents: 20},
+			},
+		},
+		{
+			name: "bounds default to MIN_AGENTS and MAX_AGENTS",
+			env: withPools(`[{"agent_pool_id":"apool-a","ecs_service":"agents-a"}]`,
+				map[string]string{"MIN_AGENTS": "2", "MAX_AGENTS": "8"}),
+			want: []PoolConfig{
+				{Name: "apool-a", AgentPoolID: "apool-a", ECSService: "agents-a", MinAgents: 2, MaxAgents: 8},
+			},
+		},
+		{
+			name: "pool-scoped token",
+			env: withPools(`[
+				{"name":"team-a","agent_pool_id":"apool-a","ecs_service":"agents-a","token":"team-a-token"},
+				{"name":"team-b","agent_pool_id":"apool-b","ecs_service":"agents-b"}
+			]`, nil),
+			want: []PoolConfig{
+				{Name: "team-a", AgentPoolID: "apool-a", ECSService: "agents-a", MaxAgents: 10, Token: "team-a-token"},
+				{Name: "team-b", AgentPoolID: "apool-b", ECSService: "agents-b", MaxAgents: 10},
+			},
+		},
+		{
+			name:    "invalid JSON",
+			env:     withPools(`{not json`, nil),
+			wantErr: true,
+		},
+		{
+			name:    "empty list",
+			env:     withPools(`[]`, nil),
+			wantErr: true,
+		},
+		{
+			name:    "missing agent_pool_id",
+			env:     withPools(`[{"ecs_service":"agents-a"}]`, nil),
+			wantErr: true,
+		},
+		{
+			name:    "missing ecs_service",
+			env:     withPools(`[{"agent_pool_id":"apool-a"}]`, nil),
+			wantErr: true,
+		},
+		{
+			name: "duplicate names",
+			env: withPools(`[
+				{"name":"x","agent_pool_id":"apool-a","ecs_service":"agents-a"},
+				{"name":"x","agent_pool_id":"apool-b","ecs_service":"agents-b"}
+			]`, nil),
+			wantErr: true,
+		},
+		{
+			name:    "min greater than max",
+			env:     withPools(`[{"agent_pool_id":"apool-a","ecs_service":"agents-a","min_agents":5,"max_agents":2}]`, nil),
+			wantErr: true,
+		},
+		{
+			name: "combined with spot service",
+			env: withPools(`[{"agent_pool_id":"apool-a","ecs_service":"agents-a"}]`,
+				map[string]string{"ECS_SPOT_SERVICE": "tfc-agent-spot"}),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadEnv(tt.env)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(got.Pools) != len(tt.want) {
+				t.Fatalf("Pools: got %d, want %d", len(got.Pools), len(tt.want))
+			}
+			for i := range got.Pools {
+				if got.Pools[i] != tt.want[i] {
+					t.Errorf("Pools[%d]: got %+v, want %+v", i, got.Pools[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLoadServices(t *testing.T) {
+	withServices := func(services string, extra map[string]string) map[string]string {
+		env := withRequired(map[string]string{"ECS_SERVICES": services})
+		delete(env, "ECS_SERVICE")
+		for k, v := range extra {
+			env[k] = v
+		}
+		return env
+	}
+
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    []ServiceConfig
+		wantErr bool
+	}{
+		{
+			name: "unset keeps single-service mode",
+			env:  withRequired(nil),
+			want: nil,
+		},
+		{
+			name: "services by run type",
+			env: withServices(`[
+				{"name":"apply","ecs_service":"agents-apply","run_type":"apply","min_agents":1,"max_agents":5},
+				{"name":"plan","ecs_service":"agents-plan","run_type":"plan","max_agents":20,"cooldown_period":"2m","agent_name_prefix":"plan-","spot":true}
+			]`, nil),
+			want: []ServiceConfig{
+				{Name: "apply", ECSService: "agents-apply", RunType: "apply", MinAgents: 1, MaxAgents: 5, CooldownPeriod: 60 * time.Second},
+				{Name: "plan", ECSService: "agents-plan", RunType: "plan", MaxAgents: 20, CooldownPeriod: 2 * time.Minute, AgentNamePrefix: "plan-", Spot: true},
+			},
+		},
+		{
+			name: "defaults to global bounds and service name",
+			env: withServices(`[{"ecs_service":"agents-plan","run_type":"plan"}]`,
+				map[string]string{"MIN_AGENTS": "2", "MAX_AGENTS": "8", "COOLDOWN_PERIOD": "3m"}),
+			want: []ServiceConfig{
+				{Name: "agents-plan", ECSService: "agents-plan", RunType: "plan", MinAgents: 2, MaxAgents: 8, CooldownPeriod: 3 * time.Minute},
+			},
+		},
+		{
+			name:    "invalid JSON",
+			env:     withServices(`{not json`, nil),
+			wantErr: true,
+		},
+		{
+			name:    "empty list",
+			env:     withServices(`[]`, nil),
+			wantErr: true,
+		},
+		{
+			name:    "missing ecs_service",
+			env:     withServices(`[{"run_type":"plan"}]`, nil),
+			wantErr: true,
+		},
+		{
+			name:    "unknown run_type",
+			env:     withServices(`[{"ecs_service":"agents","run_type":"destroy"}]`, nil),
+			wantErr: true,
+		},
+		{
+			name: "duplicate run_type",
+			env: withServices(`[
+				{"name":"a","ecs_service":"agents-a","run_type":"plan"},
+				{"name":"b","ecs_service":"agents-b","run_type":"plan"}
+			]`, nil),
+			wantErr: true,
+		},
+		{
+			name: "duplicate names",
+			env: withServices(`[
+				{"name":"x","ecs_service":"agents-a","run_type":"plan"},
+				{"name":"x","ecs_service":"agents-b","run_type":"apply"}
+			]`, nil),
+			wantErr: true,
+		},
+		{
+			name:    "min greater than max",
+			env:     withServices(`[{"ecs_service":"agents","run_type":"plan","min_agents":5,"max_agents":2}]`, nil),
+			wantErr: true,
+		},
+		{
+			name:    "invalid cooldown_period",
+			env:     withServices(`[{"ecs_service":"agents","run_type":"plan","cooldown_period":"soon"}]`, nil),
+			wantErr: true,
+		},
+		{
+			name: "combined with spot service",
+			env: withServices(`[{"ecs_service":"agents","run_type":"plan"}]`,
+				map[string]string{"ECS_SPOT_SERVICE": "tfc-agent-spot"}),
+			wantErr: true,
+		},
+		{
+			name: "combined with pools",
+			env: withServices(`[{"ecs_service":"agents","run_type":"plan"}]`,
+				map[string]string{"TFC_POOLS": `[{"agent_pool_id":"apool-a","ecs_service":"agents-a"}]`}),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadEnv(tt.env)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !slices.Equal(got.Services, tt.want) {
+				t.Errorf("Services: got %+v, want %+v", got.Services, tt.want)
+			}
+		})
+	}
+}
+
+func TestServiceList(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want []ServiceConfig
+	}{
+		{name: "single service", env: withRequired(nil), want: nil},
+		{
+			name: "dual-service env vars",
+			env: withRequired(map[string]string{
+				"MIN_AGENTS":             "1",
+				"MAX_AGENTS":             "6",
+				"AGENT_NAME_PREFIX":      "regular-",
+				"ECS_SPOT_SERVICE":       "tfc-agent-spot",
+				"SPOT_MAX_AGENTS":        "12",
+				"SPOT_COOLDOWN_PERIOD":   "30s",
+				"SPOT_AGENT_NAME_PREFIX": "spot-",
+			}),
+			want: []ServiceConfig{
+				{Name: "regular", ECSService: "tfc-agent", RunType: "apply", MinAgents: 1, MaxAgents: 6, CooldownPeriod: 60 * time.Second, AgentNamePrefix: "regular-"},
+				{Name: "spot", ECSService: "tfc-agent-spot", RunType: "plan", MaxAgents: 12, CooldownPeriod: 30 * time.Second, AgentNamePrefix: "spot-", Spot: true},
+			},
+		},
+		{
+			name: "ECS_SERVICES",
+			env:  withRequired(map[string]string{"ECS_SERVICES": `[{"name":"plan","ecs_service":"agents-plan","run_type":"plan"}]`}),
+			want: []ServiceConfig{
+				{Name: "plan", ECSService: "agents-plan", RunType: "plan", MaxAgents: 10, CooldownPeriod: 60 * time.Second},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := loadEnv(tt.env)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := cfg.ServiceList(); !slices.Equal(got, tt.want) {
+				t.Errorf("ServiceList: got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadWarmIdle(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    int
+		wantErr bool
+	}{
+		{
+			name: "default disabled",
+			env:  withRequired(nil),
+			want: 0,
+		},
+		{
+			name: "set",
+			env:  withRequired(map[string]string{"WARM_IDLE": "3"}),
+			want: 3,
+		},
+		{
+			name:    "negative",
+			env:     withRequired(map[string]string{"WARM_IDLE": "-1"}),
+			wantErr: true,
+		},
+		{
+			name:    "invalid",
+			env:     withRequired(map[string]string{"WARM_IDLE": "some"}),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadEnv(tt.env)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.WarmIdle != tt.want {
+				t.Errorf("WarmIdle: got %d, want %d", got.WarmIdle, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadHealthReadyDetails(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    bool
+		wantErr bool
+	}{
+		{name: "default off", env: withRequired(nil), want: false},
+		{name: "enabled", env: withRequired(map[string]string{"HEALTH_READY_DETAILS": "true"}), want: true},
+		{name: "invalid", env: withRequired(map[string]string{"HEALTH_READY_DETAILS": "maybe"}), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadEnv(tt.env)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.HealthReadyDetails != tt.want {
+				t.Errorf("HealthReadyDetails: got %v, want %v", got.HealthReadyDetails, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadDrainOnShutdown(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    bool
+		wantErr bool
+	}{
+		{name: "default off", env: withRequired(nil), want: false},
+		{name: "enabled", env: withRequired(map[string]string{"DRAIN_ON_SHUTDOWN": "true"}), want: true},
+		{name: "invalid", env: withRequired(map[string]string{"DRAIN_ON_SHUTDOWN": "yes please"}), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadEnv(tt.env)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.DrainOnShutdown != tt.want {
+				t.Errorf("DrainOnShutdown: got %v, want %v", got.DrainOnShutdown, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadRunWeights(t *testing.T) {
+	tests := []struct {
+		name      string
+		env       map[string]string
+		wantPlan  float64
+		wantApply float64
+		wantErr   bool
+	}{
+		{
+			name:      "defaults",
+			env:       withRequired(nil),
+			wantPlan:  1,
+			wantApply: 1,
+		},
+		{
+			name: "fractional and heavier",
+			env: withRequired(map[string]string{
+				"PLAN_RUN_WEIGHT":  "0.5",
+				"APPLY_RUN_WEIGHT": "2",
+			}),
+			wantPlan:  0.5,
+			wantApply: 2,
+		},
+		{
+			name:    "zero plan weight",
+			env:     withRequired(map[string]string{"PLAN_RUN_WEIGHT": "0"}),
+			wantErr: true,
+		},
+		{
+			name:    "negative apply weight",
+			env:     withRequired(map[string]string{"APPLY_RUN_WEIGHT": "-1"}),
+			wantErr: true,
+		},
+		{
+			name:    "NaN weight",
+			env:     withRequired(map[string]string{"APPLY_RUN_WEIGHT": "NaN"}),
+			wantErr: true,
+		},
+		{
+			name:    "infinite weight",
+			env:     withRequired(map[string]string{"PLAN_RUN_WEIGHT": "+Inf"}),
+			wantErr: true,
+		},
+		{
+			name:    "invalid weight",
+			env:     withRequired(map[string]string{"PLAN_RUN_WEIGHT": "heavy"}),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadEnv(tt.env)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.PlanRunWeight != tt.wantPlan {
+				t.Errorf("PlanRunWeight: got %v, want %v", got.PlanRunWeight, tt.wantPlan)
+			}
+			if got.ApplyRunWeight != tt.wantApply {
+				t.Errorf("ApplyRunWeight: got %v, want %v", got.ApplyRunWeight, tt.wantApply)
+			}
+		})
+	}
+}
+
+func TestLoadRunStatusWeights(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    map[string]float64
+		wantErr bool
+	}{
+		{
+			name: "unset",
+			env:  withRequired(nil),
+		},
+		{
+			name: "weights",
+			env:  withRequired(map[string]string{"RUN_STATUS_WEIGHTS": "plan_queued=1, pending=0.5"}),
+			want: map[string]float64{"plan_queued": 1, "pending": 0.5},
+		},
+		{
+			name:    "missing weight",
+			env:     withRequired(map[string]string{"RUN_STATUS_WEIGHTS": "pending"}),
+			wantErr: true,
+		},
+		{
+			name:    "empty status",
+			env:     withRequired(map[string]string{"RUN_STATUS_WEIGHTS": "=2"}),
+			wantErr: true,
+		},
+		{
+			name:    "invalid weight",
+			env:     withRequired(map[string]string{"RUN_STATUS_WEIGHTS": "pending=half"}),
+			wantErr: true,
+		},
+		{
+			name:    "negative weight",
+			env:     withRequired(map[string]string{"RUN_STATUS_WEIGHTS": "pending=-1"}),
+			wantErr: true,
+		},
+		{
+			name:    "zero weight",
+			env:     withRequired(map[string]string{"RUN_STATUS_WEIGHTS": "pending=0"}),
+			wantErr: true,
+		},
+		{
+			name:    "infinite weight",
+			env:     withRequired(map[string]string{"RUN_STATUS_WEIGHTS": "pending=+Inf"}),
+			wantErr: true,
+		},
+		{
+			name:    "unknown status",
+			env:     withRequired(map[string]string{"RUN_STATUS_WEIGHTS": "waiting=2"}),
+			wantErr: true,
+		},
+		{
+			name:    "duplicate status",
+			env:     withRequired(map[string]string{"RUN_STATUS_WEIGHTS": "pending=1,pending=2"}),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadEnv(tt.env)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !maps.Equal(got.RunStatusWeights, tt.want) {
+				t.Errorf("RunStatusWeights: got %v, want %v", got.RunStatusWeights, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadPollJitter(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    float64
+		wantErr bool
+	}{
+		{name: "default disabled", env: withRequired(nil), want: 0},
+		{name: "ten percent", env: withRequired(map[string]string{"POLL_JITTER": "0.1"}), want: 0.1},
+		{name: "negative", env: withRequired(map[string]string{"POLL_JITTER": "-0.1"}), wantErr: true},
+		{name: "one or more", env: withRequired(map[string]string{"POLL_JITTER": "1"}), wantErr: true},
+		{name: "NaN", env: withRequired(map[string]string{"POLL_JITTER": "NaN"}), wantErr: true},
+		{name: "invalid", env: withRequired(map[string]string{"POLL_JITTER": "10%"}), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadEnv(tt.env)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.PollJitter != tt.want {
+				t.Errorf("PollJitter: got %v, want %v", got.PollJitter, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadAgentNamePrefix(t *testing.T) {
+	got, err := loadEnv(withRequired(map[string]string{
+		"AGENT_NAME_PREFIX":      "regular-",
+		"ECS_SPOT_SERVICE":       "tfc-agent-spot",
+		"SPOT_AGENT_NAME_PREFIX": "spot-",
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.AgentNamePrefix != "regular-" {
+		t.Errorf("AgentNamePrefix: got %q, want %q", got.AgentNamePrefix, "regular-")
+	}
+	if got.SpotService == nil || got.SpotService.AgentNamePrefix != "spot-" {
+		t.Errorf("SpotService.AgentNamePrefix: got %+v, want %q", got.SpotService, "spot-")
+	}
+
+	got, err = loadEnv(withRequired(nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.AgentNamePrefix != "" {
+		t.Errorf("AgentNamePrefix default: got %q, want empty", got.AgentNamePrefix)
+	}
+}
+
+func TestLoadReconcileTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    time.Duration
+		wantErr bool
+	}{
+		{name: "default", env: withRequired(nil), want: 30 * time.Second},
+		{name: "overridden", env: withRequired(map[string]string{"RECONCILE_TIMEOUT": "5s"}), want: 5 * time.Second},
+		{name: "zero", env: withRequired(map[string]string{"RECONCILE_TIMEOUT": "0s"}), wantErr: true},
+		{name: "invalid", env: withRequired(map[string]string{"RECONCILE_TIMEOUT": "soon"}), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadEnv(tt.env)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.ReconcileTimeout != tt.want {
+				t.Errorf("ReconcileTimeout: got %v, want %v", got.ReconcileTimeout, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadScaleDownIdleThreshold(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    int
+		wantErr bool
+	}{
+		{name: "default disabled", env: withRequired(nil), want: 0},
+		{name: "set", env: withRequired(map[string]string{"SCALE_DOWN_IDLE_THRESHOLD": "3"}), want: 3},
+		{name: "negative", env: withRequired(map[string]string{"SCALE_DOWN_IDLE_THRESHOLD": "-1"}), wantErr: true},
+		{name: "invalid", env: withRequired(map[string]string{"SCALE_DOWN_IDLE_THRESHOLD": "few"}), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadEnv(tt.env)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.ScaleDownIdleThreshold != tt.want {
+				t.Errorf("ScaleDownIdleThreshold: got %d, want %d", got.ScaleDownIdleThreshold, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadWorkspaceTags(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want []string
+	}{
+		{name: "unset", env: withRequired(nil), want: nil},
+		{name: "single", env: withRequired(map[string]string{"WORKSPACE_TAGS": "autoscale"}), want: []string{"autoscale"}},
+		{
+			name: "list with whitespace and empties",
+			env:  withRequired(map[string]string{"WORKSPACE_TAGS": " team-a, ,team-b ,"}),
+			want: []string{"team-a", "team-b"},
+		},
+		{name: "empty", env: withRequired(map[string]string{"WORKSPACE_TAGS": ""}), want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadEnv(tt.env)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !slices.Equal(got.WorkspaceTags, tt.want) {
+				t.Errorf("WorkspaceTags: got %q, want %q", got.WorkspaceTags, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadWorkspaceIDs(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want []string
+	}{
+		{name: "unset", env: withRequired(nil), want: nil},
+		{
+			name: "list with whitespace and empties",
+			env:  withRequired(map[string]string{"WORKSPACE_IDS": " ws-abc, ,ws-def ,"}),
+			want: []string{"ws-abc", "ws-def"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadEnv(tt.env)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !slices.Equal(got.WorkspaceIDs, tt.want) {
+				t.Errorf("WorkspaceIDs: got %q, want %q", got.WorkspaceIDs, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadSpotCooldownPeriod(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    time.Duration
+		wantErr bool
+	}{
+		{
+			name: "falls back to default global cooldown",
+			env:  withRequired(map[string]string{"ECS_SPOT_SERVICE": "tfc-agent-spot"}),
+			want: 60 * time.Second,
+		},
+		{
+			name: "falls back to overridden global cooldown",
+			env: withRequired(map[string]string{
+				"ECS_SPOT_SERVICE": "tfc-agent-spot",
+				"COOLDOWN_PERIOD":  "5m",
+			}),
+			want: 5 * time.Minute,
+		},
+		{
+			name: "spot override",
+			env: withRequired(map[string]string{
+				"ECS_SPOT_SERVICE":     "tfc-agent-spot",
+				"COOLDOWN_PERIOD":      "5m",
+				"SPOT_COOLDOWN_PERIOD": "15s",
+			}),
+			want: 15 * time.Second,
+		},
+		{
+			name: "invalid",
+			env: withRequired(map[string]string{
+				"ECS_SPOT_SERVICE":     "tfc-agent-spot",
+				"SPOT_COOLDOWN_PERIOD": "fast",
+			}),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadEnv(tt.env)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.SpotService == nil {
+				t.Fatal("SpotService: got nil")
+			}
+			if got.SpotService.CooldownPeriod != tt.want {
+				t.Errorf("SpotService.CooldownPeriod: got %v, want %v", got.SpotService.CooldownPeriod, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadHealthReconcileTrigger(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    bool
+		wantErr bool
+	}{
+		{name: "default off", env: withRequired(nil), want: false},
+		{name: "enabled", env: withRequired(map[string]string{"HEALTH_RECONCILE_TRIGGER": "true"}), want: true},
+		{name: "invalid", env: withRequired(map[string]string{"HEALTH_RECONCILE_TRIGGER": "maybe"}), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadEnv(tt.env)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.HealthReconcileTrigger != tt.want {
+				t.Errorf("HealthReconcileTrigger: got %v, want %v", got.HealthReconcileTrigger, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadTFCTokenFile(t *testing.T) {
+	dir := t.TempDir()
+	tokenPath := filepath.Join(dir, "token")
+	if err := os.WriteFile(tokenPath, []byte("  file-token\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	emptyPath := filepath.Join(dir, "empty")
+	if err := os.WriteFile(emptyPath, []byte("\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	withoutToken := func(extra map[string]string) map[string]string {
+		env := withRequired(extra)
+		delete(env, "TFC_TOKEN")
+		return env
+	}
+
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    string
+		wantErr string
+	}{
+		{name: "token env", env: withRequired(nil), want: "test-token"},
+		{name: "token file trimmed", env: withoutToken(map[string]string{"TFC_TOKEN_FILE": tokenPath}), want: "file-token"},
+		{
+			name:    "both set",
+			env:     withRequired(map[string]string{"TFC_TOKEN_FILE": tokenPath}),
+			wantErr: "mutually exclusive",
+		},
+		{name: "neither set", env: withoutToken(nil), wantErr: "TFC_TOKEN or TFC_TOKEN_FILE"},
+		{
+			name:    "missing file",
+			env:     withoutToken(map[string]string{"TFC_TOKEN_FILE": filepath.Join(dir, "missing")}),
+			wantErr: "reading TFC_TOKEN_FILE",
+		},
+		{name: "empty file", env: withoutToken(map[string]string{"TFC_TOKEN_FILE": emptyPath}), wantErr: "is empty"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadEnv(tt.env)
+			if tt.wantErr != "" {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				if !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error %q does not contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.TFCToken != tt.want {
+				t.Errorf("TFCToken: got %q, want %q", got.TFCToken, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadMaxTaskAge(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    time.Duration
+		wantErr bool
+	}{
+		{name: "default disabled", env: withRequired(nil), want: 0},
+		{name: "overridden", env: withRequired(map[string]string{"MAX_TASK_AGE": "24h"}), want: 24 * time.Hour},
+		{name: "negative", env: withRequired(map[string]string{"MAX_TASK_AGE": "-1h"}), wantErr: true},
+		{name: "invalid", env: withRequired(map[string]string{"MAX_TASK_AGE": "old"}), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadEnv(tt.env)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.MaxTaskAge != tt.want {
+				t.Errorf("MaxTaskAge: got %v, want %v", got.MaxTaskAge, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadTFCAgentLimit(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    int
+		wantErr bool
+	}{
+		{name: "default unknown", env: withRequired(nil), want: 0},
+		{name: "overridden", env: withRequired(map[string]string{"TFC_AGENT_LIMIT": "25"}), want: 25},
+		{name: "negative", env: withRequired(map[string]string{"TFC_AGENT_LIMIT": "-1"}), wantErr: true},
+		{name: "invalid", env: withRequired(map[string]string{"TFC_AGENT_LIMIT": "many"}), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadEnv(tt.env)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.TFCAgentLimit != tt.want {
+				t.Errorf("TFCAgentLimit: got %d, want %d", got.TFCAgentLimit, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadTFCRunConcurrency(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    int
+		wantErr bool
+	}{
+		{name: "default unknown", env: withRequired(nil), want: 0},
+		{name: "overridden", env: withRequired(map[string]string{"TFC_RUN_CONCURRENCY": "5"}), want: 5},
+		{name: "negative", env: withRequired(map[string]string{"TFC_RUN_CONCURRENCY": "-1"}), wantErr: true},
+		{name: "invalid", env: withRequired(map[string]string{"TFC_RUN_CONCURRENCY": "many"}), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadEnv(tt.env)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.TFCRunConcurrency != tt.want {
+				t.Errorf("TFCRunConcurrency: got %d, want %d", got.TFCRunConcurrency, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadReconcileBackoffMax(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    time.Duration
+		wantErr bool
+	}{
+		{name: "default", env: withRequired(nil), want: 5 * time.Minute},
+		{name: "overridden", env: withRequired(map[string]string{"RECONCILE_BACKOFF_MAX": "2m"}), want: 2 * time.Minute},
+		{name: "disabled", env: withRequired(map[string]string{"RECONCILE_BACKOFF_MAX": "0s"}), want: 0},
+		{name: "negative", env: withRequired(map[string]string{"RECONCILE_BACKOFF_MAX": "-1m"}), wantErr: true},
+		{name: "invalid", env: withRequired(map[string]string{"RECONCILE_BACKOFF_MAX": "later"}), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadEnv(tt.env)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.ReconcileBackoffMax != tt.want {
+				t.Errorf("ReconcileBackoffMax: got %v, want %v", got.ReconcileBackoffMax, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadAWSAssumeRole(t *testing.T) {
+	const roleARN = "arn:aws:iam::123456789012:role/autoscaler"
+
+	tests := []struct {
+		name           string
+		env            map[string]string
+		wantARN        string
+		wantExternalID string
+		wantErr        bool
+	}{
+		{name: "default chain", env: withRequired(nil)},
+		{name: "role only", env: withRequired(map[string]string{"AWS_ASSUME_ROLE_ARN": roleARN}), wantARN: roleARN},
+		{
+			name: "role with external ID",
+			env: withRequired(map[string]string{
+				"AWS_ASSUME_ROLE_ARN":         roleARN,
+				"AWS_ASSUME_ROLE_EXTERNAL_ID": "ext-123",
+			}),
+			wantARN:        roleARN,
+			wantExternalID: "ext-123",
+		},
+		{
+			name:    "external ID without role",
+			env:     withRequired(map[string]string{"AWS_ASSUME_ROLE_EXTERNAL_ID": "ext-123"}),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadEnv(tt.env)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.AWSAssumeRoleARN != tt.wantARN {
+				t.Errorf("AWSAssumeRoleARN: got %q, want %q", got.AWSAssumeRoleARN, tt.wantARN)
+			}
+			if got.AWSAssumeRoleExternalID != tt.wantExternalID {
+				t.Errorf("AWSAssumeRoleExternalID: got %q, want %q", got.AWSAssumeRoleExternalID, tt.wantExternalID)
+			}
+		})
+	}
+}
+
+func TestLoadECSRegion(t *testing.T) {
+	got, err := loadEnv(withRequired(map[string]string{"ECS_REGION": "eu-west-2"}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.ECSRegion != "eu-west-2" {
+		t.Errorf("ECSRegion: got %q, want %q", got.ECSRegion, "eu-west-2")
+	}
+
+	got, err = loadEnv(withRequired(nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.ECSRegion != "" {
+		t.Errorf("ECSRegion default: got %q, want empty", got.ECSRegion)
+	}
+}
+
+func TestImmutableChanges(t *testing.T) {
+	base, err := loadEnv(withRequired(map[string]string{"ECS_SPOT_SERVICE": "tfc-agent-spot"}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		want []string
+	}{
+		{name: "unchanged", env: map[string]string{"ECS_SPOT_SERVICE": "tfc-agent-spot"}},
+		{
+			name: "mutable fields only",
+			env: map[string]string{
+				"ECS_SPOT_SERVICE": "tfc-agent-spot",
+				"MAX_AGENTS":       "20",
+				"MIN_AGENTS":       "2",
+				"COOLDOWN_PERIOD":  "5m",
+				"POLL_INTERVAL":    "30s",
+			},
+		},
+		{
+			name: "token and cluster",
+			env: map[string]string{
+				"ECS_SPOT_SERVICE": "tfc-agent-spot",
+				"TFC_TOKEN":        "rotated",
+				"ECS_CLUSTER":      "other-cluster",
+				"MAX_AGENTS":       "20",
+			},
+			want: []string{"TFC_TOKEN", "ECS_CLUSTER"},
+		},
+		{name: "spot service removed", want: []string{"ECS_SPOT_SERVICE"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next, err := loadEnv(withRequired(tt.env))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := base.ImmutableChanges(next); !slices.Equal(got, tt.want) {
+				t.Errorf("ImmutableChanges: got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnappliedChanges(t *testing.T) {
+	baseEnv := map[string]string{
+		"ECS_SPOT_SERVICE":     "tfc-agent-spot",
+		"BUSINESS_HOURS_TZ":    "America/New_York",
+		"BUSINESS_HOURS_MIN":   "2",
+		"BUSINESS_HOURS_START": "09:00",
+		"BUSINESS_HOURS_END":   "17:00",
+	}
+	base, err := loadEnv(withRequired(baseEnv))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		want []string
+	}{
+		{name: "unchanged"},
+		{
+			name: "applied and immutable fields only",
+			env: map[string]string{
+				"MAX_AGENTS":      "20",
+				"MIN_AGENTS":      "2",
+				"COOLDOWN_PERIOD": "5m",
+				"POLL_INTERVAL":   "30s",
+				"TFC_TOKEN":       "rotated",
+				"ECS_CLUSTER":     "other-cluster",
+			},
+		},
+		{
+			name: "other fields",
+			env: map[string]string{
+				"WARM_IDLE":              "2",
+				"DRY_RUN":                "true",
+				"SCALE_UP_STABILIZATION": "1m",
+			},
+			want: []string{"WarmIdle", "DryRun", "ScaleUpStabilization"},
+		},
+		{
+			name: "business hours time zone",
+			env:  map[string]string{"BUSINESS_HOURS_TZ": "Europe/London"},
+			want: []string{"BusinessHours"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := maps.Clone(baseEnv)
+			maps.Copy(env, tt.env)
+			next, err := loadEnv(withRequired(env))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := base.UnappliedChanges(next); !slices.Equal(got, tt.want) {
+				t.Errorf("UnappliedChanges: got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadPlacementStallReconciles(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    int
+		wantErr bool
+	}{
+		{name: "default", env: withRequired(nil), want: 6},
+		{name: "set", env: withRequired(map[string]string{"PLACEMENT_STALL_RECONCILES": "3"}), want: 3},
+		{name: "disabled", env: withRequired(map[string]string{"PLACEMENT_STALL_RECONCILES": "0"}), want: 0},
+		{name: "negative", env: withRequired(map[string]string{"PLACEMENT_STALL_RECONCILES": "-1"}), wantErr: true},
+		{name: "invalid", env: withRequired(map[string]string{"PLACEMENT_STALL_RECONCILES": "some"}), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadEnv(tt.env)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.PlacementStallReconciles != tt.want {
+				t.Errorf("PlacementStallReconciles: got %d, want %d", got.PlacementStallReconciles, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadTotalMaxAgents(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    int
+		wantErr bool
+	}{
+		{name: "default unlimited", env: withRequired(nil), want: 0},
+		{name: "set", env: withRequired(map[string]string{"TOTAL_MAX_AGENTS": "15"}), want: 15},
+		{
+			name: "covers dual minimums",
+			env: withRequired(map[string]string{
+				"TOTAL_MAX_AGENTS": "5", "MIN_AGENTS": "2",
+				"ECS_SPOT_SERVICE": "spot", "SPOT_MIN_AGENTS": "3",
+			}),
+			want: 5,
+		},
+		{
+			name: "below dual minimums",
+			env: withRequired(map[string]string{
+				"TOTAL_MAX_AGENTS": "4", "MIN_AGENTS": "2",
+				"ECS_SPOT_SERVICE": "spot", "SPOT_MIN_AGENTS": "3",
+			}),
+			wantErr: true,
+		},
+		{name: "below single minimum", env: withRequired(map[string]string{"TOTAL_MAX_AGENTS": "1", "MIN_AGENTS": "2"}), wantErr: true},
+		{name: "negative", env: withRequired(map[string]string{"TOTAL_MAX_AGENTS": "-1"}), wantErr: true},
+		{name: "invalid", env: withRequired(map[string]string{"TOTAL_MAX_AGENTS": "lots"}), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadEnv(tt.env)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.TotalMaxAgents != tt.want {
+				t.Errorf("TotalMaxAgents: got %d, want %d", got.TotalMaxAgents, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadPendingStatuses(t *testing.T) {
+	tests := []struct {
+		name      string
+		env       map[string]string
+		wantPlan  []string
+		wantApply []string
+		wantErr   bool
+	}{
+		{name: "defaults", env: withRequired(nil)},
+		{
+			name:      "custom apply statuses",
+			env:       withRequired(map[string]string{"APPLY_PENDING_STATUSES": "apply_queued, cost_estimated,policy_checked"}),
+			wantApply: []string{"apply_queued", "cost_estimated", "policy_checked"},
+		},
+		{
+			name:     "custom plan statuses",
+			env:      withRequired(map[string]string{"PLAN_PENDING_STATUSES": "plan_queued"}),
+			wantPlan: []string{"plan_queued"},
+		},
+		{name: "unknown plan status", env: withRequired(map[string]string{"PLAN_PENDING_STATUSES": "pending,waiting"}), wantErr: true},
+		{name: "unknown apply status", env: withRequired(map[string]string{"APPLY_PENDING_STATUSES": "APPLY_QUEUED"}), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadEnv(tt.env)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !slices.Equal(got.PlanPendingStatuses, tt.wantPlan) {
+				t.Errorf("PlanPendingStatuses: got %q, want %q", got.PlanPendingStatuses, tt.wantPlan)
+			}
+			if !slices.Equal(got.ApplyPendingStatuses, tt.wantApply) {
+				t.Errorf("ApplyPendingStatuses: got %q, want %q", got.ApplyPendingStatuses, tt.wantApply)
+			}
+		})
+	}
+}
+
+func TestLoadHealthDebugState(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    bool
+		wantErr bool
+	}{
+		{name: "default off", env: withRequired(nil), want: false},
+		{name: "enabled", env: withRequired(map[string]string{"HEALTH_DEBUG_STATE": "true"}), want: true},
+		{name: "invalid", env: withRequired(map[string]string{"HEALTH_DEBUG_STATE": "sometimes"}), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadEnv(tt.env)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.HealthDebugState != tt.want {
+				t.Errorf("HealthDebugState: got %v, want %v", got.HealthDebugState, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadScaleDownFactor(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    float64
+		wantErr bool
+	}{
+		{name: "default", env: withRequired(nil), want: 1},
+		{name: "half", env: withRequired(map[string]string{"SCALE_DOWN_FACTOR": "0.5"}), want: 0.5},
+		{name: "one", env: withRequired(map[string]string{"SCALE_DOWN_FACTOR": "1"}), want: 1},
+		{name: "zero", env: withRequired(map[string]string{"SCALE_DOWN_FACTOR": "0"}), wantErr: true},
+		{name: "above one", env: withRequired(map[string]string{"SCALE_DOWN_FACTOR": "1.5"}), wantErr: true},
+		{name: "NaN", env: withRequired(map[string]string{"SCALE_DOWN_FACTOR": "NaN"}), wantErr: true},
+		{name: "invalid", env: withRequired(map[string]string{"SCALE_DOWN_FACTOR": "half"}), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadEnv(tt.env)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.ScaleDownFactor != tt.want {
+				t.Errorf("ScaleDownFactor: got %g, want %g", got.ScaleDownFactor, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadBusinessHours(t *testing.T) {
+	nyc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("loading location: %v", err)
+	}
+	schedule := func(extra map[string]string) map[string]string {
+		env := map[string]string{
+			"BUSINESS_HOURS_MIN":   "4",
+			"BUSINESS_HOURS_START": "08:00",
+			"BUSINESS_HOURS_END":   "18:30",
+		}
+		for k, v := range extra {
+			env[k] = v
+		}
+		return withRequired(env)
+	}
+
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    *BusinessHoursConfig
+		wantErr bool
+	}{
+		{name: "unset", env: withRequired(nil), want: nil},
+		{
+			name: "defaults to UTC",
+			env:  schedule(nil),
+			want: &BusinessHoursConfig{MinAgents: 4, Start: 8 * time.Hour, End: 18*time.Hour + 30*time.Minute, Location: time.UTC},
+		},
+		{
+			name: "timezone",
+			env:  schedule(map[string]string{"BUSINESS_HOURS_TZ": "America/New_York"}),
+			want: &BusinessHoursConfig{MinAgents: 4, Start: 8 * time.Hour, End: 18*time.Hour + 30*time.Minute, Location: nyc},
+		},
+		{
+			name: "wraps midnight",
+			env:  schedule(map[string]string{"BUSINESS_HOURS_START": "22:00", "BUSINESS_HOURS_END": "06:00"}),
+			want: &BusinessHoursConfig{MinAgents: 4, Start: 22 * time.Hour, End: 6 * time.Hour, Location: time.UTC},
+		},
+		{name: "missing end", env: withRequired(map[string]string{"BUSINESS_HOURS_MIN": "4", "BUSINESS_HOURS_START": "08:00"}), wantErr: true},
+		{name: "missing min", env: withRequired(map[string]string{"BUSINESS_HOURS_START": "08:00", "BUSINESS_HOURS_END": "18:00"}), wantErr: true},
+		{name: "timezone alone", env: withRequired(map[string]string{"BUSINESS_HOURS_TZ": "UTC"}), wantErr: true},
+		{name: "invalid start", env: schedule(map[string]string{"BUSINESS_HOURS_START": "8am"}), wantErr: true},
+		{name: "out of range end", env: schedule(map[string]string{"BUSINESS_HOURS_END": "25:00"}), wantErr: true},
+		{name: "equal start and end", env: schedule(map[string]string{"BUSINESS_HOURS_END": "08:00"}), wantErr: true},
+		{name: "invalid timezone", env: schedule(map[string]string{"BUSINESS_HOURS_TZ": "Mars/Olympus"}), wantErr: true},
+		{name: "min above max", env: schedule(map[string]string{"BUSINESS_HOURS_MIN": "11"}), wantErr: true},
+		{name: "negative min", env: schedule(map[string]string{"BUSINESS_HOURS_MIN": "-1"}), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadEnv(tt.env)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.want == nil {
+				if got.BusinessHours != nil {
+					t.Errorf("BusinessHours: got %+v, want nil", got.BusinessHours)
+				}
+				return
+			}
+			if got.BusinessHours == nil {
+				t.Fatal("BusinessHours: got nil")
+			}
+			b := *got.BusinessHours
+			if b.MinAgents != tt.want.MinAgents || b.Start != tt.want.Start || b.End != tt.want.End ||
+				b.Location.String() != tt.want.Location.String() {
+				t.Errorf("BusinessHours: got %+v, want %+v", b, *tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadOrphanTaskReconciles(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    int
+		wantErr bool
+	}{
+		{name: "default", env: withRequired(nil), want: 6},
+		{name: "set", env: withRequired(map[string]string{"ORPHAN_TASK_RECONCILES": "3"}), want: 3},
+		{name: "disabled", env: withRequired(map[string]string{"ORPHAN_TASK_RECONCILES": "0"}), want: 0},
+		{name: "negative", env: withRequired(map[string]string{"ORPHAN_TASK_RECONCILES": "-1"}), wantErr: true},
+		{name: "invalid", env: withRequired(map[string]string{"ORPHAN_TASK_RECONCILES": "some"}), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadEnv(tt.env)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.OrphanTaskReconciles != tt.want {
+				t.Errorf("OrphanTaskReconciles: got %d, want %d", got.OrphanTaskReconciles, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadSmoothingAlpha(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    float64
+		wantErr bool
+	}{
+		{name: "default", env: withRequired(nil), want: 1},
+		{name: "set", env: withRequired(map[string]string{"SMOOTHING_ALPHA": "0.3"}), want: 0.3},
+		{name: "one", env: withRequired(map[string]string{"SMOOTHING_ALPHA": "1"}), want: 1},
+		{name: "zero", env: withRequired(map[string]string{"SMOOTHING_ALPHA": "0"}), wantErr: true},
+		{name: "above one", env: withRequired(map[string]string{"SMOOTHING_ALPHA": "1.5"}), wantErr: true},
+		{name: "NaN", env: withRequired(map[string]string{"SMOOTHING_ALPHA": "NaN"}), wantErr: true},
+		{name: "invalid", env: withRequired(map[string]string{"SMOOTHING_ALPHA": "half"}), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadEnv(tt.env)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.SmoothingAlpha != tt.want {
+				t.Errorf("SmoothingAlpha: got %g, want %g", got.SmoothingAlpha, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadTaskProtectionBatchSize(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    int
+		wantErr bool
+	}{
+		{name: "default", env: withRequired(nil), want: 10},
+		{name: "set", env: withRequired(map[string]string{"TASK_PROTECTION_BATCH_SIZE": "5"}), want: 5},
+		{name: "zero", env: withRequired(map[string]string{"TASK_PROTECTION_BATCH_SIZE": "0"}), wantErr: true},
+		{name: "above ECS maximum", env: withRequired(map[string]string{"TASK_PROTECTION_BATCH_SIZE": "11"}), wantErr: true},
+		{name: "invalid", env: withRequired(map[string]string{"TASK_PROTECTION_BATCH_SIZE": "ten"}), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadEnv(tt.env)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.TaskProtectionBatchSize != tt.want {
+				t.Errorf("TaskProtectionBatchSize: got %d, want %d", got.TaskProtectionBatchSize, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadIncludeSpeculative(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    bool
+		wantErr bool
+	}{
+		{name: "default on", env: withRequired(nil), want: true},
+		{name: "disabled", env: withRequired(map[string]string{"INCLUDE_SPECULATIVE": "false"}), want: false},
+		{name: "invalid", env: withRequired(map[string]string{"INCLUDE_SPECULATIVE": "yes please"}), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadEnv(tt.env)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.IncludeSpeculative != tt.want {
+				t.Errorf("IncludeSpeculative: got %v, want %v", got.IncludeSpeculative, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadTaskProtectionEnabled(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    bool
+		wantErr bool
+	}{
+		{name: "default on", env: withRequired(nil), want: true},
+		{name: "disabled", env: withRequired(map[string]string{"TASK_PROTECTION_ENABLED": "false"}), want: false},
+		{name: "invalid", env: withRequired(map[string]string{"TASK_PROTECTION_ENABLED": "yes please"}), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadEnv(tt.env)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.TaskProtectionEnabled != tt.want {
+				t.Errorf("TaskProtectionEnabled: got %v, want %v", got.TaskProtectionEnabled, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadTaskIPCacheTTL(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    time.Duration
+		wantErr bool
+	}{
+		{name: "default disabled", env: withRequired(nil), want: 0},
+		{name: "overridden", env: withRequired(map[string]string{"TASK_IP_CACHE_TTL": "10s"}), want: 10 * time.Second},
+		{name: "negative", env: withRequired(map[string]string{"TASK_IP_CACHE_TTL": "-1s"}), wantErr: true},
+		{name: "invalid", env: withRequired(map[string]string{"TASK_IP_CACHE_TTL": "old"}), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadEnv(tt.env)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.TaskIPCacheTTL != tt.want {
+				t.Errorf("TaskIPCacheTTL: got %v, want %v", got.TaskIPCacheTTL, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadTargetBusyRatio(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    float64
+		wantErr bool
+	}{
+		{name: "default disabled", env: withRequired(nil), want: 0},
+		{name: "set", env: withRequired(map[string]string{"TARGET_BUSY_RATIO": "0.7"}), want: 0.7},
+		{name: "one", env: withRequired(map[string]string{"TARGET_BUSY_RATIO": "1"}), want: 1},
+		{name: "negative", env: withRequired(map[string]string{"TARGET_BUSY_RATIO": "-0.5"}), wantErr: true},
+		{name: "above one", env: withRequired(map[string]string{"TARGET_BUSY_RATIO": "1.5"}), wantErr: true},
+		{name: "NaN", env: withRequired(map[string]string{"TARGET_BUSY_RATIO": "NaN"}), wantErr: true},
+		{name: "invalid", env: withRequired(map[string]string{"TARGET_BUSY_RATIO": "most"}), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadEnv(tt.env)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.TargetBusyRatio != tt.want {
+				t.Errorf("TargetBusyRatio: got %g, want %g", got.TargetBusyRatio, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	secrets := []string{"tfc-secret-token", "pool-secret-token", "external-secret-id"}
+	cfg := Config{
+		TFCToken:                "tfc-secret-token",
+		TFCAgentPoolID:          "apool-123",
+		ECSCluster:              "my-cluster",
+		AWSAssumeRoleExternalID: "external-secret-id",
+		Pools: []PoolConfig{
+			{Name: "a", AgentPoolID: "apool-a", ECSService: "svc-a", Token: "pool-secret-token"},
+			{Name: "b", AgentPoolID: "apool-b", ECSService: "svc-b"},
+		},
+	}
+
+	var logged bytes.Buffer
+	slog.New(slog.NewJSONHandler(&logged, nil)).Info("effective configuration", "config", cfg)
+	marshaled, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+
+	outputs := map[string]string{
+		"String":      cfg.String(),
+		"LogValue":    logged.String(),
+		"MarshalJSON": string(marshaled),
+	}
+	for name, out := range outputs {
+		for _, secret := range secrets {
+			if strings.Contains(out, secret) {
+				t.Errorf("%s output contains secret %q: %s", name, secret, out)
+			}
+		}
+		if !strings.Contains(out, "apool-123") || !strings.Contains(out, redactedValue) {
+			t.Errorf("%s output = %s, want non-secret fields kept and secrets marked %s", name, out, redactedValue)
+		}
+	}
+
+	got := cfg.Redacted()
+	if got.Pools[1].Token != "" {
+		t.Errorf("unset pool token redacted to %q, want empty", got.Pools[1].Token)
+	}
+	if cfg.TFCToken != "tfc-secret-token" || cfg.Pools[0].Token != "pool-secret-token" {
+		t.Error("Redacted modified the original config")
+	}
+}
+
+func TestLoadSpotInterruptionCompensation(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    bool
+		wantErr bool
+	}{
+		{name: "default off", env: withRequired(nil), want: false},
+		{name: "enabled", env: withRequired(map[string]string{"SPOT_INTERRUPTION_COMPENSATION": "true"}), want: true},
+		{name: "invalid", env: withRequired(map[string]string{"SPOT_INTERRUPTION_COMPENSATION": "yes please"}), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadEnv(tt.env)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.SpotInterruptionCompensation != tt.want {
+				t.Errorf("SpotInterruptionCompensation: got %v, want %v", got.SpotInterruptionCompensation, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadIdleGuardEnabled(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    bool
+		wantErr bool
+	}{
+		{name: "default on", env: withRequired(nil), want: true},
+		{name: "disabled", env: withRequired(map[string]string{"IDLE_GUARD_ENABLED": "false"}), want: false},
+		{name: "invalid", env: withRequired(map[string]string{"IDLE_GUARD_ENABLED": "yes please"}), wantErr: true},
+		{
+			name:    "both safeguards disabled",
+			env:     withRequired(map[string]string{"IDLE_GUARD_ENABLED": "false", "TASK_PROTECTION_ENABLED": "false"}),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadEnv(tt.env)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.IdleGuardEnabled != tt.want {
+				t.Errorf("IdleGuardEnabled: got %v, want %v", got.IdleGuardEnabled, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadTFCWorkspaceConcurrency(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    int
+		wantErr bool
+	}{
+		{name: "default", env: withRequired(nil), want: 8},
+		{name: "set", env: withRequired(map[string]string{"TFC_WORKSPACE_CONCURRENCY": "32"}), want: 32},
+		{name: "zero", env: withRequired(map[string]string{"TFC_WORKSPACE_CONCURRENCY": "0"}), wantErr: true},
+		{name: "negative", env: withRequired(map[string]string{"TFC_WORKSPACE_CONCURRENCY": "-1"}), wantErr: true},
+		{name: "invalid", env: withRequired(map[string]string{"TFC_WORKSPACE_CONCURRENCY": "ten"}), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadEnv(tt.env)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.TFCWorkspaceConcurrency != tt.want {
+				t.Errorf("TFCWorkspaceConcurrency: got %d, want %d", got.TFCWorkspaceConcurrency, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadTFCPageSize(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    int
+		wantErr bool
+	}{
+		{name: "default", env: withRequired(nil), want: 100},
+		{name: "set", env: withRequired(map[string]string{"TFC_PAGE_SIZE": "20"}), want: 20},
+		{name: "maximum", env: withRequired(map[string]string{"TFC_PAGE_SIZE": "100"}), want: 100},
+		{name: "above maximum", env: withRequired(map[string]string{"TFC_PAGE_SIZE": "101"}), wantErr: true},
+		{name: "zero", env: withRequired(map[string]string{"TFC_PAGE_SIZE": "0"}), wantErr: true},
+		{name: "invalid", env: withRequired(map[string]string{"TFC_PAGE_SIZE": "all"}), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadEnv(tt.env)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.TFCPageSize != tt.want {
+				t.Errorf("TFCPageSize: got %d, want %d", got.TFCPageSize, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadTFCUserAgent(t *testing.T) {
+	got, err := loadEnv(withRequired(map[string]string{"TFC_USER_AGENT": "platform-autoscaler/2.0"}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.TFCUserAgent != "platform-autoscaler/2.0" {
+		t.Errorf("TFCUserAgent: got %q, want %q", got.TFCUserAgent, "platform-autoscaler/2.0")
+	}
+
+	got, err = loadEnv(withRequired(nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.TFCUserAgent != "" {
+		t.Errorf("TFCUserAgent default: got %q, want empty", got.TFCUserAgent)
+	}
+}
+
+func TestLoadMaxQueueWait(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    time.Duration
+		wantErr bool
+	}{
+		{name: "default disabled", env: withRequired(nil), want: 0},
+		{name: "overridden", env: withRequired(map[string]string{"MAX_QUEUE_WAIT": "15m"}), want: 15 * time.Minute},
+		{name: "negative", env: withRequired(map[string]string{"MAX_QUEUE_WAIT": "-1h"}), wantErr: true},
+		{name: "invalid", env: withRequired(map[string]string{"MAX_QUEUE_WAIT": "soon"}), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadEnv(tt.env)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.MaxQueueWait != tt.want {
+				t.Errorf("MaxQueueWait: got %v, want %v", got.MaxQueueWait, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadBusyFloorEnabled(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    bool
+		wantErr bool
+	}{
+		{name: "default on", env: withRequired(nil), want: true},
+		{name: "disabled", env: withRequired(map[string]string{"BUSY_FLOOR_ENABLED": "false"}), want: false},
+		{name: "invalid", env: withRequired(map[string]string{"BUSY_FLOOR_ENABLED": "maybe"}), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadEnv(tt.env)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.BusyFloorEnabled != tt.want {
+				t.Errorf("BusyFloorEnabled: got %v, want %v", got.BusyFloorEnabled, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadAgentPoolName(t *testing.T) {
+	withName := func(extra map[string]string) map[string]string {
+		env := withRequired(map[string]string{"TFC_AGENT_POOL_NAME": "ci-agents"})
+		delete(env, "TFC_AGENT_POOL_ID")
+		for k, v := range extra {
+			env[k] = v
+		}
+		return env
+	}
+
+	tests := []struct {
+		name     string
+		env      map[string]string
+		wantID   string
+		wantName string
+		wantErr  bool
+	}{
+		{name: "ID only", env: withRequired(nil), wantID: "apool-123"},
+		{name: "name instead of ID", env: withName(nil), wantName: "ci-agents"},
+		{name: "both set", env: withName(map[string]string{"TFC_AGENT_POOL_ID": "apool-123"}), wantErr: true},
+		{name: "with TFC_POOLS", env: withName(map[string]string{"TFC_POOLS": `[{"name":"a","agent_pool_id":"apool-a","ecs_service":"svc-a"}]`}), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadEnv(tt.env)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.TFCAgentPoolID != tt.wantID || got.TFCAgentPoolName != tt.wantName {
+				t.Errorf("pool: got ID %q name %q, want ID %q name %q", got.TFCAgentPoolID, got.TFCAgentPoolName, tt.wantID, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestLoadFilterServiceAgents(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    bool
+		wantErr bool
+	}{
+		{name: "default off", env: withRequired(nil), want: false},
+		{name: "enabled", env: withRequired(map[string]string{"FILTER_SERVICE_AGENTS": "true"}), want: true},
+		{name: "invalid", env: withRequired(map[string]string{"FILTER_SERVICE_AGENTS": "maybe"}), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadEnv(tt.env)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.FilterServiceAgents != tt.want {
+				t.Errorf("FilterServiceAgents: got %v, want %v", got.FilterServiceAgents, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadProtectNewestIdle(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    bool
+		wantErr bool
+	}{
+		{name: "default off", env: withRequired(nil), want: false},
+		{name: "enabled", env: withRequired(map[string]string{"PROTECT_NEWEST_IDLE_TASK": "true"}), want: true},
+		{name: "invalid", env: withRequired(map[string]string{"PROTECT_NEWEST_IDLE_TASK": "newest"}), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadEnv(tt.env)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.ProtectNewestIdle != tt.want {
+				t.Errorf("ProtectNewestIdle: got %v, want %v", got.ProtectNewestIdle, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadRuntimeMetrics(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    bool
+		wantErr bool
+	}{
+		{name: "default on", env: withRequired(nil), want: true},
+		{name: "disabled", env: withRequired(map[string]string{"ENABLE_RUNTIME_METRICS": "false"}), want: false},
+		{name: "invalid", env: withRequired(map[string]string{"ENABLE_RUNTIME_METRICS": "sometimes"}), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadEnv(tt.env)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.RuntimeMetrics != tt.want {
+				t.Errorf("RuntimeMetrics: got %v, want %v", got.RuntimeMetrics, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadHealthAddr(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    string
+		wantErr bool
+	}{
+		{name: "default", env: withRequired(nil), want: ":8080"},
+		{name: "tcp", env: withRequired(map[string]string{"HEALTH_ADDR": "127.0.0.1:9090"}), want: "127.0.0.1:9090"},
+		{name: "unix socket", env: withRequired(map[string]string{"HEALTH_ADDR": "unix:/run/autoscaler/health.sock"}), want: "unix:/run/autoscaler/health.sock"},
+		{name: "unix without path", env: withRequired(map[string]string{"HEALTH_ADDR": "unix:"}), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadEnv(tt.env)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.HealthAddr != tt.want {
+				t.Errorf("HealthAddr: got %q, want %q", got.HealthAddr, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadMetricsNamespace(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    string
+		wantErr bool
+	}{
+		{name: "default empty", env: withRequired(nil), want: ""},
+		{name: "overridden", env: withRequired(map[string]string{"METRICS_NAMESPACE": "team_a"}), want: "team_a"},
+		{name: "leading digit", env: withRequired(map[string]string{"METRICS_NAMESPACE": "1team"}), wantErr: true},
+		{name: "invalid character", env: withRequired(map[string]string{"METRICS_NAMESPACE": "team-a"}), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadEnv(tt.env)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.MetricsNamespace != tt.want {
+				t.Errorf("MetricsNamespace: got %q, want %q", got.MetricsNamespace, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadMetricsLabels(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    map[string]string
+		wantErr bool
+	}{
+		{name: "default none", env: withRequired(nil)},
+		{
+			name: "labels",
+			env:  withRequired(map[string]string{"METRICS_LABELS": "cluster=foo, env=prod"}),
+			want: map[string]string{"cluster": "foo", "env": "prod"},
+		},
+		{name: "missing value", env: withRequired(map[string]string{"METRICS_LABELS": "cluster="}), wantErr: true},
+		{name: "missing separator", env: withRequired(map[string]string{"METRICS_LABELS": "cluster"}), wantErr: true},
+		{name: "invalid key", env: withRequired(map[string]string{"METRICS_LABELS": "aws-region=us-east-1"}), wantErr: true},
+		{name: "reserved prefix", env: withRequired(map[string]string{"METRICS_LABELS": "__name__=x"}), wantErr: true},
+		{name: "reserved label", env: withRequired(map[string]string{"METRICS_LABELS": "service=x"}), wantErr: true},
+		{name: "duplicate key", env: withRequired(map[string]string{"METRICS_LABELS": "env=prod,env=dev"}), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadEnv(tt.env)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !maps.Equal(got.MetricsLabels, tt.want) {
+				t.Errorf("MetricsLabels: got %v, want %v", got.MetricsLabels, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadReadyAfterSuccesses(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    int
+		wantErr bool
+	}{
+		{name: "default", env: withRequired(nil), want: 1},
+		{name: "set", env: withRequired(map[string]string{"READY_AFTER_SUCCESSES": "3"}), want: 3},
+		{name: "zero", env: withRequired(map[string]string{"READY_AFTER_SUCCESSES": "0"}), wantErr: true},
+		{name: "negative", env: withRequired(map[string]string{"READY_AFTER_SUCCESSES": "-1"}), wantErr: true},
+		{name: "invalid", env: withRequired(map[string]string{"READY_AFTER_SUCCESSES": "three"}), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadEnv(tt.env)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.ReadyAfterSuccesses != tt.want {
+				t.Errorf("ReadyAfterSuccesses: got %d, want %d", got.ReadyAfterSuccesses, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadHealthPauseControl(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    bool
+		wantErr bool
+	}{
+		{name: "default off", env: withRequired(nil), want: false},
+		{name: "enabled", env: withRequired(map[string]string{"HEALTH_PAUSE_CONTROL": "true"}), want: true},
+		{name: "invalid", env: withRequired(map[string]string{"HEALTH_PAUSE_CONTROL": "maybe"}), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadEnv(tt.env)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.HealthPauseControl != tt.want {
+				t.Errorf("HealthPauseControl: got %v, want %v", got.HealthPauseControl, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadPaused(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    bool
+		wantErr bool
+	}{
+		{name: "default off", env: withRequired(nil), want: false},
+		{name: "enabled", env: withRequired(map[string]string{"PAUSED": "true"}), want: true},
+		{name: "invalid", env: withRequired(map[string]string{"PAUSED": "maybe"}), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadEnv(tt.env)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.Paused != tt.want {
+				t.Errorf("Paused: got %v, want %v", got.Paused, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadUsePoolQueue(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    bool
+		wantErr bool
+	}{
+		{name: "default off", env: withRequired(nil), want: false},
+		{name: "enabled", env: withRequired(map[string]string{"USE_POOL_QUEUE": "true"}), want: true},
+		{name: "invalid", env: withRequired(map[string]string{"USE_POOL_QUEUE": "maybe"}), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadEnv(tt.env)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.UsePoolQueue != tt.want {
+				t.Errorf("UsePoolQueue: got %v, want %v", got.UsePoolQueue, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadScaleUpStabilization(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    time.Duration
+		wantErr bool
+	}{
+		{name: "default disabled", env: withRequired(nil), want: 0},
+		{name: "overridden", env: withRequired(map[string]string{"SCALE_UP_STABILIZATION": "2m"}), want: 2 * time.Minute},
+		{name: "negative", env: withRequired(map[string]string{"SCALE_UP_STABILIZATION": "-1h"}), wantErr: true},
+		{name: "invalid", env: withRequired(map[string]string{"SCALE_UP_STABILIZATION": "soon"}), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadEnv(tt.env)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.ScaleUpStabilization != tt.want {
+				t.Errorf("ScaleUpStabilization: got %v, want %v", got.ScaleUpStabilization, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadMaxConsecutiveFailures(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    int
+		wantErr bool
+	}{
+		{name: "default never exits", env: withRequired(nil), want: 0},
+		{name: "set", env: withRequired(map[string]string{"MAX_CONSECUTIVE_FAILURES": "10"}), want: 10},
+		{name: "negative", env: withRequired(map[string]string{"MAX_CONSECUTIVE_FAILURES": "-1"}), wantErr: true},
+		{name: "invalid", env: withRequired(map[string]string{"MAX_CONSECUTIVE_FAILURES": "ten"}), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadEnv(tt.env)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.MaxConsecutiveFailures != tt.want {
+				t.Errorf("MaxConsecutiveFailures: got %d, want %d", got.MaxConsecutiveFailures, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadTaskCorrelation(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    string
+		wantErr bool
+	}{
+		{name: "default ip", env: withRequired(nil), want: TaskCorrelationIP},
+		{name: "name", env: withRequired(map[string]string{"TASK_CORRELATION": "name"}), want: TaskCorrelationName},
+		{name: "unknown", env: withRequired(map[string]string{"TASK_CORRELATION": "arn"}), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadEnv(tt.env)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.TaskCorrelation != tt.want {
+				t.Errorf("TaskCorrelation: got %q, want %q", got.TaskCorrelation, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadECSWriteBreaker(t *testing.T) {
+	tests := []struct {
+		name          string
+		env           map[string]string
+		wantThreshold int
+		wantCooldown  time.Duration
+		wantErr       bool
+	}{
+		{name: "default disabled", env: withRequired(nil), wantThreshold: 0, wantCooldown: 5 * time.Minute},
+		{name: "set", env: withRequired(map[string]string{"ECS_WRITE_BREAKER_THRESHOLD": "3", "ECS_WRITE_BREAKER_COOLDOWN": "10m"}), wantThreshold: 3, wantCooldown: 10 * time.Minute},
+		{name: "negative threshold", env: withRequired(map[string]string{"ECS_WRITE_BREAKER_THRESHOLD": "-1"}), wantErr: true},
+		{name: "invalid threshold", env: withRequired(map[string]string{"ECS_WRITE_BREAKER_THRESHOLD": "three"}), wantErr: true},
+		{name: "zero cooldown", env: withRequired(map[string]string{"ECS_WRITE_BREAKER_COOLDOWN": "0s"}), wantErr: true},
+		{name: "invalid cooldown", env: withRequired(map[string]string{"ECS_WRITE_BREAKER_COOLDOWN": "later"}), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadEnv(tt.env)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.ECSWriteBreakerThreshold != tt.wantThreshold {
+				t.Errorf("ECSWriteBreakerThreshold: got %d, want %d", got.ECSWriteBreakerThreshold, tt.wantThreshold)
+			}
+			if got.ECSWriteBreakerCooldown != tt.wantCooldown {
+				t.Errorf("ECSWriteBreakerCooldown: got %v, want %v", got.ECSWriteBreakerCooldown, tt.wantCooldown)
+			}
+		})
+	}
+}
+
+func TestLoadRunPollInterval(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    time.Duration
+		wantErr bool
+	}{
+		{name: "default every reconcile", env: withRequired(nil), want: 0},
+		{name: "set", env: withRequired(map[string]string{"RUN_POLL_INTERVAL": "1m"}), want: time.Minute},
+		{name: "equal to poll interval", env: withRequired(map[string]string{"POLL_INTERVAL": "30s", "RUN_POLL_INTERVAL": "30s"}), want: 30 * time.Second},
+		{name: "below poll interval", env: withRequired(map[string]string{"POLL_INTERVAL": "30s", "RUN_POLL_INTERVAL": "20s"}), wantErr: true},
+		{name: "negative", env: withRequired(map[string]string{"RUN_POLL_INTERVAL": "-1m"}), wantErr: true},
+		{name: "invalid", env: withRequired(map[string]string{"RUN_POLL_INTERVAL": "often"}), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadEnv(tt.env)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.RunPollInterval != tt.want {
+				t.Errorf("RunPollInterval: got %v, want %v", got.RunPollInterval, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadScaleToZeroGrace(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    time.Duration
+		wantErr bool
+	}{
+		{name: "default disabled", env: withRequired(nil), want: 0},
+		{name: "overridden", env: withRequired(map[string]string{"SCALE_TO_ZERO_GRACE": "15m"}), want: 15 * time.Minute},
+		{name: "negative", env: withRequired(map[string]string{"SCALE_TO_ZERO_GRACE": "-1h"}), wantErr: true},
+		{name: "invalid", env: withRequired(map[string]string{"SCALE_TO_ZERO_GRACE": "soon"}), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadEnv(tt.env)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.ScaleToZeroGrace != tt.want {
+				t.Errorf("ScaleToZeroGrace: got %v, want %v", got.ScaleToZeroGrace, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadStartupDelay(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    time.Duration
+		wantErr bool
+	}{
+		{name: "default disabled", env: withRequired(nil), want: 0},
+		{name: "overridden", env: withRequired(map[string]string{"STARTUP_DELAY": "45s"}), want: 45 * time.Second},
+		{name: "negative", env: withRequired(map[string]string{"STARTUP_DELAY": "-1s"}), wantErr: true},
+		{name: "invalid", env: withRequired(map[string]string{"STARTUP_DELAY": "later"}), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadEnv(tt.env)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.StartupDelay != tt.want {
+				t.Errorf("StartupDelay: got %v, want %v", got.StartupDelay, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadShutdownTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    time.Duration
+		wantErr bool
+	}{
+		{name: "default", env: withRequired(nil), want: 0},
+		{name: "overridden", env: withRequired(map[string]string{"SHUTDOWN_TIMEOUT": "20s"}), want: 20 * time.Second},
+		{name: "negative", env: withRequired(map[string]string{"SHUTDOWN_TIMEOUT": "-1s"}), wantErr: true},
+		{name: "invalid", env: withRequired(map[string]string{"SHUTDOWN_TIMEOUT": "later"}), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadEnv(tt.env)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.ShutdownTimeout != tt.want {
+				t.Errorf("ShutdownTimeout: got %v, want %v", got.ShutdownTimeout, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadScaleWebhook(t *testing.T) {
+	tests := []struct {
+		name        string
+		env         map[string]string
+		wantURL     string
+		wantTimeout time.Duration
+		wantErr     bool
+	}{
+		{name: "default disabled", env: withRequired(nil), wantTimeout: 5 * time.Second},
+		{
+			name:        "overridden",
+			env:         withRequired(map[string]string{"SCALE_WEBHOOK_URL": "https://hooks.example.com/scale", "SCALE_WEBHOOK_TIMEOUT": "2s"}),
+			wantURL:     "https://hooks.example.com/scale",
+			wantTimeout: 2 * time.Second,
+		},
+		{name: "not http", env: withRequired(map[string]string{"SCALE_WEBHOOK_URL": "ftp://example.com"}), wantErr: true},
+		{name: "no host", env: withRequired(map[string]string{"SCALE_WEBHOOK_URL": "hooks.example.com/scale"}), wantErr: true},
+		{name: "zero timeout", env: withRequired(map[string]string{"SCALE_WEBHOOK_TIMEOUT": "0s"}), wantErr: true},
+		{name: "invalid timeout", env: withRequired(map[string]string{"SCALE_WEBHOOK_TIMEOUT": "soon"}), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadEnv(tt.env)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.ScaleWebhookURL != tt.wantURL {
+				t.Errorf("ScaleWebhookURL: got %q, want %q", got.ScaleWebhookURL, tt.wantURL)
+			}
+			if got.ScaleWebhookTimeout != tt.wantTimeout {
+				t.Errorf("ScaleWebhookTimeout: got %v, want %v", got.ScaleWebhookTimeout, tt.wantTimeout)
+			}
+		})
+	}
+}
+
+func TestLoadLogLevel(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    slog.Level
+		wantErr bool
+	}{
+		{name: "default info", env: withRequired(nil), want: slog.LevelInfo},
+		{name: "debug", env: withRequired(map[string]string{"LOG_LEVEL": "debug"}), want: slog.LevelDebug},
+		{name: "warn", env: withRequired(map[string]string{"LOG_LEVEL": "warn"}), want: slog.LevelWarn},
+		{name: "error upper case", env: withRequired(map[string]string{"LOG_LEVEL": "ERROR"}), want: slog.LevelError},
+		{name: "unknown", env: withRequired(map[string]string{"LOG_LEVEL": "verbose"}), wantErr: true},
+		{name: "offset not accepted", env: withRequired(map[string]string{"LOG_LEVEL": "info+2"}), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadEnv(tt.env)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				if !strings.Contains(err.Error(), "LOG_LEVEL") {
+					t.Errorf("error %q does not name LOG_LEVEL", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.LogLevel != tt.want {
+				t.Errorf("LogLevel: got %v, want %v", got.LogLevel, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadLogFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    string
+		wantErr bool
+	}{
+		{name: "default json", env: withRequired(nil), want: LogFormatJSON},
+		{name: "json", env: withRequired(map[string]string{"LOG_FORMAT": "json"}), want: LogFormatJSON},
+		{name: "text", env: withRequired(map[string]string{"LOG_FORMAT": "text"}), want: LogFormatText},
+		{name: "invalid", env: withRequired(map[string]string{"LOG_FORMAT": "logfmt"}), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadEnv(tt.env)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.LogFormat != tt.want {
+				t.Errorf("LogFormat: got %q, want %q", got.LogFormat, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadScaleDeadband(t *testing.T) {
+	tests := []struct {
+		name        string
+		env         map[string]string
+		wantCost    bool
+		wantCount   int
+		wantPercent float64
+		wantErr     bool
+	}{
+		{name: "defaults", env: withRequired(nil), wantCount: 1},
+		{name: "cost mode", env: withRequired(map[string]string{"COST_MODE": "true"}), wantCost: true, wantCount: 1},
+		{name: "count", env: withRequired(map[string]string{"COST_MODE": "true", "SCALE_DEADBAND": "3"}), wantCost: true, wantCount: 3},
+		{name: "percentage", env: withRequired(map[string]string{"COST_MODE": "true", "SCALE_DEADBAND": "20%"}), wantCost: true, wantPercent: 20},
+		{name: "zero", env: withRequired(map[string]string{"SCALE_DEADBAND": "0"})},
+		{name: "negative", env: withRequired(map[string]string{"SCALE_DEADBAND": "-1"}), wantErr: true},
+		{name: "percentage too large", env: withRequired(map[string]string{"SCALE_DEADBAND": "100%"}), wantErr: true},
+		{name: "zero percentage", env: withRequired(map[string]string{"SCALE_DEADBAND": "0%"}), wantErr: true},
+		{name: "invalid", env: withRequired(map[string]string{"SCALE_DEADBAND": "two"}), wantErr: true},
+		{name: "invalid cost mode", env: withRequired(map[string]string{"COST_MODE": "cheap"}), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadEnv(tt.env)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.CostMode != tt.wantCost {
+				t.Errorf("CostMode: got %v, want %v", got.CostMode, tt.wantCost)
+			}
+			if got.ScaleDeadband != tt.wantCount || got.ScaleDeadbandPercent != tt.wantPercent {
+				t.Errorf("deadband: got %d / %v%%, want %d / %v%%", got.ScaleDeadband, got.ScaleDeadbandPercent, tt.wantCount, tt.wantPercent)
+			}
+		})
+	}
+}
@@ -3,93 +3,525 @@ package ecs
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
 	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
 	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/smithy-go"
 )
 
 // API is the subset of the ECS API the autoscaler needs.
 type API interface { //nolint:dupl // mock in test file mirrors this interface by design
+	DescribeClusters(ctx context.Context, input *ecs.DescribeClustersInput, opts ...func(*ecs.Options)) (*ecs.DescribeClustersOutput, error)
 	DescribeServices(ctx context.Context, input *ecs.DescribeServicesInput, opts ...func(*ecs.Options)) (*ecs.DescribeServicesOutput, error)
 	UpdateService(ctx context.Context, input *ecs.UpdateServiceInput, opts ...func(*ecs.Options)) (*ecs.UpdateServiceOutput, error)
 	ListTasks(ctx context.Context, input *ecs.ListTasksInput, opts ...func(*ecs.Options)) (*ecs.ListTasksOutput, error)
 	DescribeTasks(ctx context.Context, input *ecs.DescribeTasksInput, opts ...func(*ecs.Options)) (*ecs.DescribeTasksOutput, error)
 	UpdateTaskProtection(ctx context.Context, input *ecs.UpdateTaskProtectionInput, opts ...func(*ecs.Options)) (*ecs.UpdateTaskProtectionOutput, error)
+	StopTask(ctx context.Context, input *ecs.StopTaskInput, opts ...func(*ecs.Options)) (*ecs.StopTaskOutput, error)
 }
 
-// TaskInfo holds an ECS task's ARN and private IP.
+// AgentNameTag is the task tag whose value names the TFC agent running in
+// the task. It lets agents be matched to tasks when no private IP is known.
+const AgentNameTag = "tfc-agent-name"
+
+// TaskInfo holds an ECS task's ARN, private IP, start time, tags, and, for
+// stopping tasks, why they stopped.
 type TaskInfo struct {
 	TaskArn   string
 	PrivateIP string
+	// StartedAt is zero until the task has started running.
+	StartedAt time.Time
+	Tags      map[string]string
+	// LastStatus is the task's last known lifecycle status, e.g. RUNNING or
+	// DEACTIVATING.
+	LastStatus    string
+	StopCode      string
+	StoppedReason string
+}
+
+// TaskID returns the task ID, the last segment of the task ARN.
+func (t TaskInfo) TaskID() string {
+	return t.TaskArn[strings.LastIndex(t.TaskArn, "/")+1:]
+}
+
+// MatchesAgentName reports whether the TFC agent called name runs in this
+// task: the name is the task ID, ends with "-<task ID>", or equals the
+// task's AgentNameTag tag.
+func (t TaskInfo) MatchesAgentName(name string) bool {
+	if name == "" {
+		return false
+	}
+	if tag, ok := t.Tags[AgentNameTag]; ok && tag == name {
+		return true
+	}
+	id := t.TaskID()
+	return id != "" && (name == id || strings.HasSuffix(name, "-"+id))
+}
+
+// SpotInterrupted reports whether the task was stopped because Fargate Spot
+// reclaimed its capacity, by stop code or, failing that, stopped reason.
+func (t TaskInfo) SpotInterrupted() bool {
+	if t.StopCode == string(types.TaskStopCodeSpotInterruption) {
+		return true
+	}
+	reason := strings.ToLower(t.StoppedReason)
+	return strings.Contains(reason, "spot") && strings.Contains(reason, "interrupt")
+}
+
+// Running reports whether the task is still in the RUNNING state, and so
+// still counted in the service's running count.
+func (t TaskInfo) Running() bool {
+	return t.LastStatus == string(types.DesiredStatusRunning)
+}
+
+// Age returns how long the task has been running at now, or zero if it has
+// not started.
+func (t TaskInfo) Age(now time.Time) time.Duration {
+	if t.StartedAt.IsZero() {
+		return 0
+	}
+	return now.Sub(t.StartedAt)
 }
 
 // Client wraps ECS API access for the autoscaler.
 type Client struct {
+	// cluster is the cluster ARN, resolved by New when given a name.
 	cluster string
 	service string
 	api     API
+
+	// roleARN, when set, is assumed via STS for all ECS calls.
+	roleARN string
+	// externalID is passed to STS when assuming roleARN.
+	externalID string
+	// region overrides the region from the default AWS config.
+	region string
+	// protectionBatchSize is the number of tasks per UpdateTaskProtection
+	// call. Zero uses maxProtectionBatchSize.
+	protectionBatchSize int
+
+	// taskIPCacheTTL is how long GetTaskIPs results are reused. Zero
+	// disables the cache.
+	taskIPCacheTTL time.Duration
+	// now returns the current time; nil uses time.Now.
+	now func() time.Time
+
+	// taskIPListMu serializes cache misses so concurrent callers share one
+	// listing. taskIPMu guards the cached result and is not held while
+	// listing, so invalidation never waits on ECS.
+	taskIPListMu    sync.Mutex
+	taskIPMu        sync.Mutex
+	taskIPs         []TaskInfo
+	taskIPsExpireAt time.Time
+	// taskIPGen counts invalidations, so a listing that overlaps one is not
+	// cached.
+	taskIPGen uint64
 }
 
-// New creates a new ECS client using the default AWS config.
-func New(ctx context.Context, cluster, service string) (*Client, error) {
-	cfg, err := awsconfig.LoadDefaultConfig(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("loading AWS config: %w", err)
+// maxProtectionBatchSize is the most tasks UpdateTaskProtection accepts per call.
+const maxProtectionBatchSize = 10
+
+// Option configures optional behavior for Client.
+type Option func(*Client)
+
+// WithAssumeRole makes the client call ECS with credentials from assuming
+// roleARN, e.g. when the cluster lives in another account. externalID is
+// optional. An empty roleARN keeps the default credential chain.
+func WithAssumeRole(roleARN, externalID string) Option {
+	return func(c *Client) {
+		c.roleARN = roleARN
+		c.externalID = externalID
+	}
+}
+
+// WithRegion sets the AWS region for ECS (and STS) calls, overriding the
+// default config chain. An empty region keeps the default.
+func WithRegion(region string) Option {
+	return func(c *Client) {
+		c.region = region
+	}
+}
+
+// WithProtectionBatchSize sets how many tasks SetTaskProtection updates per
+// API call. Sizes outside 1 to 10 (the ECS maximum) use 10.
+func WithProtectionBatchSize(n int) Option {
+	return func(c *Client) {
+		c.protectionBatchSize = n
 	}
+}
 
-	return &Client{
+// WithTaskIPCache reuses GetTaskIPs results for ttl so the scaler and
+// ServiceView share one ListTasks/DescribeTasks round trip per reconcile.
+// The cache is dropped whenever the client changes the desired count or stops
+// a task. Zero disables caching.
+func WithTaskIPCache(ttl time.Duration) Option {
+	return func(c *Client) {
+		c.taskIPCacheTTL = ttl
+	}
+}
+
+// New creates a new ECS client using the default AWS config.
+func New(ctx context.Context, cluster, service string, opts ...Option) (*Client, error) {
+	c := &Client{
 		cluster: cluster,
 		service: service,
-		api:     ecs.NewFromConfig(cfg),
-	}, nil
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	cfg, err := c.awsConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.api = ecs.NewFromConfig(cfg)
+
+	if err := c.resolveCluster(ctx); err != nil {
+		return nil, err
+	}
+
+	return c, nil
 }
 
-// GetServiceStatus returns the desired and running task counts for the service.
-func (c *Client) GetServiceStatus(ctx context.Context) (desired, running int32, err error) {
+// resolveCluster replaces a cluster name with the cluster's ARN, looked up
+// with DescribeClusters, so every later call names the cluster unambiguously.
+// A cluster given as an ARN is kept as it is.
+func (c *Client) resolveCluster(ctx context.Context) error {
+	if strings.HasPrefix(c.cluster, "arn:") {
+		return nil
+	}
+
+	out, err := c.api.DescribeClusters(ctx, &ecs.DescribeClustersInput{
+		Clusters: []string{c.cluster},
+	})
+	if err != nil {
+		return fmt.Errorf("describing cluster %s: %w", c.cluster, apiError(err))
+	}
+	if len(out.Clusters) == 0 || aws.ToString(out.Clusters[0].ClusterArn) == "" {
+		return fmt.Errorf("%w: cluster %s", ErrServiceNotFound, c.cluster)
+	}
+	c.cluster = aws.ToString(out.Clusters[0].ClusterArn)
+	return nil
+}
+
+// Cluster returns the ARN of the cluster the client manages.
+func (c *Client) Cluster() string {
+	return c.cluster
+}
+
+// awsConfig loads the default AWS config with any region override, replacing
+// its credentials with assumed-role credentials when a role ARN is
+// configured. The default chain is still used to call STS.
+func (c *Client) awsConfig(ctx context.Context) (aws.Config, error) {
+	var loadOpts []func(*awsconfig.LoadOptions) error
+	if c.region != "" {
+		loadOpts = append(loadOpts, awsconfig.WithRegion(c.region))
+	}
+
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, loadOpts...)
+	if err != nil {
+		return aws.Config{}, fmt.Errorf("loading AWS config: %w", err)
+	}
+
+	if c.roleARN != "" {
+		cfg.Credentials = assumeRoleCredentials(sts.NewFromConfig(cfg), c.roleARN, c.externalID)
+	}
+
+	return cfg, nil
+}
+
+// assumeRoleCredentials returns a cached credentials provider that assumes
+// roleARN through client.
+func assumeRoleCredentials(client stscreds.AssumeRoleAPIClient, roleARN, externalID string) aws.CredentialsProvider {
+	provider := stscreds.NewAssumeRoleProvider(client, roleARN, func(o *stscreds.AssumeRoleOptions) {
+		if externalID != "" {
+			o.ExternalID = aws.String(externalID)
+		}
+	})
+	return aws.NewCredentialsCache(provider)
+}
+
+// ServiceStatus holds an ECS service's task counts.
+type ServiceStatus struct {
+	Desired int32
+	Running int32
+	// Pending is the number of tasks placed but not yet running.
+	Pending int32
+	// FailedTasks is the number of tasks in the primary deployment that
+	// stopped before reaching a steady state, as reported by ECS. A non-zero
+	// value usually means tasks are crashing on start.
+	FailedTasks int32
+	// DeploymentController is the service's deployment controller type, e.g.
+	// ECS or CODE_DEPLOY. Empty when ECS does not report one, which means the
+	// default ECS rolling update controller.
+	DeploymentController string
+	// CapacityProviderStrategy is the service's capacity provider strategy,
+	// in the order ECS reports it. Empty when the service uses a launch type.
+	CapacityProviderStrategy []CapacityProviderStrategyItem
+	// MaximumPercent is the deployment configuration's maximumPercent: the
+	// most tasks ECS runs during a rolling deployment, as a percentage of the
+	// desired count. Zero when ECS does not report one.
+	MaximumPercent int32
+}
+
+// CapacityProviderStrategyItem is one capacity provider in a service's
+// strategy. Base tasks are placed on the provider first; the rest are split
+// between providers by Weight.
+type CapacityProviderStrategyItem struct {
+	Provider string
+	Weight   int32
+	Base     int32
+}
+
+// ProtectionSupported reports whether the service's deployment controller
+// supports task scale-in protection, which only the ECS rolling update
+// controller does.
+func (s ServiceStatus) ProtectionSupported() bool {
+	return s.DeploymentController == "" || s.DeploymentController == string(types.DeploymentControllerTypeEcs)
+}
+
+// GetServiceStatus returns the desired, running, pending, and failed task
+// counts, the deployment controller type and maximumPercent, and the capacity
+// provider strategy for the service.
+func (c *Client) GetServiceStatus(ctx context.Context) (ServiceStatus, error) {
 	out, err := c.api.DescribeServices(ctx, &ecs.DescribeServicesInput{
 		Cluster:  aws.String(c.cluster),
 		Services: []string{c.service},
 	})
 	if err != nil {
-		return 0, 0, fmt.Errorf("describing service: %w", err)
+		return ServiceStatus{}, fmt.Errorf("describing service: %w", apiError(err))
 	}
 
 	if len(out.Services) == 0 {
-		return 0, 0, fmt.Errorf("service %s not found in cluster %s", c.service, c.cluster)
+		return ServiceStatus{}, fmt.Errorf("%w: %s in cluster %s", ErrServiceNotFound, c.service, c.cluster)
 	}
 
 	svc := out.Services[0]
-	return svc.DesiredCount, svc.RunningCount, nil
+	status := ServiceStatus{
+		Desired: svc.DesiredCount,
+		Running: svc.RunningCount,
+		Pending: svc.PendingCount,
+	}
+	if svc.DeploymentController != nil {
+		status.DeploymentController = string(svc.DeploymentController.Type)
+	}
+	for _, d := range svc.Deployments {
+		if aws.ToString(d.Status) == "PRIMARY" {
+			status.FailedTasks = d.FailedTasks
+		}
+	}
+	if svc.DeploymentConfiguration != nil {
+		status.MaximumPercent = aws.ToInt32(svc.DeploymentConfiguration.MaximumPercent)
+	}
+	for _, item := range svc.CapacityProviderStrategy {
+		status.CapacityProviderStrategy = append(status.CapacityProviderStrategy, CapacityProviderStrategyItem{
+			Provider: aws.ToString(item.CapacityProvider),
+			Weight:   item.Weight,
+			Base:     item.Base,
+		})
+	}
+	return status, nil
 }
 
-// SetDesiredCount updates the desired task count for the service.
+// Validate describes the service once to check that the credentials can
+// reach ECS and that the service exists and is active. It is meant as a
+// startup preflight so IAM and naming mistakes fail fast.
+func (c *Client) Validate(ctx context.Context) error {
+	out, err := c.api.DescribeServices(ctx, &ecs.DescribeServicesInput{
+		Cluster:  aws.String(c.cluster),
+		Services: []string{c.service},
+	})
+	if err != nil {
+		return fmt.Errorf("describing service %s in cluster %s: %w", c.service, c.cluster, apiError(err))
+	}
+	if len(out.Services) == 0 {
+		return fmt.Errorf("%w: %s in cluster %s", ErrServiceNotFound, c.service, c.cluster)
+	}
+	if status := aws.ToString(out.Services[0].Status); status != "ACTIVE" {
+		return fmt.Errorf("service %s in cluster %s is %s", c.service, c.cluster, status)
+	}
+	return nil
+}
+
+// Errors returned by Client, wrapping the underlying AWS error, so callers
+// can tell failure categories apart with errors.Is.
+var (
+	// ErrServiceNotFound means the service or its cluster does not exist.
+	ErrServiceNotFound = errors.New("service not found")
+	// ErrThrottled means ECS rate limited the request; a later attempt may
+	// succeed.
+	ErrThrottled = errors.New("request throttled")
+	// ErrAccessDenied means the credentials lack permission for the call.
+	// Retrying will not help.
+	ErrAccessDenied = errors.New("access denied")
+)
+
+// accessDeniedErrorCodes are the AWS API error codes for missing permissions.
+var accessDeniedErrorCodes = map[string]struct{}{
+	"AccessDenied":          {},
+	"AccessDeniedException": {},
+	"UnauthorizedOperation": {},
+}
+
+// apiError maps an AWS API error to ErrServiceNotFound, ErrThrottled, or
+// ErrAccessDenied, keeping the original error in the chain. Other errors are
+// returned unchanged.
+func apiError(err error) error {
+	var serviceNotFound *types.ServiceNotFoundException
+	var clusterNotFound *types.ClusterNotFoundException
+	if errors.As(err, &serviceNotFound) || errors.As(err, &clusterNotFound) {
+		return fmt.Errorf("%w: %w", ErrServiceNotFound, err)
+	}
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+	if _, ok := retry.DefaultThrottleErrorCodes[apiErr.ErrorCode()]; ok {
+		return fmt.Errorf("%w: %w", ErrThrottled, err)
+	}
+	if _, ok := accessDeniedErrorCodes[apiErr.ErrorCode()]; ok {
+		return fmt.Errorf("%w: %w", ErrAccessDenied, err)
+	}
+	return err
+}
+
+// DesiredCountMismatchError reports that UpdateService succeeded but the
+// service's desired count afterwards differs from the one requested, usually
+// because another actor updated the service concurrently.
+type DesiredCountMismatchError struct {
+	Requested int32
+	Applied   int32
+}
+
+func (e *DesiredCountMismatchError) Error() string {
+	return fmt.Sprintf("service desired count is %d after requesting %d", e.Applied, e.Requested)
+}
+
+// SetDesiredCount updates the desired task count for the service. When the
+// response shows a different desired count than requested, the update was
+// still made and a *DesiredCountMismatchError is returned.
 func (c *Client) SetDesiredCount(ctx context.Context, count int32) error {
-	_, err := c.api.UpdateService(ctx, &ecs.UpdateServiceInput{
+	out, err := c.api.UpdateService(ctx, &ecs.UpdateServiceInput{
 		Cluster:      aws.String(c.cluster),
 		Service:      aws.String(c.service),
 		DesiredCount: aws.Int32(count),
 	})
+	// Task membership changes once ECS acts on the new count.
+	c.invalidateTaskIPs()
 	if err != nil {
-		return fmt.Errorf("updating service desired count: %w", err)
+		return fmt.Errorf("updating service desired count: %w", apiError(err))
 	}
 
+	if out != nil && out.Service != nil && out.Service.DesiredCount != count {
+		return &DesiredCountMismatchError{Requested: count, Applied: out.Service.DesiredCount}
+	}
 	return nil
 }
 
-// GetTaskIPs returns the ARN and private IP of each task in the service.
+// GetTaskIPs returns the ARN and private IP of each task in the service,
+// served from the cache when WithTaskIPCache is set and it has not expired.
 func (c *Client) GetTaskIPs(ctx context.Context) ([]TaskInfo, error) {
+	if c.taskIPCacheTTL <= 0 {
+		return c.listTaskIPs(ctx)
+	}
+
+	c.taskIPListMu.Lock()
+	defer c.taskIPListMu.Unlock()
+
+	c.taskIPMu.Lock()
+	now := c.clock()
+	if c.taskIPs != nil && now.Before(c.taskIPsExpireAt) {
+		tasks := slices.Clone(c.taskIPs)
+		c.taskIPMu.Unlock()
+		return tasks, nil
+	}
+	gen := c.taskIPGen
+	c.taskIPMu.Unlock()
+
+	tasks, err := c.listTaskIPs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c.taskIPMu.Lock()
+	defer c.taskIPMu.Unlock()
+	// A scale action during the listing may have changed the task set, so
+	// the result is returned but not cached.
+	if c.taskIPGen == gen {
+		// Store a non-nil slice so an empty service is cached too.
+		c.taskIPs = append([]TaskInfo{}, tasks...)
+		c.taskIPsExpireAt = now.Add(c.taskIPCacheTTL)
+	}
+	return tasks, nil
+}
+
+// invalidateTaskIPs drops cached GetTaskIPs results.
+func (c *Client) invalidateTaskIPs() {
+	c.taskIPMu.Lock()
+	defer c.taskIPMu.Unlock()
+	c.taskIPs = nil
+	c.taskIPGen++
+}
+
+func (c *Client) clock() time.Time {
+	if c.now != nil {
+		return c.now()
+	}
+	return time.Now()
+}
+
+// listTaskIPs lists the service's tasks and describes them to read their
+// private IPs.
+func (c *Client) listTaskIPs(ctx context.Context) ([]TaskInfo, error) {
+	arns, err := c.listTaskArns(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	return c.describeTasks(ctx, arns)
+}
+
+// GetInterruptedTasks returns the service's tasks that were stopped by a
+// Fargate Spot interruption, including ones still shutting down. ECS lists
+// stopped tasks for about an hour, so callers see each interruption on every
+// call within that window.
+func (c *Client) GetInterruptedTasks(ctx context.Context) ([]TaskInfo, error) {
+	arns, err := c.listTaskArns(ctx, types.DesiredStatusStopped)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := c.describeTasks(ctx, arns)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(tasks, func(t TaskInfo) bool { return !t.SpotInterrupted() }), nil
+}
+
+// listTaskArns lists the ARNs of the service's tasks with the given desired
+// status. An empty status lists running tasks, the ECS default.
+func (c *Client) listTaskArns(ctx context.Context, desiredStatus types.DesiredStatus) ([]string, error) {
 	var allArns []string
 	input := &ecs.ListTasksInput{
-		Cluster:     aws.String(c.cluster),
-		ServiceName: aws.String(c.service),
+		Cluster:       aws.String(c.cluster),
+		ServiceName:   aws.String(c.service),
+		DesiredStatus: desiredStatus,
 	}
 
 	for {
 		listOut, err := c.api.ListTasks(ctx, input)
 		if err != nil {
-			return nil, fmt.Errorf("listing tasks: %w", err)
+			return nil, fmt.Errorf("listing tasks: %w", apiError(err))
 		}
 		allArns = append(allArns, listOut.TaskArns...)
 
@@ -99,6 +531,12 @@ func (c *Client) GetTaskIPs(ctx context.Context) ([]TaskInfo, error) {
 		input.NextToken = listOut.NextToken
 	}
 
+	return allArns, nil
+}
+
+// describeTasks describes the given tasks in batches and converts them to
+// TaskInfo.
+func (c *Client) describeTasks(ctx context.Context, allArns []string) ([]TaskInfo, error) {
 	if len(allArns) == 0 {
 		return nil, nil
 	}
@@ -114,13 +552,26 @@ func (c *Client) GetTaskIPs(ctx context.Context) ([]TaskInfo, error) {
 		descOut, err := c.api.DescribeTasks(ctx, &ecs.DescribeTasksInput{
 			Cluster: aws.String(c.cluster),
 			Tasks:   allArns[i:end],
+			Include: []types.TaskField{types.TaskFieldTags},
 		})
 		if err != nil {
-			return nil, fmt.Errorf("describing tasks: %w", err)
+			return nil, fmt.Errorf("describing tasks: %w", apiError(err))
 		}
 
 		for _, task := range descOut.Tasks {
-			info := TaskInfo{TaskArn: aws.ToString(task.TaskArn)}
+			info := TaskInfo{
+				TaskArn:       aws.ToString(task.TaskArn),
+				StartedAt:     aws.ToTime(task.StartedAt),
+				LastStatus:    aws.ToString(task.LastStatus),
+				StopCode:      string(task.StopCode),
+				StoppedReason: aws.ToString(task.StoppedReason),
+			}
+			if len(task.Tags) > 0 {
+				info.Tags = make(map[string]string, len(task.Tags))
+				for _, tag := range task.Tags {
+					info.Tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+				}
+			}
 			for _, att := range task.Attachments {
 				if aws.ToString(att.Type) == "ElasticNetworkInterface" {
 					for _, detail := range att.Details {
@@ -137,16 +588,24 @@ func (c *Client) GetTaskIPs(ctx context.Context) ([]TaskInfo, error) {
 	return tasks, nil
 }
 
-// SetTaskProtection enables or disables scale-in protection for the given tasks.
+// SetTaskProtection enables or disables scale-in protection for the given
+// tasks in batches. Every batch is attempted even if an earlier one fails, so
+// one bad ARN does not leave the remaining tasks unprotected; all batch errors
+// and per-task failures are returned joined.
 func (c *Client) SetTaskProtection(ctx context.Context, taskArns []string, enabled bool, expiresInMinutes int32) error {
-	const batchSize = 10
+	batchSize := c.protectionBatchSize
+	if batchSize <= 0 || batchSize > maxProtectionBatchSize {
+		batchSize = maxProtectionBatchSize
+	}
 
+	var errs []error
 	for i := 0; i < len(taskArns); i += batchSize {
-		end := i + batchSize
-		if end > len(taskArns) {
-			end = len(taskArns)
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			break
 		}
 
+		end := min(i+batchSize, len(taskArns))
 		input := &ecs.UpdateTaskProtectionInput{
 			Cluster:           aws.String(c.cluster),
 			Tasks:             taskArns[i:end],
@@ -156,11 +615,31 @@ func (c *Client) SetTaskProtection(ctx context.Context, taskArns []string, enabl
 			input.ExpiresInMinutes = aws.Int32(expiresInMinutes)
 		}
 
-		_, err := c.api.UpdateTaskProtection(ctx, input)
+		out, err := c.api.UpdateTaskProtection(ctx, input)
 		if err != nil {
-			return fmt.Errorf("updating task protection: %w", err)
+			errs = append(errs, fmt.Errorf("updating task protection for tasks %d-%d: %w", i, end-1, apiError(err)))
+			continue
+		}
+		for _, f := range out.Failures {
+			errs = append(errs, fmt.Errorf("updating task protection for %s: %s", aws.ToString(f.Arn), aws.ToString(f.Reason)))
 		}
 	}
 
+	return errors.Join(errs...)
+}
+
+// StopTask stops a single task in the cluster. The service scheduler starts a
+// replacement to maintain the desired count.
+func (c *Client) StopTask(ctx context.Context, taskArn, reason string) error {
+	defer c.invalidateTaskIPs()
+	_, err := c.api.StopTask(ctx, &ecs.StopTaskInput{
+		Cluster: aws.String(c.cluster),
+		Task:    aws.String(taskArn),
+		Reason:  aws.String(reason),
+	})
+	if err != nil {
+		return fmt.Errorf("stopping task %s: %w", taskArn, apiError(err))
+	}
+
 	return nil
 }
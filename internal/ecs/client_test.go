@@ -4,19 +4,34 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
 	"github.com/aws/aws-sdk-go-v2/service/ecs"
 	"github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	ststypes "github.com/aws/aws-sdk-go-v2/service/sts/types"
+	"github.com/aws/smithy-go"
 )
 
 type mockECSAPI struct {
+	describeClustersFn     func(ctx context.Context, input *ecs.DescribeClustersInput, opts ...func(*ecs.Options)) (*ecs.DescribeClustersOutput, error)
 	describeServicesFn     func(ctx context.Context, input *ecs.DescribeServicesInput, opts ...func(*ecs.Options)) (*ecs.DescribeServicesOutput, error)
 	updateServiceFn        func(ctx context.Context, input *ecs.UpdateServiceInput, opts ...func(*ecs.Options)) (*ecs.UpdateServiceOutput, error)
 	listTasksFn            func(ctx context.Context, input *ecs.ListTasksInput, opts ...func(*ecs.Options)) (*ecs.ListTasksOutput, error)
 	describeTasksFn        func(ctx context.Context, input *ecs.DescribeTasksInput, opts ...func(*ecs.Options)) (*ecs.DescribeTasksOutput, error)
 	updateTaskProtectionFn func(ctx context.Context, input *ecs.UpdateTaskProtectionInput, opts ...func(*ecs.Options)) (*ecs.UpdateTaskProtectionOutput, error)
+	stopTaskFn             func(ctx context.Context, input *ecs.StopTaskInput, opts ...func(*ecs.Options)) (*ecs.StopTaskOutput, error)
+}
+
+func (m *mockECSAPI) DescribeClusters(ctx context.Context, input *ecs.DescribeClustersInput, opts ...func(*ecs.Options)) (*ecs.DescribeClustersOutput, error) {
+	return m.describeClustersFn(ctx, input, opts...)
 }
 
 func (m *mockECSAPI) DescribeServices(ctx context.Context, input *ecs.DescribeServicesInput, opts ...func(*ecs.Options)) (*ecs.DescribeServicesOutput, error) {
@@ -39,19 +54,33 @@ func (m *mockECSAPI) UpdateTaskProtection(ctx context.Context, input *ecs.Update
 	return m.updateTaskProtectionFn(ctx, input, opts...)
 }
 
+func (m *mockECSAPI) StopTask(ctx context.Context, input *ecs.StopTaskInput, opts ...func(*ecs.Options)) (*ecs.StopTaskOutput, error) {
+	return m.stopTaskFn(ctx, input, opts...)
+}
+
 const (
 	testCluster = "my-cluster"
 	testService = "tfc-agent"
 )
 
+// equalServiceStatus compares ServiceStatus values, which are not comparable
+// with == because of the capacity provider strategy slice.
+func equalServiceStatus(a, b ServiceStatus) bool {
+	return a.Desired == b.Desired &&
+		a.Running == b.Running &&
+		a.Pending == b.Pending &&
+		a.FailedTasks == b.FailedTasks &&
+		a.DeploymentController == b.DeploymentController &&
+		slices.Equal(a.CapacityProviderStrategy, b.CapacityProviderStrategy)
+}
+
 func TestGetServiceStatus(t *testing.T) {
 	tests := []struct {
-		name        string
-		output      *ecs.DescribeServicesOutput
-		err         error
-		wantDesired int32
-		wantRunning int32
-		wantErr     bool
+		name    string
+		output  *ecs.DescribeServicesOutput
+		err     error
+		want    ServiceStatus
+		wantErr bool
 	}{
 		{
 			name: "healthy service",
@@ -63,8 +92,7 @@ func TestGetServiceStatus(t *testing.T) {
 					},
 				},
 			},
-			wantDesired: 5,
-			wantRunning: 5,
+			want: ServiceStatus{Desired: 5, Running: 5},
 		},
 		{
 			name: "scaling up",
@@ -76,8 +104,86 @@ func TestGetServiceStatus(t *testing.T) {
 					},
 				},
 			},
-			wantDesired: 10,
-			wantRunning: 3,
+			want: ServiceStatus{Desired: 10, Running: 3},
+		},
+		{
+			name: "pending tasks",
+			output: &ecs.DescribeServicesOutput{
+				Services: []types.Service{
+					{
+						DesiredCount: 6,
+						RunningCount: 2,
+						PendingCount: 3,
+					},
+				},
+			},
+			want: ServiceStatus{Desired: 6, Running: 2, Pending: 3},
+		},
+		{
+			name: "failed tasks in primary deployment",
+			output: &ecs.DescribeServicesOutput{
+				Services: []types.Service{
+					{
+						DesiredCount: 4,
+						RunningCount: 1,
+						PendingCount: 1,
+						Deployments: []types.Deployment{
+							{Status: aws.String("ACTIVE"), FailedTasks: 7},
+							{Status: aws.String("PRIMARY"), FailedTasks: 2},
+						},
+					},
+				},
+			},
+			want: ServiceStatus{Desired: 4, Running: 1, Pending: 1, FailedTasks: 2},
+		},
+		{
+			name: "deployment controller",
+			output: &ecs.DescribeServicesOutput{
+				Services: []types.Service{
+					{
+						DesiredCount:         2,
+						RunningCount:         2,
+						DeploymentController: &types.DeploymentController{Type: types.DeploymentControllerTypeCodeDeploy},
+					},
+				},
+			},
+			want: ServiceStatus{Desired: 2, Running: 2, DeploymentController: "CODE_DEPLOY"},
+		},
+		{
+			name: "capacity provider strategy",
+			output: &ecs.DescribeServicesOutput{
+				Services: []types.Service{
+					{
+						DesiredCount: 4,
+						RunningCount: 4,
+						CapacityProviderStrategy: []types.CapacityProviderStrategyItem{
+							{CapacityProvider: aws.String("FARGATE"), Weight: 1, Base: 1},
+							{CapacityProvider: aws.String("FARGATE_SPOT"), Weight: 3},
+						},
+					},
+				},
+			},
+			want: ServiceStatus{
+				Desired: 4,
+				Running: 4,
+				CapacityProviderStrategy: []CapacityProviderStrategyItem{
+					{Provider: "FARGATE", Weight: 1, Base: 1},
+					{Provider: "FARGATE_SPOT", Weight: 3},
+				},
+			},
+		},
+		{
+			name: "deployment maximum percent",
+			output: &ecs.DescribeServicesOutput{
+				Services: []types.Service{
+					{
+						DesiredCount:            4,
+						RunningCount:            4,
+						DeploymentConfiguration: &types.DeploymentConfiguration{MaximumPercent: aws.Int32(150)},
+					},
+				},
+			},
+			want: ServiceStatus{Desired: 4, Running: 4, MaximumPercent: 150},
 		},
 		{
 			name: "no services found",
@@ -108,7 +214,7 @@ func TestGetServiceStatus(t *testing.T) {
 				},
 			}
 
-			desired, running, err := c.GetServiceStatus(context.Background())
+			got, err := c.GetServiceStatus(context.Background())
 			if tt.wantErr {
 				if err == nil {
 					t.Fatal("expected error, got nil")
@@ -118,11 +224,145 @@ func TestGetServiceStatus(t *testing.T) {
 			if err != nil {
 				t.Fatalf("unexpected error: %v", err)
 			}
-			if desired != tt.wantDesired {
-				t.Errorf("desired: got %d, want %d", desired, tt.wantDesired)
+			if !equalServiceStatus(got, tt.want) {
+				t.Errorf("GetServiceStatus() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		out     *ecs.DescribeServicesOutput
+		err     error
+		wantErr bool
+	}{
+		{
+			name: "active service",
+			out:  &ecs.DescribeServicesOutput{Services: []types.Service{{Status: aws.String("ACTIVE")}}},
+		},
+		{
+			name:    "access denied",
+			err:     &types.AccessDeniedException{Message: aws.String("not authorized to perform ecs:DescribeServices")},
+			wantErr: true,
+		},
+		{
+			name:    "service not found",
+			out:     &ecs.DescribeServicesOutput{},
+			wantErr: true,
+		},
+		{
+			name:    "inactive service",
+			out:     &ecs.DescribeServicesOutput{Services: []types.Service{{Status: aws.String("INACTIVE")}}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Client{
+				cluster: testCluster,
+				service: testService,
+				api: &mockECSAPI{
+					describeServicesFn: func(_ context.Context, _ *ecs.DescribeServicesInput, _ ...func(*ecs.Options)) (*ecs.DescribeServicesOutput, error) {
+						return tt.out, tt.err
+					},
+				},
+			}
+
+			err := c.Validate(context.Background())
+			if !tt.wantErr {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if tt.err != nil {
+				var denied *types.AccessDeniedException
+				if !errors.As(err, &denied) {
+					t.Errorf("error: got %v, want wrapped AccessDeniedException", err)
+				}
+			}
+		})
+	}
+}
+
+func TestResolveCluster(t *testing.T) {
+	const clusterARN = "arn:aws:ecs:us-east-1:123456789012:cluster/my-cluster"
+
+	tests := []struct {
+		name      string
+		cluster   string
+		out       *ecs.DescribeClustersOutput
+		err       error
+		want      string
+		wantCalls int
+		wantErr   error
+	}{
+		{
+			name:      "short name resolved to ARN",
+			cluster:   testCluster,
+			out:       &ecs.DescribeClustersOutput{Clusters: []types.Cluster{{ClusterArn: aws.String(clusterARN), ClusterName: aws.String(testCluster)}}},
+			want:      clusterARN,
+			wantCalls: 1,
+		},
+		{
+			name:    "ARN kept without a lookup",
+			cluster: clusterARN,
+			want:    clusterARN,
+		},
+		{
+			name:      "cluster not found",
+			cluster:   testCluster,
+			out:       &ecs.DescribeClustersOutput{Failures: []types.Failure{{Arn: aws.String(testCluster), Reason: aws.String("MISSING")}}},
+			wantCalls: 1,
+			wantErr:   ErrServiceNotFound,
+		},
+		{
+			name:      "access denied",
+			cluster:   testCluster,
+			err:       &types.AccessDeniedException{Message: aws.String("not authorized to perform ecs:DescribeClusters")},
+			wantCalls: 1,
+			wantErr:   ErrAccessDenied,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int
+			c := &Client{
+				cluster: tt.cluster,
+				service: testService,
+				api: &mockECSAPI{
+					describeClustersFn: func(_ context.Context, input *ecs.DescribeClustersInput, _ ...func(*ecs.Options)) (*ecs.DescribeClustersOutput, error) {
+						calls++
+						if !slices.Equal(input.Clusters, []string{tt.cluster}) {
+							t.Errorf("DescribeClusters clusters = %v, want [%s]", input.Clusters, tt.cluster)
+						}
+						return tt.out, tt.err
+					},
+				},
+			}
+
+			err := c.resolveCluster(context.Background())
+			if calls != tt.wantCalls {
+				t.Errorf("DescribeClusters calls = %d, want %d", calls, tt.wantCalls)
 			}
-			if running != tt.wantRunning {
-				t.Errorf("running: got %d, want %d", running, tt.wantRunning)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := c.Cluster(); got != tt.want {
+				t.Errorf("cluster = %q, want %q", got, tt.want)
 			}
 		})
 	}
@@ -188,6 +428,150 @@ func TestSetDesiredCount(t *testing.T) {
 	}
 }
 
+var testStartedAt = time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+func TestSetDesiredCountMismatch(t *testing.T) {
+	tests := []struct {
+		name         string
+		output       *ecs.UpdateServiceOutput
+		wantMismatch *DesiredCountMismatchError
+	}{
+		{
+			name:   "applied count matches",
+			output: &ecs.UpdateServiceOutput{Service: &types.Service{DesiredCount: 5}},
+		},
+		{
+			name:         "applied count differs",
+			output:       &ecs.UpdateServiceOutput{Service: &types.Service{DesiredCount: 8}},
+			wantMismatch: &DesiredCountMismatchError{Requested: 5, Applied: 8},
+		},
+		{
+			name:   "no service in response",
+			output: &ecs.UpdateServiceOutput{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Client{
+				cluster: testCluster,
+				service: testService,
+				api: &mockECSAPI{
+					updateServiceFn: func(_ context.Context, _ *ecs.UpdateServiceInput, _ ...func(*ecs.Options)) (*ecs.UpdateServiceOutput, error) {
+						return tt.output, nil
+					},
+				},
+			}
+
+			err := c.SetDesiredCount(context.Background(), 5)
+			if tt.wantMismatch == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			var mismatch *DesiredCountMismatchError
+			if !errors.As(err, &mismatch) {
+				t.Fatalf("error = %v, want *DesiredCountMismatchError", err)
+			}
+			if *mismatch != *tt.wantMismatch {
+				t.Errorf("mismatch = %+v, want %+v", *mismatch, *tt.wantMismatch)
+			}
+		})
+	}
+}
+
+func TestServiceStatusProtectionSupported(t *testing.T) {
+	tests := []struct {
+		controller string
+		want       bool
+	}{
+		{controller: "", want: true},
+		{controller: "ECS", want: true},
+		{controller: "CODE_DEPLOY", want: false},
+		{controller: "EXTERNAL", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := (ServiceStatus{DeploymentController: tt.controller}).ProtectionSupported(); got != tt.want {
+			t.Errorf("ProtectionSupported() with controller %q = %v, want %v", tt.controller, got, tt.want)
+		}
+	}
+}
+
+func TestClientErrorCategories(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		wantErr error
+	}{
+		{name: "service not found", err: &types.ServiceNotFoundException{Message: aws.String("Service not found.")}, wantErr: ErrServiceNotFound},
+		{name: "cluster not found", err: &types.ClusterNotFoundException{Message: aws.String("Cluster not found.")}, wantErr: ErrServiceNotFound},
+		{name: "throttling", err: &smithy.GenericAPIError{Code: "ThrottlingException", Message: "Rate exceeded"}, wantErr: ErrThrottled},
+		{name: "too many requests", err: &smithy.GenericAPIError{Code: "TooManyRequestsException"}, wantErr: ErrThrottled},
+		{name: "access denied", err: &types.AccessDeniedException{Message: aws.String("not authorized")}, wantErr: ErrAccessDenied},
+		{name: "other API error", err: &types.InvalidParameterException{Message: aws.String("bad input")}},
+		{name: "non-API error", err: errors.New("connection reset")},
+	}
+	categories := []error{ErrServiceNotFound, ErrThrottled, ErrAccessDenied}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Client{
+				cluster: testCluster,
+				service: testService,
+				api: &mockECSAPI{
+					describeServicesFn: func(_ context.Context, _ *ecs.DescribeServicesInput, _ ...func(*ecs.Options)) (*ecs.DescribeServicesOutput, error) {
+						return nil, tt.err
+					},
+					updateServiceFn: func(_ context.Context, _ *ecs.UpdateServiceInput, _ ...func(*ecs.Options)) (*ecs.UpdateServiceOutput, error) {
+						return nil, tt.err
+					},
+					stopTaskFn: func(_ context.Context, _ *ecs.StopTaskInput, _ ...func(*ecs.Options)) (*ecs.StopTaskOutput, error) {
+						return nil, tt.err
+					},
+				},
+			}
+
+			_, statusErr := c.GetServiceStatus(context.Background())
+			calls := map[string]error{
+				"GetServiceStatus": statusErr,
+				"SetDesiredCount":  c.SetDesiredCount(context.Background(), 1),
+				"StopTask":         c.StopTask(context.Background(), "arn:task/1", "test"),
+			}
+			for call, err := range calls {
+				if !errors.Is(err, tt.err) {
+					t.Errorf("%s: error %v does not wrap the API error", call, err)
+				}
+				for _, category := range categories {
+					if got, want := errors.Is(err, category), category == tt.wantErr; got != want {
+						t.Errorf("%s: errors.Is(%v, %v) = %v, want %v", call, err, category, got, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestGetServiceStatusMissingService(t *testing.T) {
+	c := &Client{
+		cluster: testCluster,
+		service: testService,
+		api: &mockECSAPI{
+			describeServicesFn: func(_ context.Context, _ *ecs.DescribeServicesInput, _ ...func(*ecs.Options)) (*ecs.DescribeServicesOutput, error) {
+				return &ecs.DescribeServicesOutput{}, nil
+			},
+		},
+	}
+
+	if _, err := c.GetServiceStatus(context.Background()); !errors.Is(err, ErrServiceNotFound) {
+		t.Errorf("GetServiceStatus error: got %v, want ErrServiceNotFound", err)
+	}
+	if err := c.Validate(context.Background()); !errors.Is(err, ErrServiceNotFound) {
+		t.Errorf("Validate error: got %v, want ErrServiceNotFound", err)
+	}
+}
+
 func TestGetTaskIPs(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -207,7 +591,8 @@ func TestGetTaskIPs(t *testing.T) {
 			descOut: &ecs.DescribeTasksOutput{
 				Tasks: []types.Task{
 					{
-						TaskArn: aws.String("arn:aws:ecs:us-east-1:123:task/cluster/task1"),
+						TaskArn:   aws.String("arn:aws:ecs:us-east-1:123:task/cluster/task1"),
+						StartedAt: aws.Time(testStartedAt),
 						Attachments: []types.Attachment{
 							{
 								Type: aws.String("ElasticNetworkInterface"),
@@ -220,6 +605,9 @@ func TestGetTaskIPs(t *testing.T) {
 					},
 					{
 						TaskArn: aws.String("arn:aws:ecs:us-east-1:123:task/cluster/task2"),
+						Tags: []types.Tag{
+							{Key: aws.String(AgentNameTag), Value: aws.String("agent-2")},
+						},
 						Attachments: []types.Attachment{
 							{
 								Type: aws.String("ElasticNetworkInterface"),
@@ -233,8 +621,8 @@ func TestGetTaskIPs(t *testing.T) {
 			},
 			wantDescribe: true,
 			want: []TaskInfo{
-				{TaskArn: "arn:aws:ecs:us-east-1:123:task/cluster/task1", PrivateIP: "10.0.1.5"},
-				{TaskArn: "arn:aws:ecs:us-east-1:123:task/cluster/task2", PrivateIP: "10.0.1.6"},
+				{TaskArn: "arn:aws:ecs:us-east-1:123:task/cluster/task1", PrivateIP: "10.0.1.5", StartedAt: testStartedAt},
+				{TaskArn: "arn:aws:ecs:us-east-1:123:task/cluster/task2", PrivateIP: "10.0.1.6", Tags: map[string]string{AgentNameTag: "agent-2"}},
 			},
 		},
 		{
@@ -303,6 +691,9 @@ func TestGetTaskIPs(t *testing.T) {
 						if *input.Cluster != testCluster {
 							t.Errorf("DescribeTasks cluster: got %s, want my-cluster", *input.Cluster)
 						}
+						if len(input.Include) != 1 || input.Include[0] != types.TaskFieldTags {
+							t.Errorf("DescribeTasks include: got %v, want [TAGS]", input.Include)
+						}
 						if tt.descErr != nil {
 							return nil, tt.descErr
 						}
@@ -334,11 +725,146 @@ func TestGetTaskIPs(t *testing.T) {
 				if task.PrivateIP != tt.want[i].PrivateIP {
 					t.Errorf("task[%d].PrivateIP: got %s, want %s", i, task.PrivateIP, tt.want[i].PrivateIP)
 				}
+				if !task.StartedAt.Equal(tt.want[i].StartedAt) {
+					t.Errorf("task[%d].StartedAt: got %v, want %v", i, task.StartedAt, tt.want[i].StartedAt)
+				}
+				if !maps.Equal(task.Tags, tt.want[i].Tags) {
+					t.Errorf("task[%d].Tags: got %v, want %v", i, task.Tags, tt.want[i].Tags)
+				}
 			}
 		})
 	}
 }
 
+func TestGetTaskIPsCache(t *testing.T) {
+	tests := []struct {
+		name      string
+		ttl       time.Duration
+		between   func(c *Client, now *time.Time)
+		wantLists int
+	}{
+		{
+			name:      "reused within TTL",
+			ttl:       10 * time.Second,
+			between:   func(_ *Client, now *time.Time) { *now = now.Add(9 * time.Second) },
+			wantLists: 1,
+		},
+		{
+			name:      "refetched after TTL",
+			ttl:       10 * time.Second,
+			between:   func(_ *Client, now *time.Time) { *now = now.Add(10 * time.Second) },
+			wantLists: 2,
+		},
+		{
+			name: "invalidated by scaling",
+			ttl:  time.Minute,
+			between: func(c *Client, _ *time.Time) {
+				if err := c.SetDesiredCount(context.Background(), 3); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			},
+			wantLists: 2,
+		},
+		{
+			name: "invalidated by stopping a task",
+			ttl:  time.Minute,
+			between: func(c *Client, _ *time.Time) {
+				if err := c.StopTask(context.Background(), "arn:task/1", "recycle"); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			},
+			wantLists: 2,
+		},
+		{
+			name:      "disabled",
+			between:   func(_ *Client, _ *time.Time) {},
+			wantLists: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var lists int
+			now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+			c := &Client{
+				cluster: testCluster,
+				service: testService,
+				now:     func() time.Time { return now },
+				api: &mockECSAPI{
+					listTasksFn: func(_ context.Context, _ *ecs.ListTasksInput, _ ...func(*ecs.Options)) (*ecs.ListTasksOutput, error) {
+						lists++
+						return &ecs.ListTasksOutput{TaskArns: []string{"arn:task/1"}}, nil
+					},
+					describeTasksFn: func(_ context.Context, _ *ecs.DescribeTasksInput, _ ...func(*ecs.Options)) (*ecs.DescribeTasksOutput, error) {
+						return &ecs.DescribeTasksOutput{Tasks: []types.Task{{TaskArn: aws.String("arn:task/1")}}}, nil
+					},
+					updateServiceFn: func(_ context.Context, _ *ecs.UpdateServiceInput, _ ...func(*ecs.Options)) (*ecs.UpdateServiceOutput, error) {
+						return &ecs.UpdateServiceOutput{}, nil
+					},
+					stopTaskFn: func(_ context.Context, _ *ecs.StopTaskInput, _ ...func(*ecs.Options)) (*ecs.StopTaskOutput, error) {
+						return &ecs.StopTaskOutput{}, nil
+					},
+				},
+			}
+			WithTaskIPCache(tt.ttl)(c)
+
+			for i := range 2 {
+				if i > 0 {
+					tt.between(c, &now)
+				}
+				tasks, err := c.GetTaskIPs(context.Background())
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if len(tasks) != 1 || tasks[0].TaskArn != "arn:task/1" {
+					t.Errorf("tasks = %+v, want arn:task/1", tasks)
+				}
+			}
+			if lists != tt.wantLists {
+				t.Errorf("ListTasks calls = %d, want %d", lists, tt.wantLists)
+			}
+		})
+	}
+}
+
+func TestGetTaskIPsInvalidatedDuringListing(t *testing.T) {
+	var c *Client
+	lists := 0
+	c = &Client{
+		cluster: testCluster,
+		service: testService,
+		api: &mockECSAPI{
+			listTasksFn: func(ctx context.Context, _ *ecs.ListTasksInput, _ ...func(*ecs.Options)) (*ecs.ListTasksOutput, error) {
+				lists++
+				if lists == 1 {
+					// The scaler changes the desired count while the first
+					// listing is in flight.
+					if err := c.SetDesiredCount(ctx, 3); err != nil {
+						t.Fatalf("unexpected error: %v", err)
+					}
+				}
+				return &ecs.ListTasksOutput{TaskArns: []string{"arn:task/1"}}, nil
+			},
+			describeTasksFn: func(_ context.Context, _ *ecs.DescribeTasksInput, _ ...func(*ecs.Options)) (*ecs.DescribeTasksOutput, error) {
+				return &ecs.DescribeTasksOutput{Tasks: []types.Task{{TaskArn: aws.String("arn:task/1")}}}, nil
+			},
+			updateServiceFn: func(_ context.Context, _ *ecs.UpdateServiceInput, _ ...func(*ecs.Options)) (*ecs.UpdateServiceOutput, error) {
+				return &ecs.UpdateServiceOutput{}, nil
+			},
+		},
+	}
+	WithTaskIPCache(time.Minute)(c)
+
+	for range 2 {
+		if _, err := c.GetTaskIPs(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if lists != 2 {
+		t.Errorf("ListTasks calls = %d, want 2 (listing overlapping an invalidation is not cached)", lists)
+	}
+}
+
 func TestGetTaskIPsPagination(t *testing.T) {
 	t.Run("multiple pages of ListTasks", func(t *testing.T) {
 		callCount := 0
@@ -545,6 +1071,81 @@ func TestSetTaskProtection(t *testing.T) {
 		}
 	})
 
+	t.Run("failed batch does not stop later batches", func(t *testing.T) {
+		var calls []*ecs.UpdateTaskProtectionInput
+		c := &Client{
+			cluster:             testCluster,
+			service:             testService,
+			protectionBatchSize: 2,
+			api: &mockECSAPI{
+				updateTaskProtectionFn: func(_ context.Context, input *ecs.UpdateTaskProtectionInput, _ ...func(*ecs.Options)) (*ecs.UpdateTaskProtectionOutput, error) {
+					calls = append(calls, input)
+					if len(calls) == 2 {
+						return nil, errors.New("invalid task")
+					}
+					return &ecs.UpdateTaskProtectionOutput{}, nil
+				},
+			},
+		}
+
+		arns := []string{"arn:task/1", "arn:task/2", "arn:task/3", "arn:task/4", "arn:task/5"}
+		err := c.SetTaskProtection(context.Background(), arns, true, 60)
+		if err == nil || !strings.Contains(err.Error(), "invalid task") {
+			t.Fatalf("error: got %v, want batch 2 failure", err)
+		}
+		if len(calls) != 3 {
+			t.Fatalf("API calls: got %d, want 3", len(calls))
+		}
+		if got := calls[2].Tasks; len(got) != 1 || got[0] != "arn:task/5" {
+			t.Errorf("batch 3 tasks: got %v, want [arn:task/5]", got)
+		}
+	})
+
+	t.Run("per-task failures are returned", func(t *testing.T) {
+		c := &Client{
+			cluster: testCluster,
+			service: testService,
+			api: &mockECSAPI{
+				updateTaskProtectionFn: func(_ context.Context, _ *ecs.UpdateTaskProtectionInput, _ ...func(*ecs.Options)) (*ecs.UpdateTaskProtectionOutput, error) {
+					return &ecs.UpdateTaskProtectionOutput{
+						Failures: []types.Failure{{Arn: aws.String("arn:task/2"), Reason: aws.String("MISSING")}},
+					}, nil
+				},
+			},
+		}
+
+		err := c.SetTaskProtection(context.Background(), []string{"arn:task/1", "arn:task/2"}, true, 60)
+		if err == nil || !strings.Contains(err.Error(), "arn:task/2: MISSING") {
+			t.Fatalf("error: got %v, want failure for arn:task/2", err)
+		}
+	})
+
+	t.Run("batch size above ECS maximum is capped", func(t *testing.T) {
+		var calls []*ecs.UpdateTaskProtectionInput
+		c := &Client{
+			cluster: testCluster,
+			service: testService,
+			api: &mockECSAPI{
+				updateTaskProtectionFn: func(_ context.Context, input *ecs.UpdateTaskProtectionInput, _ ...func(*ecs.Options)) (*ecs.UpdateTaskProtectionOutput, error) {
+					calls = append(calls, input)
+					return &ecs.UpdateTaskProtectionOutput{}, nil
+				},
+			},
+		}
+		WithProtectionBatchSize(50)(c)
+
+		arns := make([]string, 15)
+		for i := range arns {
+			arns[i] = "arn:task/" + string(rune('a'+i))
+		}
+		if err := c.SetTaskProtection(context.Background(), arns, true, 60); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(calls) != 2 || len(calls[0].Tasks) != 10 {
+			t.Errorf("got %d calls, first with %d tasks; want 2 calls, first with 10", len(calls), len(calls[0].Tasks))
+		}
+	})
+
 	t.Run("disabled protection omits ExpiresInMinutes", func(t *testing.T) {
 		var captured *ecs.UpdateTaskProtectionInput
 		c := &Client{
@@ -570,3 +1171,288 @@ func TestSetTaskProtection(t *testing.T) {
 		}
 	})
 }
+
+func TestTaskInfoAge(t *testing.T) {
+	now := time.Date(2025, 1, 2, 4, 0, 0, 0, time.UTC)
+	if got := (TaskInfo{StartedAt: now.Add(-90 * time.Minute)}).Age(now); got != 90*time.Minute {
+		t.Errorf("Age: got %v, want 90m", got)
+	}
+	if got := (TaskInfo{}).Age(now); got != 0 {
+		t.Errorf("Age of unstarted task: got %v, want 0", got)
+	}
+}
+
+func TestTaskInfoSpotInterrupted(t *testing.T) {
+	tests := []struct {
+		name string
+		task TaskInfo
+		want bool
+	}{
+		{name: "spot interruption stop code", task: TaskInfo{StopCode: "SpotInterruption"}, want: true},
+		{name: "spot interruption stopped reason", task: TaskInfo{StoppedReason: "Your Spot Task was interrupted."}, want: true},
+		{name: "scaled in", task: TaskInfo{StopCode: "ServiceSchedulerInitiated", StoppedReason: "Scaling activity initiated by deployment"}},
+		{name: "essential container exited", task: TaskInfo{StopCode: "EssentialContainerExited", StoppedReason: "Essential container in task exited"}},
+		{name: "running task", task: TaskInfo{LastStatus: "RUNNING"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.task.SpotInterrupted(); got != tt.want {
+				t.Errorf("SpotInterrupted() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetInterruptedTasks(t *testing.T) {
+	var listInput *ecs.ListTasksInput
+	c := &Client{
+		cluster: testCluster,
+		service: testService,
+		api: &mockECSAPI{
+			listTasksFn: func(_ context.Context, input *ecs.ListTasksInput, _ ...func(*ecs.Options)) (*ecs.ListTasksOutput, error) {
+				listInput = input
+				return &ecs.ListTasksOutput{TaskArns: []string{"arn:task/spot-1", "arn:task/spot-2", "arn:task/scaled-in"}}, nil
+			},
+			describeTasksFn: func(_ context.Context, _ *ecs.DescribeTasksInput, _ ...func(*ecs.Options)) (*ecs.DescribeTasksOutput, error) {
+				return &ecs.DescribeTasksOutput{Tasks: []types.Task{
+					{
+						TaskArn:       aws.String("arn:task/spot-1"),
+						LastStatus:    aws.String("RUNNING"),
+						StopCode:      types.TaskStopCodeSpotInterruption,
+						StoppedReason: aws.String("Your Spot Task was interrupted."),
+					},
+					{
+						TaskArn:       aws.String("arn:task/spot-2"),
+						LastStatus:    aws.String("STOPPED"),
+						StoppedReason: aws.String("Your Spot Task was interrupted."),
+					},
+					{
+						TaskArn:       aws.String("arn:task/scaled-in"),
+						LastStatus:    aws.String("STOPPED"),
+						StopCode:      types.TaskStopCodeServiceSchedulerInitiated,
+						StoppedReason: aws.String("Scaling activity initiated by deployment"),
+					},
+				}}, nil
+			},
+		},
+	}
+
+	got, err := c.GetInterruptedTasks(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if listInput.DesiredStatus != types.DesiredStatusStopped {
+		t.Errorf("ListTasks DesiredStatus = %q, want STOPPED", listInput.DesiredStatus)
+	}
+	if len(got) != 2 || got[0].TaskArn != "arn:task/spot-1" || got[1].TaskArn != "arn:task/spot-2" {
+		t.Fatalf("interrupted tasks = %+v, want spot-1 and spot-2", got)
+	}
+	if !got[0].Running() || got[1].Running() {
+		t.Errorf("Running() = %v, %v; want true, false", got[0].Running(), got[1].Running())
+	}
+}
+
+func TestStopTask(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		wantErr bool
+	}{
+		{name: "success"},
+		{name: "API error", err: errors.New("task not found"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *ecs.StopTaskInput
+			c := &Client{
+				cluster: testCluster,
+				service: testService,
+				api: &mockECSAPI{
+					stopTaskFn: func(_ context.Context, input *ecs.StopTaskInput, _ ...func(*ecs.Options)) (*ecs.StopTaskOutput, error) {
+						got = input
+						if tt.err != nil {
+							return nil, tt.err
+						}
+						return &ecs.StopTaskOutput{}, nil
+					},
+				},
+			}
+
+			err := c.StopTask(context.Background(), "arn:task/1", "max task age exceeded")
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if aws.ToString(got.Cluster) != testCluster {
+				t.Errorf("cluster: got %s, want %s", aws.ToString(got.Cluster), testCluster)
+			}
+			if aws.ToString(got.Task) != "arn:task/1" {
+				t.Errorf("task: got %s, want arn:task/1", aws.ToString(got.Task))
+			}
+			if aws.ToString(got.Reason) != "max task age exceeded" {
+				t.Errorf("reason: got %s, want %q", aws.ToString(got.Reason), "max task age exceeded")
+			}
+		})
+	}
+}
+
+type mockSTS struct {
+	input *sts.AssumeRoleInput
+}
+
+func (m *mockSTS) AssumeRole(_ context.Context, input *sts.AssumeRoleInput, _ ...func(*sts.Options)) (*sts.AssumeRoleOutput, error) {
+	m.input = input
+	return &sts.AssumeRoleOutput{
+		Credentials: &ststypes.Credentials{
+			AccessKeyId:     aws.String("AKIDASSUMED"),
+			SecretAccessKey: aws.String("secret"),
+			SessionToken:    aws.String("token"),
+			Expiration:      aws.Time(time.Now().Add(time.Hour)),
+		},
+	}, nil
+}
+
+func TestAWSConfigAssumeRole(t *testing.T) {
+	t.Setenv("AWS_REGION", "us-east-1")
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDDEFAULT")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(t.TempDir(), "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(t.TempDir(), "credentials"))
+
+	tests := []struct {
+		name           string
+		opts           []Option
+		wantAssumeRole bool
+	}{
+		{name: "default chain"},
+		{
+			name:           "role ARN set",
+			opts:           []Option{WithAssumeRole("arn:aws:iam::123456789012:role/autoscaler", "")},
+			wantAssumeRole: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Client{cluster: testCluster, service: testService}
+			for _, opt := range tt.opts {
+				opt(c)
+			}
+
+			cfg, err := c.awsConfig(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got := aws.IsCredentialsProvider(cfg.Credentials, (*stscreds.AssumeRoleProvider)(nil))
+			if got != tt.wantAssumeRole {
+				t.Errorf("assume role provider: got %v, want %v", got, tt.wantAssumeRole)
+			}
+		})
+	}
+}
+
+func TestAssumeRoleCredentials(t *testing.T) {
+	tests := []struct {
+		name       string
+		externalID string
+	}{
+		{name: "without external ID"},
+		{name: "with external ID", externalID: "ext-123"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &mockSTS{}
+			provider := assumeRoleCredentials(client, "arn:aws:iam::123456789012:role/autoscaler", tt.externalID)
+
+			creds, err := provider.Retrieve(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if creds.AccessKeyID != "AKIDASSUMED" {
+				t.Errorf("access key: got %s, want AKIDASSUMED", creds.AccessKeyID)
+			}
+			if got := aws.ToString(client.input.RoleArn); got != "arn:aws:iam::123456789012:role/autoscaler" {
+				t.Errorf("role ARN: got %s", got)
+			}
+			if tt.externalID == "" && client.input.ExternalId != nil {
+				t.Errorf("external ID: got %q, want unset", *client.input.ExternalId)
+			}
+			if got := aws.ToString(client.input.ExternalId); got != tt.externalID {
+				t.Errorf("external ID: got %q, want %q", got, tt.externalID)
+			}
+		})
+	}
+}
+
+func TestAWSConfigRegion(t *testing.T) {
+	t.Setenv("AWS_REGION", "us-east-1")
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(t.TempDir(), "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(t.TempDir(), "credentials"))
+
+	tests := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{name: "default chain", want: "us-east-1"},
+		{name: "override", opts: []Option{WithRegion("eu-west-2")}, want: "eu-west-2"},
+		{name: "empty override keeps default", opts: []Option{WithRegion("")}, want: "us-east-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Client{cluster: testCluster, service: testService}
+			for _, opt := range tt.opts {
+				opt(c)
+			}
+
+			cfg, err := c.awsConfig(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cfg.Region != tt.want {
+				t.Errorf("region: got %q, want %q", cfg.Region, tt.want)
+			}
+		})
+	}
+}
+
+func TestTaskInfoMatchesAgentName(t *testing.T) {
+	task := TaskInfo{
+		TaskArn: "arn:aws:ecs:us-east-1:123:task/cluster/0123456789abcdef",
+		Tags:    map[string]string{AgentNameTag: "tagged-agent"},
+	}
+
+	tests := []struct {
+		name  string
+		agent string
+		want  bool
+	}{
+		{name: "task ID", agent: "0123456789abcdef", want: true},
+		{name: "prefixed task ID", agent: "tfc-agent-0123456789abcdef", want: true},
+		{name: "name tag", agent: "tagged-agent", want: true},
+		{name: "partial task ID", agent: "tfc-agent-89abcdef", want: false},
+		{name: "unrelated", agent: "tfc-agent", want: false},
+		{name: "empty", agent: "", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := task.MatchesAgentName(tt.agent); got != tt.want {
+				t.Errorf("MatchesAgentName(%q): got %v, want %v", tt.agent, got, tt.want)
+			}
+		})
+	}
+
+	if got := task.TaskID(); got != "0123456789abcdef" {
+		t.Errorf("TaskID: got %s, want 0123456789abcdef", got)
+	}
+}
@@ -3,9 +3,13 @@ package health
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"net"
 	"net/http"
+	"os"
+	"strings"
 	"sync/atomic"
 	"time"
 )
@@ -51,6 +55,24 @@ func (p *ChannelProbe) IsReady() bool {
 	}
 }
 
+// NamedProbe attaches a name to a ReadinessProbe so its state can be
+// reported individually.
+type NamedProbe struct {
+	ReadinessProbe
+	Name string
+}
+
+// NewNamedProbe wraps probe with the given name.
+func NewNamedProbe(name string, probe ReadinessProbe) *NamedProbe {
+	return &NamedProbe{ReadinessProbe: probe, Name: name}
+}
+
+// ProbeStatus is the readiness state of a single probe.
+type ProbeStatus struct {
+	Name  string `json:"name"`
+	Ready bool   `json:"ready"`
+}
+
 // CompositeProbe aggregates multiple ReadinessProbes.
 // It reports ready only when all sub-probes are ready.
 type CompositeProbe struct {
@@ -72,6 +94,23 @@ func (c *CompositeProbe) IsReady() bool {
 	return true
 }
 
+// Status returns the readiness of each sub-probe in order. Probes that are
+// not a NamedProbe are named by their position, e.g. "probe-1".
+func (c *CompositeProbe) Status() []ProbeStatus {
+	statuses := make([]ProbeStatus, 0, len(c.probes))
+	for i, p := range c.probes {
+		statuses = append(statuses, ProbeStatus{Name: probeName(p, i), Ready: p.IsReady()})
+	}
+	return statuses
+}
+
+func probeName(p ReadinessProbe, i int) string {
+	if np, ok := p.(*NamedProbe); ok {
+		return np.Name
+	}
+	return fmt.Sprintf("probe-%d", i)
+}
+
 // ServerOption configures optional behavior for Server.
 type ServerOption func(*Server)
 
@@ -82,10 +121,122 @@ func WithMetricsHandler(h http.Handler) ServerOption {
 	}
 }
 
+// WithReadyDetails makes /readyz respond with a JSON body describing each
+// probe instead of plain text. The status code is unchanged.
+func WithReadyDetails() ServerOption {
+	return func(s *Server) {
+		s.readyDetails = true
+	}
+}
+
+// WithReconcileTrigger registers POST /reconcile, which requests an immediate
+// reconcile by sending on each trigger channel. Sends never block: if a
+// request is already pending on a channel it is coalesced. The handler
+// responds 202 without waiting for the reconcile to run.
+func WithReconcileTrigger(triggers ...chan<- struct{}) ServerOption {
+	return func(s *Server) {
+		s.handler.HandleFunc("POST /reconcile", func(w http.ResponseWriter, _ *http.Request) {
+			for _, trigger := range triggers {
+				select {
+				case trigger <- struct{}{}:
+				default:
+				}
+			}
+			w.WriteHeader(http.StatusAccepted)
+			_, _ = w.Write([]byte("accepted\n"))
+		})
+	}
+}
+
+// WithPauseControl registers POST /pause and POST /resume, which set and
+// clear paused. Scalers sharing the flag keep reconciling and recording
+// metrics while paused, but make no changes.
+func WithPauseControl(paused *atomic.Bool) ServerOption {
+	return func(s *Server) {
+		s.handler.HandleFunc("POST /pause", func(w http.ResponseWriter, _ *http.Request) {
+			paused.Store(true)
+			_, _ = w.Write([]byte("paused\n"))
+		})
+		s.handler.HandleFunc("POST /resume", func(w http.ResponseWriter, _ *http.Request) {
+			paused.Store(false)
+			_, _ = w.Write([]byte("resumed\n"))
+		})
+	}
+}
+
+// StateFunc returns a JSON-serializable snapshot of a component's state.
+type StateFunc func() any
+
+// WithDebugState registers GET /debug/state, which responds with a JSON
+// object mapping each name in states to the snapshot its StateFunc returns.
+func WithDebugState(states map[string]StateFunc) ServerOption {
+	return func(s *Server) {
+		s.handler.HandleFunc("GET /debug/state", func(w http.ResponseWriter, _ *http.Request) {
+			resp := make(map[string]any, len(states))
+			for name, state := range states {
+				resp[name] = state()
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(resp)
+		})
+	}
+}
+
+// BuildInfo identifies the running build.
+type BuildInfo struct {
+	Version string `json:"version"`
+	Commit  string `json:"commit"`
+	Date    string `json:"date"`
+}
+
+// WithBuildInfo registers GET /version, which responds with info as JSON.
+func WithBuildInfo(info BuildInfo) ServerOption {
+	return func(s *Server) {
+		s.handler.HandleFunc("GET /version", func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(info)
+		})
+	}
+}
+
+// WithConfig registers GET /config, which responds with cfg encoded as JSON.
+// Callers must pass a value whose encoding omits secrets.
+func WithConfig(cfg any) ServerOption {
+	return func(s *Server) {
+		s.handler.HandleFunc("GET /config", func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(cfg)
+		})
+	}
+}
+
+// WithShutdownTimeout sets how long Run waits for in-flight requests, such as
+// a metrics scrape, to finish on shutdown. Zero keeps the default of 5 seconds.
+func WithShutdownTimeout(d time.Duration) ServerOption {
+	return func(s *Server) {
+		if d > 0 {
+			s.shutdownTimeout = d
+		}
+	}
+}
+
+// defaultShutdownTimeout is how long Run waits for in-flight requests when no
+// shutdown timeout is configured.
+const defaultShutdownTimeout = 5 * time.Second
+
 // Server serves health check endpoints.
 type Server struct {
-	httpServer *http.Server
-	handler    *http.ServeMux
+	httpServer      *http.Server
+	handler         *http.ServeMux
+	probe           ReadinessProbe
+	readyDetails    bool
+	shutdownTimeout time.Duration
+}
+
+// readyResponse is the /readyz body when ready details are enabled.
+type readyResponse struct {
+	Ready  bool          `json:"ready"`
+	Probes []ProbeStatus `json:"probes"`
 }
 
 // NewServer creates a new health check server.
@@ -97,16 +248,6 @@ func NewServer(addr string, probe ReadinessProbe, opts ...ServerOption) *Server
 		_, _ = w.Write([]byte("ok\n"))
 	})
 
-	mux.HandleFunc("GET /readyz", func(w http.ResponseWriter, _ *http.Request) {
-		if probe.IsReady() {
-			w.WriteHeader(http.StatusOK)
-			_, _ = w.Write([]byte("ok\n"))
-			return
-		}
-		w.WriteHeader(http.StatusServiceUnavailable)
-		_, _ = w.Write([]byte("not ready\n"))
-	})
-
 	s := &Server{
 		httpServer: &http.Server{
 			Addr:              addr,
@@ -116,9 +257,13 @@ func NewServer(addr string, probe ReadinessProbe, opts ...ServerOption) *Server
 			ReadHeaderTimeout: 5 * time.Second,
 			IdleTimeout:       60 * time.Second,
 		},
-		handler: mux,
+		handler:         mux,
+		probe:           probe,
+		shutdownTimeout: defaultShutdownTimeout,
 	}
 
+	mux.HandleFunc("GET /readyz", s.handleReady)
+
 	for _, opt := range opts {
 		opt(s)
 	}
@@ -126,15 +271,52 @@ func NewServer(addr string, probe ReadinessProbe, opts ...ServerOption) *Server
 	return s
 }
 
+func (s *Server) handleReady(w http.ResponseWriter, _ *http.Request) {
+	if s.readyDetails {
+		s.writeReadyDetails(w)
+		return
+	}
+	if s.probe.IsReady() {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("ok\n"))
+		return
+	}
+	w.WriteHeader(http.StatusServiceUnavailable)
+	_, _ = w.Write([]byte("not ready\n"))
+}
+
+func (s *Server) writeReadyDetails(w http.ResponseWriter) {
+	resp := readyResponse{Ready: s.probe.IsReady()}
+	if c, ok := s.probe.(*CompositeProbe); ok {
+		resp.Probes = c.Status()
+	} else {
+		resp.Probes = []ProbeStatus{{Name: probeName(s.probe, 0), Ready: resp.Ready}}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if resp.Ready {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// UnixAddrPrefix marks a server address as a unix domain socket path, as in
+// "unix:/run/autoscaler/health.sock".
+const UnixAddrPrefix = "unix:"
+
 // Run starts the HTTP server and blocks until the context is canceled,
-// then gracefully shuts down.
+// then gracefully shuts down. An address with UnixAddrPrefix listens on a
+// unix domain socket, which is removed again on shutdown.
 func (s *Server) Run(ctx context.Context) error {
-	lc := net.ListenConfig{}
-	ln, err := lc.Listen(ctx, "tcp", s.httpServer.Addr)
+	ln, err := listen(ctx, s.httpServer.Addr)
 	if err != nil {
 		return err
 	}
-	s.httpServer.Addr = ln.Addr().String()
+	if ln.Addr().Network() == "tcp" {
+		s.httpServer.Addr = ln.Addr().String()
+	}
 
 	errCh := make(chan error, 1)
 	go func() {
@@ -143,7 +325,7 @@ func (s *Server) Run(ctx context.Context) error {
 
 	select {
 	case <-ctx.Done():
-		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second) //nolint:contextcheck // intentional fresh context for graceful shutdown
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout) //nolint:contextcheck // intentional fresh context for graceful shutdown
 		defer cancel()
 		if err := s.httpServer.Shutdown(shutdownCtx); err != nil { //nolint:contextcheck // shutdownCtx is derived from Background intentionally
 			return err
@@ -157,3 +339,39 @@ func (s *Server) Run(ctx context.Context) error {
 		return err
 	}
 }
+
+// listen opens a unix domain socket listener for an address with
+// UnixAddrPrefix and a TCP listener otherwise.
+func listen(ctx context.Context, addr string) (net.Listener, error) {
+	lc := net.ListenConfig{}
+	path, ok := strings.CutPrefix(addr, UnixAddrPrefix)
+	if !ok {
+		return lc.Listen(ctx, "tcp", addr)
+	}
+	if err := removeStaleSocket(ctx, path); err != nil {
+		return nil, err
+	}
+	return lc.Listen(ctx, "unix", path)
+}
+
+// removeStaleSocket removes a socket file left behind by a process that
+// exited without cleaning up. It refuses to remove anything that is not a
+// socket, or a socket another process is still listening on.
+func removeStaleSocket(ctx context.Context, path string) error {
+	info, err := os.Lstat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.Mode().Type() != os.ModeSocket {
+		return fmt.Errorf("%s exists and is not a socket", path)
+	}
+	var d net.Dialer
+	if conn, err := d.DialContext(ctx, "unix", path); err == nil {
+		_ = conn.Close()
+		return fmt.Errorf("socket %s is already in use", path)
+	}
+	return os.Remove(path)
+}
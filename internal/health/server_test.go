@@ -2,11 +2,20 @@ package health
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/oulman/tfc-agent-autoscaler/internal/config"
 )
 
 func TestAtomicReady(t *testing.T) {
@@ -136,6 +145,187 @@ func TestMetricsEndpointNotRegisteredWithoutOption(t *testing.T) {
 	}
 }
 
+func TestReconcileTrigger(t *testing.T) {
+	first := make(chan struct{}, 1)
+	second := make(chan struct{}, 1)
+	srv := NewServer(":0", &AtomicReady{}, WithReconcileTrigger(first, second))
+
+	req := httptest.NewRequest(http.MethodPost, "/reconcile", nil)
+	w := httptest.NewRecorder()
+	srv.handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusAccepted {
+		t.Errorf("got status %d, want %d", w.Code, http.StatusAccepted)
+	}
+	for i, ch := range []chan struct{}{first, second} {
+		select {
+		case <-ch:
+		default:
+			t.Errorf("trigger %d: no reconcile request sent", i)
+		}
+	}
+}
+
+func TestReconcileTriggerDoesNotBlock(t *testing.T) {
+	trigger := make(chan struct{}, 1)
+	srv := NewServer(":0", &AtomicReady{}, WithReconcileTrigger(trigger))
+
+	// The second request finds the buffer full and must be coalesced.
+	for range 2 {
+		req := httptest.NewRequest(http.MethodPost, "/reconcile", nil)
+		w := httptest.NewRecorder()
+		srv.handler.ServeHTTP(w, req)
+		if w.Code != http.StatusAccepted {
+			t.Errorf("got status %d, want %d", w.Code, http.StatusAccepted)
+		}
+	}
+	if got := len(trigger); got != 1 {
+		t.Errorf("pending requests: got %d, want 1", got)
+	}
+}
+
+func TestReconcileTriggerNotRegisteredWithoutOption(t *testing.T) {
+	srv := NewServer(":0", &AtomicReady{})
+
+	req := httptest.NewRequest(http.MethodPost, "/reconcile", nil)
+	w := httptest.NewRecorder()
+	srv.handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("got status %d, want %d (no reconcile trigger configured)", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestPauseControl(t *testing.T) {
+	var paused atomic.Bool
+	srv := NewServer(":0", &AtomicReady{}, WithPauseControl(&paused))
+
+	for _, tt := range []struct {
+		path       string
+		wantPaused bool
+		wantBody   string
+	}{
+		{path: "/pause", wantPaused: true, wantBody: "paused\n"},
+		{path: "/pause", wantPaused: true, wantBody: "paused\n"},
+		{path: "/resume", wantPaused: false, wantBody: "resumed\n"},
+	} {
+		req := httptest.NewRequest(http.MethodPost, tt.path, nil)
+		w := httptest.NewRecorder()
+		srv.handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("POST %s: got status %d, want %d", tt.path, w.Code, http.StatusOK)
+		}
+		if w.Body.String() != tt.wantBody {
+			t.Errorf("POST %s: got body %q, want %q", tt.path, w.Body.String(), tt.wantBody)
+		}
+		if paused.Load() != tt.wantPaused {
+			t.Errorf("after POST %s: paused = %v, want %v", tt.path, paused.Load(), tt.wantPaused)
+		}
+	}
+}
+
+func TestPauseControlNotRegisteredWithoutOption(t *testing.T) {
+	srv := NewServer(":0", &AtomicReady{})
+
+	for _, path := range []string{"/pause", "/resume"} {
+		req := httptest.NewRequest(http.MethodPost, path, nil)
+		w := httptest.NewRecorder()
+		srv.handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("POST %s: got status %d, want %d (no pause control configured)", path, w.Code, http.StatusNotFound)
+		}
+	}
+}
+
+func TestReconcileTriggerRejectsGet(t *testing.T) {
+	srv := NewServer(":0", &AtomicReady{}, WithReconcileTrigger(make(chan struct{}, 1)))
+
+	req := httptest.NewRequest(http.MethodGet, "/reconcile", nil)
+	w := httptest.NewRecorder()
+	srv.handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("got status %d, want %d", w.Code, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestDebugState(t *testing.T) {
+	type state struct {
+		Desired int `json:"desired"`
+	}
+	srv := NewServer(":0", &AtomicReady{}, WithDebugState(map[string]StateFunc{
+		"regular": func() any { return state{Desired: 3} },
+		"spot":    func() any { return state{Desired: 1} },
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/debug/state", nil)
+	w := httptest.NewRecorder()
+	srv.handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("got status %d, want %d", w.Code, http.StatusOK)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+	var got map[string]state
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("decoding body: %v", err)
+	}
+	if got["regular"].Desired != 3 || got["spot"].Desired != 1 || len(got) != 2 {
+		t.Errorf("state = %+v, want regular=3 spot=1", got)
+	}
+}
+
+func TestDebugStateNotRegisteredWithoutOption(t *testing.T) {
+	srv := NewServer(":0", &AtomicReady{})
+
+	req := httptest.NewRequest(http.MethodGet, "/debug/state", nil)
+	w := httptest.NewRecorder()
+	srv.handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("got status %d, want %d (no debug state configured)", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestBuildInfo(t *testing.T) {
+	want := BuildInfo{Version: "v1.2.3", Commit: "abc1234", Date: "2025-01-01T00:00:00Z"}
+	srv := NewServer(":0", &AtomicReady{}, WithBuildInfo(want))
+
+	req := httptest.NewRequest(http.MethodGet, "/version", nil)
+	w := httptest.NewRecorder()
+	srv.handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("got status %d, want %d", w.Code, http.StatusOK)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+	var got BuildInfo
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("decoding body: %v", err)
+	}
+	if got != want {
+		t.Errorf("build info = %+v, want %+v", got, want)
+	}
+}
+
+func TestBuildInfoNotRegisteredWithoutOption(t *testing.T) {
+	srv := NewServer(":0", &AtomicReady{})
+
+	req := httptest.NewRequest(http.MethodGet, "/version", nil)
+	w := httptest.NewRecorder()
+	srv.handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("got status %d, want %d (no build info configured)", w.Code, http.StatusNotFound)
+	}
+}
+
 func TestCompositeProbeAllReady(t *testing.T) {
 	ch1 := make(chan struct{})
 	ch2 := make(chan struct{})
@@ -177,6 +367,94 @@ func TestCompositeProbeEmpty(t *testing.T) {
 	}
 }
 
+func TestCompositeProbeStatus(t *testing.T) {
+	ready := make(chan struct{})
+	close(ready)
+
+	probe := NewCompositeProbe(
+		NewNamedProbe("regular", NewChannelProbe(ready)),
+		NewChannelProbe(make(chan struct{})),
+	)
+
+	got := probe.Status()
+	want := []ProbeStatus{
+		{Name: "regular", Ready: true},
+		{Name: "probe-1", Ready: false},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d statuses, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("status[%d]: got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestReadyzDetailsJSON(t *testing.T) {
+	ready := make(chan struct{})
+	close(ready)
+
+	probe := NewCompositeProbe(
+		NewNamedProbe("regular", NewChannelProbe(ready)),
+		NewNamedProbe("spot", NewChannelProbe(make(chan struct{}))),
+	)
+	srv := NewServer(":0", probe, WithReadyDetails())
+
+	req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+	w := httptest.NewRecorder()
+	srv.handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("got status %d, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("got Content-Type %q, want application/json", ct)
+	}
+
+	var body struct {
+		Ready  bool `json:"ready"`
+		Probes []struct {
+			Name  string `json:"name"`
+			Ready bool   `json:"ready"`
+		} `json:"probes"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decoding body %q: %v", w.Body.String(), err)
+	}
+
+	if body.Ready {
+		t.Error("expected ready=false")
+	}
+	if len(body.Probes) != 2 {
+		t.Fatalf("got %d probes, want 2", len(body.Probes))
+	}
+	if body.Probes[0].Name != "regular" || !body.Probes[0].Ready {
+		t.Errorf("probes[0] = %+v, want regular ready", body.Probes[0])
+	}
+	if body.Probes[1].Name != "spot" || body.Probes[1].Ready {
+		t.Errorf("probes[1] = %+v, want spot not ready", body.Probes[1])
+	}
+}
+
+func TestReadyzDetailsSingleProbe(t *testing.T) {
+	ar := &AtomicReady{}
+	ar.MarkReady()
+	srv := NewServer(":0", NewNamedProbe("default", ar), WithReadyDetails())
+
+	req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+	w := httptest.NewRecorder()
+	srv.handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("got status %d, want %d", w.Code, http.StatusOK)
+	}
+	want := `{"ready":true,"probes":[{"name":"default","ready":true}]}` + "\n"
+	if w.Body.String() != want {
+		t.Errorf("got body %q, want %q", w.Body.String(), want)
+	}
+}
+
 func TestServerTimeouts(t *testing.T) {
 	srv := NewServer(":0", &AtomicReady{})
 
@@ -192,6 +470,9 @@ func TestServerTimeouts(t *testing.T) {
 	if srv.httpServer.IdleTimeout != 60*time.Second {
 		t.Errorf("IdleTimeout: got %v, want 60s", srv.httpServer.IdleTimeout)
 	}
+	if srv.shutdownTimeout != 5*time.Second {
+		t.Errorf("shutdownTimeout: got %v, want 5s", srv.shutdownTimeout)
+	}
 }
 
 func TestServerRunAndShutdown(t *testing.T) {
@@ -217,3 +498,171 @@ func TestServerRunAndShutdown(t *testing.T) {
 		t.Fatal("server did not shut down in time")
 	}
 }
+
+func TestServerRunUnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "health.sock")
+	// A socket left behind by an unclean exit must not block startup.
+	stale, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("creating stale socket: %v", err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	_ = stale.Close()
+
+	srv := NewServer(UnixAddrPrefix+path, &AtomicReady{})
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.Run(ctx)
+	}()
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", path)
+			},
+		},
+		Timeout: time.Second,
+	}
+	var resp *http.Response
+	for range 50 {
+		resp, err = client.Get("http://health/healthz")
+		if err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("GET /healthz over unix socket: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(body) != "ok\n" {
+		t.Errorf("GET /healthz: got %d %q, want %d %q", resp.StatusCode, body, http.StatusOK, "ok\n")
+	}
+
+	cancel()
+	select {
+	case err := <-errCh:
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("server did not shut down in time")
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("socket file still exists after shutdown: %v", err)
+	}
+}
+
+func TestServerShutdownTimeout(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "health.sock")
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	// A scrape that outlives the shutdown timeout.
+	blocking := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		close(started)
+		<-release
+		w.WriteHeader(http.StatusOK)
+	})
+
+	srv := NewServer(UnixAddrPrefix+path, &AtomicReady{},
+		WithMetricsHandler(blocking),
+		WithShutdownTimeout(100*time.Millisecond),
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.Run(ctx)
+	}()
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				for range 50 {
+					conn, err := d.DialContext(ctx, "unix", path)
+					if err == nil {
+						return conn, nil
+					}
+					time.Sleep(10 * time.Millisecond)
+				}
+				return d.DialContext(ctx, "unix", path)
+			},
+		},
+	}
+	go func() {
+		resp, err := client.Get("http://health/metrics")
+		if err == nil {
+			_ = resp.Body.Close()
+		}
+	}()
+
+	select {
+	case <-started:
+	case <-time.After(2 * time.Second):
+		t.Fatal("scrape did not reach the handler")
+	}
+	cancel()
+
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("Run error = %v, want context.DeadlineExceeded", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("server did not give up on the blocked scrape after the shutdown timeout")
+	}
+}
+
+func TestServerRunUnixSocketRefusesNonSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "health.sock")
+	if err := os.WriteFile(path, []byte("not a socket"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := NewServer(UnixAddrPrefix+path, &AtomicReady{})
+	if err := srv.Run(context.Background()); err == nil {
+		t.Fatal("expected error for a non-socket file, got nil")
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("regular file was removed: %v", err)
+	}
+}
+
+func TestConfigEndpoint(t *testing.T) {
+	cfg := config.Config{TFCToken: "tfc-secret-token", TFCAgentPoolID: "apool-123"}
+	srv := NewServer(":0", &AtomicReady{}, WithConfig(cfg.Redacted()))
+
+	req := httptest.NewRequest(http.MethodGet, "/config", nil)
+	w := httptest.NewRecorder()
+	srv.handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("got status %d, want %d", w.Code, http.StatusOK)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+	body := w.Body.String()
+	if strings.Contains(body, "tfc-secret-token") {
+		t.Errorf("body contains the TFC token: %s", body)
+	}
+	if !strings.Contains(body, "apool-123") {
+		t.Errorf("body = %s, want the agent pool ID", body)
+	}
+}
+
+func TestConfigEndpointNotRegisteredWithoutOption(t *testing.T) {
+	srv := NewServer(":0", &AtomicReady{})
+
+	req := httptest.NewRequest(http.MethodGet, "/config", nil)
+	w := httptest.NewRecorder()
+	srv.handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("got status %d, want %d (no config configured)", w.Code, http.StatusNotFound)
+	}
+}
@@ -2,9 +2,12 @@
 package metrics //nolint:revive // "metrics" is not a stdlib package; revive false positive
 
 import (
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
@@ -12,64 +15,315 @@ import (
 type Metrics struct {
 	registry *prometheus.Registry
 
-	pendingRuns     *prometheus.GaugeVec
-	busyAgents      *prometheus.GaugeVec
-	idleAgents      *prometheus.GaugeVec
-	totalAgents     *prometheus.GaugeVec
-	ecsDesiredCount *prometheus.GaugeVec
-	ecsRunningCount *prometheus.GaugeVec
+	pendingRuns         *prometheus.GaugeVec
+	busyAgents          *prometheus.GaugeVec
+	idleAgents          *prometheus.GaugeVec
+	totalAgents         *prometheus.GaugeVec
+	ecsDesiredCount     *prometheus.GaugeVec
+	ecsRunningCount     *prometheus.GaugeVec
+	ecsPlacementGap     *prometheus.GaugeVec
+	orphanTasks         *prometheus.GaugeVec
+	queueWait           *prometheus.GaugeVec
+	planPending         *prometheus.GaugeVec
+	applyPending        *prometheus.GaugeVec
+	otherAgents         *prometheus.GaugeVec
+	ecsWriteCircuitOpen *prometheus.GaugeVec
+	cooldownHeldAgents  *prometheus.GaugeVec
+	computedDesired     *prometheus.GaugeVec
+	appliedDesired      *prometheus.GaugeVec
 
-	reconcileTotal            *prometheus.CounterVec
-	scaleEventsTotal          *prometheus.CounterVec
-	cooldownSkipsTotal        *prometheus.CounterVec
-	taskProtectionErrorsTotal *prometheus.CounterVec
+	reconcileTotal               *prometheus.CounterVec
+	scaleEventsTotal             *prometheus.CounterVec
+	dryRunScaleEventsTotal       *prometheus.CounterVec
+	cooldownSkipsTotal           *prometheus.CounterVec
+	taskProtectionErrorsTotal    *prometheus.CounterVec
+	placementStallsTotal         *prometheus.CounterVec
+	maxClampTotal                *prometheus.CounterVec
+	desiredCountMismatchTotal    *prometheus.CounterVec
+	orphanTasksPersistent        *prometheus.CounterVec
+	spotInterruptionsTotal       *prometheus.CounterVec
+	tfcRateLimitedTotal          *prometheus.CounterVec
+	deploymentLimitExceededTotal *prometheus.CounterVec
+	webhookFailuresTotal         *prometheus.CounterVec
+	deadbandSkipsTotal           *prometheus.CounterVec
+
+	reconcileDuration *prometheus.HistogramVec
+	lastReconcileTime *prometheus.GaugeVec
+	cooldownRemaining *prometheus.GaugeVec
+	scaleDownReasons  *prometheus.CounterVec
+	scaleDownSkips    *prometheus.CounterVec
+	agentsByStatus    *prometheus.GaugeVec
+	reconcileOutcomes *prometheus.CounterVec
+}
+
+// Option configures the collectors created by New.
+type Option func(*options)
+
+// options holds the settings applied to every collector.
+type options struct {
+	namespace      string
+	constLabels    prometheus.Labels
+	runtimeMetrics bool
+}
+
+// WithNamespace prefixes every metric name with namespace and an underscore,
+// so several autoscalers can share a Prometheus without name collisions.
+func WithNamespace(namespace string) Option {
+	return func(o *options) {
+		o.namespace = namespace
+	}
+}
+
+// WithConstLabels adds labels with fixed values, such as cluster or
+// environment, to every metric. They must not clash with the service,
+// direction, reason, or result labels.
+func WithConstLabels(labels map[string]string) Option {
+	return func(o *options) {
+		o.constLabels = labels
+	}
+}
+
+// WithRuntimeMetrics adds the standard go_* and process_* collectors to the
+// registry. They are not namespaced or given the constant labels, so they
+// match the names shared dashboards expect.
+func WithRuntimeMetrics(enabled bool) Option {
+	return func(o *options) {
+		o.runtimeMetrics = enabled
+	}
 }
 
 // New creates a new Metrics instance with a custom registry.
-func New() *Metrics {
+func New(opts ...Option) *Metrics {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
 	reg := prometheus.NewRegistry()
 
 	m := &Metrics{
 		registry: reg,
 		pendingRuns: prometheus.NewGaugeVec(prometheus.GaugeOpts{
-			Name: "tfc_pending_runs",
-			Help: "Number of queued TFC runs.",
+			Namespace:   o.namespace,
+			ConstLabels: o.constLabels,
+			Name:        "tfc_pending_runs",
+			Help:        "Number of queued TFC runs.",
 		}, []string{"service"}),
 		busyAgents: prometheus.NewGaugeVec(prometheus.GaugeOpts{
-			Name: "tfc_busy_agents",
-			Help: "Number of agents currently running jobs.",
+			Namespace:   o.namespace,
+			ConstLabels: o.constLabels,
+			Name:        "tfc_busy_agents",
+			Help:        "Number of agents currently running jobs.",
 		}, []string{"service"}),
 		idleAgents: prometheus.NewGaugeVec(prometheus.GaugeOpts{
-			Name: "tfc_idle_agents",
-			Help: "Number of available agents.",
+			Namespace:   o.namespace,
+			ConstLabels: o.constLabels,
+			Name:        "tfc_idle_agents",
+			Help:        "Number of available agents.",
 		}, []string{"service"}),
 		totalAgents: prometheus.NewGaugeVec(prometheus.GaugeOpts{
-			Name: "tfc_total_agents",
-			Help: "Total number of agents in pool.",
+			Namespace:   o.namespace,
+			ConstLabels: o.constLabels,
+			Name:        "tfc_total_agents",
+			Help:        "Total number of agents in pool.",
 		}, []string{"service"}),
 		ecsDesiredCount: prometheus.NewGaugeVec(prometheus.GaugeOpts{
-			Name: "ecs_desired_count",
-			Help: "ECS desired task count.",
+			Namespace:   o.namespace,
+			ConstLabels: o.constLabels,
+			Name:        "ecs_desired_count",
+			Help:        "ECS desired task count.",
 		}, []string{"service"}),
 		ecsRunningCount: prometheus.NewGaugeVec(prometheus.GaugeOpts{
-			Name: "ecs_running_count",
-			Help: "ECS running task count.",
+			Namespace:   o.namespace,
+			ConstLabels: o.constLabels,
+			Name:        "ecs_running_count",
+			Help:        "ECS running task count.",
+		}, []string{"service"}),
+		ecsPlacementGap: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   o.namespace,
+			ConstLabels: o.constLabels,
+			Name:        "ecs_placement_gap",
+			Help:        "ECS desired minus running task count.",
+		}, []string{"service"}),
+		orphanTasks: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   o.namespace,
+			ConstLabels: o.constLabels,
+			Name:        "orphan_tasks",
+			Help:        "Running ECS tasks in excess of registered TFC agents.",
+		}, []string{"service"}),
+		queueWait: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   o.namespace,
+			ConstLabels: o.constLabels,
+			Name:        "queue_wait_seconds",
+			Help:        "Seconds the longest-waiting pending run has been queued (0 when none are pending).",
+		}, []string{"service"}),
+		otherAgents: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   o.namespace,
+			ConstLabels: o.constLabels,
+			Name:        "tfc_other_agents",
+			Help:        "Agents in pool that are neither busy nor idle.",
+		}, []string{"service"}),
+		planPending: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   o.namespace,
+			ConstLabels: o.constLabels,
+			Name:        "tfc_plan_pending",
+			Help:        "Pending plan runs in the agent pool, whichever run type the service handles.",
+		}, []string{"service"}),
+		applyPending: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   o.namespace,
+			ConstLabels: o.constLabels,
+			Name:        "tfc_apply_pending",
+			Help:        "Pending apply runs in the agent pool, whichever run type the service handles.",
 		}, []string{"service"}),
 		reconcileTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
-			Name: "autoscaler_reconcile_total",
-			Help: "Total reconcile cycles.",
+			Namespace:   o.namespace,
+			ConstLabels: o.constLabels,
+			Name:        "autoscaler_reconcile_total",
+			Help:        "Total reconcile cycles.",
 		}, []string{"service", "result"}),
 		scaleEventsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
-			Name: "autoscaler_scale_events_total",
-			Help: "Scaling actions taken.",
+			Namespace:   o.namespace,
+			ConstLabels: o.constLabels,
+			Name:        "autoscaler_scale_events_total",
+			Help:        "Scaling actions taken.",
+		}, []string{"service", "direction"}),
+		dryRunScaleEventsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   o.namespace,
+			ConstLabels: o.constLabels,
+			Name:        "autoscaler_dry_run_scale_events_total",
+			Help:        "Scaling actions that would have been taken in dry-run mode.",
 		}, []string{"service", "direction"}),
 		cooldownSkipsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
-			Name: "autoscaler_cooldown_skips_total",
-			Help: "Scale-downs blocked by cooldown.",
+			Namespace:   o.namespace,
+			ConstLabels: o.constLabels,
+			Name:        "autoscaler_cooldown_skips_total",
+			Help:        "Scale-downs blocked by cooldown.",
 		}, []string{"service"}),
 		taskProtectionErrorsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
-			Name: "autoscaler_task_protection_errors_total",
-			Help: "Total task protection API failures.",
+			Namespace:   o.namespace,
+			ConstLabels: o.constLabels,
+			Name:        "autoscaler_task_protection_errors_total",
+			Help:        "Total task protection API failures.",
+		}, []string{"service"}),
+		placementStallsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   o.namespace,
+			ConstLabels: o.constLabels,
+			Name:        "ecs_placement_stall_total",
+			Help:        "Reconciles in which ECS running count trailed desired for longer than the stall threshold.",
+		}, []string{"service"}),
+		maxClampTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   o.namespace,
+			ConstLabels: o.constLabels,
+			Name:        "autoscaler_max_clamp_total",
+			Help:        "Reconciles in which demand exceeded the maximum agent count.",
+		}, []string{"service"}),
+		desiredCountMismatchTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   o.namespace,
+			ConstLabels: o.constLabels,
+			Name:        "autoscaler_desired_count_mismatch_total",
+			Help:        "Scale updates after which ECS reported a different desired count than requested.",
+		}, []string{"service"}),
+		orphanTasksPersistent: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   o.namespace,
+			ConstLabels: o.constLabels,
+			Name:        "orphan_tasks_persistent_total",
+			Help:        "Reconciles in which orphan tasks had persisted for longer than the threshold.",
+		}, []string{"service"}),
+		spotInterruptionsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   o.namespace,
+			ConstLabels: o.constLabels,
+			Name:        "spot_interruptions_total",
+			Help:        "Fargate Spot tasks stopped by a spot interruption.",
+		}, []string{"service"}),
+		reconcileDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   o.namespace,
+			ConstLabels: o.constLabels,
+			Name:        "autoscaler_reconcile_duration_seconds",
+			Help:        "Wall-clock duration of reconcile cycles.",
+			Buckets:     prometheus.ExponentialBuckets(0.05, 2, 10),
+		}, []string{"service"}),
+		lastReconcileTime: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   o.namespace,
+			ConstLabels: o.constLabels,
+			Name:        "autoscaler_last_reconcile_timestamp_seconds",
+			Help:        "Unix time at which the last reconcile cycle finished.",
+		}, []string{"service"}),
+		cooldownRemaining: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   o.namespace,
+			ConstLabels: o.constLabels,
+			Name:        "autoscaler_cooldown_remaining_seconds",
+			Help:        "Seconds until the scale-down cooldown ends (0 when not in cooldown).",
+		}, []string{"service"}),
+		scaleDownReasons: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   o.namespace,
+			ConstLabels: o.constLabels,
+			Name:        "autoscaler_scale_down_reason_total",
+			Help:        "Scale-downs by the reason that determined the new count.",
+		}, []string{"service", "reason"}),
+		scaleDownSkips: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   o.namespace,
+			ConstLabels: o.constLabels,
+			Name:        "autoscaler_scale_down_skips_total",
+			Help:        "Scale-downs blocked, by the guard that blocked them.",
+		}, []string{"service", "reason"}),
+		reconcileOutcomes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   o.namespace,
+			ConstLabels: o.constLabels,
+			Name:        "autoscaler_reconcile_outcomes_total",
+			Help:        "Reconcile cycles by outcome: scaled_up, scaled_down, no_change, cooldown_skip, or error.",
+		}, []string{"service", "outcome"}),
+		agentsByStatus: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   o.namespace,
+			ConstLabels: o.constLabels,
+			Name:        "tfc_agents_by_status",
+			Help:        "TFC agents by agent status.",
+		}, []string{"service", "status"}),
+		ecsWriteCircuitOpen: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   o.namespace,
+			ConstLabels: o.constLabels,
+			Name:        "ecs_write_circuit_open",
+			Help:        "1 while ECS writes are skipped by the write circuit breaker, 0 otherwise.",
+		}, []string{"service"}),
+		cooldownHeldAgents: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   o.namespace,
+			ConstLabels: o.constLabels,
+			Name:        "autoscaler_cooldown_held_agents",
+			Help:        "Agents a scale-down would have removed but cooldown kept (0 when no scale-down was blocked).",
+		}, []string{"service"}),
+		tfcRateLimitedTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   o.namespace,
+			ConstLabels: o.constLabels,
+			Name:        "tfc_rate_limited_total",
+			Help:        "Total TFC API attempts rejected by rate limiting.",
+		}, []string{"service"}),
+		deploymentLimitExceededTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   o.namespace,
+			ConstLabels: o.constLabels,
+			Name:        "autoscaler_deployment_limit_exceeded_total",
+			Help:        "Total scale-ups beyond the tasks the ECS deployment configuration runs at once.",
+		}, []string{"service"}),
+		computedDesired: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   o.namespace,
+			ConstLabels: o.constLabels,
+			Name:        "autoscaler_computed_desired",
+			Help:        "Desired count the scaling formula computed in the last reconcile.",
+		}, []string{"service"}),
+		appliedDesired: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   o.namespace,
+			ConstLabels: o.constLabels,
+			Name:        "autoscaler_applied_desired",
+			Help:        "Desired count the service was left at after the last reconcile's guards and clamps.",
+		}, []string{"service"}),
+		webhookFailuresTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   o.namespace,
+			ConstLabels: o.constLabels,
+			Name:        "autoscaler_webhook_failures_total",
+			Help:        "Total scale event notifications that could not be delivered to SCALE_WEBHOOK_URL.",
+		}, []string{"service"}),
+		deadbandSkipsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   o.namespace,
+			ConstLabels: o.constLabels,
+			Name:        "autoscaler_scale_deadband_skips_total",
+			Help:        "Total reconciles that skipped a scaling change within SCALE_DEADBAND.",
 		}, []string{"service"}),
 	}
 
@@ -80,15 +334,58 @@ func New() *Metrics {
 		m.totalAgents,
 		m.ecsDesiredCount,
 		m.ecsRunningCount,
+		m.ecsPlacementGap,
+		m.orphanTasks,
+		m.queueWait,
+		m.otherAgents,
+		m.planPending,
+		m.applyPending,
 		m.reconcileTotal,
 		m.scaleEventsTotal,
+		m.dryRunScaleEventsTotal,
 		m.cooldownSkipsTotal,
 		m.taskProtectionErrorsTotal,
+		m.placementStallsTotal,
+		m.maxClampTotal,
+		m.desiredCountMismatchTotal,
+		m.orphanTasksPersistent,
+		m.spotInterruptionsTotal,
+		m.reconcileDuration,
+		m.lastReconcileTime,
+		m.cooldownRemaining,
+		m.scaleDownReasons,
+		m.scaleDownSkips,
+		m.reconcileOutcomes,
+		m.agentsByStatus,
+		m.ecsWriteCircuitOpen,
+		m.cooldownHeldAgents,
+		m.tfcRateLimitedTotal,
+		m.deploymentLimitExceededTotal,
+		m.computedDesired,
+		m.appliedDesired,
+		m.webhookFailuresTotal,
+		m.deadbandSkipsTotal,
 	)
 
+	if o.runtimeMetrics {
+		registerOnce(reg, collectors.NewGoCollector())
+		registerOnce(reg, collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	}
+
 	return m
 }
 
+// registerOnce registers c with reg, ignoring a collector that is already
+// registered so repeated options cannot panic.
+func registerOnce(reg *prometheus.Registry, c prometheus.Collector) {
+	if err := reg.Register(c); err != nil {
+		var are prometheus.AlreadyRegisteredError
+		if !errors.As(err, &are) {
+			panic(err)
+		}
+	}
+}
+
 // Registry returns the custom prometheus registry.
 func (m *Metrics) Registry() *prometheus.Registry {
 	return m.registry
@@ -102,18 +399,46 @@ func (m *Metrics) Handler() http.Handler {
 // ForService returns a ServiceMetrics that records metrics with the given service label.
 func (m *Metrics) ForService(name string) *ServiceMetrics {
 	return &ServiceMetrics{
-		pendingRuns:      m.pendingRuns.WithLabelValues(name),
-		busyAgents:       m.busyAgents.WithLabelValues(name),
-		idleAgents:       m.idleAgents.WithLabelValues(name),
-		totalAgents:      m.totalAgents.WithLabelValues(name),
-		ecsDesiredCount:  m.ecsDesiredCount.WithLabelValues(name),
-		ecsRunningCount:  m.ecsRunningCount.WithLabelValues(name),
-		reconcileSuccess: m.reconcileTotal.WithLabelValues(name, "success"),
-		reconcileError:   m.reconcileTotal.WithLabelValues(name, "error"),
-		scaleUp:          m.scaleEventsTotal.WithLabelValues(name, "up"),
-		scaleDown:        m.scaleEventsTotal.WithLabelValues(name, "down"),
-		cooldownSkips:    m.cooldownSkipsTotal.WithLabelValues(name),
-		taskProtErrors:   m.taskProtectionErrorsTotal.WithLabelValues(name),
+		pendingRuns:       m.pendingRuns.WithLabelValues(name),
+		busyAgents:        m.busyAgents.WithLabelValues(name),
+		idleAgents:        m.idleAgents.WithLabelValues(name),
+		totalAgents:       m.totalAgents.WithLabelValues(name),
+		ecsDesiredCount:   m.ecsDesiredCount.WithLabelValues(name),
+		ecsRunningCount:   m.ecsRunningCount.WithLabelValues(name),
+		placementGap:      m.ecsPlacementGap.WithLabelValues(name),
+		orphanTasks:       m.orphanTasks.WithLabelValues(name),
+		queueWait:         m.queueWait.WithLabelValues(name),
+		otherAgents:       m.otherAgents.WithLabelValues(name),
+		planPending:       m.planPending.WithLabelValues(name),
+		applyPending:      m.applyPending.WithLabelValues(name),
+		reconcileSuccess:  m.reconcileTotal.WithLabelValues(name, "success"),
+		reconcileError:    m.reconcileTotal.WithLabelValues(name, "error"),
+		scaleUp:           m.scaleEventsTotal.WithLabelValues(name, "up"),
+		scaleDown:         m.scaleEventsTotal.WithLabelValues(name, "down"),
+		dryRunScaleUp:     m.dryRunScaleEventsTotal.WithLabelValues(name, "up"),
+		dryRunScaleDown:   m.dryRunScaleEventsTotal.WithLabelValues(name, "down"),
+		cooldownSkips:     m.cooldownSkipsTotal.WithLabelValues(name),
+		taskProtErrors:    m.taskProtectionErrorsTotal.WithLabelValues(name),
+		placementStalls:   m.placementStallsTotal.WithLabelValues(name),
+		maxClamps:         m.maxClampTotal.WithLabelValues(name),
+		countMismatches:   m.desiredCountMismatchTotal.WithLabelValues(name),
+		orphanPersistent:  m.orphanTasksPersistent.WithLabelValues(name),
+		spotInterrupts:    m.spotInterruptionsTotal.WithLabelValues(name),
+		reconcileDur:      m.reconcileDuration.WithLabelValues(name),
+		lastReconcile:     m.lastReconcileTime.WithLabelValues(name),
+		cooldownLeft:      m.cooldownRemaining.WithLabelValues(name),
+		scaleDownReasons:  m.scaleDownReasons.MustCurryWith(prometheus.Labels{"service": name}),
+		scaleDownSkips:    m.scaleDownSkips.MustCurryWith(prometheus.Labels{"service": name}),
+		reconcileOutcomes: m.reconcileOutcomes.MustCurryWith(prometheus.Labels{"service": name}),
+		agentsByStatus:    m.agentsByStatus.MustCurryWith(prometheus.Labels{"service": name}),
+		writeCircuitOpen:  m.ecsWriteCircuitOpen.WithLabelValues(name),
+		cooldownHeld:      m.cooldownHeldAgents.WithLabelValues(name),
+		tfcRateLimited:    m.tfcRateLimitedTotal.WithLabelValues(name),
+		deploymentLimit:   m.deploymentLimitExceededTotal.WithLabelValues(name),
+		computedDesired:   m.computedDesired.WithLabelValues(name),
+		appliedDesired:    m.appliedDesired.WithLabelValues(name),
+		webhookFailures:   m.webhookFailuresTotal.WithLabelValues(name),
+		deadbandSkips:     m.deadbandSkipsTotal.WithLabelValues(name),
 	}
 }
 
@@ -132,6 +457,11 @@ func (m *Metrics) RecordScaleEvent(direction string) {
 	m.ForService("default").RecordScaleEvent(direction)
 }
 
+// RecordDryRunScaleEvent increments the dry-run scale events counter (default service).
+func (m *Metrics) RecordDryRunScaleEvent(direction string) {
+	m.ForService("default").RecordDryRunScaleEvent(direction)
+}
+
 // RecordCooldownSkip increments the cooldown skips counter (default service).
 func (m *Metrics) RecordCooldownSkip() {
 	m.ForService("default").RecordCooldownSkip()
@@ -142,20 +472,168 @@ func (m *Metrics) RecordTaskProtectionError() {
 	m.ForService("default").RecordTaskProtectionError()
 }
 
+// RecordReconcileDuration observes the duration of a reconcile cycle (default service).
+func (m *Metrics) RecordReconcileDuration(seconds float64) {
+	m.ForService("default").RecordReconcileDuration(seconds)
+}
+
+// RecordReconcileTimestamp sets the last reconcile timestamp gauge (default service).
+func (m *Metrics) RecordReconcileTimestamp(t time.Time) {
+	m.ForService("default").RecordReconcileTimestamp(t)
+}
+
+// RecordScaleDownReason increments the scale-down reason counter (default service).
+func (m *Metrics) RecordScaleDownReason(reason string) {
+	m.ForService("default").RecordScaleDownReason(reason)
+}
+
+// RecordScaleDownSkip increments the scale-down skips counter (default service).
+func (m *Metrics) RecordScaleDownSkip(reason string) {
+	m.ForService("default").RecordScaleDownSkip(reason)
+}
+
+// RecordReconcileOutcome increments the reconcile outcome counter (default service).
+func (m *Metrics) RecordReconcileOutcome(outcome string) {
+	m.ForService("default").RecordReconcileOutcome(outcome)
+}
+
+// RecordPlacementGap sets the ECS placement gap gauge (default service).
+func (m *Metrics) RecordPlacementGap(gap int) {
+	m.ForService("default").RecordPlacementGap(gap)
+}
+
+// RecordPlacementStall increments the placement stall counter (default service).
+func (m *Metrics) RecordPlacementStall() {
+	m.ForService("default").RecordPlacementStall()
+}
+
+// RecordMaxClamp increments the max clamp counter (default service).
+func (m *Metrics) RecordMaxClamp() {
+	m.ForService("default").RecordMaxClamp()
+}
+
+// RecordDesiredCountMismatch increments the desired count mismatch counter (default service).
+func (m *Metrics) RecordDesiredCountMismatch() {
+	m.ForService("default").RecordDesiredCountMismatch()
+}
+
+// RecordOrphanTasks sets the orphan tasks gauge (default service).
+func (m *Metrics) RecordOrphanTasks(count int) {
+	m.ForService("default").RecordOrphanTasks(count)
+}
+
+// RecordOrphanTasksPersistent increments the persistent orphan tasks counter (default service).
+func (m *Metrics) RecordOrphanTasksPersistent() {
+	m.ForService("default").RecordOrphanTasksPersistent()
+}
+
+// RecordCooldownRemaining sets the cooldown remaining gauge (default service).
+func (m *Metrics) RecordCooldownRemaining(seconds float64) {
+	m.ForService("default").RecordCooldownRemaining(seconds)
+}
+
+// RecordSpotInterruptions adds to the spot interruptions counter (default service).
+func (m *Metrics) RecordSpotInterruptions(count int) {
+	m.ForService("default").RecordSpotInterruptions(count)
+}
+
+// RecordQueueWait sets the queue wait gauge (default service).
+func (m *Metrics) RecordQueueWait(seconds float64) {
+	m.ForService("default").RecordQueueWait(seconds)
+}
+
+// RecordOtherAgents sets the other agents gauge (default service).
+func (m *Metrics) RecordOtherAgents(count int) {
+	m.ForService("default").RecordOtherAgents(count)
+}
+
+// RecordAgentsByStatus sets the agents by status gauge (default service).
+func (m *Metrics) RecordAgentsByStatus(counts map[string]int) {
+	m.ForService("default").RecordAgentsByStatus(counts)
+}
+
+// RecordPendingByType sets the plan and apply pending gauges (default service).
+func (m *Metrics) RecordPendingByType(plan, apply int) {
+	m.ForService("default").RecordPendingByType(plan, apply)
+}
+
+// RecordECSWriteCircuitOpen sets the ECS write circuit gauge (default service).
+func (m *Metrics) RecordECSWriteCircuitOpen(open bool) {
+	m.ForService("default").RecordECSWriteCircuitOpen(open)
+}
+
+// RecordCooldownHeldAgents sets the cooldown held agents gauge (default service).
+func (m *Metrics) RecordCooldownHeldAgents(count int) {
+	m.ForService("default").RecordCooldownHeldAgents(count)
+}
+
+// RecordTFCRateLimited increments the TFC rate-limited counter (default service).
+func (m *Metrics) RecordTFCRateLimited() {
+	m.ForService("default").RecordTFCRateLimited()
+}
+
+// RecordDeploymentLimitExceeded increments the deployment limit counter (default service).
+func (m *Metrics) RecordDeploymentLimitExceeded() {
+	m.ForService("default").RecordDeploymentLimitExceeded()
+}
+
+// RecordDesired sets the computed and applied desired count gauges (default service).
+func (m *Metrics) RecordDesired(computed, applied int) {
+	m.ForService("default").RecordDesired(computed, applied)
+}
+
+// RecordWebhookFailure increments the webhook failure counter (default service).
+func (m *Metrics) RecordWebhookFailure() {
+	m.ForService("default").RecordWebhookFailure()
+}
+
+// RecordWithinDeadband increments the scale deadband skip counter (default service).
+func (m *Metrics) RecordWithinDeadband() {
+	m.ForService("default").RecordWithinDeadband()
+}
+
 // ServiceMetrics records metrics for a specific service.
 type ServiceMetrics struct {
-	pendingRuns      prometheus.Gauge
-	busyAgents       prometheus.Gauge
-	idleAgents       prometheus.Gauge
-	totalAgents      prometheus.Gauge
-	ecsDesiredCount  prometheus.Gauge
-	ecsRunningCount  prometheus.Gauge
-	reconcileSuccess prometheus.Counter
-	reconcileError   prometheus.Counter
-	scaleUp          prometheus.Counter
-	scaleDown        prometheus.Counter
-	cooldownSkips    prometheus.Counter
-	taskProtErrors   prometheus.Counter
+	pendingRuns       prometheus.Gauge
+	busyAgents        prometheus.Gauge
+	idleAgents        prometheus.Gauge
+	totalAgents       prometheus.Gauge
+	ecsDesiredCount   prometheus.Gauge
+	ecsRunningCount   prometheus.Gauge
+	placementGap      prometheus.Gauge
+	orphanTasks       prometheus.Gauge
+	queueWait         prometheus.Gauge
+	otherAgents       prometheus.Gauge
+	planPending       prometheus.Gauge
+	applyPending      prometheus.Gauge
+	reconcileSuccess  prometheus.Counter
+	reconcileError    prometheus.Counter
+	scaleUp           prometheus.Counter
+	scaleDown         prometheus.Counter
+	dryRunScaleUp     prometheus.Counter
+	dryRunScaleDown   prometheus.Counter
+	cooldownSkips     prometheus.Counter
+	taskProtErrors    prometheus.Counter
+	placementStalls   prometheus.Counter
+	maxClamps         prometheus.Counter
+	countMismatches   prometheus.Counter
+	orphanPersistent  prometheus.Counter
+	spotInterrupts    prometheus.Counter
+	reconcileDur      prometheus.Observer
+	lastReconcile     prometheus.Gauge
+	cooldownLeft      prometheus.Gauge
+	scaleDownReasons  *prometheus.CounterVec
+	scaleDownSkips    *prometheus.CounterVec
+	reconcileOutcomes *prometheus.CounterVec
+	agentsByStatus    *prometheus.GaugeVec
+	writeCircuitOpen  prometheus.Gauge
+	cooldownHeld      prometheus.Gauge
+	tfcRateLimited    prometheus.Counter
+	deploymentLimit   prometheus.Counter
+	computedDesired   prometheus.Gauge
+	appliedDesired    prometheus.Gauge
+	webhookFailures   prometheus.Counter
+	deadbandSkips     prometheus.Counter
 }
 
 // RecordReconcile updates all gauge metrics with current values.
@@ -187,6 +665,16 @@ func (sm *ServiceMetrics) RecordScaleEvent(direction string) {
 	}
 }
 
+// RecordDryRunScaleEvent increments the dry-run scale events counter.
+func (sm *ServiceMetrics) RecordDryRunScaleEvent(direction string) {
+	switch direction {
+	case "up":
+		sm.dryRunScaleUp.Inc()
+	case "down":
+		sm.dryRunScaleDown.Inc()
+	}
+}
+
 // RecordCooldownSkip increments the cooldown skips counter.
 func (sm *ServiceMetrics) RecordCooldownSkip() {
 	sm.cooldownSkips.Inc()
@@ -196,3 +684,134 @@ func (sm *ServiceMetrics) RecordCooldownSkip() {
 func (sm *ServiceMetrics) RecordTaskProtectionError() {
 	sm.taskProtErrors.Inc()
 }
+
+// RecordReconcileDuration observes the duration of a reconcile cycle.
+func (sm *ServiceMetrics) RecordReconcileDuration(seconds float64) {
+	sm.reconcileDur.Observe(seconds)
+}
+
+// RecordReconcileTimestamp sets the last reconcile timestamp gauge to t.
+func (sm *ServiceMetrics) RecordReconcileTimestamp(t time.Time) {
+	sm.lastReconcile.Set(float64(t.UnixNano()) / 1e9)
+}
+
+// RecordScaleDownReason increments the scale-down reason counter.
+func (sm *ServiceMetrics) RecordScaleDownReason(reason string) {
+	sm.scaleDownReasons.WithLabelValues(reason).Inc()
+}
+
+// RecordScaleDownSkip increments the scale-down skips counter.
+func (sm *ServiceMetrics) RecordScaleDownSkip(reason string) {
+	sm.scaleDownSkips.WithLabelValues(reason).Inc()
+}
+
+// RecordReconcileOutcome increments the reconcile outcome counter.
+func (sm *ServiceMetrics) RecordReconcileOutcome(outcome string) {
+	sm.reconcileOutcomes.WithLabelValues(outcome).Inc()
+}
+
+// RecordPlacementGap sets the ECS placement gap gauge to desired minus running.
+func (sm *ServiceMetrics) RecordPlacementGap(gap int) {
+	sm.placementGap.Set(float64(gap))
+}
+
+// RecordPlacementStall increments the placement stall counter.
+func (sm *ServiceMetrics) RecordPlacementStall() {
+	sm.placementStalls.Inc()
+}
+
+// RecordMaxClamp increments the counter of reconciles clamped by maxAgents.
+func (sm *ServiceMetrics) RecordMaxClamp() {
+	sm.maxClamps.Inc()
+}
+
+// RecordDesiredCountMismatch increments the desired count mismatch counter.
+func (sm *ServiceMetrics) RecordDesiredCountMismatch() {
+	sm.countMismatches.Inc()
+}
+
+// RecordOrphanTasks sets the gauge of running tasks without a registered agent.
+func (sm *ServiceMetrics) RecordOrphanTasks(count int) {
+	sm.orphanTasks.Set(float64(count))
+}
+
+// RecordOrphanTasksPersistent increments the persistent orphan tasks counter.
+func (sm *ServiceMetrics) RecordOrphanTasksPersistent() {
+	sm.orphanPersistent.Inc()
+}
+
+// RecordCooldownRemaining sets the seconds left until scale-down is allowed.
+func (sm *ServiceMetrics) RecordCooldownRemaining(seconds float64) {
+	sm.cooldownLeft.Set(seconds)
+}
+
+// RecordSpotInterruptions adds newly detected spot interruptions to the counter.
+func (sm *ServiceMetrics) RecordSpotInterruptions(count int) {
+	sm.spotInterrupts.Add(float64(count))
+}
+
+// RecordQueueWait sets how long the longest-waiting pending run has been queued.
+func (sm *ServiceMetrics) RecordQueueWait(seconds float64) {
+	sm.queueWait.Set(seconds)
+}
+
+// RecordPendingByType sets the pool's pending plan and apply run counts.
+func (sm *ServiceMetrics) RecordPendingByType(plan, apply int) {
+	sm.planPending.Set(float64(plan))
+	sm.applyPending.Set(float64(apply))
+}
+
+// RecordAgentsByStatus sets the agent count for each status in counts.
+// Statuses missing from counts keep their last value, so callers pass zero
+// for statuses no agent currently has.
+func (sm *ServiceMetrics) RecordAgentsByStatus(counts map[string]int) {
+	for status, n := range counts {
+		sm.agentsByStatus.WithLabelValues(status).Set(float64(n))
+	}
+}
+
+// RecordOtherAgents sets the number of agents that are neither busy nor idle.
+func (sm *ServiceMetrics) RecordOtherAgents(count int) {
+	sm.otherAgents.Set(float64(count))
+}
+
+// RecordECSWriteCircuitOpen sets whether ECS writes are being skipped by the write circuit breaker.
+func (sm *ServiceMetrics) RecordECSWriteCircuitOpen(open bool) {
+	if open {
+		sm.writeCircuitOpen.Set(1)
+	} else {
+		sm.writeCircuitOpen.Set(0)
+	}
+}
+
+// RecordCooldownHeldAgents sets the number of agents kept by a cooldown-blocked scale-down.
+func (sm *ServiceMetrics) RecordCooldownHeldAgents(count int) {
+	sm.cooldownHeld.Set(float64(count))
+}
+
+// RecordTFCRateLimited counts a TFC API attempt rejected by rate limiting.
+func (sm *ServiceMetrics) RecordTFCRateLimited() {
+	sm.tfcRateLimited.Inc()
+}
+
+// RecordDeploymentLimitExceeded counts a scale-up beyond the tasks the deployment configuration runs at once.
+func (sm *ServiceMetrics) RecordDeploymentLimitExceeded() {
+	sm.deploymentLimit.Inc()
+}
+
+// RecordDesired sets the desired count the scaling formula computed and the
+// one the service was left at after guards and clamps.
+func (sm *ServiceMetrics) RecordDesired(computed, applied int) {
+	sm.computedDesired.Set(float64(computed))
+	sm.appliedDesired.Set(float64(applied))
+}
+
+// RecordWebhookFailure counts a scale event notification that could not be delivered.
+func (sm *ServiceMetrics) RecordWebhookFailure() {
+	sm.webhookFailures.Inc()
+}
+
+// RecordWithinDeadband counts a scaling change skipped for falling within the scale deadband.
+func (sm *ServiceMetrics) RecordWithinDeadband() {
+	sm.deadbandSkips.Inc()
+}
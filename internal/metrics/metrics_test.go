@@ -5,8 +5,10 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 	io_prometheus_client "github.com/prometheus/client_model/go"
 )
 
@@ -52,6 +54,17 @@ func TestRecordScaleEvent(t *testing.T) {
 	assertCounterVecValue(t, m.scaleEventsTotal, "default", "down", 1)
 }
 
+func TestRecordDryRunScaleEvent(t *testing.T) {
+	m := New()
+	m.RecordDryRunScaleEvent("up")
+	m.RecordDryRunScaleEvent("down")
+	m.RecordDryRunScaleEvent("down")
+
+	assertCounterVecValue(t, m.dryRunScaleEventsTotal, "default", "up", 1)
+	assertCounterVecValue(t, m.dryRunScaleEventsTotal, "default", "down", 2)
+	assertCounterVecValue(t, m.scaleEventsTotal, "default", "up", 0)
+}
+
 func TestRecordCooldownSkip(t *testing.T) {
 	m := New()
 	m.RecordCooldownSkip()
@@ -68,12 +81,243 @@ func TestRecordTaskProtectionError(t *testing.T) {
 	assertCounterVecSingleLabel(t, m.taskProtectionErrorsTotal, "default", 2)
 }
 
+func TestRecordReconcileDuration(t *testing.T) {
+	m := New()
+	m.RecordReconcileDuration(0.25)
+	m.RecordReconcileDuration(1.5)
+
+	assertHistogramVecCount(t, m.reconcileDuration, "default", 2, 1.75)
+}
+
+func TestRecordReconcileTimestamp(t *testing.T) {
+	m := New()
+	m.RecordReconcileTimestamp(time.Unix(1700000000, 500000000))
+
+	assertGaugeVecValue(t, m.lastReconcileTime, "default", 1700000000.5)
+}
+
+func TestRecordScaleDownReason(t *testing.T) {
+	m := New()
+	m.RecordScaleDownReason("idle_guard")
+	m.RecordScaleDownReason("idle_guard")
+	m.RecordScaleDownReason("no_work")
+
+	assertCounterVecValue(t, m.scaleDownReasons, "default", "idle_guard", 2)
+	assertCounterVecValue(t, m.scaleDownReasons, "default", "no_work", 1)
+
+	spot := m.ForService("spot")
+	spot.RecordScaleDownReason("step_limit")
+	assertCounterVecValue(t, m.scaleDownReasons, "spot", "step_limit", 1)
+	assertCounterVecValue(t, m.scaleDownReasons, "default", "step_limit", 0)
+}
+
+func TestRecordScaleDownSkip(t *testing.T) {
+	m := New()
+	m.RecordScaleDownSkip("idle_threshold")
+	m.RecordScaleDownSkip("cooldown")
+	m.RecordScaleDownSkip("idle_threshold")
+
+	assertCounterVecValue(t, m.scaleDownSkips, "default", "idle_threshold", 2)
+	assertCounterVecValue(t, m.scaleDownSkips, "default", "cooldown", 1)
+}
+
+func TestRecordReconcileOutcome(t *testing.T) {
+	m := New()
+	m.RecordReconcileOutcome("no_change")
+	m.RecordReconcileOutcome("scaled_up")
+	m.RecordReconcileOutcome("no_change")
+
+	assertCounterVecValue(t, m.reconcileOutcomes, "default", "no_change", 2)
+	assertCounterVecValue(t, m.reconcileOutcomes, "default", "scaled_up", 1)
+
+	spot := m.ForService("spot")
+	spot.RecordReconcileOutcome("error")
+	assertCounterVecValue(t, m.reconcileOutcomes, "spot", "error", 1)
+	assertCounterVecValue(t, m.reconcileOutcomes, "default", "error", 0)
+}
+
+func TestRecordPlacement(t *testing.T) {
+	m := New()
+	m.RecordPlacementGap(3)
+	m.RecordPlacementStall()
+	m.RecordPlacementStall()
+
+	assertGaugeVecValue(t, m.ecsPlacementGap, "default", 3)
+	assertCounterVecSingleLabel(t, m.placementStallsTotal, "default", 2)
+}
+
+func TestRecordMaxClamp(t *testing.T) {
+	m := New()
+	m.RecordMaxClamp()
+	m.RecordMaxClamp()
+	m.RecordMaxClamp()
+
+	assertCounterVecSingleLabel(t, m.maxClampTotal, "default", 3)
+}
+
+func TestRecordDesiredCountMismatch(t *testing.T) {
+	m := New()
+	m.RecordDesiredCountMismatch()
+
+	assertCounterVecSingleLabel(t, m.desiredCountMismatchTotal, "default", 1)
+}
+
+func TestRecordOrphanTasks(t *testing.T) {
+	m := New()
+	m.RecordOrphanTasks(3)
+	m.RecordOrphanTasksPersistent()
+
+	assertGaugeVecValue(t, m.orphanTasks, "default", 3)
+	assertCounterVecSingleLabel(t, m.orphanTasksPersistent, "default", 1)
+}
+
+func TestRecordCooldownRemaining(t *testing.T) {
+	m := New()
+	m.RecordCooldownRemaining(42.5)
+
+	assertGaugeVecValue(t, m.cooldownRemaining, "default", 42.5)
+}
+
+func TestRecordCooldownHeldAgents(t *testing.T) {
+	m := New()
+	m.RecordCooldownHeldAgents(4)
+
+	assertGaugeVecValue(t, m.cooldownHeldAgents, "default", 4)
+}
+
+func TestRecordTFCRateLimited(t *testing.T) {
+	m := New()
+	m.RecordTFCRateLimited()
+	m.RecordTFCRateLimited()
+
+	assertCounterVecSingleLabel(t, m.tfcRateLimitedTotal, "default", 2)
+}
+
+func TestRecordDesired(t *testing.T) {
+	m := New()
+	m.RecordDesired(3, 4)
+
+	assertGaugeVecValue(t, m.computedDesired, "default", 3)
+	assertGaugeVecValue(t, m.appliedDesired, "default", 4)
+}
+
+func TestRecordDeploymentLimitExceeded(t *testing.T) {
+	m := New()
+	m.RecordDeploymentLimitExceeded()
+
+	assertCounterVecSingleLabel(t, m.deploymentLimitExceededTotal, "default", 1)
+}
+
+func TestRecordWebhookFailure(t *testing.T) {
+	m := New()
+	m.RecordWebhookFailure()
+	m.RecordWithinDeadband()
+	m.RecordWebhookFailure()
+	m.RecordWithinDeadband()
+
+	assertCounterVecSingleLabel(t, m.webhookFailuresTotal, "default", 2)
+}
+
+func TestRecordWithinDeadband(t *testing.T) {
+	m := New()
+	m.RecordWithinDeadband()
+
+	assertCounterVecSingleLabel(t, m.deadbandSkipsTotal, "default", 1)
+}
+
+func TestRecordSpotInterruptions(t *testing.T) {
+	m := New()
+	m.RecordSpotInterruptions(2)
+	m.RecordSpotInterruptions(1)
+
+	assertCounterVecSingleLabel(t, m.spotInterruptionsTotal, "default", 3)
+}
+
+func TestRecordQueueWait(t *testing.T) {
+	m := New()
+	m.RecordQueueWait(1200)
+
+	assertGaugeVecValue(t, m.queueWait, "default", 1200)
+}
+
+func TestRecordOtherAgents(t *testing.T) {
+	m := New()
+	m.RecordOtherAgents(3)
+
+	assertGaugeVecValue(t, m.otherAgents, "default", 3)
+}
+
+func TestRecordECSWriteCircuitOpen(t *testing.T) {
+	m := New()
+	m.RecordECSWriteCircuitOpen(true)
+
+	assertGaugeVecValue(t, m.ecsWriteCircuitOpen, "default", 1)
+
+	m.RecordECSWriteCircuitOpen(false)
+
+	assertGaugeVecValue(t, m.ecsWriteCircuitOpen, "default", 0)
+}
+
+func TestRecordAgentsByStatus(t *testing.T) {
+	m := New()
+	m.RecordAgentsByStatus(map[string]int{"busy": 2, "idle": 1, "errored": 3, "exited": 0})
+
+	assertGaugeVecLabelValue(t, m.agentsByStatus, "default", "busy", 2)
+	assertGaugeVecLabelValue(t, m.agentsByStatus, "default", "idle", 1)
+	assertGaugeVecLabelValue(t, m.agentsByStatus, "default", "errored", 3)
+	assertGaugeVecLabelValue(t, m.agentsByStatus, "default", "exited", 0)
+
+	m.RecordAgentsByStatus(map[string]int{"busy": 2, "idle": 1, "errored": 0, "exited": 0})
+
+	assertGaugeVecLabelValue(t, m.agentsByStatus, "default", "errored", 0)
+}
+
+func TestRecordPendingByType(t *testing.T) {
+	m := New()
+	m.RecordPendingByType(4, 2)
+
+	assertGaugeVecValue(t, m.planPending, "default", 4)
+	assertGaugeVecValue(t, m.applyPending, "default", 2)
+
+	regular := m.ForService("regular")
+	regular.RecordPendingByType(1, 0)
+
+	assertGaugeVecValue(t, m.planPending, "regular", 1)
+	assertGaugeVecValue(t, m.applyPending, "regular", 0)
+	assertGaugeVecValue(t, m.planPending, "default", 4)
+}
+
 func TestHTTPHandler(t *testing.T) {
 	m := New()
 	m.RecordReconcile(1, 0, 1, 2, 3, 3)
 	m.RecordReconcileResult(true)
 	m.RecordScaleEvent("up")
+	m.RecordDryRunScaleEvent("up")
 	m.RecordCooldownSkip()
+	m.RecordReconcileDuration(0.1)
+	m.RecordReconcileTimestamp(time.Now())
+	m.RecordScaleDownReason("no_work")
+	m.RecordScaleDownSkip("cooldown")
+	m.RecordReconcileOutcome("no_change")
+	m.RecordPlacementGap(0)
+	m.RecordPlacementStall()
+	m.RecordMaxClamp()
+	m.RecordDesiredCountMismatch()
+	m.RecordOrphanTasks(0)
+	m.RecordOrphanTasksPersistent()
+	m.RecordCooldownRemaining(0)
+	m.RecordSpotInterruptions(0)
+	m.RecordQueueWait(0)
+	m.RecordPendingByType(0, 0)
+	m.RecordOtherAgents(0)
+	m.RecordAgentsByStatus(map[string]int{"idle": 0})
+	m.RecordECSWriteCircuitOpen(false)
+	m.RecordCooldownHeldAgents(0)
+	m.RecordTFCRateLimited()
+	m.RecordDeploymentLimitExceeded()
+	m.RecordDesired(0, 0)
+	m.RecordWebhookFailure()
+	m.RecordWithinDeadband()
 
 	handler := m.Handler()
 	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
@@ -92,10 +336,37 @@ func TestHTTPHandler(t *testing.T) {
 		"tfc_total_agents",
 		"ecs_desired_count",
 		"ecs_running_count",
+		"ecs_placement_gap",
+		"ecs_placement_stall_total",
 		"autoscaler_reconcile_total",
 		"autoscaler_scale_events_total",
+		"autoscaler_dry_run_scale_events_total",
 		"autoscaler_cooldown_skips_total",
 		"autoscaler_task_protection_errors_total",
+		"autoscaler_reconcile_duration_seconds",
+		"autoscaler_last_reconcile_timestamp_seconds",
+		"autoscaler_scale_down_reason_total",
+		"autoscaler_reconcile_outcomes_total",
+		"autoscaler_scale_down_skips_total",
+		"autoscaler_max_clamp_total",
+		"autoscaler_desired_count_mismatch_total",
+		"orphan_tasks",
+		"orphan_tasks_persistent_total",
+		"autoscaler_cooldown_remaining_seconds",
+		"spot_interruptions_total",
+		"queue_wait_seconds",
+		"tfc_plan_pending",
+		"tfc_apply_pending",
+		"tfc_agents_by_status",
+		"tfc_other_agents",
+		"ecs_write_circuit_open",
+		"autoscaler_cooldown_held_agents",
+		"tfc_rate_limited_total",
+		"autoscaler_deployment_limit_exceeded_total",
+		"autoscaler_computed_desired",
+		"autoscaler_applied_desired",
+		"autoscaler_webhook_failures_total",
+		"autoscaler_scale_deadband_skips_total",
 	} {
 		if !strings.Contains(body, want) {
 			t.Errorf("metrics output missing %q", want)
@@ -112,6 +383,8 @@ func TestForService(t *testing.T) {
 	sm.RecordScaleEvent("up")
 	sm.RecordCooldownSkip()
 	sm.RecordTaskProtectionError()
+	sm.RecordReconcileDuration(0.5)
+	sm.RecordReconcileTimestamp(time.Unix(1700000000, 0))
 
 	assertGaugeVecValue(t, m.pendingRuns, "spot", 4)
 	assertGaugeVecValue(t, m.busyAgents, "spot", 3)
@@ -124,6 +397,8 @@ func TestForService(t *testing.T) {
 	assertCounterVecValue(t, m.scaleEventsTotal, "spot", "up", 1)
 	assertCounterVecSingleLabel(t, m.cooldownSkipsTotal, "spot", 1)
 	assertCounterVecSingleLabel(t, m.taskProtectionErrorsTotal, "spot", 1)
+	assertHistogramVecCount(t, m.reconcileDuration, "spot", 1, 0.5)
+	assertGaugeVecValue(t, m.lastReconcileTime, "spot", 1700000000)
 }
 
 func TestForServiceIsolation(t *testing.T) {
@@ -154,6 +429,23 @@ func assertGaugeVecValue(t *testing.T, gv *prometheus.GaugeVec, service string,
 	}
 }
 
+// assertGaugeVecLabelValue asserts a gauge in a 2-label GaugeVec (service + another label).
+func assertGaugeVecLabelValue(t *testing.T, gv *prometheus.GaugeVec, service, secondLabel string, want float64) {
+	t.Helper()
+	g, err := gv.GetMetricWithLabelValues(service, secondLabel)
+	if err != nil {
+		t.Fatalf("getting gauge with labels %s, %s: %v", service, secondLabel, err)
+	}
+	m := &io_prometheus_client.Metric{}
+	if err := g.Write(m); err != nil {
+		t.Fatalf("writing metric: %v", err)
+	}
+	got := m.GetGauge().GetValue()
+	if got != want {
+		t.Errorf("gauge(%s, %s) = %v, want %v", service, secondLabel, got, want)
+	}
+}
+
 // assertCounterVecValue asserts a counter in a 2-label CounterVec (service + another label).
 func assertCounterVecValue(t *testing.T, cv *prometheus.CounterVec, service, secondLabel string, want float64) {
 	t.Helper()
@@ -187,3 +479,107 @@ func assertCounterVecSingleLabel(t *testing.T, cv *prometheus.CounterVec, servic
 		t.Errorf("counter(service=%s) = %v, want %v", service, got, want)
 	}
 }
+
+// assertHistogramVecCount asserts the sample count and sum of a single-label HistogramVec.
+func assertHistogramVecCount(t *testing.T, hv *prometheus.HistogramVec, service string, wantCount uint64, wantSum float64) {
+	t.Helper()
+	o, err := hv.GetMetricWithLabelValues(service)
+	if err != nil {
+		t.Fatalf("getting histogram with service=%s: %v", service, err)
+	}
+	m := &io_prometheus_client.Metric{}
+	if err := o.(prometheus.Metric).Write(m); err != nil {
+		t.Fatalf("writing metric: %v", err)
+	}
+	h := m.GetHistogram()
+	if h.GetSampleCount() != wantCount {
+		t.Errorf("histogram(service=%s) count = %d, want %d", service, h.GetSampleCount(), wantCount)
+	}
+	if h.GetSampleSum() != wantSum {
+		t.Errorf("histogram(service=%s) sum = %v, want %v", service, h.GetSampleSum(), wantSum)
+	}
+}
+
+func TestNewWithNamespace(t *testing.T) {
+	m := New(WithNamespace("team_a"))
+	m.RecordReconcile(1, 0, 1, 2, 3, 3)
+	m.RecordReconcileResult(true)
+	m.RecordReconcileDuration(0.1)
+
+	families, err := m.Registry().Gather()
+	if err != nil {
+		t.Fatalf("gathering metrics: %v", err)
+	}
+	if len(families) == 0 {
+		t.Fatal("no metrics gathered")
+	}
+	for _, mf := range families {
+		if !strings.HasPrefix(mf.GetName(), "team_a_") {
+			t.Errorf("metric %q is missing the team_a_ prefix", mf.GetName())
+		}
+	}
+
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := w.Body.String()
+	for _, want := range []string{
+		"team_a_tfc_pending_runs",
+		"team_a_autoscaler_reconcile_total",
+		"team_a_autoscaler_reconcile_duration_seconds",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics output missing %q", want)
+		}
+	}
+}
+
+func TestNewWithRuntimeMetrics(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		want bool
+	}{
+		{name: "enabled", opts: []Option{WithRuntimeMetrics(true)}, want: true},
+		{name: "enabled twice", opts: []Option{WithRuntimeMetrics(true), WithRuntimeMetrics(true)}, want: true},
+		{name: "enabled with namespace", opts: []Option{WithNamespace("team_a"), WithRuntimeMetrics(true)}, want: true},
+		{name: "disabled", opts: []Option{WithRuntimeMetrics(false)}, want: false},
+		{name: "default", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := New(tt.opts...)
+
+			w := httptest.NewRecorder()
+			m.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+			body := w.Body.String()
+			if got := strings.Contains(body, "\ngo_goroutines "); got != tt.want {
+				t.Errorf("go_goroutines exposed = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegisterOnceIgnoresDuplicates(t *testing.T) {
+	m := New(WithRuntimeMetrics(true))
+	// The registry already holds a Go collector; a second must not panic.
+	registerOnce(m.Registry(), collectors.NewGoCollector())
+}
+
+func TestNewWithConstLabels(t *testing.T) {
+	m := New(WithConstLabels(map[string]string{"cluster": "foo", "env": "prod"}))
+	m.RecordReconcile(1, 0, 1, 2, 3, 3)
+	m.RecordScaleEvent("up")
+
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := w.Body.String()
+	for _, want := range []string{
+		`tfc_pending_runs{cluster="foo",env="prod",service="default"} 2`,
+		`autoscaler_scale_events_total{cluster="foo",direction="up",env="prod",service="default"} 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics output missing %q", want)
+		}
+	}
+}
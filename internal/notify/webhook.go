@@ -0,0 +1,108 @@
+// Package notify sends notifications about scaling actions to external
+// systems.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Event describes a scaling action applied to an ECS service.
+type Event struct {
+	Service   string `json:"service"`
+	From      int    `json:"from"`
+	To        int    `json:"to"`
+	Direction string `json:"direction"`
+	Reason    string `json:"reason"`
+}
+
+// Webhook POSTs each Event as JSON to a URL. Requests are sent in the
+// background so a slow or unreachable endpoint never delays scaling; failures
+// are only logged and reported to the failure hook.
+type Webhook struct {
+	url       string
+	timeout   time.Duration
+	client    *http.Client
+	logger    *slog.Logger
+	onFailure func(service string)
+
+	// inflight tracks requests still being sent, for Wait.
+	inflight sync.WaitGroup
+}
+
+// WebhookOption configures optional behavior for Webhook.
+type WebhookOption func(*Webhook)
+
+// WithFailureHook calls fn with the event's service each time a notification
+// cannot be delivered, for example to count it in a metric.
+func WithFailureHook(fn func(service string)) WebhookOption {
+	return func(w *Webhook) {
+		w.onFailure = fn
+	}
+}
+
+// NewWebhook returns a Webhook posting to url, giving up on each request
+// after timeout.
+func NewWebhook(url string, timeout time.Duration, logger *slog.Logger, opts ...WebhookOption) *Webhook {
+	w := &Webhook{
+		url:     url,
+		timeout: timeout,
+		client:  &http.Client{},
+		logger:  logger,
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+// Notify sends ev in the background and returns immediately.
+func (w *Webhook) Notify(ev Event) {
+	w.inflight.Add(1)
+	go func() {
+		defer w.inflight.Done()
+		if err := w.send(ev); err != nil {
+			w.logger.Warn("scale webhook failed", "service", ev.Service, "error", err)
+			if w.onFailure != nil {
+				w.onFailure(ev.Service)
+			}
+		}
+	}()
+}
+
+// Wait blocks until every notification sent so far has been delivered or
+// has failed.
+func (w *Webhook) Wait() {
+	w.inflight.Wait()
+}
+
+func (w *Webhook) send(ev Event) error {
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return fmt.Errorf("encoding event: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), w.timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("posting event: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("posting event: unexpected status %s", resp.Status)
+	}
+	return nil
+}
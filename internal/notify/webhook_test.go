@@ -0,0 +1,94 @@
+package notify
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWebhookPostsEvent(t *testing.T) {
+	received := make(chan Event, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("method = %s, want POST", r.Method)
+		}
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Content-Type = %q, want application/json", ct)
+		}
+		var ev Event
+		if err := json.NewDecoder(r.Body).Decode(&ev); err != nil {
+			t.Errorf("decoding body: %v", err)
+		}
+		received <- ev
+	}))
+	defer srv.Close()
+
+	var failures atomic.Int32
+	w := NewWebhook(srv.URL, time.Second, slog.Default(), WithFailureHook(func(string) { failures.Add(1) }))
+	want := Event{Service: "default", From: 2, To: 5, Direction: "up", Reason: ""}
+	w.Notify(want)
+	w.Wait()
+
+	select {
+	case got := <-received:
+		if got != want {
+			t.Errorf("event = %+v, want %+v", got, want)
+		}
+	default:
+		t.Fatal("webhook received no request")
+	}
+	if n := failures.Load(); n != 0 {
+		t.Errorf("failures = %d, want 0", n)
+	}
+}
+
+func TestWebhookFailures(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		timeout time.Duration
+	}{
+		{
+			name:    "error status",
+			handler: func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusInternalServerError) },
+			timeout: time.Second,
+		},
+		{
+			name: "timeout",
+			handler: func(_ http.ResponseWriter, r *http.Request) {
+				select {
+				case <-r.Context().Done():
+				case <-time.After(time.Second):
+				}
+			},
+			timeout: 50 * time.Millisecond,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(tt.handler)
+			defer srv.Close()
+
+			var failed []string
+			w := NewWebhook(srv.URL, tt.timeout, slog.Default(), WithFailureHook(func(service string) {
+				failed = append(failed, service)
+			}))
+
+			start := time.Now()
+			w.Notify(Event{Service: "spot", From: 3, To: 1, Direction: "down", Reason: "no_work"})
+			if elapsed := time.Since(start); elapsed > 20*time.Millisecond {
+				t.Errorf("Notify blocked for %v", elapsed)
+			}
+			w.Wait()
+
+			if len(failed) != 1 || failed[0] != "spot" {
+				t.Errorf("failure hook calls = %v, want [spot]", failed)
+			}
+		})
+	}
+}
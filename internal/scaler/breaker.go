@@ -0,0 +1,82 @@
+package scaler
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrECSWriteCircuitOpen is returned in place of an ECS write skipped because
+// the write circuit breaker is open.
+var ErrECSWriteCircuitOpen = errors.New("ECS write circuit open")
+
+// breakerState is the state of a writeBreaker.
+type breakerState int
+
+const (
+	// breakerClosed lets every write through.
+	breakerClosed breakerState = iota
+	// breakerOpen skips every write until the cooldown has passed.
+	breakerOpen
+	// breakerHalfOpen lets writes through on trial: the next success closes
+	// the breaker and the next failure opens it again.
+	breakerHalfOpen
+)
+
+// writeBreaker stops ECS writes after threshold consecutive failures, so a
+// persistent error such as a revoked IAM permission is not retried every
+// reconcile. Once cooldown has passed since it opened, writes are tried again.
+// A nil *writeBreaker never opens. It is not safe for concurrent use; the
+// Scaler only uses it while holding settingsMu.
+type writeBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	state     breakerState
+	failures  int
+	openedAt  time.Time
+}
+
+// newWriteBreaker returns a breaker opening after threshold consecutive
+// failures for cooldown. It returns nil, meaning never open, when threshold is
+// not positive.
+func newWriteBreaker(threshold int, cooldown time.Duration) *writeBreaker {
+	if threshold <= 0 {
+		return nil
+	}
+	return &writeBreaker{threshold: threshold, cooldown: cooldown}
+}
+
+// allow reports whether a write may be attempted at now, moving an open
+// breaker to half-open once its cooldown has passed.
+func (b *writeBreaker) allow(now time.Time) bool {
+	if b == nil {
+		return true
+	}
+	if b.state == breakerOpen && now.Sub(b.openedAt) >= b.cooldown {
+		b.state = breakerHalfOpen
+	}
+	return b.state != breakerOpen
+}
+
+// record records the outcome of a write attempted at now. A success closes the
+// breaker; a failure opens it once threshold failures have happened in a row,
+// or at once while half-open.
+func (b *writeBreaker) record(err error, now time.Time) {
+	if b == nil {
+		return
+	}
+	if err == nil {
+		b.state = breakerClosed
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.state == breakerHalfOpen || b.failures >= b.threshold {
+		b.state = breakerOpen
+		b.openedAt = now
+	}
+}
+
+// isOpen reports whether writes are currently being skipped.
+func (b *writeBreaker) isOpen() bool {
+	return b != nil && b.state == breakerOpen
+}
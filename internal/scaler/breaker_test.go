@@ -0,0 +1,188 @@
+package scaler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/oulman/tfc-agent-autoscaler/internal/ecs"
+)
+
+func TestWriteBreakerTransitions(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	errWrite := errors.New("access denied")
+
+	type step struct {
+		at        time.Duration
+		err       error
+		wantAllow bool
+		wantState breakerState
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "opens after threshold failures",
+			steps: []step{
+				{at: 0, err: errWrite, wantAllow: true, wantState: breakerClosed},
+				{at: time.Second, err: errWrite, wantAllow: true, wantState: breakerClosed},
+				{at: 2 * time.Second, err: errWrite, wantAllow: true, wantState: breakerOpen},
+				{at: time.Minute, wantAllow: false, wantState: breakerOpen},
+			},
+		},
+		{
+			name: "success resets the failure count",
+			steps: []step{
+				{at: 0, err: errWrite, wantAllow: true, wantState: breakerClosed},
+				{at: time.Second, err: errWrite, wantAllow: true, wantState: breakerClosed},
+				{at: 2 * time.Second, wantAllow: true, wantState: breakerClosed},
+				{at: 3 * time.Second, err: errWrite, wantAllow: true, wantState: breakerClosed},
+				{at: 4 * time.Second, err: errWrite, wantAllow: true, wantState: breakerClosed},
+			},
+		},
+		{
+			name: "half-open success closes",
+			steps: []step{
+				{at: 0, err: errWrite, wantAllow: true, wantState: breakerClosed},
+				{at: time.Second, err: errWrite, wantAllow: true, wantState: breakerClosed},
+				{at: 2 * time.Second, err: errWrite, wantAllow: true, wantState: breakerOpen},
+				{at: 2*time.Second + 5*time.Minute, wantAllow: true, wantState: breakerClosed},
+				{at: 6 * time.Minute, err: errWrite, wantAllow: true, wantState: breakerClosed},
+			},
+		},
+		{
+			name: "half-open failure reopens",
+			steps: []step{
+				{at: 0, err: errWrite, wantAllow: true, wantState: breakerClosed},
+				{at: time.Second, err: errWrite, wantAllow: true, wantState: breakerClosed},
+				{at: 2 * time.Second, err: errWrite, wantAllow: true, wantState: breakerOpen},
+				{at: 6 * time.Minute, err: errWrite, wantAllow: true, wantState: breakerOpen},
+				{at: 10 * time.Minute, wantAllow: false, wantState: breakerOpen},
+				{at: 11 * time.Minute, wantAllow: true, wantState: breakerClosed},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newWriteBreaker(3, 5*time.Minute)
+			for i, st := range tt.steps {
+				now := start.Add(st.at)
+				allowed := b.allow(now)
+				if allowed != st.wantAllow {
+					t.Fatalf("step %d: allow = %v, want %v", i, allowed, st.wantAllow)
+				}
+				if allowed {
+					b.record(st.err, now)
+				}
+				if b.state != st.wantState {
+					t.Fatalf("step %d: state = %d, want %d", i, b.state, st.wantState)
+				}
+			}
+		})
+	}
+}
+
+func TestWriteBreakerHalfOpen(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	b := newWriteBreaker(1, time.Minute)
+	b.record(errors.New("boom"), start)
+
+	if b.allow(start.Add(30 * time.Second)) {
+		t.Fatal("allowed a write before the cooldown passed")
+	}
+	if !b.allow(start.Add(time.Minute)) {
+		t.Fatal("blocked the trial write after the cooldown")
+	}
+	if b.state != breakerHalfOpen {
+		t.Errorf("state = %d, want half-open", b.state)
+	}
+	if b.isOpen() {
+		t.Error("half-open breaker reported as open")
+	}
+}
+
+func TestWriteBreakerDisabled(t *testing.T) {
+	for _, threshold := range []int{0, -1} {
+		b := newWriteBreaker(threshold, time.Minute)
+		if b != nil {
+			t.Fatalf("newWriteBreaker(%d) = %v, want nil", threshold, b)
+		}
+		// A nil breaker must never block or panic.
+		b.record(errors.New("boom"), time.Now())
+		if !b.allow(time.Now()) || b.isOpen() {
+			t.Errorf("nil breaker blocked writes")
+		}
+	}
+}
+
+func TestReconcileECSWriteBreaker(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	now := start
+	setErr := errors.New("not authorized to perform ecs:UpdateService")
+	var setCalls int
+
+	ecsClient := &mockECS{
+		serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+			return ecs.ServiceStatus{Desired: 1, Running: 1}, nil
+		},
+		setDesiredFn: func(_ context.Context, _ int32) error {
+			setCalls++
+			return setErr
+		},
+	}
+	s := New("test",
+		&mockTFC{
+			agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+				return 1, 0, 1, nil
+			},
+			pendingRunsFn: func(_ context.Context) (int, error) {
+				return 3, nil
+			},
+		},
+		ecsClient,
+		0, 10, time.Second, time.Minute, slog.Default(),
+		WithECSWriteBreaker(2, 5*time.Minute),
+	)
+	s.now = func() time.Time { return now }
+	fm := &fakeMetrics{}
+	s.SetMetrics(fm)
+
+	// Two failed writes open the breaker.
+	for range 2 {
+		if err := s.Reconcile(context.Background()); !errors.Is(err, setErr) {
+			t.Fatalf("Reconcile error = %v, want %v", err, setErr)
+		}
+	}
+
+	// While open, reads continue but the write is skipped.
+	now = start.Add(time.Minute)
+	if err := s.Reconcile(context.Background()); !errors.Is(err, ErrECSWriteCircuitOpen) {
+		t.Fatalf("Reconcile error = %v, want ErrECSWriteCircuitOpen", err)
+	}
+	if setCalls != 2 {
+		t.Errorf("SetDesiredCount calls = %d, want 2", setCalls)
+	}
+	if fm.reconcileCalls != 3 {
+		t.Errorf("RecordReconcile calls = %d, want 3", fm.reconcileCalls)
+	}
+
+	// After the cooldown a successful trial write closes the breaker.
+	now = start.Add(6 * time.Minute)
+	setErr = nil
+	if err := s.Reconcile(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if setCalls != 3 {
+		t.Errorf("SetDesiredCount calls = %d, want 3", setCalls)
+	}
+
+	want := []bool{false, true, true, false}
+	if !slices.Equal(fm.writeCircuitOpen, want) {
+		t.Errorf("circuit open gauge = %v, want %v", fm.writeCircuitOpen, want)
+	}
+}
@@ -0,0 +1,60 @@
+package scaler
+
+import "sync"
+
+// Budget caps the combined desired count of several scalers, such as the
+// regular and spot services in dual-service mode, so together they stay
+// within an account-wide limit. Each scaler reports its current desired count
+// every reconcile and reserves capacity before scaling up; a nil *Budget
+// imposes no limit.
+type Budget struct {
+	mu       sync.Mutex
+	maxTotal int
+	desired  map[string]int32
+}
+
+// NewBudget returns a Budget allowing at most maxTotal agents across all
+// scalers sharing it. It returns nil, meaning unlimited, when maxTotal is not
+// positive.
+func NewBudget(maxTotal int) *Budget {
+	if maxTotal <= 0 {
+		return nil
+	}
+	return &Budget{
+		maxTotal: maxTotal,
+		desired:  make(map[string]int32),
+	}
+}
+
+// observe records the current desired count of the named scaler.
+func (b *Budget) observe(name string, desired int32) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.desired[name] = desired
+}
+
+// reserve caps a scale-up of the named scaler from current to target so the
+// sum of all desired counts stays within the budget, and records the result.
+// The returned count is never below current: a budget that is already
+// exceeded blocks further scale-up but does not force a scale-down.
+func (b *Budget) reserve(name string, current, target int32) int32 {
+	if b == nil {
+		return target
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var siblings int32
+	for other, desired := range b.desired {
+		if other != name {
+			siblings += desired
+		}
+	}
+
+	allowed := max(min(target, int32(b.maxTotal)-siblings), current)
+	b.desired[name] = allowed
+	return allowed
+}
@@ -0,0 +1,52 @@
+package scaler
+
+import "testing"
+
+func TestBudgetReserve(t *testing.T) {
+	tests := []struct {
+		name     string
+		maxTotal int
+		siblings map[string]int32
+		current  int32
+		target   int32
+		want     int32
+	}{
+		{name: "within budget", maxTotal: 10, siblings: map[string]int32{"spot": 3}, current: 2, target: 5, want: 5},
+		{name: "capped by sibling", maxTotal: 10, siblings: map[string]int32{"spot": 7}, current: 1, target: 6, want: 3},
+		{name: "exactly at budget", maxTotal: 10, siblings: map[string]int32{"spot": 4}, current: 2, target: 6, want: 6},
+		{name: "already exceeded keeps current", maxTotal: 10, siblings: map[string]int32{"spot": 9}, current: 3, target: 8, want: 3},
+		{name: "several siblings", maxTotal: 12, siblings: map[string]int32{"a": 4, "b": 5}, current: 0, target: 6, want: 3},
+		{name: "own previous count ignored", maxTotal: 10, siblings: map[string]int32{"regular": 9, "spot": 2}, current: 1, target: 9, want: 8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBudget(tt.maxTotal)
+			for name, desired := range tt.siblings {
+				b.observe(name, desired)
+			}
+
+			got := b.reserve("regular", tt.current, tt.target)
+			if got != tt.want {
+				t.Errorf("reserve = %d, want %d", got, tt.want)
+			}
+			if b.desired["regular"] != tt.want {
+				t.Errorf("recorded desired = %d, want %d", b.desired["regular"], tt.want)
+			}
+		})
+	}
+}
+
+func TestNewBudgetDisabled(t *testing.T) {
+	for _, maxTotal := range []int{0, -1} {
+		b := NewBudget(maxTotal)
+		if b != nil {
+			t.Fatalf("NewBudget(%d) = %v, want nil", maxTotal, b)
+		}
+		// A nil budget must not limit or panic.
+		b.observe("regular", 4)
+		if got := b.reserve("regular", 1, 50); got != 50 {
+			t.Errorf("nil budget reserve = %d, want 50", got)
+		}
+	}
+}
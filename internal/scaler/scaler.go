@@ -2,13 +2,20 @@
 package scaler
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"math"
+	"math/rand/v2"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/oulman/tfc-agent-autoscaler/internal/ecs"
+	"github.com/oulman/tfc-agent-autoscaler/internal/notify"
 	"github.com/oulman/tfc-agent-autoscaler/internal/tfc"
 )
 
@@ -19,12 +26,173 @@ type TFCClient interface {
 	GetAgentDetails(ctx context.Context) ([]tfc.AgentInfo, error)
 }
 
+// workspacePendingReporter is optionally implemented by TFCClients that can
+// break pending runs down per workspace.
+type workspacePendingReporter interface {
+	GetPendingRunsByWorkspace(ctx context.Context) ([]tfc.WorkspacePendingRuns, error)
+}
+
+// pendingTypeReporter is optionally implemented by TFCClients that can split
+// pending runs into plan and apply counts.
+type pendingTypeReporter interface {
+	GetPendingRunsByType(ctx context.Context) (tfc.PendingRunCounts, error)
+}
+
+// pendingBreakdownReporter is optionally implemented by TFCClients that can
+// report the pool-wide plan and apply counts alongside the service's own.
+type pendingBreakdownReporter interface {
+	GetPendingRunsBreakdown(ctx context.Context) (service tfc.PendingRunDetail, pool tfc.PendingRunCounts, err error)
+}
+
+// pendingDetailReporter is optionally implemented by TFCClients that can
+// split pending runs by run status.
+type pendingDetailReporter interface {
+	GetPendingRunsDetailed(ctx context.Context) (tfc.PendingRunDetail, error)
+}
+
+// agentStatusReporter is optionally implemented by TFCClients that can count
+// agents in every status, not only busy and idle.
+type agentStatusReporter interface {
+	GetAgentStatusCounts(ctx context.Context) (map[string]int, error)
+}
+
+// poolLimitReporter is optionally implemented by TFCClients that know the
+// maximum number of agents able to connect to the pool.
+type poolLimitReporter interface {
+	GetPoolLimit(ctx context.Context) (int, error)
+}
+
+// runConcurrencyReporter is optionally implemented by TFCClients that know
+// how many runs the organization executes at once. Agents beyond that limit
+// would sit idle.
+type runConcurrencyReporter interface {
+	GetRunConcurrency(ctx context.Context) (int, error)
+}
+
+// placementRecorder is optionally implemented by MetricsRecorders that track
+// the gap between the ECS desired and running counts.
+type placementRecorder interface {
+	RecordPlacementGap(gap int)
+	RecordPlacementStall()
+}
+
+// orphanRecorder is optionally implemented by MetricsRecorders that track
+// running ECS tasks without a registered TFC agent.
+type orphanRecorder interface {
+	RecordOrphanTasks(count int)
+	RecordOrphanTasksPersistent()
+}
+
+// maxClampRecorder is optionally implemented by MetricsRecorders that count
+// reconciles whose demand exceeded maxAgents.
+type maxClampRecorder interface {
+	RecordMaxClamp()
+}
+
+// desiredCountMismatchRecorder is optionally implemented by MetricsRecorders
+// that count scale updates whose applied desired count differed from the
+// requested one.
+type desiredCountMismatchRecorder interface {
+	RecordDesiredCountMismatch()
+}
+
+// cooldownRecorder is optionally implemented by MetricsRecorders that track
+// the time left until scale-down is allowed again.
+type cooldownRecorder interface {
+	RecordCooldownRemaining(seconds float64)
+}
+
+// cooldownHeldRecorder is optionally implemented by MetricsRecorders that
+// track how many agents a cooldown-blocked scale-down kept running.
+type cooldownHeldRecorder interface {
+	RecordCooldownHeldAgents(count int)
+}
+
+// outcomeRecorder is optionally implemented by MetricsRecorders that count
+// reconciles by outcome: scaled_up, scaled_down, no_change, cooldown_skip, or
+// error.
+type outcomeRecorder interface {
+	RecordReconcileOutcome(outcome string)
+}
+
+// deadbandRecorder is optionally implemented by MetricsRecorders that count
+// scaling changes skipped for falling within the scale deadband.
+type deadbandRecorder interface {
+	RecordWithinDeadband()
+}
+
+// desiredRecorder is optionally implemented by MetricsRecorders that track
+// how far guards and clamps move the applied desired count from the computed
+// one.
+type desiredRecorder interface {
+	RecordDesired(computed, applied int)
+}
+
+// deploymentLimitRecorder is optionally implemented by MetricsRecorders that
+// count scale-ups beyond what the service's deployment configuration runs at
+// once.
+type deploymentLimitRecorder interface {
+	RecordDeploymentLimitExceeded()
+}
+
+// spotInterruptionRecorder is optionally implemented by MetricsRecorders
+// that count Fargate Spot interruptions.
+type spotInterruptionRecorder interface {
+	RecordSpotInterruptions(count int)
+}
+
+// queueWaitRecorder is optionally implemented by MetricsRecorders that track
+// how long the longest-waiting pending run has been queued.
+type queueWaitRecorder interface {
+	RecordQueueWait(seconds float64)
+}
+
+// pendingTypeRecorder is optionally implemented by MetricsRecorders that track
+// the pool's pending plan and apply runs separately.
+type pendingTypeRecorder interface {
+	RecordPendingByType(plan, apply int)
+}
+
+// agentStatusRecorder is optionally implemented by MetricsRecorders that track
+// agents by status.
+type agentStatusRecorder interface {
+	RecordAgentsByStatus(counts map[string]int)
+}
+
+// otherAgentsRecorder is optionally implemented by MetricsRecorders that track
+// agents that are neither busy nor idle.
+type otherAgentsRecorder interface {
+	RecordOtherAgents(count int)
+}
+
+// writeCircuitRecorder is optionally implemented by MetricsRecorders that
+// track whether ECS writes are being skipped by the write circuit breaker.
+type writeCircuitRecorder interface {
+	RecordECSWriteCircuitOpen(open bool)
+}
+
+// interruptionReporter is optionally implemented by ECSClients that can list
+// tasks stopped by a Fargate Spot interruption.
+type interruptionReporter interface {
+	GetInterruptedTasks(ctx context.Context) ([]ecs.TaskInfo, error)
+}
+
+// maxLoggedWorkspaces caps how many workspaces are logged per scale-up.
+const maxLoggedWorkspaces = 5
+
 // ECSClient is the interface for managing the ECS service.
 type ECSClient interface {
-	GetServiceStatus(ctx context.Context) (desired, running int32, err error)
+	GetServiceStatus(ctx context.Context) (ecs.ServiceStatus, error)
 	SetDesiredCount(ctx context.Context, count int32) error
 	GetTaskIPs(ctx context.Context) ([]ecs.TaskInfo, error)
 	SetTaskProtection(ctx context.Context, taskArns []string, enabled bool, expiresInMinutes int32) error
+	StopTask(ctx context.Context, taskArn, reason string) error
+}
+
+// Notifier is told about each scaling action applied to the ECS service.
+// Notify must not block the reconcile loop.
+type Notifier interface {
+	Notify(ev notify.Event)
 }
 
 // MetricsRecorder records autoscaler metrics.
@@ -32,281 +200,2477 @@ type MetricsRecorder interface {
 	RecordReconcile(busy, idle, total, pending, desired, running int)
 	RecordReconcileResult(success bool)
 	RecordScaleEvent(direction string)
+	RecordDryRunScaleEvent(direction string)
 	RecordCooldownSkip()
 	RecordTaskProtectionError()
+	RecordReconcileDuration(seconds float64)
+	RecordReconcileTimestamp(t time.Time)
+	RecordScaleDownReason(reason string)
+	RecordScaleDownSkip(reason string)
 }
 
+// Reconcile outcomes reported via RecordReconcileOutcome.
+const (
+	// outcomeScaledUp: the desired count was raised.
+	outcomeScaledUp = "scaled_up"
+	// outcomeScaledDown: the desired count was lowered.
+	outcomeScaledDown = "scaled_down"
+	// outcomeNoChange: the desired count was left as it was, including
+	// scaling skipped by a guard other than cooldown, a pause, or a dry run.
+	outcomeNoChange = "no_change"
+	// outcomeCooldownSkip: a scale-down was skipped because of cooldown.
+	outcomeCooldownSkip = "cooldown_skip"
+	// outcomeError: the reconcile failed.
+	outcomeError = "error"
+)
+
+// Scale-down reasons reported via MetricsRecorder.RecordScaleDownReason.
+const (
+	// reasonNoWork: no pending runs and no busy agents.
+	reasonNoWork = "no_work"
+	// reasonReducedDemand: work remains but needs fewer agents.
+	reasonReducedDemand = "reduced_demand"
+	// reasonMinClamp: demand fell below minAgents, so the target is the minimum.
+	reasonMinClamp = "min_clamp"
+	// reasonIdleGuard: the scale-down was capped by the number of idle agents.
+	reasonIdleGuard = "idle_guard"
+	// reasonStepLimit: the scale-down was capped by maxScaleDownStep.
+	reasonStepLimit = "step_limit"
+	// reasonScaleDownFactor: only a fraction of the scale-down was applied.
+	reasonScaleDownFactor = "scale_down_factor"
+	// reasonBusyFloor: the scale-down was capped at busy agents plus warmIdle.
+	reasonBusyFloor = "busy_floor"
+	// reasonShutdownDrain: the service was drained to minAgents on shutdown.
+	reasonShutdownDrain = "shutdown_drain"
+	// reasonBoundsCorrection: the current desired count was outside the agent
+	// bounds, for example after a manual change, and was clamped into them.
+	reasonBoundsCorrection = "bounds_correction"
+)
+
+// Scale-down skip reasons reported via MetricsRecorder.RecordScaleDownSkip.
+const (
+	skipCooldown      = "cooldown"
+	skipIdleThreshold = "idle_threshold"
+	skipConverging    = "converging"
+)
+
 // Scaler orchestrates the autoscaling control loop.
 type Scaler struct {
-	name          string
-	tfc           TFCClient
-	ecs           ECSClient
-	minAgents     int
-	maxAgents     int
-	pollInterval  time.Duration
-	cooldown      time.Duration
+	name string
+	tfc  TFCClient
+	ecs  ECSClient
+
+	// settingsMu guards minAgents, maxAgents, pollInterval, and cooldown,
+	// which UpdateSettings may change while Run is active. Reconcile holds it
+	// for the whole cycle so each reconcile sees one consistent set.
+	settingsMu   sync.Mutex
+	minAgents    int
+	maxAgents    int
+	pollInterval time.Duration
+	cooldown     time.Duration
+
 	lastScaleTime time.Time
 	logger        *slog.Logger
 	ready         chan struct{}
 	readyOnce     sync.Once
 	metrics       MetricsRecorder
+
+	// maxScaleDownStep caps how many agents a single reconcile may remove.
+	// Zero means unlimited.
+	maxScaleDownStep int
+	// maxScaleUpStep caps how many agents a single reconcile may add.
+	// Zero means unlimited.
+	maxScaleUpStep int
+	// dryRun computes and logs scaling decisions without mutating ECS.
+	dryRun bool
+	// protectionExpiry is how long busy tasks stay scale-in protected.
+	protectionExpiry time.Duration
+	// taskProtectionDisabled skips scale-in protection, leaving the idle
+	// guard as the only safeguard for busy tasks.
+	taskProtectionDisabled bool
+	// idleGuardDisabled lets scale-down remove more agents than are idle,
+	// leaving task protection as the only safeguard for busy tasks.
+	idleGuardDisabled bool
+	// protectionUnsupported is set when the service's deployment controller
+	// does not support task scale-in protection, so protection is skipped.
+	// protectionUnsupportedWarned makes the warning a one-time event.
+	protectionUnsupported       bool
+	protectionUnsupportedWarned bool
+	// busyFloorDisabled lets scale-down go below the busy agent count plus
+	// warmIdle.
+	busyFloorDisabled bool
+	// warmIdle is the number of spare idle agents kept ahead of demand.
+	warmIdle int
+	// drainOnShutdown scales the service to minAgents when Run is canceled.
+	drainOnShutdown bool
+	// drainTimeout bounds the drain on shutdown.
+	drainTimeout time.Duration
+	// startupDelay is how long Run waits before its first reconcile.
+	startupDelay time.Duration
+	// runWeights scales pending runs by type; nil counts each run as one agent.
+	runWeights *runWeights
+	// statusWeights overrides the run weight for pending runs in specific
+	// statuses; nil weights runs by type only.
+	statusWeights map[string]float64
+	// pollJitter randomizes each poll interval by up to ±pollJitter of its
+	// length. Zero disables jitter.
+	pollJitter float64
+	// rand drives poll jitter. It is per-Scaler so tests can seed it.
+	rand *rand.Rand
+	// reconcileTimeout bounds each reconcile started by Run. Zero disables it.
+	reconcileTimeout time.Duration
+	// scaleDownIdleThreshold blocks scale-down until idle agents exceed it.
+	// Zero disables the threshold.
+	scaleDownIdleThreshold int
+	// reconcileTrigger requests an out-of-band reconcile from Run. Nil
+	// disables on-demand reconciles.
+	reconcileTrigger <-chan struct{}
+	// maxTaskAge marks idle tasks older than this for recycling. Zero
+	// disables age-based recycling.
+	maxTaskAge time.Duration
+	// protectNewestIdle keeps the most recently started idle task protected
+	// during scale-down so ECS stops older idle tasks first.
+	protectNewestIdle bool
+	// warnedPoolLimit is the last pool limit reported as below maxAgents, so
+	// the warning is logged once per limit rather than every reconcile.
+	warnedPoolLimit int
+	// warnedRunConcurrency is the last organization run concurrency
+	// reported as clamping maxAgents, logged once per limit.
+	warnedRunConcurrency int
+	// failureBackoffMax caps the doubled poll interval after consecutive
+	// reconcile failures. Zero disables the backoff.
+	failureBackoffMax time.Duration
+	// consecutiveFailures counts reconcile failures in Run since the last
+	// success.
+	consecutiveFailures int
+	// maxConsecutiveFailures makes Run return ErrTooManyFailures once this
+	// many reconciles in a row have failed. Zero never gives up.
+	maxConsecutiveFailures int
+	// readyAfterSuccesses is how many consecutive successful reconciles Run
+	// needs before the scaler is marked ready. Zero or one marks it ready on
+	// the first success.
+	readyAfterSuccesses int
+	// consecutiveSuccesses counts successful reconciles in Run since the last
+	// failure.
+	consecutiveSuccesses int
+	// placementStallThreshold is how many consecutive reconciles running must
+	// trail desired before each further one counts as a placement stall.
+	// Zero disables stall detection.
+	placementStallThreshold int
+	// placementStalls counts consecutive reconciles in which running trailed
+	// desired.
+	placementStalls int
+	// orphanTaskThreshold is how many consecutive reconciles running tasks
+	// may outnumber registered agents before each further one is reported
+	// as persistent. Zero disables the persistence counter.
+	orphanTaskThreshold int
+	// orphanStreak counts consecutive reconciles with orphan tasks.
+	orphanStreak int
+	// scaleDownFactor is the fraction of the computed scale-down applied per
+	// reconcile, rounded up. Zero or one applies all of it.
+	scaleDownFactor float64
+	// targetBusyRatio, when positive, switches to target tracking: enough
+	// agents that busy ones make up this fraction of them.
+	targetBusyRatio float64
+	// smoothingAlpha weights the latest pending demand in the exponentially
+	// weighted moving average. Zero or one disables smoothing.
+	smoothingAlpha float64
+	// smoothedDemand is the moving average of pending demand; seeded by the
+	// first reconcile once smoothedSeeded is set.
+	smoothedDemand float64
+	smoothedSeeded bool
+	// lastMaxClampWarning is when the max-clamp warning was last logged, so
+	// sustained under-provisioning is reported every maxClampWarnInterval
+	// rather than every reconcile.
+	lastMaxClampWarning time.Time
+	// stateMu guards state and cooldownUntil, the snapshot of the last
+	// reconcile returned by State.
+	stateMu       sync.Mutex
+	state         State
+	cooldownUntil time.Time
+	// businessHours raises the minimum agent count during a daily window.
+	// Nil disables it.
+	businessHours *BusinessHours
+	// now returns the current time for schedule evaluation. Tests replace it.
+	now func() time.Time
+	// budget caps scale-ups so the desired counts of all scalers sharing it
+	// stay within a combined maximum. Nil means no shared limit.
+	budget *Budget
+	// spotInterruptions enables detection of tasks lost to Fargate Spot
+	// interruptions.
+	spotInterruptions bool
+	// onSpotInterruption is called with the number of newly interrupted
+	// tasks. Nil disables compensation.
+	onSpotInterruption func(n int)
+	// seenInterruptions holds the ARNs of interrupted tasks ECS still lists,
+	// so each interruption is reported once. Nil until the first check.
+	seenInterruptions map[string]bool
+	// compensation is demand added by Compensate, consumed by the next
+	// reconcile that applies a scale-up or needs none.
+	compensation atomic.Int64
+	// maxQueueWait forces a scale-up once the longest-waiting pending run has
+	// been queued longer than this. Zero disables it.
+	maxQueueWait time.Duration
+	// scaleUpStabilization is how long demand must stay above the current
+	// desired count before scaling up. Zero scales up immediately.
+	scaleUpStabilization time.Duration
+	// scaleUpSince is when demand first exceeded the current desired count;
+	// zero while it does not.
+	scaleUpSince time.Time
+	// runPollInterval is how long a pending run count is reused before the
+	// runs are listed again. Zero lists them every reconcile.
+	runPollInterval time.Duration
+	// pendingCache is the last pending run count, reused until it is older
+	// than runPollInterval.
+	pendingCache pendingSnapshot
+	// scaleToZeroGrace is how long the pool must have had no busy agents and
+	// no demand before the last agent is removed. Zero removes it at once.
+	scaleToZeroGrace time.Duration
+	// idleSince is when the pool last became free of busy agents and demand;
+	// zero while it is not.
+	idleSince time.Time
+	// paused, while set, makes Reconcile record metrics but skip scaling.
+	// Nil means never paused.
+	paused *atomic.Bool
+	// writeBreaker skips ECS writes after repeated failures. Nil never skips.
+	writeBreaker *writeBreaker
+	// correlation is how agents are matched to their tasks.
+	correlation Correlation
+	// cooldownHeld is how many agents the current reconcile's scale-down
+	// would have removed had cooldown not blocked it.
+	cooldownHeld int
+	// notifier is told about each applied scaling action. Nil disables
+	// notifications.
+	notifier Notifier
+	// deadband is the largest change in desired count left unapplied, and
+	// deadbandPercent the same as a percentage of the current desired
+	// count. Zero disables each.
+	deadband        int
+	deadbandPercent float64
 }
 
-// New creates a new Scaler with the given name for logging disambiguation.
-func New(name string, tfc TFCClient, ecs ECSClient, minAgents, maxAgents int, pollInterval, cooldown time.Duration, logger *slog.Logger) *Scaler {
-	return &Scaler{
-		name:         name,
-		tfc:          tfc,
-		ecs:          ecs,
-		minAgents:    minAgents,
-		maxAgents:    maxAgents,
-		pollInterval: pollInterval,
-		cooldown:     cooldown,
-		logger:       logger,
-		ready:        make(chan struct{}),
-	}
+// pendingSnapshot is a pending run count and when it was fetched.
+type pendingSnapshot struct {
+	pending, demand int
+	oldest          time.Time
+	fetchedAt       time.Time
 }
 
-// SetMetrics configures an optional metrics recorder.
-func (s *Scaler) SetMetrics(m MetricsRecorder) {
-	s.metrics = m
+// runWeights is the number of agents reserved per pending run of each type.
+type runWeights struct {
+	plan  float64
+	apply float64
 }
 
-// Ready returns a channel that is closed after the first successful reconcile.
-func (s *Scaler) Ready() <-chan struct{} {
-	return s.ready
+// defaultProtectionExpiry is used when no task protection expiry is configured.
+const defaultProtectionExpiry = 120 * time.Minute
+
+// defaultDrainTimeout bounds the final scale-down performed on shutdown when
+// no drain timeout is configured.
+const defaultDrainTimeout = 30 * time.Second
+
+// defaultReconcileTimeout bounds each reconcile when no timeout is configured.
+const defaultReconcileTimeout = 30 * time.Second
+
+// defaultFailureBackoffMax caps the poll interval after repeated reconcile
+// failures when no cap is configured.
+const defaultFailureBackoffMax = 5 * time.Minute
+
+// maxClampWarnInterval is the minimum time between warnings that demand
+// exceeds maxAgents.
+const maxClampWarnInterval = 5 * time.Minute
+
+// failureBackoffJitter is the minimum jitter applied to backed-off intervals
+// so scalers failing together do not retry in lockstep.
+const failureBackoffJitter = 0.2
+
+// ErrTooManyFailures is returned by Run when the number of consecutive
+// reconcile failures reaches WithMaxConsecutiveFailures.
+var ErrTooManyFailures = errors.New("too many consecutive reconcile failures")
+
+// Option configures optional behavior for Scaler.
+type Option func(*Scaler)
+
+// Correlation selects how TFC agents are matched to the ECS tasks running
+// them for task protection and recycling.
+type Correlation int
+
+const (
+	// CorrelateByIP matches an agent to the task with its IP, falling back to
+	// the agent name while the task has not reported an IP.
+	CorrelateByIP Correlation = iota
+	// CorrelateByName matches an agent to the task whose ID, taken from the
+	// task ARN, is the agent name (or its "-<task ID>" suffix), or whose
+	// agent name tag equals it. IPs are ignored.
+	CorrelateByName
+)
+
+// WithMaxScaleDownStep limits how many agents can be removed in a single
+// reconcile. Zero (the default) means unlimited.
+func WithMaxScaleDownStep(n int) Option {
+	return func(s *Scaler) {
+		s.maxScaleDownStep = n
+	}
 }
 
-// Run starts the polling loop and blocks until the context is canceled.
-func (s *Scaler) Run(ctx context.Context) error {
-	s.logger.Info("starting autoscaler",
-		"scaler", s.name,
-		"min_agents", s.minAgents,
-		"max_agents", s.maxAgents,
-		"poll_interval", s.pollInterval,
-		"cooldown", s.cooldown,
-	)
+// WithMaxScaleUpStep limits how many agents can be added in a single
+// reconcile. Zero (the default) means unlimited. The limit never prevents
+// reaching minAgents in one step.
+func WithMaxScaleUpStep(n int) Option {
+	return func(s *Scaler) {
+		s.maxScaleUpStep = n
+	}
+}
 
-	ticker := time.NewTicker(s.pollInterval)
-	defer ticker.Stop()
+// WithDryRun makes Reconcile log intended scaling actions without calling
+// SetDesiredCount or SetTaskProtection.
+func WithDryRun(enabled bool) Option {
+	return func(s *Scaler) {
+		s.dryRun = enabled
+	}
+}
 
-	// Run immediately on start, then on each tick.
-	if err := s.Reconcile(ctx); err != nil {
-		s.logger.Error("reconcile failed", "scaler", s.name, "error", err)
-	} else {
-		s.markReady()
+// WithTaskProtectionExpiry sets how long busy tasks remain scale-in
+// protected. It is passed to ECS in whole minutes.
+func WithTaskProtectionExpiry(d time.Duration) Option {
+	return func(s *Scaler) {
+		s.protectionExpiry = d
 	}
+}
 
-	for {
-		select {
-		case <-ctx.Done():
-			s.logger.Info("shutting down autoscaler", "scaler", s.name)
-			return ctx.Err()
-		case <-ticker.C:
-			if err := s.Reconcile(ctx); err != nil {
-				s.logger.Error("reconcile failed", "scaler", s.name, "error", err)
-			} else {
-				s.markReady()
-			}
-		}
+// WithTaskProtection enables or disables ECS task scale-in protection
+// before scale-down. It is enabled by default; disable it when the task role
+// lacks ecs:UpdateTaskProtection so every scale-down does not log a failure.
+// The idle guard still caps scale-down at the number of idle agents.
+func WithTaskProtection(enabled bool) Option {
+	return func(s *Scaler) {
+		s.taskProtectionDisabled = !enabled
 	}
 }
 
-// Reconcile performs a single check-and-scale cycle.
-func (s *Scaler) Reconcile(ctx context.Context) error {
-	busy, idle, total, err := s.tfc.GetAgentPoolStatus(ctx)
-	if err != nil {
-		s.recordResult(false)
-		return fmt.Errorf("getting agent pool status: %w", err)
+// WithCorrelation sets how agents are matched to the ECS tasks running them.
+// The default, CorrelateByIP, suits tasks with one ENI each; CorrelateByName
+// suits agents started with TFC_AGENT_NAME set to the task ID, where IPs may
+// be reused or shared.
+func WithCorrelation(c Correlation) Option {
+	return func(s *Scaler) {
+		s.correlation = c
 	}
+}
 
-	pendingRuns, err := s.tfc.GetPendingRuns(ctx)
-	if err != nil {
-		s.recordResult(false)
-		return fmt.Errorf("getting pending runs: %w", err)
+// WithIdleGuard enables or disables the idle guard, which caps each
+// scale-down at the number of idle agents. It is enabled by default; disabled,
+// scale-down goes straight to the computed count and relies on task protection
+// to keep ECS from stopping busy tasks. If setting protection fails, that
+// scale-down falls back to the idle guard.
+func WithIdleGuard(enabled bool) Option {
+	return func(s *Scaler) {
+		s.idleGuardDisabled = !enabled
 	}
+}
 
-	currentDesired, currentRunning, err := s.ecs.GetServiceStatus(ctx)
-	if err != nil {
-		s.recordResult(false)
-		return fmt.Errorf("getting ECS service status: %w", err)
+// WithBusyFloor enables or disables the busy floor, which never lets a
+// scale-down go below the latest busy agent count plus warmIdle, even when a
+// stale idle count or a lowered maximum would allow it. It is enabled by
+// default and independent of the idle guard.
+func WithBusyFloor(enabled bool) Option {
+	return func(s *Scaler) {
+		s.busyFloorDisabled = !enabled
 	}
+}
 
-	if s.metrics != nil {
-		s.metrics.RecordReconcile(busy, idle, total, pendingRuns, int(currentDesired), int(currentRunning))
+// WithWarmIdle keeps n idle agents running on top of pending and busy work so
+// new runs avoid task cold starts. The target is still clamped to maxAgents.
+func WithWarmIdle(n int) Option {
+	return func(s *Scaler) {
+		s.warmIdle = n
 	}
+}
 
-	desired := computeDesired(pendingRuns, busy, s.minAgents, s.maxAgents)
-	desiredInt32 := int32(desired)
+// WithDrainOnShutdown makes Run scale the service down to minAgents after its
+// context is canceled. Busy tasks are scale-in protected first so ECS only
+// stops idle agents; without task protection, or when protecting them fails,
+// the drain stops at the number of agents that may be running a job.
+func WithDrainOnShutdown(enabled bool) Option {
+	return func(s *Scaler) {
+		s.drainOnShutdown = enabled
+	}
+}
 
-	s.logger.Info("reconcile",
-		"scaler", s.name,
-		"pending_runs", pendingRuns,
-		"busy_agents", busy,
-		"idle_agents", idle,
-		"total_agents", total,
-		"current_desired", currentDesired,
-		"current_running", currentRunning,
-		"computed_desired", desired,
-	)
+// WithDrainTimeout bounds the drain on shutdown enabled by
+// WithDrainOnShutdown. Zero keeps the default of 30 seconds.
+func WithDrainTimeout(d time.Duration) Option {
+	return func(s *Scaler) {
+		if d > 0 {
+			s.drainTimeout = d
+		}
+	}
+}
 
-	if desiredInt32 == currentDesired {
-		s.recordResult(true)
-		return nil
+// WithStartupDelay makes Run wait d before its first reconcile, so the ECS
+// service can stabilize and its tasks register as agents first. Ready is
+// still only closed after a successful reconcile.
+func WithStartupDelay(d time.Duration) Option {
+	return func(s *Scaler) {
+		s.startupDelay = d
 	}
+}
 
-	// Scale-up always proceeds immediately. Scale-down respects cooldown and idle guard.
-	if desiredInt32 < currentDesired {
-		adjusted, done := s.applyScaleDownGuards(ctx, desired, idle, currentDesired)
-		if done {
-			return nil
+// WithRunWeights sets how many agents each pending plan and apply run
+// reserves. Weighted demand is rounded up. Weights of 1 (the default) count
+// every pending run as one agent.
+func WithRunWeights(plan, apply float64) Option {
+	return func(s *Scaler) {
+		if plan == 1 && apply == 1 {
+			s.runWeights = nil
+			return
 		}
-		desiredInt32 = adjusted
+		s.runWeights = &runWeights{plan: plan, apply: apply}
 	}
+}
 
-	direction := "up"
-	if desiredInt32 < currentDesired {
-		direction = "down"
+// WithRunStatusWeights sets how many agents each pending run in the given
+// statuses reserves, overriding WithRunWeights for those statuses, e.g. to
+// weight plan_queued runs, which are waiting for an agent, above pending runs
+// that are not ready to start. It requires a TFC client that reports pending
+// runs by status; New logs a warning and ignores the weights otherwise. An
+// empty map disables it.
+func WithRunStatusWeights(weights map[string]float64) Option {
+	return func(s *Scaler) {
+		if len(weights) == 0 {
+			s.statusWeights = nil
+			return
+		}
+		s.statusWeights = weights
 	}
+}
 
-	s.logger.Info("scaling",
-		"scaler", s.name,
-		"from", currentDesired,
-		"to", desiredInt32,
-	)
+// WithPollJitter randomizes each poll interval by up to ±fraction of its
+// length (e.g. 0.1 for ±10%) so multiple autoscalers spread their TFC API
+// calls. The first reconcile in Run still happens immediately.
+func WithPollJitter(fraction float64) Option {
+	return func(s *Scaler) {
+		s.pollJitter = fraction
+	}
+}
 
-	if err := s.ecs.SetDesiredCount(ctx, desiredInt32); err != nil {
-		s.recordResult(false)
-		return fmt.Errorf("setting desired count: %w", err)
+// WithReconcileTimeout bounds each reconcile started by Run so a hung TFC or
+// ECS call fails the cycle instead of stalling the loop. Zero disables it.
+func WithReconcileTimeout(d time.Duration) Option {
+	return func(s *Scaler) {
+		s.reconcileTimeout = d
 	}
+}
 
-	if s.metrics != nil {
-		s.metrics.RecordScaleEvent(direction)
+// WithScaleDownIdleThreshold only allows scale-down when more than n agents
+// are idle, leaving a cushion for runs that arrive in bursts. Unlike
+// WithWarmIdle it controls when scale-down triggers, not the target count.
+func WithScaleDownIdleThreshold(n int) Option {
+	return func(s *Scaler) {
+		s.scaleDownIdleThreshold = n
 	}
+}
 
-	s.lastScaleTime = time.Now()
-	s.recordResult(true)
-	return nil
+// WithScaleDownFactor removes only fraction (0 < fraction <= 1) of the
+// computed scale-down per reconcile, rounded up so the target is still
+// reached over successive reconciles. It applies before the idle guard and
+// step limit. Zero or one removes the whole amount at once.
+func WithScaleDownFactor(fraction float64) Option {
+	return func(s *Scaler) {
+		s.scaleDownFactor = fraction
+	}
 }
 
-// applyScaleDownGuards checks cooldown and idle guard before scaling down.
-// It returns the adjusted desired count and true if scaling should be skipped entirely.
-func (s *Scaler) applyScaleDownGuards(ctx context.Context, desired, idle int, currentDesired int32) (int32, bool) {
-	if !s.lastScaleTime.IsZero() && time.Since(s.lastScaleTime) < s.cooldown {
-		s.logger.Info("scale-down skipped due to cooldown",
-			"scaler", s.name,
-			"last_scale", s.lastScaleTime,
-			"cooldown_remaining", s.cooldown-time.Since(s.lastScaleTime),
-		)
-		if s.metrics != nil {
-			s.metrics.RecordCooldownSkip()
-		}
-		s.recordResult(true)
-		return 0, true
+// WithTargetBusyRatio replaces the additive formula with target tracking:
+// the desired count keeps busy agents at ratio (0 < ratio <= 1) of the pool,
+// rounded up, plus pending demand as headroom. WARM_IDLE still sets a floor on
+// idle agents. Zero keeps the additive formula.
+func WithTargetBusyRatio(ratio float64) Option {
+	return func(s *Scaler) {
+		s.targetBusyRatio = ratio
 	}
+}
 
-	// Idle guard: never scale down by more than the number of idle agents.
-	scaleDownBy := int(currentDesired) - desired
-	if idle < scaleDownBy {
-		scaleDownBy = idle
+// WithSmoothingAlpha feeds an exponentially weighted moving average of
+// pending demand into the desired count, weighting the latest reconcile by
+// alpha (0 < alpha <= 1). The larger of the raw and smoothed demand is used,
+// so scale-up still reacts immediately while short-lived dips in pending runs
+// no longer trigger a scale-down. Zero or one disables smoothing.
+func WithSmoothingAlpha(alpha float64) Option {
+	return func(s *Scaler) {
+		s.smoothingAlpha = alpha
 	}
-	adjusted := currentDesired - int32(scaleDownBy)
+}
 
-	s.logger.Info("idle guard applied",
-		"scaler", s.name,
-		"computed_desired", desired,
-		"idle_agents", idle,
-		"scale_down_by", scaleDownBy,
-		"guarded_desired", adjusted,
-	)
+// WithBusinessHours raises the minimum agent count to hours.MinAgents while
+// hours is active. Outside the window the configured minimum applies.
+func WithBusinessHours(hours *BusinessHours) Option {
+	return func(s *Scaler) {
+		s.businessHours = hours
+	}
+}
 
-	if adjusted == currentDesired {
-		s.recordResult(true)
-		return 0, true
+// WithReconcileTrigger makes Run reconcile immediately whenever a value is
+// received on trigger, in addition to the regular poll interval.
+func WithReconcileTrigger(trigger <-chan struct{}) Option {
+	return func(s *Scaler) {
+		s.reconcileTrigger = trigger
 	}
+}
 
-	// Task protection: protect busy tasks before scaling down.
-	if err := s.protectBusyTasks(ctx); err != nil {
-		s.logger.Warn("task protection failed, proceeding with idle-guarded scale-down",
-			"scaler", s.name,
-			"error", err,
-		)
-		if s.metrics != nil {
-			s.metrics.RecordTaskProtectionError()
-		}
+// WithMaxTaskAge recycles idle tasks that have been running longer than age.
+// Expired idle tasks are removed first when scaling down, and when the
+// desired count is unchanged the oldest expired idle task is stopped so ECS
+// replaces it. Zero disables recycling.
+func WithMaxTaskAge(age time.Duration) Option {
+	return func(s *Scaler) {
+		s.maxTaskAge = age
 	}
+}
 
-	return adjusted, false
+// WithProtectNewestIdle keeps the most recently started idle task scale-in
+// protected during scale-down, so ECS stops older idle tasks first and the
+// agent that just paid its cold start is kept. It has no effect when task
+// protection is disabled, and expired tasks under WithMaxTaskAge are still
+// removed oldest first.
+func WithProtectNewestIdle(enabled bool) Option {
+	return func(s *Scaler) {
+		s.protectNewestIdle = enabled
+	}
 }
 
-// protectBusyTasks correlates TFC agents with ECS tasks by IP and sets
-// scale-in protection on busy tasks while removing it from idle ones.
-func (s *Scaler) protectBusyTasks(ctx context.Context) error {
-	agents, err := s.tfc.GetAgentDetails(ctx)
-	if err != nil {
-		return fmt.Errorf("getting agent details: %w", err)
+// WithFailureBackoffMax sets the cap for Run's poll interval, which doubles
+// after each consecutive reconcile failure and resets on the first success.
+// Zero disables the backoff.
+func WithFailureBackoffMax(d time.Duration) Option {
+	return func(s *Scaler) {
+		s.failureBackoffMax = d
 	}
+}
 
-	tasks, err := s.ecs.GetTaskIPs(ctx)
-	if err != nil {
-		return fmt.Errorf("getting task IPs: %w", err)
+// WithReadyAfterSuccesses delays readiness until n consecutive reconciles in
+// Run have succeeded, so a flapping dependency does not report ready after a
+// single lucky reconcile. Any failure restarts the count. Once ready, the
+// scaler stays ready.
+func WithReadyAfterSuccesses(n int) Option {
+	return func(s *Scaler) {
+		s.readyAfterSuccesses = n
 	}
+}
 
-	// Build IP → task ARN map.
-	ipToArn := make(map[string]string, len(tasks))
-	for _, t := range tasks {
-		if t.PrivateIP != "" {
-			ipToArn[t.PrivateIP] = t.TaskArn
-		}
+// WithMaxConsecutiveFailures makes Run return an error wrapping
+// ErrTooManyFailures once n reconciles in a row have failed, so a supervisor
+// can restart the process instead of it retrying forever while not ready.
+// Zero, the default, never gives up.
+func WithMaxConsecutiveFailures(n int) Option {
+	return func(s *Scaler) {
+		s.maxConsecutiveFailures = n
 	}
+}
 
-	var busyArns, idleArns []string
-	for _, agent := range agents {
-		arn, ok := ipToArn[agent.IP]
-		if !ok {
-			continue
-		}
-		if agent.Status == "busy" {
-			busyArns = append(busyArns, arn)
-		} else {
-			idleArns = append(idleArns, arn)
-		}
+// WithPlacementStallThreshold reports a placement stall for every reconcile
+// once the ECS running count has trailed the desired count for n consecutive
+// reconciles, which usually means ECS cannot place tasks. Zero disables stall
+// detection.
+func WithPlacementStallThreshold(n int) Option {
+	return func(s *Scaler) {
+		s.placementStallThreshold = n
 	}
+}
 
-	if len(busyArns) > 0 {
-		if err := s.ecs.SetTaskProtection(ctx, busyArns, true, 120); err != nil {
-			return fmt.Errorf("protecting busy tasks: %w", err)
-		}
+// WithBudget makes the scaler share budget with other scalers: it reports
+// its desired count each reconcile and caps scale-ups so the combined desired
+// count stays within the budget. A nil budget disables the shared limit.
+func WithBudget(budget *Budget) Option {
+	return func(s *Scaler) {
+		s.budget = budget
 	}
+}
 
-	if len(idleArns) > 0 {
-		if err := s.ecs.SetTaskProtection(ctx, idleArns, false, 0); err != nil {
-			return fmt.Errorf("unprotecting idle tasks: %w", err)
-		}
+// WithPause shares a pause flag with the scaler. While it is set, Reconcile
+// still reads TFC and ECS and records metrics but makes no changes: it does
+// not scale, recycle tasks, or drain on shutdown. Several scalers may share
+// one flag.
+func WithPause(paused *atomic.Bool) Option {
+	return func(s *Scaler) {
+		s.paused = paused
 	}
+}
 
-	s.logger.Info("task protection updated",
-		"scaler", s.name,
-		"busy_protected", len(busyArns),
-		"idle_unprotected", len(idleArns),
-	)
+// WithOrphanTaskThreshold reports orphan tasks as persistent for every
+// reconcile once running ECS tasks have outnumbered registered TFC agents for
+// n consecutive reconciles, which usually means agents cannot register (bad
+// token, no network path to TFC). Zero disables the report.
+func WithOrphanTaskThreshold(n int) Option {
+	return func(s *Scaler) {
+		s.orphanTaskThreshold = n
+	}
+}
 
-	return nil
+// WithSpotInterruptions makes the scaler watch its ECS service for tasks
+// stopped by a Fargate Spot interruption. Interrupted tasks that are still
+// shutting down are not counted as running capacity, each interruption is
+// counted once, and onInterrupt, when non-nil, is called with the number of
+// new interruptions so another service can compensate. It has no effect when
+// the ECS client cannot list interrupted tasks.
+func WithSpotInterruptions(onInterrupt func(n int)) Option {
+	return func(s *Scaler) {
+		s.spotInterruptions = true
+		s.onSpotInterruption = onInterrupt
+	}
 }
 
-func (s *Scaler) recordResult(success bool) {
-	if s.metrics != nil {
-		s.metrics.RecordReconcileResult(success)
+// WithMaxQueueWait forces a scale-up of one agent per reconcile once the
+// longest-waiting pending run has been queued longer than d, even when the
+// pending count alone would not add capacity. No agent is forced while tasks
+// from an earlier scale-up are still starting. It requires a TFC client that
+// reports pending runs by type; zero disables it.
+func WithMaxQueueWait(d time.Duration) Option {
+	return func(s *Scaler) {
+		s.maxQueueWait = d
 	}
 }
 
-func (s *Scaler) markReady() {
-	s.readyOnce.Do(func() { close(s.ready) })
+// WithScaleUpStabilization delays scale-up until demand has exceeded the
+// current desired count for d across reconciles, so a one-off spike in pending
+// runs does not add capacity. A run queued longer than WithMaxQueueWait
+// bypasses the window. Zero disables it.
+func WithScaleUpStabilization(d time.Duration) Option {
+	return func(s *Scaler) {
+		s.scaleUpStabilization = d
+	}
 }
 
-// computeDesired calculates the target agent count.
-// Formula: desired = max(min, min(pendingRuns + busyAgents, max))
-func computeDesired(pendingRuns, busyAgents, minAgents, maxAgents int) int {
-	desired := pendingRuns + busyAgents
-	return max(minAgents, min(desired, maxAgents))
+// WithRunPollInterval lists pending runs at most once per d, reusing the last
+// count in the reconciles between. Agent status is still read every
+// reconcile. Zero lists runs every reconcile.
+func WithRunPollInterval(d time.Duration) Option {
+	return func(s *Scaler) {
+		s.runPollInterval = d
+	}
+}
+
+// WithScaleToZeroGrace keeps one agent running until the pool has had no busy
+// agents and no pending demand for d, so a brief gap between runs does not
+// force a cold start. It only applies while the minimum is zero. Zero scales
+// to zero as soon as the other guards allow.
+func WithScaleToZeroGrace(d time.Duration) Option {
+	return func(s *Scaler) {
+		s.scaleToZeroGrace = d
+	}
+}
+
+// WithECSWriteBreaker skips ECS writes (desired count, task protection, and
+// task stops) for cooldown once threshold of them in a row have failed, so a
+// persistent error such as a revoked IAM permission is not retried every
+// reconcile. Reads continue, and a reconcile whose write is skipped fails with
+// ErrECSWriteCircuitOpen. After the cooldown the next write is tried: success
+// resumes writes, failure skips them for another cooldown. Zero disables the
+// breaker.
+func WithECSWriteBreaker(threshold int, cooldown time.Duration) Option {
+	return func(s *Scaler) {
+		s.writeBreaker = newWriteBreaker(threshold, cooldown)
+	}
+}
+
+// WithNotifier tells n about each scaling action applied to the ECS service,
+// including the drain on shutdown. Dry-run and paused decisions are not
+// notified.
+func WithNotifier(n Notifier) Option {
+	return func(s *Scaler) {
+		s.notifier = n
+	}
+}
+
+// WithScaleDeadband skips scaling while the computed desired count differs
+// from the current one by no more than count agents, or, when percent is
+// positive, by no more than percent of the current desired count, trading
+// exact sizing for fewer UpdateService calls and less task churn. A change to
+// exactly the minimum or maximum, and a bounds correction, is always applied.
+// Zero disables it.
+func WithScaleDeadband(count int, percent float64) Option {
+	return func(s *Scaler) {
+		s.deadband = count
+		s.deadbandPercent = percent
+	}
+}
+
+// New creates a new Scaler with the given name for logging disambiguation.
+func New(name string, tfc TFCClient, ecs ECSClient, minAgents, maxAgents int, pollInterval, cooldown time.Duration, logger *slog.Logger, opts ...Option) *Scaler {
+	s := &Scaler{
+		name:         name,
+		tfc:          tfc,
+		ecs:          ecs,
+		minAgents:    minAgents,
+		maxAgents:    maxAgents,
+		pollInterval: pollInterval,
+		cooldown:     cooldown,
+		logger:       logger,
+		ready:        make(chan struct{}),
+
+		protectionExpiry:  defaultProtectionExpiry,
+		reconcileTimeout:  defaultReconcileTimeout,
+		drainTimeout:      defaultDrainTimeout,
+		failureBackoffMax: defaultFailureBackoffMax,
+		rand:              rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64())),
+		now:               time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if s.statusWeights != nil && !reportsPendingByStatus(tfc) {
+		logger.Warn("TFC client does not report pending runs by status, ignoring run status weights", "scaler", name)
+	}
+
+	return s
+}
+
+// reportsPendingByStatus reports whether client can split pending runs by run
+// status, which WithRunStatusWeights needs.
+func reportsPendingByStatus(client TFCClient) bool {
+	if _, ok := client.(pendingBreakdownReporter); ok {
+		return true
+	}
+	_, ok := client.(pendingDetailReporter)
+	return ok
+}
+
+// trackPlacement records the gap between the desired and running counts and
+// counts consecutive reconciles in which tasks have not been placed. Once the
+// streak reaches placementStallThreshold, every further stalled reconcile is
+// reported as a stall so the counter keeps rising until ECS catches up.
+func (s *Scaler) trackPlacement(desired, running int32) {
+	gap := int(desired - running)
+	if gap > 0 {
+		s.placementStalls++
+	} else {
+		s.placementStalls = 0
+	}
+
+	recorder, ok := s.metrics.(placementRecorder)
+	if ok {
+		recorder.RecordPlacementGap(gap)
+	}
+
+	if s.placementStallThreshold <= 0 || s.placementStalls < s.placementStallThreshold {
+		return
+	}
+	if s.placementStalls == s.placementStallThreshold {
+		s.logger.Warn("ECS tasks are not being placed",
+			"scaler", s.name,
+			"desired", desired,
+			"running", running,
+			"reconciles", s.placementStalls,
+		)
+	}
+	if ok {
+		recorder.RecordPlacementStall()
+	}
+}
+
+// State is a snapshot of what the scaler saw and computed in its last
+// reconcile, for debugging.
+type State struct {
+	LastReconcile            time.Time `json:"last_reconcile,omitzero"`
+	ComputedDesired          int       `json:"computed_desired"`
+	CurrentDesired           int32     `json:"current_desired"`
+	CurrentRunning           int32     `json:"current_running"`
+	PendingRuns              int       `json:"pending_runs"`
+	BusyAgents               int       `json:"busy_agents"`
+	IdleAgents               int       `json:"idle_agents"`
+	TotalAgents              int       `json:"total_agents"`
+	CooldownRemainingSeconds float64   `json:"cooldown_remaining_seconds"`
+	Paused                   bool      `json:"paused"`
+}
+
+// State returns the snapshot of the last reconcile that got as far as
+// computing a desired count. It is the zero State before then. It is safe to
+// call while Run is active.
+func (s *Scaler) State() State {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+
+	state := s.state
+	if remaining := time.Until(s.cooldownUntil); remaining > 0 {
+		state.CooldownRemainingSeconds = remaining.Seconds()
+	}
+	return state
+}
+
+// saveState stores state as the latest snapshot along with the end of the
+// current scale-down cooldown. The caller must hold settingsMu.
+func (s *Scaler) saveState(state State) {
+	var cooldownUntil time.Time
+	if !s.lastScaleTime.IsZero() {
+		cooldownUntil = s.lastScaleTime.Add(s.cooldown)
+	}
+
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	s.state = state
+	s.cooldownUntil = cooldownUntil
+}
+
+// cooldownRemaining returns how long scale-down stays blocked by the
+// cooldown, or zero when no scale has happened or the cooldown has passed.
+func (s *Scaler) cooldownRemaining() time.Duration {
+	if s.lastScaleTime.IsZero() {
+		return 0
+	}
+	return max(s.cooldown-time.Since(s.lastScaleTime), 0)
+}
+
+// recordCooldownRemaining reports cooldownRemaining to the metrics recorder.
+func (s *Scaler) recordCooldownRemaining() {
+	if recorder, ok := s.metrics.(cooldownRecorder); ok {
+		recorder.RecordCooldownRemaining(s.cooldownRemaining().Seconds())
+	}
+}
+
+// recordCooldownHeld reports the agents held by a cooldown-blocked
+// scale-down in this reconcile, zero when none was blocked.
+func (s *Scaler) recordCooldownHeld() {
+	if recorder, ok := s.metrics.(cooldownHeldRecorder); ok {
+		recorder.RecordCooldownHeldAgents(s.cooldownHeld)
+	}
+}
+
+// trackOrphans records how many running tasks have no registered agent and
+// counts consecutive reconciles with orphans. Once the streak reaches
+// orphanTaskThreshold, every further such reconcile is reported as
+// persistent.
+func (s *Scaler) trackOrphans(running int32, totalAgents int) {
+	orphans := max(int(running)-totalAgents, 0)
+	if orphans > 0 {
+		s.orphanStreak++
+	} else {
+		s.orphanStreak = 0
+	}
+
+	recorder, ok := s.metrics.(orphanRecorder)
+	if ok {
+		recorder.RecordOrphanTasks(orphans)
+	}
+
+	if s.orphanTaskThreshold <= 0 || s.orphanStreak < s.orphanTaskThreshold {
+		return
+	}
+	if s.orphanStreak == s.orphanTaskThreshold {
+		s.logger.Warn("ECS tasks are running without registered TFC agents",
+			"scaler", s.name,
+			"running", running,
+			"total_agents", totalAgents,
+			"reconciles", s.orphanStreak,
+		)
+	}
+	if ok {
+		recorder.RecordOrphanTasksPersistent()
+	}
+}
+
+// checkSpotInterruptions reports tasks newly stopped by a Fargate Spot
+// interruption and returns how many interrupted tasks are still running. On
+// the first check, interruptions that had already finished stopping are
+// remembered without being reported, since they predate the scaler. Failures
+// are logged and treated as no interruptions.
+func (s *Scaler) checkSpotInterruptions(ctx context.Context) int32 {
+	reporter, ok := s.ecs.(interruptionReporter)
+	if !s.spotInterruptions || !ok {
+		return 0
+	}
+
+	tasks, err := reporter.GetInterruptedTasks(ctx)
+	if err != nil {
+		s.logger.Warn("failed to check for spot interruptions", "scaler", s.name, "error", err)
+		return 0
+	}
+
+	firstCheck := s.seenInterruptions == nil
+	seen := make(map[string]bool, len(tasks))
+	var interrupted int
+	var stillRunning int32
+	for _, task := range tasks {
+		seen[task.TaskArn] = true
+		if task.Running() {
+			stillRunning++
+		}
+		if !s.seenInterruptions[task.TaskArn] && (!firstCheck || task.Running()) {
+			interrupted++
+		}
+	}
+	// Tasks ECS no longer lists are dropped so the set stays bounded.
+	s.seenInterruptions = seen
+
+	if interrupted == 0 {
+		return stillRunning
+	}
+	s.logger.Warn("spot tasks interrupted",
+		"scaler", s.name,
+		"interrupted", interrupted,
+		"still_running", stillRunning,
+	)
+	if recorder, ok := s.metrics.(spotInterruptionRecorder); ok {
+		recorder.RecordSpotInterruptions(interrupted)
+	}
+	if s.onSpotInterruption != nil {
+		s.onSpotInterruption(interrupted)
+	}
+	return stillRunning
+}
+
+// Compensate adds n agents to the demand of the next reconcile, e.g. to cover
+// capacity another service lost to spot interruptions. The extra agents are
+// then subject to the usual cooldown and idle guard, and are carried over to
+// later reconciles until a scale-up applies them. It is safe to call while
+// Run is active.
+func (s *Scaler) Compensate(n int) {
+	s.compensation.Add(int64(n))
+}
+
+// Settings holds the scaler parameters that can be changed while it runs.
+type Settings struct {
+	MinAgents    int
+	MaxAgents    int
+	PollInterval time.Duration
+	Cooldown     time.Duration
+}
+
+// UpdateSettings replaces the scaler's bounds, poll interval, and cooldown.
+// It is safe to call while Run is active: an in-flight reconcile finishes with
+// the old settings, and a new poll interval takes effect after the pending
+// poll fires.
+func (s *Scaler) UpdateSettings(settings Settings) {
+	s.settingsMu.Lock()
+	defer s.settingsMu.Unlock()
+
+	s.minAgents = settings.MinAgents
+	s.maxAgents = settings.MaxAgents
+	s.pollInterval = settings.PollInterval
+	s.cooldown = settings.Cooldown
+}
+
+// SetMetrics configures an optional metrics recorder.
+func (s *Scaler) SetMetrics(m MetricsRecorder) {
+	s.metrics = m
+}
+
+// Ready returns a channel that is closed after the first successful reconcile.
+func (s *Scaler) Ready() <-chan struct{} {
+	return s.ready
+}
+
+// Run starts the polling loop and blocks until the context is canceled or a
+// failure that retrying cannot fix stops it: a reconcile reports
+// tfc.ErrPoolNotFound or ecs.ErrAccessDenied, or, with
+// WithMaxConsecutiveFailures, ErrTooManyFailures is reached. The returned
+// error wraps the cause.
+func (s *Scaler) Run(ctx context.Context) error {
+	s.settingsMu.Lock()
+	s.logger.Info("starting autoscaler",
+		"scaler", s.name,
+		"min_agents", s.minAgents,
+		"max_agents", s.maxAgents,
+		"poll_interval", s.pollInterval,
+		"poll_jitter", s.pollJitter,
+		"cooldown", s.cooldown,
+	)
+	s.settingsMu.Unlock()
+
+	if s.startupDelay > 0 {
+		s.logger.Info("delaying first reconcile", "scaler", s.name, "startup_delay", s.startupDelay)
+		delay := time.NewTimer(s.startupDelay)
+		select {
+		case <-ctx.Done():
+			delay.Stop()
+			s.logger.Info("shutting down autoscaler", "scaler", s.name)
+			return ctx.Err()
+		case <-delay.C:
+		}
+	}
+
+	timer := time.NewTimer(s.nextPollInterval())
+	defer timer.Stop()
+
+	// Run immediately on start, then after each (jittered) interval.
+	if err := s.runReconcile(ctx); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.logger.Info("shutting down autoscaler", "scaler", s.name)
+			if s.drainOnShutdown {
+				drainCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), s.drainTimeout)
+				if err := s.drain(drainCtx); err != nil {
+					s.logger.Error("drain on shutdown failed", "scaler", s.name, "error", err)
+				}
+				cancel()
+			}
+			return ctx.Err()
+		case <-timer.C:
+			if err := s.runReconcile(ctx); err != nil {
+				return err
+			}
+			timer.Reset(s.nextPollInterval())
+		case <-s.reconcileTrigger:
+			s.logger.Info("reconcile triggered", "scaler", s.name)
+			if err := s.runReconcile(ctx); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Reconcile performs a single check-and-scale cycle.
+func (s *Scaler) Reconcile(ctx context.Context) error {
+	start := time.Now()
+	defer s.recordTiming(start)
+
+	s.settingsMu.Lock()
+	defer s.settingsMu.Unlock()
+	// Runs after any scale below so a fresh cooldown is reported.
+	defer s.recordCooldownRemaining()
+	s.cooldownHeld = 0
+	defer s.recordCooldownHeld()
+	paused := s.isPaused()
+
+	busy, idle, total, err := s.agentPoolStatus(ctx)
+	if err != nil {
+		s.recordResult(outcomeError)
+		return fmt.Errorf("getting agent pool status: %w", err)
+	}
+	// Agents in transitional or exited states are neither busy nor idle.
+	other := max(total-busy-idle, 0)
+	if recorder, ok := s.metrics.(otherAgentsRecorder); ok {
+		recorder.RecordOtherAgents(other)
+	}
+
+	pendingRuns, rawDemand, oldestPending, err := s.cachedPendingDemand(ctx)
+	if err != nil {
+		s.recordResult(outcomeError)
+		return fmt.Errorf("getting pending runs: %w", err)
+	}
+	demand := s.smoothDemand(rawDemand)
+
+	status, err := s.ecs.GetServiceStatus(ctx)
+	if err != nil {
+		s.recordResult(outcomeError)
+		return fmt.Errorf("getting ECS service status: %w", err)
+	}
+	currentDesired, currentRunning := status.Desired, status.Running
+	s.observeDeploymentController(status)
+	// Interrupted spot tasks still running are about to stop, so they are
+	// not healthy capacity.
+	currentRunning = max(currentRunning-s.checkSpotInterruptions(ctx), 0)
+	compensation := int(s.compensation.Swap(0))
+	demand += compensation
+
+	if s.metrics != nil {
+		s.metrics.RecordReconcile(busy, idle, total, pendingRuns, int(currentDesired), int(currentRunning))
+	}
+	s.trackPlacement(currentDesired, currentRunning)
+	s.trackOrphans(currentRunning, total)
+	s.budget.observe(s.name, currentDesired)
+
+	maxAgents := s.effectiveMaxAgents(ctx)
+	desired := computeDesired(demand, busy, s.warmIdle, s.effectiveMinAgents(), maxAgents)
+	if s.targetBusyRatio > 0 {
+		desired = computeTargetDesired(demand, busy, s.warmIdle, s.targetBusyRatio, s.effectiveMinAgents(), maxAgents)
+	}
+	desired, queueWaitExceeded := s.applyMaxQueueWait(oldestPending, desired, currentDesired, status.Pending, maxAgents)
+	desired = s.applyScaleToZeroGrace(desired, demand, busy, currentDesired)
+	// Compensation behind a scale-up this cycle does not apply, e.g. one held
+	// by stabilization or a failed write, is kept for the next reconcile.
+	compensationApplied := false
+	defer func(wantsScaleUp bool) {
+		if compensation > 0 && wantsScaleUp && !compensationApplied {
+			s.compensation.Add(int64(compensation))
+		}
+	}(desired > int(currentDesired))
+	// Saved once the cycle ends so the cooldown reflects any scale below.
+	defer s.saveState(State{
+		LastReconcile:   time.Now(),
+		ComputedDesired: desired,
+		CurrentDesired:  currentDesired,
+		CurrentRunning:  currentRunning,
+		PendingRuns:     pendingRuns,
+		BusyAgents:      busy,
+		IdleAgents:      idle,
+		TotalAgents:     total,
+		Paused:          paused,
+	})
+	if wanted := s.wantedAgents(demand, busy); wanted > maxAgents {
+		s.recordMaxClamp(wanted, maxAgents)
+	}
+	desiredInt32 := int32(desired)
+	if desiredInt32 <= currentDesired {
+		s.scaleUpSince = time.Time{}
+	}
+	// The service stays at its current count unless a scale succeeds below.
+	applied := currentDesired
+	defer func(computed int) {
+		if recorder, ok := s.metrics.(desiredRecorder); ok {
+			recorder.RecordDesired(computed, int(applied))
+		}
+	}(desired)
+
+	s.logger.Debug("reconcile",
+		"scaler", s.name,
+		"pending_runs", pendingRuns,
+		"pending_demand", rawDemand,
+		"smoothed_demand", demand,
+		"busy_agents", busy,
+		"idle_agents", idle,
+		"total_agents", total,
+		"other_agents", other,
+		"current_desired", currentDesired,
+		"current_running", currentRunning,
+		"pending_tasks", status.Pending,
+		"failed_tasks", status.FailedTasks,
+		"warm_idle", s.warmIdle,
+		"compensation", compensation,
+		"computed_desired", desired,
+		"paused", paused,
+	)
+
+	if paused {
+		if desiredInt32 != currentDesired {
+			s.logger.Info("paused, skipping scaling",
+				"scaler", s.name,
+				"current_desired", currentDesired,
+				"computed_desired", desired,
+			)
+		}
+		s.recordResult(outcomeNoChange)
+		return nil
+	}
+
+	bounded, boundsReason, outOfBounds := s.boundsCorrection(busy, idle, other, maxAgents, currentDesired, currentRunning)
+	if outOfBounds {
+		desiredInt32 = bounded
+	}
+
+	if desiredInt32 == currentDesired {
+		s.recycleExpiredTask(ctx)
+		s.recordResult(outcomeNoChange)
+		return nil
+	}
+	if !outOfBounds && s.withinDeadband(desired, currentDesired, maxAgents) {
+		s.logger.Debug("change within scale deadband, skipping",
+			"scaler", s.name,
+			"current_desired", currentDesired,
+			"computed_desired", desired,
+		)
+		if recorder, ok := s.metrics.(deadbandRecorder); ok {
+			recorder.RecordWithinDeadband()
+		}
+		s.recordResult(outcomeNoChange)
+		return nil
+	}
+
+	// Scale-up proceeds once stabilized, limited by the step size.
+	// Scale-down respects cooldown and idle guard.
+	if desiredInt32 > currentDesired && !outOfBounds {
+		if !s.scaleUpStabilized(queueWaitExceeded, currentDesired, desired) {
+			s.recordResult(outcomeNoChange)
+			return nil
+		}
+		desiredInt32 = s.applyBudget(s.applyScaleUpStep(desired, currentDesired, status.Pending), currentDesired)
+		s.logPendingWorkspaces(ctx)
+		if desiredInt32 == currentDesired {
+			s.recordResult(outcomeNoChange)
+			return nil
+		}
+		s.checkDeploymentLimit(status, desiredInt32)
+	}
+	var reason string
+	switch {
+	case outOfBounds:
+		s.logger.Warn("current desired count is outside the agent bounds, correcting it",
+			"scaler", s.name,
+			"current_desired", currentDesired,
+			"min_agents", s.effectiveMinAgents(),
+			"max_agents", maxAgents,
+			"corrected_desired", desiredInt32,
+		)
+		reason = boundsReason
+		if desiredInt32 < currentDesired && !s.dryRun {
+			s.protectBeforeBoundsCorrection(ctx, int(currentDesired-desiredInt32))
+		}
+	case desiredInt32 < currentDesired:
+		reason = s.scaleDownReason(demand, busy, desired)
+		adjusted, guardReason, done := s.applyScaleDownGuards(ctx, desired, busy, idle, other, currentDesired, currentRunning)
+		if done {
+			return nil
+		}
+		desiredInt32 = adjusted
+		if guardReason != "" {
+			reason = guardReason
+		}
+	}
+
+	direction := "up"
+	if desiredInt32 < currentDesired {
+		direction = "down"
+	}
+
+	s.logger.Info("scaling",
+		"scaler", s.name,
+		"from", currentDesired,
+		"to", desiredInt32,
+		"reason", reason,
+		"dry_run", s.dryRun,
+	)
+
+	if s.dryRun {
+		if s.metrics != nil {
+			s.metrics.RecordDryRunScaleEvent(direction)
+		}
+		// Track the would-be scale time so cooldown behaves as it would live.
+		s.lastScaleTime = time.Now()
+		compensationApplied = direction == "up"
+		s.recordResult(outcomeNoChange)
+		return nil
+	}
+
+	if err := s.setDesiredCount(ctx, desiredInt32); err != nil {
+		s.recordResult(outcomeError)
+		return fmt.Errorf("setting desired count: %w", err)
+	}
+	applied = desiredInt32
+	compensationApplied = direction == "up"
+
+	if s.metrics != nil {
+		s.metrics.RecordScaleEvent(direction)
+		if direction == "down" {
+			s.metrics.RecordScaleDownReason(reason)
+		}
+	}
+
+	s.logger.LogAttrs(ctx, slog.LevelInfo, "scale", s.scaleEventAttrs(scaleEvent{
+		from:      currentDesired,
+		to:        desiredInt32,
+		direction: direction,
+		reason:    reason,
+		pending:   pendingRuns,
+		busy:      busy,
+		idle:      idle,
+		total:     total,
+	})...)
+	s.notify(currentDesired, desiredInt32, direction, reason)
+
+	s.budget.observe(s.name, desiredInt32)
+	s.lastScaleTime = time.Now()
+	s.recordResult(scaledOutcome(direction))
+	return nil
+}
+
+// scaleEvent describes a scaling action applied to the ECS service.
+type scaleEvent struct {
+	from, to                   int32
+	direction, reason          string
+	pending, busy, idle, total int
+}
+
+// scaleEventAttrs builds the attributes for the event=scale log line. The key
+// set is fixed so log pipelines can parse every scaling action the same way.
+func (s *Scaler) scaleEventAttrs(ev scaleEvent) []slog.Attr {
+	return []slog.Attr{
+		slog.String("event", "scale"),
+		slog.String("service", s.name),
+		slog.Int("from", int(ev.from)),
+		slog.Int("to", int(ev.to)),
+		slog.String("direction", ev.direction),
+		slog.String("reason", ev.reason),
+		slog.Int("pending", ev.pending),
+		slog.Int("busy", ev.busy),
+		slog.Int("idle", ev.idle),
+		slog.Int("total", ev.total),
+	}
+}
+
+// runReconcile performs one reconcile for Run, logging failures and marking
+// the scaler ready once enough consecutive reconciles have succeeded. It
+// returns an error only for failures that should stop Run.
+func (s *Scaler) runReconcile(ctx context.Context) error {
+	if err := s.reconcileWithTimeout(ctx); err != nil {
+		// A missing agent pool or ECS permission will not fix itself; stop
+		// instead of retrying.
+		if errors.Is(err, tfc.ErrPoolNotFound) || errors.Is(err, ecs.ErrAccessDenied) {
+			return fmt.Errorf("reconcile: %w", err)
+		}
+		s.consecutiveFailures++
+		s.consecutiveSuccesses = 0
+		if s.maxConsecutiveFailures > 0 && s.consecutiveFailures >= s.maxConsecutiveFailures {
+			return fmt.Errorf("%w (%d): %w", ErrTooManyFailures, s.consecutiveFailures, err)
+		}
+		// Skipped writes back off like any failure; the breaker already
+		// warned when it opened.
+		if errors.Is(err, ErrECSWriteCircuitOpen) {
+			s.logger.Warn("reconcile skipped ECS writes, circuit breaker open",
+				"scaler", s.name,
+				"consecutive_failures", s.consecutiveFailures,
+			)
+			return nil
+		}
+		// Throttling backs off like any failure, but is expected under load.
+		if errors.Is(err, ecs.ErrThrottled) {
+			s.logger.Warn("reconcile throttled by ECS, backing off",
+				"scaler", s.name,
+				"consecutive_failures", s.consecutiveFailures,
+				"error", err,
+			)
+			return nil
+		}
+		s.logger.Error("reconcile failed",
+			"scaler", s.name,
+			"consecutive_failures", s.consecutiveFailures,
+			"error", err,
+		)
+		return nil
+	}
+	s.consecutiveFailures = 0
+	s.consecutiveSuccesses++
+	if s.consecutiveSuccesses >= s.readyAfterSuccesses {
+		s.markReady()
+	}
+	return nil
+}
+
+// reconcileWithTimeout runs Reconcile under the configured reconcile timeout.
+func (s *Scaler) reconcileWithTimeout(ctx context.Context) error {
+	if s.reconcileTimeout <= 0 {
+		return s.Reconcile(ctx)
+	}
+	ctx, cancel := context.WithTimeout(ctx, s.reconcileTimeout)
+	defer cancel()
+	return s.Reconcile(ctx)
+}
+
+// nextPollInterval returns the poll interval, backed off after consecutive
+// failures, with jitter applied. While backing off the jitter is at least
+// failureBackoffJitter; the cap applies before jitter.
+func (s *Scaler) nextPollInterval() time.Duration {
+	s.settingsMu.Lock()
+	defer s.settingsMu.Unlock()
+
+	interval := s.backoffInterval()
+	jitter := s.pollJitter
+	if s.consecutiveFailures > 0 && interval > s.pollInterval {
+		jitter = max(jitter, failureBackoffJitter)
+	}
+	if jitter <= 0 || s.rand == nil {
+		return interval
+	}
+	offset := (s.rand.Float64()*2 - 1) * jitter
+	return interval + time.Duration(float64(interval)*offset)
+}
+
+// backoffInterval doubles the poll interval for each consecutive failure, up
+// to failureBackoffMax.
+func (s *Scaler) backoffInterval() time.Duration {
+	if s.consecutiveFailures == 0 || s.failureBackoffMax <= s.pollInterval {
+		return s.pollInterval
+	}
+	interval := s.pollInterval
+	for i := 0; i < s.consecutiveFailures && interval < s.failureBackoffMax; i++ {
+		interval *= 2
+	}
+	return min(interval, s.failureBackoffMax)
+}
+
+// drain scales the service down to minAgents, protecting busy tasks first so
+// only idle agents are stopped.
+func (s *Scaler) drain(ctx context.Context) error {
+	s.settingsMu.Lock()
+	defer s.settingsMu.Unlock()
+
+	status, err := s.ecs.GetServiceStatus(ctx)
+	if err != nil {
+		return fmt.Errorf("getting ECS service status: %w", err)
+	}
+	currentDesired := status.Desired
+	s.observeDeploymentController(status)
+
+	target := int32(s.minAgents)
+	if currentDesired <= target {
+		return nil
+	}
+	if s.isPaused() {
+		s.logger.Info("paused, skipping drain on shutdown", "scaler", s.name)
+		return nil
+	}
+
+	s.logger.Info("draining on shutdown",
+		"scaler", s.name,
+		"from", currentDesired,
+		"to", target,
+		"dry_run", s.dryRun,
+	)
+
+	if s.dryRun {
+		if s.metrics != nil {
+			s.metrics.RecordDryRunScaleEvent("down")
+		}
+		return nil
+	}
+
+	protected := !s.taskProtectionDisabled && !s.protectionUnsupported
+	if protected {
+		if err := s.protectBusyTasks(ctx, int(currentDesired-target)); err != nil {
+			s.logger.Warn("task protection failed during drain", "scaler", s.name, "error", err)
+			if s.metrics != nil {
+				s.metrics.RecordTaskProtectionError()
+			}
+			protected = false
+		}
+	}
+	// Without protection, or when it failed, ECS may stop any task, so the
+	// drain keeps every agent that may be running a job.
+	if !protected {
+		floor, err := s.drainFloor(ctx, status)
+		if err != nil {
+			return err
+		}
+		if floor > target {
+			s.logger.Info("busy agents without task protection, limiting drain",
+				"scaler", s.name,
+				"min_agents", s.minAgents,
+				"drain_to", floor,
+			)
+			target = floor
+		}
+		if target >= currentDesired {
+			return nil
+		}
+	}
+
+	if err := s.setDesiredCount(ctx, target); err != nil {
+		return fmt.Errorf("setting desired count: %w", err)
+	}
+
+	if s.metrics != nil {
+		s.metrics.RecordScaleEvent("down")
+		s.metrics.RecordScaleDownReason(reasonShutdownDrain)
+	}
+	s.notify(currentDesired, target, "down", reasonShutdownDrain)
+	return nil
+}
+
+// drainFloor returns the fewest agents an unprotected drain may leave: the
+// agents that may be running a job, capped at the current desired count.
+func (s *Scaler) drainFloor(ctx context.Context, status ecs.ServiceStatus) (int32, error) {
+	busy, idle, total, err := s.agentPoolStatus(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("getting agent pool status: %w", err)
+	}
+	other := max(total-busy-idle, 0)
+	return int32(min(heldAgents(busy, idle, other, status.Running), int(status.Desired))), nil
+}
+
+// notify tells the notifier, if any, about a scaling action applied to the
+// ECS service.
+func (s *Scaler) notify(from, to int32, direction, reason string) {
+	if s.notifier == nil {
+		return
+	}
+	s.notifier.Notify(notify.Event{
+		Service:   s.name,
+		From:      int(from),
+		To:        int(to),
+		Direction: direction,
+		Reason:    reason,
+	})
+}
+
+// logPendingWorkspaces logs the workspaces contributing the most pending runs
+// at debug level. It is a no-op when debug logging is disabled or the TFC
+// client cannot report per-workspace counts.
+func (s *Scaler) logPendingWorkspaces(ctx context.Context) {
+	reporter, ok := s.tfc.(workspacePendingReporter)
+	if !ok || !s.logger.Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+
+	workspaces, err := reporter.GetPendingRunsByWorkspace(ctx)
+	if err != nil {
+		s.logger.Debug("failed to get pending runs by workspace", "scaler", s.name, "error", err)
+		return
+	}
+
+	workspaces = slices.DeleteFunc(workspaces, func(ws tfc.WorkspacePendingRuns) bool {
+		return ws.Total() == 0
+	})
+	slices.SortStableFunc(workspaces, func(a, b tfc.WorkspacePendingRuns) int {
+		return cmp.Compare(b.Total(), a.Total())
+	})
+
+	for _, ws := range workspaces[:min(len(workspaces), maxLoggedWorkspaces)] {
+		s.logger.Debug("pending runs by workspace",
+			"scaler", s.name,
+			"workspace_id", ws.WorkspaceID,
+			"workspace_name", ws.WorkspaceName,
+			"plan_pending", ws.PlanPending,
+			"apply_pending", ws.ApplyPending,
+		)
+	}
+}
+
+// applyScaleUpStep clamps the upward delta to maxScaleUpStep. Tasks still
+// pending from an earlier step count against the step, so a slow-starting
+// batch is not followed by another one before its agents register. Because
+// desired is already clamped to maxAgents, the step limit can only slow the
+// approach to maxAgents, never exceed it. The result is never below
+// currentDesired or minAgents, so a service starting under its floor reaches
+// it in a single step.
+func (s *Scaler) applyScaleUpStep(desired int, currentDesired, pendingTasks int32) int32 {
+	if s.maxScaleUpStep <= 0 {
+		return int32(desired)
+	}
+
+	limited := min(desired, max(int(currentDesired-pendingTasks)+s.maxScaleUpStep, int(currentDesired)))
+	limited = max(limited, s.effectiveMinAgents())
+
+	if limited != desired {
+		s.logger.Info("scale-up step limit applied",
+			"scaler", s.name,
+			"computed_desired", desired,
+			"max_scale_up_step", s.maxScaleUpStep,
+			"pending_tasks", pendingTasks,
+			"limited_desired", limited,
+		)
+	}
+
+	return int32(limited)
+}
+
+// setDesiredCount updates the service's desired count. A desired count
+// mismatch means the update was made but raced with another actor; it is
+// logged and recorded rather than failing the caller, and the next reconcile
+// works from the applied count.
+func (s *Scaler) setDesiredCount(ctx context.Context, count int32) error {
+	var mismatch *ecs.DesiredCountMismatchError
+	err := s.ecsWrite(ctx, func(ctx context.Context) error {
+		err := s.ecs.SetDesiredCount(ctx, count)
+		if errors.As(err, &mismatch) {
+			// The update was applied, so the write succeeded.
+			return nil
+		}
+		return err
+	})
+	if mismatch == nil {
+		return err
+	}
+
+	s.logger.Warn("ECS desired count differs from requested, service may have been updated concurrently",
+		"scaler", s.name,
+		"requested", mismatch.Requested,
+		"applied", mismatch.Applied,
+	)
+	if recorder, ok := s.metrics.(desiredCountMismatchRecorder); ok {
+		recorder.RecordDesiredCountMismatch()
+	}
+	return nil
+}
+
+// ecsWrite performs an ECS write through the write breaker, returning
+// ErrECSWriteCircuitOpen without calling write while the breaker is open. A
+// write canceled with its context does not count as a failure.
+func (s *Scaler) ecsWrite(ctx context.Context, write func(ctx context.Context) error) error {
+	if s.writeBreaker == nil {
+		return write(ctx)
+	}
+	defer s.recordWriteCircuit()
+	if !s.writeBreaker.allow(s.now()) {
+		return ErrECSWriteCircuitOpen
+	}
+
+	wasClosed := s.writeBreaker.state == breakerClosed
+	err := write(ctx)
+	if errors.Is(err, context.Canceled) {
+		return err
+	}
+	s.writeBreaker.record(err, s.now())
+
+	switch {
+	case s.writeBreaker.isOpen():
+		s.logger.Warn("ECS writes failing, skipping them until the circuit breaker cooldown passes",
+			"scaler", s.name,
+			"cooldown", s.writeBreaker.cooldown,
+			"consecutive_failures", s.writeBreaker.failures,
+			"error", err,
+		)
+	case err == nil && !wasClosed:
+		s.logger.Info("ECS write succeeded, resuming ECS writes", "scaler", s.name)
+	}
+	return err
+}
+
+// recordWriteCircuit reports whether the write breaker is skipping ECS writes.
+func (s *Scaler) recordWriteCircuit() {
+	if recorder, ok := s.metrics.(writeCircuitRecorder); ok {
+		recorder.RecordECSWriteCircuitOpen(s.writeBreaker.isOpen())
+	}
+}
+
+// applyBudget caps a scale-up target to the capacity left in the shared
+// budget once sibling scalers' desired counts are accounted for.
+func (s *Scaler) applyBudget(target, currentDesired int32) int32 {
+	limited := s.budget.reserve(s.name, currentDesired, target)
+	if limited != target {
+		s.logger.Info("scale-up limited by total agent budget",
+			"scaler", s.name,
+			"computed_desired", target,
+			"limited_desired", limited,
+		)
+	}
+	return limited
+}
+
+// scaleDownReason classifies why the computed target is below the current
+// desired count, before any guards are applied.
+func (s *Scaler) scaleDownReason(demand, busy, desired int) string {
+	minAgents := s.effectiveMinAgents()
+	switch {
+	case desired == minAgents && s.wantedAgents(demand, busy) < minAgents:
+		return reasonMinClamp
+	case demand == 0 && busy == 0:
+		return reasonNoWork
+	default:
+		return reasonReducedDemand
+	}
+}
+
+// applyScaleDownGuards checks cooldown, convergence, idle guard, and busy
+// floor before scaling down.
+// The returned reason is non-empty when a guard capped the scale-down.
+// It returns the adjusted desired count and true if scaling should be skipped entirely.
+func (s *Scaler) applyScaleDownGuards(ctx context.Context, desired, busy, idle, other int, currentDesired, currentRunning int32) (int32, string, bool) {
+	if remaining := s.cooldownRemaining(); remaining > 0 {
+		s.logger.Info("scale-down skipped due to cooldown",
+			"scaler", s.name,
+			"last_scale", s.lastScaleTime,
+			"cooldown_remaining", remaining,
+		)
+		if s.metrics != nil {
+			s.metrics.RecordCooldownSkip()
+			s.metrics.RecordScaleDownSkip(skipCooldown)
+		}
+		s.cooldownHeld = int(currentDesired) - desired
+		s.recordResult(outcomeCooldownSkip)
+		return 0, "", true
+	}
+
+	// Convergence: while running tasks trail the desired count, a previous
+	// scale-up is still starting agents and the idle count is about to
+	// change. Once placement is reported as stalled, scale-down is allowed
+	// again so an unplaceable target does not pin the service.
+	stalled := s.placementStallThreshold > 0 && s.placementStalls >= s.placementStallThreshold
+	if currentRunning < currentDesired && !stalled {
+		s.logger.Info("scale-down skipped while service is converging",
+			"scaler", s.name,
+			"current_desired", currentDesired,
+			"current_running", currentRunning,
+		)
+		if s.metrics != nil {
+			s.metrics.RecordScaleDownSkip(skipConverging)
+		}
+		s.recordResult(outcomeNoChange)
+		return 0, "", true
+	}
+
+	// Idle threshold: leave a cushion of idle agents before scaling down.
+	if s.scaleDownIdleThreshold > 0 && idle <= s.scaleDownIdleThreshold {
+		s.logger.Info("scale-down skipped due to idle threshold",
+			"scaler", s.name,
+			"idle_agents", idle,
+			"idle_threshold", s.scaleDownIdleThreshold,
+		)
+		if s.metrics != nil {
+			s.metrics.RecordScaleDownSkip(skipIdleThreshold)
+		}
+		s.recordResult(outcomeNoChange)
+		return 0, "", true
+	}
+
+	var reason string
+	scaleDownBy := int(currentDesired) - desired
+	// Scale-down factor: remove a fraction of the gap so brief lulls do not
+	// shed every idle agent at once.
+	if s.scaleDownFactor > 0 && s.scaleDownFactor < 1 {
+		if factored := int(math.Ceil(float64(scaleDownBy) * s.scaleDownFactor)); factored < scaleDownBy {
+			scaleDownBy = factored
+			reason = reasonScaleDownFactor
+		}
+	}
+	// Idle guard: never scale down by more than the number of idle agents,
+	// keeping the warm idle buffer in place.
+	removableIdle := max(0, idle-s.warmIdle)
+	if !s.idleGuardDisabled && removableIdle < scaleDownBy {
+		scaleDownBy = removableIdle
+		reason = reasonIdleGuard
+	}
+	// Step limit: never remove more than maxScaleDownStep agents per reconcile.
+	if s.maxScaleDownStep > 0 && scaleDownBy > s.maxScaleDownStep {
+		scaleDownBy = s.maxScaleDownStep
+		reason = reasonStepLimit
+	}
+	// Busy floor: never go below busy agents plus the warm idle buffer, even
+	// if the idle count is stale or the maximum was lowered below them.
+	if floor := s.busyFloor(busy, idle, other, currentDesired, currentRunning); !s.busyFloorDisabled && int(currentDesired)-scaleDownBy < floor {
+		scaleDownBy = int(currentDesired) - floor
+		reason = reasonBusyFloor
+	}
+	adjusted := currentDesired - int32(scaleDownBy)
+
+	s.logger.Info("idle guard applied",
+		"scaler", s.name,
+		"computed_desired", desired,
+		"idle_agents", idle,
+		"other_agents", other,
+		"warm_idle", s.warmIdle,
+		"idle_guard", !s.idleGuardDisabled,
+		"busy_floor", !s.busyFloorDisabled,
+		"scale_down_factor", s.scaleDownFactor,
+		"max_scale_down_step", s.maxScaleDownStep,
+		"scale_down_by", scaleDownBy,
+		"guarded_desired", adjusted,
+	)
+
+	if adjusted == currentDesired {
+		s.recordResult(outcomeNoChange)
+		return 0, "", true
+	}
+
+	// Task protection: protect busy tasks before scaling down.
+	protected := true
+	switch {
+	case s.dryRun:
+		s.logger.Info("dry run: skipping task protection", "scaler", s.name)
+	case s.taskProtectionDisabled:
+	case s.protectionUnsupported:
+		protected = false
+	default:
+		if err := s.protectBusyTasks(ctx, scaleDownBy); err != nil {
+			s.logger.Warn("task protection failed, proceeding with idle-guarded scale-down",
+				"scaler", s.name,
+				"error", err,
+			)
+			if s.metrics != nil {
+				s.metrics.RecordTaskProtectionError()
+			}
+			protected = false
+		}
+	}
+	// Without protection, only the idle guard keeps busy tasks safe.
+	if !protected && s.idleGuardDisabled && removableIdle < scaleDownBy {
+		scaleDownBy = removableIdle
+		reason = reasonIdleGuard
+		adjusted = currentDesired - int32(scaleDownBy)
+		if adjusted == currentDesired {
+			s.recordResult(outcomeNoChange)
+			return 0, "", true
+		}
+	}
+
+	return adjusted, reason, false
+}
+
+// busyFloor returns the fewest agents a scale-down may leave: the busy agents
+// plus the warm idle buffer, capped at currentDesired. Agents in other
+// statuses may still hold a running task, so those the running count leaves
+// room for are kept like busy ones.
+func (s *Scaler) busyFloor(busy, idle, other int, currentDesired, currentRunning int32) int {
+	return min(heldAgents(busy, idle, other, currentRunning)+s.warmIdle, int(currentDesired))
+}
+
+// heldAgents returns how many agents may be running a job: the busy agents
+// plus the agents in other statuses the running count leaves room for.
+func heldAgents(busy, idle, other int, running int32) int {
+	return busy + min(other, max(int(running)-busy-idle, 0))
+}
+
+// boundsCorrection clamps a current desired count outside the agent bounds,
+// e.g. one set by hand, into them. The correction is applied at once rather
+// than waiting on demand, cooldown, and the other scale-down guards, but a
+// correction below maxAgents still keeps the busy floor. It reports whether
+// the count needs correcting, along with the corrected count and its reason.
+func (s *Scaler) boundsCorrection(busy, idle, other, maxAgents int, currentDesired, currentRunning int32) (int32, string, bool) {
+	bounded := min(max(currentDesired, int32(s.effectiveMinAgents())), int32(maxAgents))
+	reason := reasonBoundsCorrection
+	if floor := int32(s.busyFloor(busy, idle, other, currentDesired, currentRunning)); !s.busyFloorDisabled && bounded < floor {
+		bounded, reason = floor, reasonBusyFloor
+	}
+	return bounded, reason, bounded != currentDesired
+}
+
+// protectBeforeBoundsCorrection protects busy tasks before a bounds
+// correction removes scaleDownBy agents. The correction bypasses the idle
+// guard, so protection is what keeps busy tasks from being stopped; a failure
+// is logged and counted but does not hold the correction back.
+func (s *Scaler) protectBeforeBoundsCorrection(ctx context.Context, scaleDownBy int) {
+	if s.taskProtectionDisabled || s.protectionUnsupported {
+		return
+	}
+	if err := s.protectBusyTasks(ctx, scaleDownBy); err != nil {
+		s.logger.Warn("task protection failed during bounds correction", "scaler", s.name, "error", err)
+		if s.metrics != nil {
+			s.metrics.RecordTaskProtectionError()
+		}
+	}
+}
+
+// withinDeadband reports whether the change from currentDesired to desired is
+// too small to act on under WithScaleDeadband. A change to exactly the minimum
+// or maxAgents is never within it.
+func (s *Scaler) withinDeadband(desired int, currentDesired int32, maxAgents int) bool {
+	if s.deadband <= 0 && s.deadbandPercent <= 0 {
+		return false
+	}
+	if desired == s.effectiveMinAgents() || desired == maxAgents {
+		return false
+	}
+	threshold := float64(s.deadband)
+	if s.deadbandPercent > 0 {
+		threshold = float64(currentDesired) * s.deadbandPercent / 100
+	}
+	return math.Abs(float64(desired)-float64(currentDesired)) <= threshold
+}
+
+// isPaused reports whether the shared pause flag is set.
+func (s *Scaler) isPaused() bool {
+	return s.paused != nil && s.paused.Load()
+}
+
+// checkDeploymentLimit warns when a scale-up to desired asks for more tasks
+// than the service's deployment configuration lets ECS run at once from its
+// current desired count, maximumPercent of it. While a rolling deployment is
+// in progress the extra tasks may not be placed until it finishes.
+func (s *Scaler) checkDeploymentLimit(status ecs.ServiceStatus, desired int32) {
+	if status.MaximumPercent <= 0 || status.Desired <= 0 {
+		return
+	}
+	limit := status.Desired * status.MaximumPercent / 100
+	if desired <= limit {
+		return
+	}
+	s.logger.Warn("scale-up exceeds the tasks the deployment configuration runs at once",
+		"scaler", s.name,
+		"current_desired", status.Desired,
+		"desired", desired,
+		"maximum_percent", status.MaximumPercent,
+		"limit", limit,
+	)
+	if recorder, ok := s.metrics.(deploymentLimitRecorder); ok {
+		recorder.RecordDeploymentLimitExceeded()
+	}
+}
+
+// observeDeploymentController records whether the service's deployment
+// controller supports task protection, warning the first time it does not.
+func (s *Scaler) observeDeploymentController(status ecs.ServiceStatus) {
+	s.protectionUnsupported = !status.ProtectionSupported()
+	if s.protectionUnsupported && !s.taskProtectionDisabled && !s.protectionUnsupportedWarned {
+		s.protectionUnsupportedWarned = true
+		s.logger.Warn("deployment controller does not support task protection, skipping it",
+			"scaler", s.name,
+			"deployment_controller", status.DeploymentController,
+		)
+	}
+}
+
+// protectBusyTasks correlates TFC agents with ECS tasks and sets
+// scale-in protection on busy tasks while removing it from idle ones. When
+// any idle task is past maxTaskAge, only the scaleDownBy oldest idle tasks are
+// left unprotected so ECS stops those instead of younger ones.
+func (s *Scaler) protectBusyTasks(ctx context.Context, scaleDownBy int) error {
+	busyArns, idleTasks, err := s.classifyTasks(ctx)
+	if err != nil {
+		return err
+	}
+
+	removable, kept := s.selectIdleForRemoval(idleTasks, scaleDownBy, time.Now())
+	protectArns := append(busyArns, taskArns(kept)...)
+	idleArns := taskArns(removable)
+
+	if len(protectArns) > 0 {
+		if err := s.ecsWrite(ctx, func(ctx context.Context) error {
+			return s.ecs.SetTaskProtection(ctx, protectArns, true, s.protectionExpiryMinutes())
+		}); err != nil {
+			return fmt.Errorf("protecting busy tasks: %w", err)
+		}
+	}
+
+	if len(idleArns) > 0 {
+		if err := s.ecsWrite(ctx, func(ctx context.Context) error {
+			return s.ecs.SetTaskProtection(ctx, idleArns, false, 0)
+		}); err != nil {
+			return fmt.Errorf("unprotecting idle tasks: %w", err)
+		}
+	}
+
+	s.logger.Info("task protection updated",
+		"scaler", s.name,
+		"busy_protected", len(busyArns),
+		"idle_kept", len(kept),
+		"idle_unprotected", len(idleArns),
+	)
+
+	return nil
+}
+
+// classifyTasks correlates TFC agents with ECS tasks using the configured
+// correlation. It returns the ARNs of tasks running busy agents and the tasks
+// running idle ones.
+func (s *Scaler) classifyTasks(ctx context.Context) (busyArns []string, idle []ecs.TaskInfo, err error) {
+	agents, err := s.tfc.GetAgentDetails(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("getting agent details: %w", err)
+	}
+
+	tasks, err := s.ecs.GetTaskIPs(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("getting task IPs: %w", err)
+	}
+
+	// Build IP → task map.
+	ipToTask := make(map[string]ecs.TaskInfo, len(tasks))
+	for _, t := range tasks {
+		if t.PrivateIP != "" {
+			ipToTask[t.PrivateIP] = t
+		}
+	}
+
+	for _, agent := range agents {
+		task, ok := s.taskForAgent(agent, ipToTask, tasks)
+		if !ok {
+			continue
+		}
+		if agent.Status == "busy" {
+			busyArns = append(busyArns, task.TaskArn)
+		} else {
+			idle = append(idle, task)
+		}
+	}
+
+	return busyArns, idle, nil
+}
+
+// taskForAgent returns the task running agent: by name under CorrelateByName,
+// otherwise by IP, falling back to the name when no task has the agent's IP.
+func (s *Scaler) taskForAgent(agent tfc.AgentInfo, ipToTask map[string]ecs.TaskInfo, tasks []ecs.TaskInfo) (ecs.TaskInfo, bool) {
+	if s.correlation == CorrelateByName {
+		return taskByAgentName(tasks, agent.Name)
+	}
+	if task, ok := ipToTask[agent.IP]; ok {
+		return task, true
+	}
+	// The task's ENI may not report an IP yet; fall back to the agent name.
+	return taskByAgentName(tasks, agent.Name)
+}
+
+// taskByAgentName returns the task whose ID or agent name tag matches name.
+func taskByAgentName(tasks []ecs.TaskInfo, name string) (ecs.TaskInfo, bool) {
+	for _, t := range tasks {
+		if t.MatchesAgentName(name) {
+			return t, true
+		}
+	}
+	return ecs.TaskInfo{}, false
+}
+
+// selectIdleForRemoval splits idle tasks into those ECS may stop and those to
+// keep protected. With an expired task the scaleDownBy oldest tasks are
+// removable. Otherwise every idle task stays removable and ECS picks, except
+// the newest one when protectNewestIdle is set.
+func (s *Scaler) selectIdleForRemoval(idle []ecs.TaskInfo, scaleDownBy int, now time.Time) (removable, kept []ecs.TaskInfo) {
+	if scaleDownBy >= len(idle) {
+		return idle, nil
+	}
+	if s.maxTaskAge <= 0 || !slices.ContainsFunc(idle, func(t ecs.TaskInfo) bool { return t.Age(now) > s.maxTaskAge }) {
+		return s.keepNewestIdle(idle)
+	}
+
+	sorted := slices.Clone(idle)
+	slices.SortStableFunc(sorted, func(a, b ecs.TaskInfo) int {
+		return cmp.Compare(b.Age(now), a.Age(now))
+	})
+	scaleDownBy = max(scaleDownBy, 0)
+	return sorted[:scaleDownBy], sorted[scaleDownBy:]
+}
+
+// keepNewestIdle splits off the most recently started idle task to keep
+// protected when protectNewestIdle is set. Tasks that have not reported a
+// start time are never picked.
+func (s *Scaler) keepNewestIdle(idle []ecs.TaskInfo) (removable, kept []ecs.TaskInfo) {
+	if !s.protectNewestIdle {
+		return idle, nil
+	}
+	newest := -1
+	for i, t := range idle {
+		if !t.StartedAt.IsZero() && (newest < 0 || t.StartedAt.After(idle[newest].StartedAt)) {
+			newest = i
+		}
+	}
+	if newest < 0 {
+		return idle, nil
+	}
+	removable = slices.Delete(slices.Clone(idle), newest, newest+1)
+	return removable, []ecs.TaskInfo{idle[newest]}
+}
+
+// recycleExpiredTask stops the oldest idle task past maxTaskAge so the ECS
+// service replaces it with a fresh one. At most one task is recycled per
+// reconcile. Failures are logged and do not fail the reconcile.
+func (s *Scaler) recycleExpiredTask(ctx context.Context) {
+	if s.maxTaskAge <= 0 {
+		return
+	}
+
+	_, idle, err := s.classifyTasks(ctx)
+	if err != nil {
+		s.logger.Warn("task recycling skipped", "scaler", s.name, "error", err)
+		return
+	}
+
+	now := time.Now()
+	var oldest *ecs.TaskInfo
+	for i := range idle {
+		if idle[i].Age(now) > s.maxTaskAge && (oldest == nil || idle[i].Age(now) > oldest.Age(now)) {
+			oldest = &idle[i]
+		}
+	}
+	if oldest == nil {
+		return
+	}
+
+	s.logger.Info("recycling idle task past max age",
+		"scaler", s.name,
+		"task_arn", oldest.TaskArn,
+		"task_age", oldest.Age(now),
+		"max_task_age", s.maxTaskAge,
+		"dry_run", s.dryRun,
+	)
+	if s.dryRun {
+		return
+	}
+
+	if err := s.ecsWrite(ctx, func(ctx context.Context) error {
+		return s.ecs.StopTask(ctx, oldest.TaskArn, "tfc-agent-autoscaler: max task age exceeded")
+	}); err != nil {
+		s.logger.Warn("task recycling failed", "scaler", s.name, "task_arn", oldest.TaskArn, "error", err)
+	}
+}
+
+// taskArns returns the ARNs of tasks.
+func taskArns(tasks []ecs.TaskInfo) []string {
+	arns := make([]string, 0, len(tasks))
+	for _, t := range tasks {
+		arns = append(arns, t.TaskArn)
+	}
+	return arns
+}
+
+// protectionExpiryMinutes returns the configured protection expiry in minutes,
+// falling back to the default when unset.
+func (s *Scaler) protectionExpiryMinutes() int32 {
+	expiry := s.protectionExpiry
+	if expiry <= 0 {
+		expiry = defaultProtectionExpiry
+	}
+	return int32(expiry / time.Minute)
+}
+
+// recordResult records how a reconcile ended, both as success or error and
+// as its outcome.
+func (s *Scaler) recordResult(outcome string) {
+	if s.metrics != nil {
+		s.metrics.RecordReconcileResult(outcome != outcomeError)
+	}
+	if recorder, ok := s.metrics.(outcomeRecorder); ok {
+		recorder.RecordReconcileOutcome(outcome)
+	}
+}
+
+// scaledOutcome returns the outcome of a reconcile that scaled in direction.
+func scaledOutcome(direction string) string {
+	if direction == "down" {
+		return outcomeScaledDown
+	}
+	return outcomeScaledUp
+}
+
+// recordTiming reports the reconcile duration since start and marks the
+// reconcile as finished now, whether it succeeded or not.
+func (s *Scaler) recordTiming(start time.Time) {
+	if s.metrics != nil {
+		end := time.Now()
+		s.metrics.RecordReconcileDuration(end.Sub(start).Seconds())
+		s.metrics.RecordReconcileTimestamp(end)
+	}
+}
+
+func (s *Scaler) markReady() {
+	s.readyOnce.Do(func() { close(s.ready) })
+}
+
+// agentPoolStatus returns the busy, idle, and total agent counts. When the
+// client can count agents in every status, the full breakdown is recorded
+// from the same listing.
+func (s *Scaler) agentPoolStatus(ctx context.Context) (busy, idle, total int, err error) {
+	reporter, ok := s.tfc.(agentStatusReporter)
+	if !ok {
+		return s.tfc.GetAgentPoolStatus(ctx)
+	}
+
+	counts, err := reporter.GetAgentStatusCounts(ctx)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	if recorder, ok := s.metrics.(agentStatusRecorder); ok {
+		recorder.RecordAgentsByStatus(counts)
+	}
+	for _, n := range counts {
+		total += n
+	}
+	return counts["busy"], counts["idle"], total, nil
+}
+
+// cachedPendingDemand returns pendingDemand, reusing the last result while it
+// is younger than runPollInterval.
+func (s *Scaler) cachedPendingDemand(ctx context.Context) (pending, demand int, oldest time.Time, err error) {
+	if s.runPollInterval <= 0 {
+		return s.pendingDemand(ctx)
+	}
+	now := s.now()
+	if c := s.pendingCache; !c.fetchedAt.IsZero() && now.Sub(c.fetchedAt) < s.runPollInterval {
+		return c.pending, c.demand, c.oldest, nil
+	}
+
+	pending, demand, oldest, err = s.pendingDemand(ctx)
+	if err != nil {
+		return 0, 0, time.Time{}, err
+	}
+	s.pendingCache = pendingSnapshot{pending: pending, demand: demand, oldest: oldest, fetchedAt: now}
+	return pending, demand, oldest, nil
+}
+
+// pendingDemand returns the pending run count and the number of agents those
+// runs need. Without run weights, or when the TFC client cannot split runs by
+// type, every pending run needs one agent. When the client can report the
+// pool-wide plan and apply counts, they are recorded from the same query.
+func (s *Scaler) pendingDemand(ctx context.Context) (pending, demand int, oldest time.Time, err error) {
+	var detail tfc.PendingRunDetail
+	if breakdown, ok := s.tfc.(pendingBreakdownReporter); ok {
+		var pool tfc.PendingRunCounts
+		detail, pool, err = breakdown.GetPendingRunsBreakdown(ctx)
+		if err != nil {
+			return 0, 0, time.Time{}, err
+		}
+		if recorder, ok := s.metrics.(pendingTypeRecorder); ok {
+			recorder.RecordPendingByType(pool.PlanPending, pool.ApplyPending)
+		}
+	} else if detailed, ok := s.tfc.(pendingDetailReporter); ok && s.statusWeights != nil {
+		detail, err = detailed.GetPendingRunsDetailed(ctx)
+		if err != nil {
+			return 0, 0, time.Time{}, err
+		}
+	} else {
+		reporter, ok := s.tfc.(pendingTypeReporter)
+		if !ok || (s.runWeights == nil && s.maxQueueWait <= 0) {
+			pending, err = s.tfc.GetPendingRuns(ctx)
+			return pending, pending, time.Time{}, err
+		}
+		detail.PendingRunCounts, err = reporter.GetPendingRunsByType(ctx)
+		if err != nil {
+			return 0, 0, time.Time{}, err
+		}
+	}
+
+	counts := detail.PendingRunCounts
+	demand = counts.Total()
+	switch {
+	case s.statusWeights != nil:
+		demand = s.statusWeightedDemand(detail)
+	case s.runWeights != nil:
+		demand = weightedDemand(counts, s.runWeights.plan, s.runWeights.apply)
+	}
+	return counts.Total(), demand, counts.Oldest(), nil
+}
+
+// applyMaxQueueWait reports how long the oldest pending run has been queued
+// and, once that exceeds maxQueueWait, raises desired to one more agent than
+// the current desired count (up to maxAgents). Smoothing and the computed
+// demand are bypassed because the count alone is not getting the run placed.
+// Nothing is forced while earlier tasks are still pending placement. The
+// returned flag reports whether the wait exceeded maxQueueWait.
+func (s *Scaler) applyMaxQueueWait(oldest time.Time, desired int, currentDesired, pendingTasks int32, maxAgents int) (int, bool) {
+	if s.maxQueueWait <= 0 {
+		return desired, false
+	}
+
+	var wait time.Duration
+	if !oldest.IsZero() {
+		wait = max(s.now().Sub(oldest), 0)
+	}
+	if recorder, ok := s.metrics.(queueWaitRecorder); ok {
+		recorder.RecordQueueWait(wait.Seconds())
+	}
+
+	if wait <= s.maxQueueWait {
+		return desired, false
+	}
+	if pendingTasks > 0 {
+		return desired, true
+	}
+	forced := min(max(desired, int(currentDesired)+1), maxAgents)
+	if forced > desired {
+		s.logger.Warn("pending run queued longer than max queue wait, forcing scale-up",
+			"scaler", s.name,
+			"queue_wait", wait,
+			"max_queue_wait", s.maxQueueWait,
+			"computed_desired", desired,
+			"forced_desired", forced,
+		)
+	}
+	return forced, true
+}
+
+// applyScaleToZeroGrace tracks how long the pool has had no busy agents and no
+// demand, and keeps one of the current agents until that has lasted
+// scaleToZeroGrace. It never raises desired above currentDesired.
+func (s *Scaler) applyScaleToZeroGrace(desired, demand, busy int, currentDesired int32) int {
+	if s.scaleToZeroGrace <= 0 {
+		return desired
+	}
+	now := s.now()
+	if demand > 0 || busy > 0 {
+		s.idleSince = time.Time{}
+		return desired
+	}
+	if s.idleSince.IsZero() {
+		s.idleSince = now
+	}
+	if desired > 0 || currentDesired == 0 || s.effectiveMinAgents() > 0 {
+		return desired
+	}
+	idleFor := now.Sub(s.idleSince)
+	if idleFor >= s.scaleToZeroGrace {
+		return desired
+	}
+	s.logger.Info("keeping last agent until scale-to-zero grace elapses",
+		"scaler", s.name,
+		"idle_for", idleFor,
+		"scale_to_zero_grace", s.scaleToZeroGrace,
+	)
+	return 1
+}
+
+// scaleUpStabilized reports whether a scale-up to desired may proceed. Demand
+// must have exceeded currentDesired for scaleUpStabilization since it first
+// did; an exceeded queue wait skips the window.
+func (s *Scaler) scaleUpStabilized(queueWaitExceeded bool, currentDesired int32, desired int) bool {
+	if s.scaleUpStabilization <= 0 {
+		return true
+	}
+	now := s.now()
+	if s.scaleUpSince.IsZero() {
+		s.scaleUpSince = now
+	}
+	if queueWaitExceeded {
+		return true
+	}
+	elapsed := now.Sub(s.scaleUpSince)
+	if elapsed >= s.scaleUpStabilization {
+		return true
+	}
+	s.logger.Info("skipping scale-up until demand stabilizes",
+		"scaler", s.name,
+		"current_desired", currentDesired,
+		"computed_desired", desired,
+		"elevated_for", elapsed,
+		"stabilization", s.scaleUpStabilization,
+	)
+	return false
+}
+
+// smoothDemand folds raw into the moving average of pending demand and
+// returns the larger of raw and the rounded average. Rounding to nearest lets
+// a decaying average reach zero; raw demand is always covered by the max.
+// Without smoothing it returns raw unchanged.
+func (s *Scaler) smoothDemand(raw int) int {
+	if s.smoothingAlpha <= 0 || s.smoothingAlpha >= 1 {
+		return raw
+	}
+	if !s.smoothedSeeded {
+		s.smoothedDemand = float64(raw)
+		s.smoothedSeeded = true
+	} else {
+		s.smoothedDemand = s.smoothingAlpha*float64(raw) + (1-s.smoothingAlpha)*s.smoothedDemand
+	}
+	return max(raw, int(math.Round(s.smoothedDemand)))
+}
+
+// statusWeightedDemand converts pending runs into agents, weighting runs in a
+// status listed in statusWeights by that weight and every other run by its
+// type's weight. Runs the client did not attribute to a status fall back to
+// the type weight too.
+func (s *Scaler) statusWeightedDemand(detail tfc.PendingRunDetail) int {
+	planWeight, applyWeight := 1.0, 1.0
+	if s.runWeights != nil {
+		planWeight, applyWeight = s.runWeights.plan, s.runWeights.apply
+	}
+	demand := typeDemand(detail.PlanPending, detail.PlanByStatus, planWeight, s.statusWeights) +
+		typeDemand(detail.ApplyPending, detail.ApplyByStatus, applyWeight, s.statusWeights)
+	// Trim floating-point noise so e.g. 10 * 0.3 does not round up to 4.
+	return int(math.Ceil(demand - 1e-9))
+}
+
+// typeDemand weights the pending runs of one type.
+func typeDemand(pending int, byStatus map[string]int, typeWeight float64, statusWeights map[string]float64) float64 {
+	var demand float64
+	for status, n := range byStatus {
+		weight, ok := statusWeights[status]
+		if !ok {
+			weight = typeWeight
+		}
+		demand += float64(n) * weight
+		pending -= n
+	}
+	return demand + float64(max(pending, 0))*typeWeight
+}
+
+// weightedDemand converts pending runs into agents using per-type weights,
+// rounding up so fractional demand still reserves an agent.
+func weightedDemand(counts tfc.PendingRunCounts, planWeight, applyWeight float64) int {
+	demand := float64(counts.PlanPending)*planWeight + float64(counts.ApplyPending)*applyWeight
+	// Trim floating-point noise so e.g. 10 * 0.3 does not round up to 4.
+	return int(math.Ceil(demand - 1e-9))
+}
+
+// effectiveMaxAgents returns maxAgents clamped to the TFC pool's agent limit
+// and the organization's run concurrency when the client reports them, never
+// going below minAgents. Failing to read a limit is not fatal; it is ignored.
+func (s *Scaler) effectiveMaxAgents(ctx context.Context) int {
+	maxAgents := s.maxAgents
+	if limit := s.poolLimit(ctx); limit > 0 && limit < maxAgents {
+		if limit != s.warnedPoolLimit {
+			s.logger.Warn("max agents exceeds TFC agent limit, clamping",
+				"scaler", s.name,
+				"max_agents", s.maxAgents,
+				"agent_limit", limit,
+			)
+			s.warnedPoolLimit = limit
+		}
+		maxAgents = limit
+	}
+	if limit := s.runConcurrency(ctx); limit > 0 && limit < maxAgents {
+		if limit != s.warnedRunConcurrency {
+			s.logger.Warn("max agents exceeds organization run concurrency, clamping",
+				"scaler", s.name,
+				"max_agents", maxAgents,
+				"run_concurrency", limit,
+			)
+			s.warnedRunConcurrency = limit
+		}
+		maxAgents = limit
+	}
+	if maxAgents == s.maxAgents {
+		return maxAgents
+	}
+	return max(maxAgents, s.effectiveMinAgents())
+}
+
+// poolLimit returns the TFC agent limit, or zero when it is unknown or
+// cannot be read.
+func (s *Scaler) poolLimit(ctx context.Context) int {
+	reporter, ok := s.tfc.(poolLimitReporter)
+	if !ok {
+		return 0
+	}
+	limit, err := reporter.GetPoolLimit(ctx)
+	if err != nil {
+		s.logger.Warn("failed to get pool agent limit", "scaler", s.name, "error", err)
+		return 0
+	}
+	return limit
+}
+
+// runConcurrency returns the organization's run concurrency limit, or zero
+// when it is unknown or cannot be read.
+func (s *Scaler) runConcurrency(ctx context.Context) int {
+	reporter, ok := s.tfc.(runConcurrencyReporter)
+	if !ok {
+		return 0
+	}
+	limit, err := reporter.GetRunConcurrency(ctx)
+	if err != nil {
+		s.logger.Warn("failed to get organization run concurrency", "scaler", s.name, "error", err)
+		return 0
+	}
+	return limit
+}
+
+// effectiveMinAgents returns minAgents, raised to the business hours minimum
+// while the schedule is active and capped at maxAgents.
+func (s *Scaler) effectiveMinAgents() int {
+	if s.businessHours == nil || !s.businessHours.Active(s.now()) {
+		return s.minAgents
+	}
+	return min(max(s.minAgents, s.businessHours.MinAgents), s.maxAgents)
+}
+
+// recordMaxClamp counts a reconcile whose demand of wanted agents exceeded
+// maxAgents and logs a warning at most once per maxClampWarnInterval.
+func (s *Scaler) recordMaxClamp(wanted, maxAgents int) {
+	if recorder, ok := s.metrics.(maxClampRecorder); ok {
+		recorder.RecordMaxClamp()
+	}
+
+	now := time.Now()
+	if !s.lastMaxClampWarning.IsZero() && now.Sub(s.lastMaxClampWarning) < maxClampWarnInterval {
+		return
+	}
+	s.lastMaxClampWarning = now
+	s.logger.Warn("demand exceeds max agents, clamping",
+		"scaler", s.name,
+		"wanted", wanted,
+		"max_agents", maxAgents,
+	)
+}
+
+// computeDesired calculates the target agent count. pendingRuns is the agent
+// demand of queued runs, already scaled by any run weights.
+// Formula: desired = max(min, min(pendingRuns + busyAgents + warmIdle, max))
+func computeDesired(pendingRuns, busyAgents, warmIdle, minAgents, maxAgents int) int {
+	desired := pendingRuns + busyAgents + warmIdle
+	return max(minAgents, min(desired, maxAgents))
+}
+
+// computeTargetDesired calculates the target agent count in target-tracking
+// mode, clamped to minAgents and maxAgents.
+func computeTargetDesired(pendingRuns, busyAgents, warmIdle int, ratio float64, minAgents, maxAgents int) int {
+	return max(minAgents, min(targetTrackingAgents(pendingRuns, busyAgents, warmIdle, ratio), maxAgents))
+}
+
+// targetTrackingAgents returns the unclamped agent count that keeps busy
+// agents at ratio of the pool, but never fewer than warmIdle idle agents,
+// plus pendingRuns of headroom.
+// Formula: max(ceil(busyAgents / ratio), busyAgents + warmIdle) + pendingRuns
+func targetTrackingAgents(pendingRuns, busyAgents, warmIdle int, ratio float64) int {
+	// Trim floating-point noise so e.g. 7 / 0.7 does not round up to 11.
+	tracked := int(math.Ceil(float64(busyAgents)/ratio - 1e-9))
+	return max(tracked, busyAgents+warmIdle) + pendingRuns
+}
+
+// wantedAgents returns the agent count the configured formula asks for
+// before clamping to the minimum and maximum.
+func (s *Scaler) wantedAgents(demand, busy int) int {
+	if s.targetBusyRatio > 0 {
+		return targetTrackingAgents(demand, busy, s.warmIdle, s.targetBusyRatio)
+	}
+	return demand + busy + s.warmIdle
 }
@@ -1,14 +1,25 @@
 package scaler
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"log/slog"
+	"maps"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/oulman/tfc-agent-autoscaler/internal/ecs"
+	"github.com/oulman/tfc-agent-autoscaler/internal/notify"
 	"github.com/oulman/tfc-agent-autoscaler/internal/tfc"
 )
 
@@ -34,12 +45,15 @@ func (m *mockTFC) GetAgentDetails(ctx context.Context) ([]tfc.AgentInfo, error)
 }
 
 type mockECS struct {
-	serviceStatusFn  func(ctx context.Context) (int32, int32, error)
+	serviceStatusFn  func(ctx context.Context) (ecs.ServiceStatus, error)
 	setDesiredFn     func(ctx context.Context, count int32) error
 	getTaskIPsFn     func(ctx context.Context) ([]ecs.TaskInfo, error)
 	setTaskProtFn    func(ctx context.Context, taskArns []string, enabled bool, expiresInMinutes int32) error
+	stopTaskFn       func(ctx context.Context, taskArn, reason string) error
+	interruptedFn    func(ctx context.Context) ([]ecs.TaskInfo, error)
 	lastDesiredCount int32
 	protectCalls     []protectCall
+	stoppedTasks     []string
 }
 
 type protectCall struct {
@@ -48,7 +62,7 @@ type protectCall struct {
 	expiresInMinutes int32
 }
 
-func (m *mockECS) GetServiceStatus(ctx context.Context) (int32, int32, error) {
+func (m *mockECS) GetServiceStatus(ctx context.Context) (ecs.ServiceStatus, error) {
 	return m.serviceStatusFn(ctx)
 }
 
@@ -72,11 +86,27 @@ func (m *mockECS) SetTaskProtection(ctx context.Context, taskArns []string, enab
 	return nil
 }
 
+func (m *mockECS) StopTask(ctx context.Context, taskArn, reason string) error {
+	m.stoppedTasks = append(m.stoppedTasks, taskArn)
+	if m.stopTaskFn != nil {
+		return m.stopTaskFn(ctx, taskArn, reason)
+	}
+	return nil
+}
+
+func (m *mockECS) GetInterruptedTasks(ctx context.Context) ([]ecs.TaskInfo, error) {
+	if m.interruptedFn != nil {
+		return m.interruptedFn(ctx)
+	}
+	return nil, nil
+}
+
 func TestComputeDesired(t *testing.T) {
 	tests := []struct {
 		name        string
 		pendingRuns int
 		busyAgents  int
+		warmIdle    int
 		minAgents   int
 		maxAgents   int
 		want        int
@@ -121,11 +151,109 @@ func TestComputeDesired(t *testing.T) {
 			maxAgents:   10,
 			want:        3,
 		},
+		{
+			name:        "warm idle added to demand",
+			pendingRuns: 2,
+			busyAgents:  1,
+			warmIdle:    2,
+			minAgents:   0,
+			maxAgents:   10,
+			want:        5,
+		},
+		{
+			name:        "warm idle with no work",
+			pendingRuns: 0,
+			busyAgents:  0,
+			warmIdle:    2,
+			minAgents:   0,
+			maxAgents:   10,
+			want:        2,
+		},
+		{
+			name:        "warm idle clamped to max",
+			pendingRuns: 8,
+			busyAgents:  1,
+			warmIdle:    3,
+			minAgents:   0,
+			maxAgents:   10,
+			want:        10,
+		},
+		{
+			name:        "min exceeds warm idle",
+			pendingRuns: 0,
+			busyAgents:  0,
+			warmIdle:    1,
+			minAgents:   3,
+			maxAgents:   10,
+			want:        3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := computeDesired(tt.pendingRuns, tt.busyAgents, tt.warmIdle, tt.minAgents, tt.maxAgents)
+			if got != tt.want {
+				t.Errorf("got %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWeightedDemand(t *testing.T) {
+	tests := []struct {
+		name        string
+		counts      tfc.PendingRunCounts
+		planWeight  float64
+		applyWeight float64
+		want        int
+	}{
+		{
+			name:        "unit weights",
+			counts:      tfc.PendingRunCounts{PlanPending: 3, ApplyPending: 2},
+			planWeight:  1,
+			applyWeight: 1,
+			want:        5,
+		},
+		{
+			name:        "heavier applies",
+			counts:      tfc.PendingRunCounts{PlanPending: 3, ApplyPending: 2},
+			planWeight:  1,
+			applyWeight: 2,
+			want:        7,
+		},
+		{
+			name:        "fractional rounded up",
+			counts:      tfc.PendingRunCounts{PlanPending: 3, ApplyPending: 1},
+			planWeight:  0.5,
+			applyWeight: 1.5,
+			want:        3,
+		},
+		{
+			name:        "single fractional run still needs an agent",
+			counts:      tfc.PendingRunCounts{PlanPending: 1},
+			planWeight:  0.25,
+			applyWeight: 1,
+			want:        1,
+		},
+		{
+			name:        "exact product not rounded past float noise",
+			counts:      tfc.PendingRunCounts{PlanPending: 10},
+			planWeight:  0.3,
+			applyWeight: 1,
+			want:        3,
+		},
+		{
+			name:        "no pending",
+			counts:      tfc.PendingRunCounts{},
+			planWeight:  0.5,
+			applyWeight: 2,
+			want:        0,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := computeDesired(tt.pendingRuns, tt.busyAgents, tt.minAgents, tt.maxAgents)
+			got := weightedDemand(tt.counts, tt.planWeight, tt.applyWeight)
 			if got != tt.want {
 				t.Errorf("got %d, want %d", got, tt.want)
 			}
@@ -220,8 +348,8 @@ func TestReconcile(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			ecsClient := &mockECS{
-				serviceStatusFn: func(_ context.Context) (int32, int32, error) {
-					return tt.currentDesired, tt.currentRunning, nil
+				serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+					return ecs.ServiceStatus{Desired: tt.currentDesired, Running: tt.currentRunning}, nil
 				},
 				setDesiredFn: func(_ context.Context, _ int32) error {
 					return nil
@@ -270,8 +398,8 @@ func TestReconcileDoesNotSignalReady(t *testing.T) {
 			},
 		},
 		&mockECS{
-			serviceStatusFn: func(_ context.Context) (int32, int32, error) {
-				return 0, 0, nil
+			serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+				return ecs.ServiceStatus{}, nil
 			},
 			setDesiredFn: func(_ context.Context, _ int32) error {
 				return nil
@@ -303,8 +431,8 @@ func TestRunSignalsReadyAfterFirstSuccess(t *testing.T) {
 			},
 		},
 		&mockECS{
-			serviceStatusFn: func(_ context.Context) (int32, int32, error) {
-				return 0, 0, nil
+			serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+				return ecs.ServiceStatus{}, nil
 			},
 			setDesiredFn: func(_ context.Context, _ int32) error {
 				return nil
@@ -325,45 +453,307 @@ func TestRunSignalsReadyAfterFirstSuccess(t *testing.T) {
 	cancel()
 }
 
-func TestRunDoesNotSignalReadyOnPersistentError(t *testing.T) {
+func TestRunStartupDelay(t *testing.T) {
+	const delay = 100 * time.Millisecond
+	reconciled := make(chan time.Time, 10)
 	s := New("test",
 		&mockTFC{
 			agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
-				return 0, 0, 0, errors.New("fail")
+				reconciled <- time.Now()
+				return 0, 0, 0, nil
 			},
 			pendingRunsFn: func(_ context.Context) (int, error) {
 				return 0, nil
 			},
 		},
 		&mockECS{
-			serviceStatusFn: func(_ context.Context) (int32, int32, error) {
-				return 0, 0, nil
-			},
-			setDesiredFn: func(_ context.Context, _ int32) error {
-				return nil
+			serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+				return ecs.ServiceStatus{}, nil
 			},
 		},
-		0, 10, 50*time.Millisecond, time.Minute, slog.Default(),
+		0, 10, time.Hour, time.Minute, slog.Default(),
+		WithStartupDelay(delay),
 	)
 
 	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	start := time.Now()
 	go func() { _ = s.Run(ctx) }()
 
-	// Give a few poll cycles for it to try.
-	time.Sleep(200 * time.Millisecond)
-	cancel()
+	select {
+	case at := <-reconciled:
+		if elapsed := at.Sub(start); elapsed < delay {
+			t.Errorf("first reconcile after %v, want at least %v", elapsed, delay)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no reconcile after the startup delay")
+	}
+	select {
+	case <-s.Ready():
+	case <-time.After(2 * time.Second):
+		t.Fatal("Ready channel was not closed after the first reconcile")
+	}
+}
+
+func TestRunStartupDelayCanceled(t *testing.T) {
+	var calls atomic.Int32
+	s := New("test",
+		&mockTFC{
+			agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+				calls.Add(1)
+				return 0, 0, 0, nil
+			},
+		},
+		&mockECS{},
+		0, 10, time.Hour, time.Minute, slog.Default(),
+		WithStartupDelay(time.Hour),
+	)
 
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := s.Run(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Run error = %v, want context.DeadlineExceeded", err)
+	}
+	if n := calls.Load(); n != 0 {
+		t.Errorf("reconciled %d times during the startup delay, want 0", n)
+	}
 	select {
 	case <-s.Ready():
-		t.Fatal("Ready channel should not be closed when all reconciles fail")
+		t.Error("Ready channel closed without a reconcile")
 	default:
 	}
 }
 
-func TestReadyChannelIsIdempotent(t *testing.T) {
+func TestRunDrainOnShutdown(t *testing.T) {
+	tests := []struct {
+		name          string
+		drain         bool
+		dryRun        bool
+		wantSetCalls  int
+		wantCount     int32
+		wantProtected bool
+	}{
+		{name: "drain scales to min", drain: true, wantSetCalls: 1, wantCount: 1, wantProtected: true},
+		{name: "no drain leaves count", drain: false, wantSetCalls: 0},
+		{name: "dry run drain does not call ECS", drain: true, dryRun: true, wantSetCalls: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var setCalls int
+			ecsClient := &mockECS{
+				// 3 busy agents and no pending work, so reconciles are no-ops.
+				serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+					return ecs.ServiceStatus{Desired: 3, Running: 3}, nil
+				},
+				setDesiredFn: func(ctx context.Context, _ int32) error {
+					if ctx.Err() != nil {
+						return ctx.Err()
+					}
+					setCalls++
+					return nil
+				},
+				getTaskIPsFn: func(_ context.Context) ([]ecs.TaskInfo, error) {
+					return []ecs.TaskInfo{
+						{TaskArn: "arn:task/1", PrivateIP: "10.0.0.1"},
+						{TaskArn: "arn:task/2", PrivateIP: "10.0.0.2"},
+						{TaskArn: "arn:task/3", PrivateIP: "10.0.0.3"},
+					}, nil
+				},
+			}
+
+			s := New("test",
+				&mockTFC{
+					agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+						return 3, 0, 3, nil
+					},
+					pendingRunsFn: func(_ context.Context) (int, error) {
+						return 0, nil
+					},
+					agentDetailsFn: func(_ context.Context) ([]tfc.AgentInfo, error) {
+						return []tfc.AgentInfo{
+							{ID: "a1", IP: "10.0.0.1", Status: "busy"},
+							{ID: "a2", IP: "10.0.0.2", Status: "busy"},
+							{ID: "a3", IP: "10.0.0.3", Status: "idle"},
+						}, nil
+					},
+				},
+				ecsClient,
+				1, 10, time.Hour, time.Minute, slog.Default(),
+				WithDrainOnShutdown(tt.drain),
+				WithDryRun(tt.dryRun),
+			)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan error, 1)
+			go func() { done <- s.Run(ctx) }()
+
+			select {
+			case <-s.Ready():
+			case <-time.After(2 * time.Second):
+				t.Fatal("scaler did not become ready")
+			}
+			cancel()
+
+			select {
+			case err := <-done:
+				if !errors.Is(err, context.Canceled) {
+					t.Errorf("Run returned %v, want context.Canceled", err)
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatal("Run did not return after cancel")
+			}
+
+			if setCalls != tt.wantSetCalls {
+				t.Fatalf("SetDesiredCount called %d times, want %d", setCalls, tt.wantSetCalls)
+			}
+			if tt.wantSetCalls > 0 && ecsClient.lastDesiredCount != tt.wantCount {
+				t.Errorf("drained to %d, want %d", ecsClient.lastDesiredCount, tt.wantCount)
+			}
+
+			protected := false
+			for _, c := range ecsClient.protectCalls {
+				if c.enabled && len(c.taskArns) == 2 {
+					protected = true
+				}
+			}
+			if protected != tt.wantProtected {
+				t.Errorf("busy tasks protected = %v, want %v (calls: %+v)", protected, tt.wantProtected, ecsClient.protectCalls)
+			}
+		})
+	}
+}
+
+func TestDrainWithoutTaskProtection(t *testing.T) {
+	tests := []struct {
+		name         string
+		busy, idle   int
+		protectErr   error
+		wantSetCalls int
+		wantCount    int32
+	}{
+		// 3 of 5 agents are busy, so the drain stops above MIN_AGENTS.
+		{name: "busy agents above min", busy: 3, idle: 2, wantSetCalls: 1, wantCount: 3},
+		{name: "no busy agents", busy: 0, idle: 5, wantSetCalls: 1, wantCount: 1},
+		{name: "all agents busy", busy: 5, wantSetCalls: 0},
+		// Protection is enabled but fails, so the busy tasks are unprotected.
+		{name: "protection fails", busy: 3, idle: 2, protectErr: errors.New("not authorized"), wantSetCalls: 1, wantCount: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var setCalls int
+			ecsClient := &mockECS{
+				serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+					return ecs.ServiceStatus{Desired: 5, Running: 5}, nil
+				},
+				setDesiredFn: func(_ context.Context, _ int32) error {
+					setCalls++
+					return nil
+				},
+				getTaskIPsFn: func(_ context.Context) ([]ecs.TaskInfo, error) {
+					return []ecs.TaskInfo{{TaskArn: "arn:task/1", PrivateIP: "10.0.0.1"}}, nil
+				},
+				setTaskProtFn: func(_ context.Context, _ []string, _ bool, _ int32) error {
+					return tt.protectErr
+				},
+			}
+			s := New("test",
+				&mockTFC{
+					agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+						return tt.busy, tt.idle, tt.busy + tt.idle, nil
+					},
+					agentDetailsFn: func(_ context.Context) ([]tfc.AgentInfo, error) {
+						return []tfc.AgentInfo{{ID: "a1", IP: "10.0.0.1", Status: "busy"}}, nil
+					},
+				},
+				ecsClient,
+				1, 10, time.Hour, time.Minute, slog.Default(),
+				WithDrainOnShutdown(true),
+				WithTaskProtection(tt.protectErr != nil),
+			)
+
+			if err := s.drain(context.Background()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if setCalls != tt.wantSetCalls {
+				t.Fatalf("SetDesiredCount called %d times, want %d", setCalls, tt.wantSetCalls)
+			}
+			if tt.wantSetCalls > 0 && ecsClient.lastDesiredCount != tt.wantCount {
+				t.Errorf("drained to %d, want %d", ecsClient.lastDesiredCount, tt.wantCount)
+			}
+			if tt.protectErr == nil && len(ecsClient.protectCalls) != 0 {
+				t.Errorf("task protection called with protection disabled: %+v", ecsClient.protectCalls)
+			}
+			if tt.protectErr != nil && len(ecsClient.protectCalls) == 0 {
+				t.Error("task protection was not attempted")
+			}
+		})
+	}
+}
+
+func TestNextPollInterval(t *testing.T) {
+	tests := []struct {
+		name   string
+		jitter float64
+	}{
+		{name: "no jitter", jitter: 0},
+		{name: "ten percent", jitter: 0.1},
+		{name: "half", jitter: 0.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Scaler{
+				pollInterval: 10 * time.Second,
+				pollJitter:   tt.jitter,
+				rand:         rand.New(rand.NewPCG(1, 2)),
+			}
+
+			lo := time.Duration(float64(s.pollInterval) * (1 - tt.jitter))
+			hi := time.Duration(float64(s.pollInterval) * (1 + tt.jitter))
+			seen := make(map[time.Duration]bool)
+			for range 1000 {
+				d := s.nextPollInterval()
+				if d < lo || d > hi {
+					t.Fatalf("interval %v outside [%v, %v]", d, lo, hi)
+				}
+				seen[d] = true
+			}
+			if tt.jitter == 0 && len(seen) != 1 {
+				t.Errorf("got %d distinct intervals without jitter, want 1", len(seen))
+			}
+			if tt.jitter > 0 && len(seen) < 2 {
+				t.Error("expected jitter to vary the interval")
+			}
+		})
+	}
+}
+
+func TestRunPollJitterTiming(t *testing.T) {
+	const (
+		interval = 50 * time.Millisecond
+		jitter   = 0.2
+		cycles   = 5
+		// slack absorbs scheduler delay; timers never fire early.
+		slack = 40 * time.Millisecond
+	)
+
+	var (
+		mu    sync.Mutex
+		calls []time.Time
+	)
+	enough := make(chan struct{})
+
 	s := New("test",
 		&mockTFC{
 			agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+				mu.Lock()
+				defer mu.Unlock()
+				calls = append(calls, time.Now())
+				if len(calls) == cycles+1 {
+					close(enough)
+				}
 				return 0, 0, 0, nil
 			},
 			pendingRunsFn: func(_ context.Context) (int, error) {
@@ -371,35 +761,101 @@ func TestReadyChannelIsIdempotent(t *testing.T) {
 			},
 		},
 		&mockECS{
-			serviceStatusFn: func(_ context.Context) (int32, int32, error) {
-				return 0, 0, nil
-			},
-			setDesiredFn: func(_ context.Context, _ int32) error {
-				return nil
+			serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+				return ecs.ServiceStatus{}, nil
 			},
 		},
-		0, 10, 50*time.Millisecond, time.Minute, slog.Default(),
+		0, 10, interval, time.Minute, slog.Default(),
+		WithPollJitter(jitter),
 	)
+	s.rand = rand.New(rand.NewPCG(3, 4))
 
 	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	start := time.Now()
 	go func() { _ = s.Run(ctx) }()
 
-	<-s.Ready()
-
-	// Multiple reads from a closed channel should all succeed.
 	select {
-	case <-s.Ready():
-		// expected
-	default:
-		t.Fatal("Ready channel should remain closed (idempotent)")
+	case <-enough:
+	case <-time.After(5 * time.Second):
+		t.Fatal("scaler did not complete enough reconcile cycles")
 	}
 	cancel()
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if first := calls[0].Sub(start); first > slack {
+		t.Errorf("first reconcile after %v, want immediate", first)
+	}
+
+	lo := time.Duration(float64(interval) * (1 - jitter))
+	hi := time.Duration(float64(interval)*(1+jitter)) + slack
+	for i := 1; i <= cycles; i++ {
+		gap := calls[i].Sub(calls[i-1])
+		if gap < lo || gap > hi {
+			t.Errorf("gap %d = %v, want within [%v, %v]", i, gap, lo, hi)
+		}
+	}
 }
 
-func TestReadyConcurrentAccess(t *testing.T) {
+func TestReconcileWithTimeoutSlowClient(t *testing.T) {
+	fm := &fakeMetrics{}
 	s := New("test",
 		&mockTFC{
-			agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+			agentPoolStatusFn: func(ctx context.Context) (int, int, int, error) {
+				// Simulate a hung API call that only returns when canceled.
+				<-ctx.Done()
+				return 0, 0, 0, ctx.Err()
+			},
+			pendingRunsFn: func(_ context.Context) (int, error) {
+				return 0, nil
+			},
+		},
+		&mockECS{},
+		0, 10, time.Second, time.Minute, slog.Default(),
+		WithReconcileTimeout(50*time.Millisecond),
+	)
+	s.SetMetrics(fm)
+
+	done := make(chan error, 1)
+	go func() { done <- s.reconcileWithTimeout(context.Background()) }()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("got error %v, want context.DeadlineExceeded", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("reconcile hung instead of timing out")
+	}
+
+	if fm.resultCalls != 1 || fm.lastSuccess {
+		t.Errorf("result calls = %d, lastSuccess = %v; want one error result", fm.resultCalls, fm.lastSuccess)
+	}
+}
+
+func TestRunContinuesAfterReconcileTimeout(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		calls int
+	)
+	recovered := make(chan struct{})
+
+	s := New("test",
+		&mockTFC{
+			agentPoolStatusFn: func(ctx context.Context) (int, int, int, error) {
+				mu.Lock()
+				calls++
+				n := calls
+				mu.Unlock()
+				if n == 1 {
+					<-ctx.Done()
+					return 0, 0, 0, ctx.Err()
+				}
+				if n == 2 {
+					close(recovered)
+				}
 				return 0, 0, 0, nil
 			},
 			pendingRunsFn: func(_ context.Context) (int, error) {
@@ -407,57 +863,279 @@ func TestReadyConcurrentAccess(t *testing.T) {
 			},
 		},
 		&mockECS{
-			serviceStatusFn: func(_ context.Context) (int32, int32, error) {
-				return 0, 0, nil
-			},
-			setDesiredFn: func(_ context.Context, _ int32) error {
-				return nil
+			serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+				return ecs.ServiceStatus{}, nil
 			},
 		},
-		0, 10, 50*time.Millisecond, time.Minute, slog.Default(),
+		0, 10, 20*time.Millisecond, time.Minute, slog.Default(),
+		WithReconcileTimeout(50*time.Millisecond),
 	)
 
 	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 	go func() { _ = s.Run(ctx) }()
 
-	// Multiple goroutines waiting on Ready should all unblock.
-	var wg sync.WaitGroup
-	for range 10 {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			<-s.Ready()
-		}()
+	select {
+	case <-recovered:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Run did not continue after a timed-out reconcile")
 	}
 
-	done := make(chan struct{})
-	go func() {
-		wg.Wait()
-		close(done)
-	}()
-
 	select {
-	case <-done:
-		// all goroutines unblocked
+	case <-s.Ready():
 	case <-time.After(2 * time.Second):
-		t.Fatal("not all goroutines unblocked from Ready channel")
+		t.Fatal("scaler not ready after a successful reconcile")
 	}
-	cancel()
 }
 
-type fakeMetrics struct {
-	reconcileCalls       int
-	lastBusy             int
-	lastIdle             int
-	lastTotal            int
-	lastPending          int
-	lastDesired          int
-	lastRunning          int
+func TestRunDoesNotSignalReadyOnPersistentError(t *testing.T) {
+	s := New("test",
+		&mockTFC{
+			agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+				return 0, 0, 0, errors.New("fail")
+			},
+			pendingRunsFn: func(_ context.Context) (int, error) {
+				return 0, nil
+			},
+		},
+		&mockECS{
+			serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+				return ecs.ServiceStatus{}, nil
+			},
+			setDesiredFn: func(_ context.Context, _ int32) error {
+				return nil
+			},
+		},
+		0, 10, 50*time.Millisecond, time.Minute, slog.Default(),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() { _ = s.Run(ctx) }()
+
+	// Give a few poll cycles for it to try.
+	time.Sleep(200 * time.Millisecond)
+	cancel()
+
+	select {
+	case <-s.Ready():
+		t.Fatal("Ready channel should not be closed when all reconciles fail")
+	default:
+	}
+}
+
+func TestRunStopsWhenPoolNotFound(t *testing.T) {
+	var calls int
+	s := New("test",
+		&mockTFC{
+			agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+				calls++
+				return 0, 0, 0, fmt.Errorf("listing agents: %w", tfc.ErrPoolNotFound)
+			},
+			pendingRunsFn: func(_ context.Context) (int, error) {
+				return 0, nil
+			},
+		},
+		&mockECS{
+			serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+				return ecs.ServiceStatus{}, nil
+			},
+		},
+		0, 10, 10*time.Millisecond, time.Minute, slog.Default(),
+	)
+
+	done := make(chan error, 1)
+	go func() { done <- s.Run(context.Background()) }()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, tfc.ErrPoolNotFound) {
+			t.Errorf("Run error: got %v, want ErrPoolNotFound", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Run kept polling after the agent pool was not found")
+	}
+	if calls != 1 {
+		t.Errorf("reconciles: got %d, want 1", calls)
+	}
+}
+
+func TestRunECSErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantStop bool
+	}{
+		{name: "access denied stops", err: fmt.Errorf("describing service: %w", ecs.ErrAccessDenied), wantStop: true},
+		{name: "throttling backs off", err: fmt.Errorf("describing service: %w", ecs.ErrThrottled)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			s := New("test",
+				&mockTFC{
+					agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+						return 0, 0, 0, nil
+					},
+					pendingRunsFn: func(_ context.Context) (int, error) {
+						return 0, nil
+					},
+				},
+				&mockECS{
+					serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+						calls.Add(1)
+						return ecs.ServiceStatus{}, tt.err
+					},
+				},
+				0, 10, 10*time.Millisecond, time.Minute, slog.Default(),
+			)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+			defer cancel()
+			err := s.Run(ctx)
+
+			if tt.wantStop {
+				if !errors.Is(err, tt.err) {
+					t.Errorf("Run error: got %v, want %v", err, tt.err)
+				}
+				if got := calls.Load(); got != 1 {
+					t.Errorf("reconciles: got %d, want 1", got)
+				}
+				return
+			}
+			if errors.Is(err, ecs.ErrThrottled) {
+				t.Errorf("Run stopped on throttling: %v", err)
+			}
+			// Backoff doubles the 10ms interval after each failure, so far
+			// fewer than the 20 unthrottled polls fit in the timeout.
+			if got := calls.Load(); got < 2 || got > 6 {
+				t.Errorf("reconciles: got %d, want between 2 and 6", got)
+			}
+		})
+	}
+}
+
+func TestReadyChannelIsIdempotent(t *testing.T) {
+	s := New("test",
+		&mockTFC{
+			agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+				return 0, 0, 0, nil
+			},
+			pendingRunsFn: func(_ context.Context) (int, error) {
+				return 0, nil
+			},
+		},
+		&mockECS{
+			serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+				return ecs.ServiceStatus{}, nil
+			},
+			setDesiredFn: func(_ context.Context, _ int32) error {
+				return nil
+			},
+		},
+		0, 10, 50*time.Millisecond, time.Minute, slog.Default(),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() { _ = s.Run(ctx) }()
+
+	<-s.Ready()
+
+	// Multiple reads from a closed channel should all succeed.
+	select {
+	case <-s.Ready():
+		// expected
+	default:
+		t.Fatal("Ready channel should remain closed (idempotent)")
+	}
+	cancel()
+}
+
+func TestReadyConcurrentAccess(t *testing.T) {
+	s := New("test",
+		&mockTFC{
+			agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+				return 0, 0, 0, nil
+			},
+			pendingRunsFn: func(_ context.Context) (int, error) {
+				return 0, nil
+			},
+		},
+		&mockECS{
+			serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+				return ecs.ServiceStatus{}, nil
+			},
+			setDesiredFn: func(_ context.Context, _ int32) error {
+				return nil
+			},
+		},
+		0, 10, 50*time.Millisecond, time.Minute, slog.Default(),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() { _ = s.Run(ctx) }()
+
+	// Multiple goroutines waiting on Ready should all unblock.
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-s.Ready()
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		// all goroutines unblocked
+	case <-time.After(2 * time.Second):
+		t.Fatal("not all goroutines unblocked from Ready channel")
+	}
+	cancel()
+}
+
+type fakeMetrics struct {
+	reconcileCalls       int
+	lastBusy             int
+	lastIdle             int
+	lastTotal            int
+	lastPending          int
+	lastDesired          int
+	lastRunning          int
 	resultCalls          int
 	lastSuccess          bool
 	scaleEvents          []string
+	dryRunScaleEvents    []string
 	cooldownSkips        int
 	taskProtectionErrors int
+	durations            []float64
+	timestamps           []time.Time
+	scaleDownReasons     []string
+	scaleDownSkips       []string
+	placementGaps        []int
+	placementStalls      int
+	maxClamps            int
+	countMismatches      int
+	orphanTasks          []int
+	orphanPersistent     int
+	cooldownRemaining    []float64
+	spotInterruptions    int
+	queueWait            []float64
+	pendingByType        [][2]int
+	agentsByStatus       []map[string]int
+	otherAgents          []int
+	writeCircuitOpen     []bool
+	cooldownHeld         []int
+	deploymentLimit      int
+	desired              [][2]int
+	withinDeadband       int
+	outcomes             []string
 }
 
 func (f *fakeMetrics) RecordReconcile(busy, idle, total, pending, desired, running int) {
@@ -479,6 +1157,10 @@ func (f *fakeMetrics) RecordScaleEvent(direction string) {
 	f.scaleEvents = append(f.scaleEvents, direction)
 }
 
+func (f *fakeMetrics) RecordDryRunScaleEvent(direction string) {
+	f.dryRunScaleEvents = append(f.dryRunScaleEvents, direction)
+}
+
 func (f *fakeMetrics) RecordCooldownSkip() {
 	f.cooldownSkips++
 }
@@ -487,11 +1169,99 @@ func (f *fakeMetrics) RecordTaskProtectionError() {
 	f.taskProtectionErrors++
 }
 
+func (f *fakeMetrics) RecordReconcileDuration(seconds float64) {
+	f.durations = append(f.durations, seconds)
+}
+
+func (f *fakeMetrics) RecordReconcileTimestamp(t time.Time) {
+	f.timestamps = append(f.timestamps, t)
+}
+
+func (f *fakeMetrics) RecordScaleDownReason(reason string) {
+	f.scaleDownReasons = append(f.scaleDownReasons, reason)
+}
+
+func (f *fakeMetrics) RecordScaleDownSkip(reason string) {
+	f.scaleDownSkips = append(f.scaleDownSkips, reason)
+}
+
+func (f *fakeMetrics) RecordPlacementGap(gap int) {
+	f.placementGaps = append(f.placementGaps, gap)
+}
+
+func (f *fakeMetrics) RecordPlacementStall() {
+	f.placementStalls++
+}
+
+func (f *fakeMetrics) RecordMaxClamp() {
+	f.maxClamps++
+}
+
+func (f *fakeMetrics) RecordDesiredCountMismatch() {
+	f.countMismatches++
+}
+
+func (f *fakeMetrics) RecordOrphanTasks(count int) {
+	f.orphanTasks = append(f.orphanTasks, count)
+}
+
+func (f *fakeMetrics) RecordOrphanTasksPersistent() {
+	f.orphanPersistent++
+}
+
+func (f *fakeMetrics) RecordCooldownRemaining(seconds float64) {
+	f.cooldownRemaining = append(f.cooldownRemaining, seconds)
+}
+
+func (f *fakeMetrics) RecordSpotInterruptions(count int) {
+	f.spotInterruptions += count
+}
+
+func (f *fakeMetrics) RecordQueueWait(seconds float64) {
+	f.queueWait = append(f.queueWait, seconds)
+}
+
+func (f *fakeMetrics) RecordOtherAgents(count int) {
+	f.otherAgents = append(f.otherAgents, count)
+}
+
+func (f *fakeMetrics) RecordCooldownHeldAgents(count int) {
+	f.cooldownHeld = append(f.cooldownHeld, count)
+}
+
+func (f *fakeMetrics) RecordDesired(computed, applied int) {
+	f.desired = append(f.desired, [2]int{computed, applied})
+}
+
+func (f *fakeMetrics) RecordDeploymentLimitExceeded() {
+	f.deploymentLimit++
+}
+
+func (f *fakeMetrics) RecordWithinDeadband() {
+	f.withinDeadband++
+}
+
+func (f *fakeMetrics) RecordReconcileOutcome(outcome string) {
+	f.outcomes = append(f.outcomes, outcome)
+}
+
+func (f *fakeMetrics) RecordECSWriteCircuitOpen(open bool) {
+	f.writeCircuitOpen = append(f.writeCircuitOpen, open)
+}
+
+func (f *fakeMetrics) RecordAgentsByStatus(counts map[string]int) {
+	f.agentsByStatus = append(f.agentsByStatus, counts)
+}
+
+func (f *fakeMetrics) RecordPendingByType(plan, apply int) {
+	f.pendingByType = append(f.pendingByType, [2]int{plan, apply})
+}
+
 func TestReconcileRecordsMetrics(t *testing.T) {
 	fm := &fakeMetrics{}
 	ecsClient := &mockECS{
-		serviceStatusFn: func(_ context.Context) (int32, int32, error) {
-			return 1, 1, nil
+		serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+			return ecs.ServiceStatus{Desired: 1, Running: 1}, nil
 		},
 		setDesiredFn: func(_ context.Context, _ int32) error {
 			return nil
@@ -536,6 +1306,11 @@ func TestReconcileRecordsMetrics(t *testing.T) {
 	if len(fm.scaleEvents) != 1 || fm.scaleEvents[0] != "up" {
 		t.Errorf("scale events = %v, want [up]", fm.scaleEvents)
 	}
+	if len(fm.durations) != 1 {
+		t.Errorf("RecordReconcileDuration called %d times, want 1", len(fm.durations))
+	} else if fm.durations[0] < 0 {
+		t.Errorf("duration = %v, want non-negative", fm.durations[0])
+	}
 }
 
 func TestReconcileCooldownSkipRecordsMetric(t *testing.T) {
@@ -550,8 +1325,8 @@ func TestReconcileCooldownSkipRecordsMetric(t *testing.T) {
 			},
 		},
 		ecs: &mockECS{
-			serviceStatusFn: func(_ context.Context) (int32, int32, error) {
-				return 5, 5, nil
+			serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+				return ecs.ServiceStatus{Desired: 5, Running: 5}, nil
 			},
 			setDesiredFn: func(_ context.Context, _ int32) error {
 				return nil
@@ -573,6 +1348,12 @@ func TestReconcileCooldownSkipRecordsMetric(t *testing.T) {
 	if fm.cooldownSkips != 1 {
 		t.Errorf("cooldown skips = %d, want 1", fm.cooldownSkips)
 	}
+	if len(fm.scaleDownSkips) != 1 || fm.scaleDownSkips[0] != "cooldown" {
+		t.Errorf("scale-down skips = %v, want [cooldown]", fm.scaleDownSkips)
+	}
+	if len(fm.durations) != 1 {
+		t.Errorf("RecordReconcileDuration called %d times, want 1", len(fm.durations))
+	}
 }
 
 func TestReconcileErrorRecordsMetric(t *testing.T) {
@@ -587,8 +1368,8 @@ func TestReconcileErrorRecordsMetric(t *testing.T) {
 			},
 		},
 		ecs: &mockECS{
-			serviceStatusFn: func(_ context.Context) (int32, int32, error) {
-				return 0, 0, nil
+			serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+				return ecs.ServiceStatus{}, nil
 			},
 			setDesiredFn: func(_ context.Context, _ int32) error {
 				return nil
@@ -605,6 +1386,58 @@ func TestReconcileErrorRecordsMetric(t *testing.T) {
 	if fm.lastSuccess {
 		t.Error("expected error result")
 	}
+	if len(fm.durations) != 1 {
+		t.Errorf("RecordReconcileDuration called %d times, want 1", len(fm.durations))
+	}
+}
+
+func TestReconcileRecordsTimestamp(t *testing.T) {
+	tests := []struct {
+		name    string
+		tfcErr  error
+		wantErr bool
+	}{
+		{name: "success", tfcErr: nil, wantErr: false},
+		{name: "failure", tfcErr: errors.New("fail"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fm := &fakeMetrics{}
+			s := &Scaler{
+				tfc: &mockTFC{
+					agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+						return 0, 0, 0, tt.tfcErr
+					},
+					pendingRunsFn: func(_ context.Context) (int, error) {
+						return 0, nil
+					},
+				},
+				ecs: &mockECS{
+					serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+						return ecs.ServiceStatus{}, nil
+					},
+				},
+				maxAgents: 10,
+				logger:    slog.Default(),
+				metrics:   fm,
+			}
+
+			before := time.Now()
+			err := s.Reconcile(context.Background())
+			after := time.Now()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if len(fm.timestamps) != 1 {
+				t.Fatalf("RecordReconcileTimestamp called %d times, want 1", len(fm.timestamps))
+			}
+			if ts := fm.timestamps[0]; ts.Before(before) || ts.After(after) {
+				t.Errorf("timestamp %v not within reconcile window [%v, %v]", ts, before, after)
+			}
+		})
+	}
 }
 
 func TestReconcileWithNilMetrics(t *testing.T) {
@@ -619,8 +1452,8 @@ func TestReconcileWithNilMetrics(t *testing.T) {
 			},
 		},
 		ecs: &mockECS{
-			serviceStatusFn: func(_ context.Context) (int32, int32, error) {
-				return 0, 0, nil
+			serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+				return ecs.ServiceStatus{}, nil
 			},
 			setDesiredFn: func(_ context.Context, _ int32) error {
 				return nil
@@ -647,8 +1480,8 @@ func TestReconcileTFCError(t *testing.T) {
 			},
 		},
 		ecs: &mockECS{
-			serviceStatusFn: func(_ context.Context) (int32, int32, error) {
-				return 0, 0, nil
+			serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+				return ecs.ServiceStatus{}, nil
 			},
 			setDesiredFn: func(_ context.Context, _ int32) error {
 				return nil
@@ -668,8 +1501,8 @@ func TestReconcileScaleDownCappedByIdleCount(t *testing.T) {
 	// but idle guard caps scale-down to removing only 2 idle agents.
 	// currentDesired=5, computedDesired=3, idle=2 → scaleDownBy=min(2,2)=2 → newDesired=3
 	ecsClient := &mockECS{
-		serviceStatusFn: func(_ context.Context) (int32, int32, error) {
-			return 5, 5, nil
+		serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+			return ecs.ServiceStatus{Desired: 5, Running: 5}, nil
 		},
 		setDesiredFn: func(_ context.Context, _ int32) error {
 			return nil
@@ -724,8 +1557,8 @@ func TestReconcileScaleDownCappedWhenMoreBusyThanComputed(t *testing.T) {
 	// currentDesired=5, computedDesired=0 (no work), but 3 busy + 2 idle
 	// idle guard: scaleDownBy=min(5-0, 2)=2 → newDesired=3
 	ecsClient := &mockECS{
-		serviceStatusFn: func(_ context.Context) (int32, int32, error) {
-			return 5, 5, nil
+		serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+			return ecs.ServiceStatus{Desired: 5, Running: 5}, nil
 		},
 		setDesiredFn: func(_ context.Context, _ int32) error {
 			return nil
@@ -779,8 +1612,8 @@ func TestReconcileScaleDownCappedWhenMoreBusyThanComputed(t *testing.T) {
 func TestReconcileAllBusyNoScaleDown(t *testing.T) {
 	// All agents busy, idle=0, computedDesired=3 == currentDesired=3 → no change
 	ecsClient := &mockECS{
-		serviceStatusFn: func(_ context.Context) (int32, int32, error) {
-			return 3, 3, nil
+		serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+			return ecs.ServiceStatus{Desired: 3, Running: 3}, nil
 		},
 		setDesiredFn: func(_ context.Context, _ int32) error {
 			t.Fatal("SetDesiredCount should not be called when no change needed")
@@ -812,8 +1645,8 @@ func TestReconcileAllBusyNoScaleDown(t *testing.T) {
 
 func TestReconcileBusyTasksGetProtected(t *testing.T) {
 	ecsClient := &mockECS{
-		serviceStatusFn: func(_ context.Context) (int32, int32, error) {
-			return 5, 5, nil
+		serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+			return ecs.ServiceStatus{Desired: 5, Running: 5}, nil
 		},
 		setDesiredFn: func(_ context.Context, _ int32) error {
 			return nil
@@ -891,8 +1724,8 @@ func TestReconcileBusyTasksGetProtected(t *testing.T) {
 func TestReconcileProtectionFailureIsNonFatal(t *testing.T) {
 	fm := &fakeMetrics{}
 	ecsClient := &mockECS{
-		serviceStatusFn: func(_ context.Context) (int32, int32, error) {
-			return 5, 5, nil
+		serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+			return ecs.ServiceStatus{Desired: 5, Running: 5}, nil
 		},
 		setDesiredFn: func(_ context.Context, _ int32) error {
 			return nil
@@ -939,23 +1772,76 @@ func TestReconcileProtectionFailureIsNonFatal(t *testing.T) {
 	}
 }
 
-func TestReconcileNoProtectionCallsOnScaleUp(t *testing.T) {
+func TestReconcileTaskProtectionDisabled(t *testing.T) {
+	fm := &fakeMetrics{}
 	ecsClient := &mockECS{
-		serviceStatusFn: func(_ context.Context) (int32, int32, error) {
-			return 2, 2, nil
+		serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+			return ecs.ServiceStatus{Desired: 5, Running: 5}, nil
 		},
 		setDesiredFn: func(_ context.Context, _ int32) error {
 			return nil
 		},
+		getTaskIPsFn: func(_ context.Context) ([]ecs.TaskInfo, error) {
+			return []ecs.TaskInfo{{TaskArn: "arn:task/1", PrivateIP: "10.0.0.1"}}, nil
+		},
+		setTaskProtFn: func(_ context.Context, _ []string, _ bool, _ int32) error {
+			return errors.New("AccessDeniedException")
+		},
 	}
 
-	s := &Scaler{
-		tfc: &mockTFC{
+	s := New("test",
+		&mockTFC{
 			agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
-				return 2, 0, 2, nil
+				return 1, 4, 5, nil
 			},
 			pendingRunsFn: func(_ context.Context) (int, error) {
-				return 5, nil
+				return 0, nil
+			},
+			agentDetailsFn: func(_ context.Context) ([]tfc.AgentInfo, error) {
+				return []tfc.AgentInfo{{ID: "a1", IP: "10.0.0.1", Status: "busy"}}, nil
+			},
+		},
+		ecsClient, 0, 10, time.Second, 0, slog.Default(),
+		WithTaskProtection(false),
+	)
+	s.SetMetrics(fm)
+
+	if err := s.Reconcile(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ecsClient.lastDesiredCount != 1 {
+		t.Errorf("scaled to %d, want 1 (idle guard still applies)", ecsClient.lastDesiredCount)
+	}
+
+	if err := s.drain(context.Background()); err != nil {
+		t.Fatalf("unexpected drain error: %v", err)
+	}
+
+	if len(ecsClient.protectCalls) != 0 {
+		t.Errorf("SetTaskProtection calls = %d, want 0", len(ecsClient.protectCalls))
+	}
+	if fm.taskProtectionErrors != 0 {
+		t.Errorf("task protection errors = %d, want 0", fm.taskProtectionErrors)
+	}
+}
+
+func TestReconcileNoProtectionCallsOnScaleUp(t *testing.T) {
+	ecsClient := &mockECS{
+		serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+			return ecs.ServiceStatus{Desired: 2, Running: 2}, nil
+		},
+		setDesiredFn: func(_ context.Context, _ int32) error {
+			return nil
+		},
+	}
+
+	s := &Scaler{
+		tfc: &mockTFC{
+			agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+				return 2, 0, 2, nil
+			},
+			pendingRunsFn: func(_ context.Context) (int, error) {
+				return 5, nil
 			},
 		},
 		ecs:       ecsClient,
@@ -977,8 +1863,8 @@ func TestReconcileNoProtectionCallsOnScaleUp(t *testing.T) {
 
 func TestReconcileNoProtectionCallsOnNoChange(t *testing.T) {
 	ecsClient := &mockECS{
-		serviceStatusFn: func(_ context.Context) (int32, int32, error) {
-			return 3, 3, nil
+		serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+			return ecs.ServiceStatus{Desired: 3, Running: 3}, nil
 		},
 		setDesiredFn: func(_ context.Context, _ int32) error {
 			return nil
@@ -1010,3 +1896,4290 @@ func TestReconcileNoProtectionCallsOnNoChange(t *testing.T) {
 		t.Errorf("expected no protection calls when no change, got %d", len(ecsClient.protectCalls))
 	}
 }
+
+func TestReconcileScaleDownStepLimit(t *testing.T) {
+	tests := []struct {
+		name             string
+		busy             int
+		idle             int
+		currentDesired   int32
+		maxScaleDownStep int
+		wantCount        int32
+	}{
+		{
+			name:             "unlimited step uses idle guard",
+			busy:             0,
+			idle:             10,
+			currentDesired:   10,
+			maxScaleDownStep: 0,
+			wantCount:        0,
+		},
+		{
+			name:             "step limit smaller than idle",
+			busy:             0,
+			idle:             10,
+			currentDesired:   10,
+			maxScaleDownStep: 3,
+			wantCount:        7,
+		},
+		{
+			name:             "idle guard smaller than step limit",
+			busy:             8,
+			idle:             2,
+			currentDesired:   10,
+			maxScaleDownStep: 5,
+			wantCount:        8,
+		},
+		{
+			name:             "step limit equal to idle",
+			busy:             6,
+			idle:             4,
+			currentDesired:   10,
+			maxScaleDownStep: 4,
+			wantCount:        6,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ecsClient := &mockECS{
+				serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+					return ecs.ServiceStatus{Desired: tt.currentDesired, Running: tt.currentDesired}, nil
+				},
+				setDesiredFn: func(_ context.Context, _ int32) error {
+					return nil
+				},
+			}
+
+			s := New("test",
+				&mockTFC{
+					agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+						return tt.busy, tt.idle, tt.busy + tt.idle, nil
+					},
+					pendingRunsFn: func(_ context.Context) (int, error) {
+						return 0, nil
+					},
+				},
+				ecsClient,
+				0, 20, time.Second, time.Minute, slog.Default(),
+				WithMaxScaleDownStep(tt.maxScaleDownStep),
+			)
+
+			if err := s.Reconcile(context.Background()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if ecsClient.lastDesiredCount != tt.wantCount {
+				t.Errorf("scaled to %d, want %d", ecsClient.lastDesiredCount, tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestReconcileWarmIdle(t *testing.T) {
+	tests := []struct {
+		name           string
+		busy           int
+		idle           int
+		pending        int
+		currentDesired int32
+		warmIdle       int
+		wantScale      bool
+		wantCount      int32
+	}{
+		{
+			name:           "buffer maintained with no work",
+			busy:           0,
+			idle:           2,
+			currentDesired: 2,
+			warmIdle:       2,
+			wantScale:      false,
+		},
+		{
+			name:           "scales up from zero to fill buffer",
+			busy:           0,
+			idle:           0,
+			currentDesired: 0,
+			warmIdle:       2,
+			wantScale:      true,
+			wantCount:      2,
+		},
+		{
+			name:           "scale-down stops at buffer",
+			busy:           0,
+			idle:           6,
+			currentDesired: 6,
+			warmIdle:       2,
+			wantScale:      true,
+			wantCount:      2,
+		},
+		{
+			name:           "idle guard keeps buffer when busy agents finish",
+			busy:           3,
+			idle:           3,
+			currentDesired: 6,
+			warmIdle:       2,
+			wantScale:      true,
+			wantCount:      5,
+		},
+		{
+			name:           "pending runs added on top of buffer",
+			busy:           1,
+			idle:           2,
+			pending:        3,
+			currentDesired: 3,
+			warmIdle:       2,
+			wantScale:      true,
+			wantCount:      6,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scaled := false
+			ecsClient := &mockECS{
+				serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+					return ecs.ServiceStatus{Desired: tt.currentDesired, Running: tt.currentDesired}, nil
+				},
+				setDesiredFn: func(_ context.Context, _ int32) error {
+					scaled = true
+					return nil
+				},
+			}
+
+			s := New("test",
+				&mockTFC{
+					agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+						return tt.busy, tt.idle, tt.busy + tt.idle, nil
+					},
+					pendingRunsFn: func(_ context.Context) (int, error) {
+						return tt.pending, nil
+					},
+				},
+				ecsClient,
+				0, 20, time.Second, time.Minute, slog.Default(),
+				WithWarmIdle(tt.warmIdle),
+			)
+
+			if err := s.Reconcile(context.Background()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if scaled != tt.wantScale {
+				t.Fatalf("scaled = %v, want %v", scaled, tt.wantScale)
+			}
+			if tt.wantScale && ecsClient.lastDesiredCount != tt.wantCount {
+				t.Errorf("scaled to %d, want %d", ecsClient.lastDesiredCount, tt.wantCount)
+			}
+		})
+	}
+}
+
+// mockTypedTFC adds per-type pending counts to mockTFC.
+type mockTypedTFC struct {
+	mockTFC
+	counts tfc.PendingRunCounts
+}
+
+func (m *mockTypedTFC) GetPendingRunsByType(_ context.Context) (tfc.PendingRunCounts, error) {
+	return m.counts, nil
+}
+
+func TestReconcileRunWeights(t *testing.T) {
+	tests := []struct {
+		name        string
+		counts      tfc.PendingRunCounts
+		busy        int
+		planWeight  float64
+		applyWeight float64
+		maxAgents   int
+		wantCount   int32
+		wantPending int
+	}{
+		{
+			name:        "apply weight reserves extra capacity",
+			counts:      tfc.PendingRunCounts{PlanPending: 2, ApplyPending: 2},
+			busy:        1,
+			planWeight:  1,
+			applyWeight: 2,
+			maxAgents:   20,
+			wantCount:   7,
+			wantPending: 4,
+		},
+		{
+			name:        "fractional weights rounded up",
+			counts:      tfc.PendingRunCounts{PlanPending: 3},
+			planWeight:  0.5,
+			applyWeight: 1,
+			maxAgents:   20,
+			wantCount:   2,
+			wantPending: 3,
+		},
+		{
+			name:        "weighted demand clamped to max",
+			counts:      tfc.PendingRunCounts{PlanPending: 2, ApplyPending: 5},
+			busy:        2,
+			planWeight:  1,
+			applyWeight: 3,
+			maxAgents:   10,
+			wantCount:   10,
+			wantPending: 7,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fm := &fakeMetrics{}
+			ecsClient := &mockECS{
+				serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+					return ecs.ServiceStatus{}, nil
+				},
+				setDesiredFn: func(_ context.Context, _ int32) error {
+					return nil
+				},
+			}
+
+			s := New("test",
+				&mockTypedTFC{
+					mockTFC: mockTFC{
+						agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+							return tt.busy, 0, tt.busy, nil
+						},
+						pendingRunsFn: func(_ context.Context) (int, error) {
+							t.Error("GetPendingRuns called; want GetPendingRunsByType")
+							return 0, nil
+						},
+					},
+					counts: tt.counts,
+				},
+				ecsClient,
+				0, tt.maxAgents, time.Second, time.Minute, slog.Default(),
+				WithRunWeights(tt.planWeight, tt.applyWeight),
+			)
+			s.SetMetrics(fm)
+
+			if err := s.Reconcile(context.Background()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if ecsClient.lastDesiredCount != tt.wantCount {
+				t.Errorf("scaled to %d, want %d", ecsClient.lastDesiredCount, tt.wantCount)
+			}
+			if fm.lastPending != tt.wantPending {
+				t.Errorf("pending metric = %d, want raw count %d", fm.lastPending, tt.wantPending)
+			}
+		})
+	}
+}
+
+func TestReconcileUnitWeightsUseTotalPending(t *testing.T) {
+	ecsClient := &mockECS{
+		serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+			return ecs.ServiceStatus{}, nil
+		},
+		setDesiredFn: func(_ context.Context, _ int32) error {
+			return nil
+		},
+	}
+
+	s := New("test",
+		&mockTypedTFC{
+			mockTFC: mockTFC{
+				agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+					return 0, 0, 0, nil
+				},
+				pendingRunsFn: func(_ context.Context) (int, error) {
+					return 4, nil
+				},
+			},
+			counts: tfc.PendingRunCounts{PlanPending: 100},
+		},
+		ecsClient,
+		0, 10, time.Second, time.Minute, slog.Default(),
+		WithRunWeights(1, 1),
+	)
+
+	if err := s.Reconcile(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ecsClient.lastDesiredCount != 4 {
+		t.Errorf("scaled to %d, want 4", ecsClient.lastDesiredCount)
+	}
+}
+
+// mockDetailedTFC adds per-status pending counts to mockTFC.
+type mockDetailedTFC struct {
+	mockTFC
+	detail tfc.PendingRunDetail
+}
+
+func (m *mockDetailedTFC) GetPendingRunsDetailed(_ context.Context) (tfc.PendingRunDetail, error) {
+	return m.detail, nil
+}
+
+func TestReconcileRunStatusWeights(t *testing.T) {
+	detail := tfc.PendingRunDetail{
+		PendingRunCounts: tfc.PendingRunCounts{PlanPending: 6, ApplyPending: 2},
+		PlanByStatus:     map[string]int{"pending": 4, "plan_queued": 2},
+		ApplyByStatus:    map[string]int{"apply_queued": 2},
+	}
+	tests := []struct {
+		name          string
+		statusWeights map[string]float64
+		opts          []Option
+		detail        tfc.PendingRunDetail
+		wantCount     int32
+	}{
+		{
+			name:          "pending runs weighted below queued runs",
+			statusWeights: map[string]float64{"pending": 0.5, "plan_queued": 1},
+			detail:        detail,
+			wantCount:     6,
+		},
+		{
+			name:          "unlisted statuses use the type weight",
+			statusWeights: map[string]float64{"pending": 0.25},
+			opts:          []Option{WithRunWeights(1, 2)},
+			detail:        detail,
+			wantCount:     7,
+		},
+		{
+			name:          "runs without a status use the type weight",
+			statusWeights: map[string]float64{"pending": 0.5},
+			detail: tfc.PendingRunDetail{
+				PendingRunCounts: tfc.PendingRunCounts{PlanPending: 5},
+				PlanByStatus:     map[string]int{"pending": 2},
+			},
+			wantCount: 4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fm := &fakeMetrics{}
+			ecsClient := &mockECS{
+				serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+					return ecs.ServiceStatus{}, nil
+				},
+				setDesiredFn: func(_ context.Context, _ int32) error {
+					return nil
+				},
+			}
+
+			opts := append(slices.Clone(tt.opts), WithRunStatusWeights(tt.statusWeights))
+			s := New("test",
+				&mockDetailedTFC{
+					mockTFC: mockTFC{
+						agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+							return 0, 0, 0, nil
+						},
+						pendingRunsFn: func(_ context.Context) (int, error) {
+							t.Error("GetPendingRuns called; want GetPendingRunsDetailed")
+							return 0, nil
+						},
+					},
+					detail: tt.detail,
+				},
+				ecsClient,
+				0, 20, time.Second, time.Minute, slog.Default(),
+				opts...,
+			)
+			s.SetMetrics(fm)
+
+			if err := s.Reconcile(context.Background()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if ecsClient.lastDesiredCount != tt.wantCount {
+				t.Errorf("scaled to %d, want %d", ecsClient.lastDesiredCount, tt.wantCount)
+			}
+			if fm.lastPending != tt.detail.Total() {
+				t.Errorf("pending metric = %d, want raw count %d", fm.lastPending, tt.detail.Total())
+			}
+		})
+	}
+}
+
+func TestNewWarnsRunStatusWeightsUnsupported(t *testing.T) {
+	tests := []struct {
+		name     string
+		tfc      TFCClient
+		wantWarn bool
+	}{
+		{name: "typed client", tfc: &mockTypedTFC{}, wantWarn: true},
+		{name: "detailed client", tfc: &mockDetailedTFC{}},
+		{name: "breakdown client", tfc: &mockBreakdownTFC{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			New("test", tt.tfc, &mockECS{}, 0, 10, time.Second, time.Minute, slog.New(slog.NewTextHandler(&logs, nil)),
+				WithRunStatusWeights(map[string]float64{"pending": 0.5}),
+			)
+			if got := strings.Contains(logs.String(), "ignoring run status weights"); got != tt.wantWarn {
+				t.Errorf("warned = %v, want %v; logs: %s", got, tt.wantWarn, logs.String())
+			}
+		})
+	}
+}
+
+func TestReconcileScaleDownReason(t *testing.T) {
+	tests := []struct {
+		name             string
+		pending          int
+		busy             int
+		idle             int
+		currentDesired   int32
+		minAgents        int
+		maxScaleDownStep int
+		wantCount        int32
+		wantReason       string
+	}{
+		{
+			name:           "no work",
+			busy:           0,
+			idle:           4,
+			currentDesired: 4,
+			wantCount:      0,
+			wantReason:     "no_work",
+		},
+		{
+			name:           "reduced demand",
+			pending:        1,
+			busy:           2,
+			idle:           3,
+			currentDesired: 5,
+			wantCount:      3,
+			wantReason:     "reduced_demand",
+		},
+		{
+			name:           "min clamp",
+			busy:           0,
+			idle:           5,
+			currentDesired: 5,
+			minAgents:      2,
+			wantCount:      2,
+			wantReason:     "min_clamp",
+		},
+		{
+			// Computed 0 but only 2 idle agents: capped by the idle guard.
+			name:           "idle guard caps scale-down",
+			busy:           0,
+			idle:           2,
+			currentDesired: 5,
+			wantCount:      3,
+			wantReason:     "idle_guard",
+		},
+		{
+			// Busy agents finished but the pool still reports more tasks than
+			// agents: the idle guard wins over the demand reduction.
+			name:           "idle guard with busy agents",
+			busy:           3,
+			idle:           1,
+			currentDesired: 6,
+			wantCount:      5,
+			wantReason:     "idle_guard",
+		},
+		{
+			name:             "step limit caps scale-down",
+			busy:             0,
+			idle:             6,
+			currentDesired:   6,
+			maxScaleDownStep: 2,
+			wantCount:        4,
+			wantReason:       "step_limit",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fm := &fakeMetrics{}
+			ecsClient := &mockECS{
+				serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+					return ecs.ServiceStatus{Desired: tt.currentDesired, Running: tt.currentDesired}, nil
+				},
+				setDesiredFn: func(_ context.Context, _ int32) error {
+					return nil
+				},
+			}
+
+			s := New("test",
+				&mockTFC{
+					agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+						return tt.busy, tt.idle, tt.busy + tt.idle, nil
+					},
+					pendingRunsFn: func(_ context.Context) (int, error) {
+						return tt.pending, nil
+					},
+				},
+				ecsClient,
+				tt.minAgents, 20, time.Second, time.Minute, slog.Default(),
+				WithMaxScaleDownStep(tt.maxScaleDownStep),
+			)
+			s.SetMetrics(fm)
+
+			if err := s.Reconcile(context.Background()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if ecsClient.lastDesiredCount != tt.wantCount {
+				t.Errorf("scaled to %d, want %d", ecsClient.lastDesiredCount, tt.wantCount)
+			}
+			if len(fm.scaleDownReasons) != 1 || fm.scaleDownReasons[0] != tt.wantReason {
+				t.Errorf("scale-down reasons = %v, want [%s]", fm.scaleDownReasons, tt.wantReason)
+			}
+			if len(fm.scaleEvents) != 1 || fm.scaleEvents[0] != "down" {
+				t.Errorf("scale events = %v, want [down]", fm.scaleEvents)
+			}
+		})
+	}
+}
+
+func TestReconcileScaleUpRecordsNoReason(t *testing.T) {
+	fm := &fakeMetrics{}
+	s := New("test",
+		&mockTFC{
+			agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+				return 0, 0, 0, nil
+			},
+			pendingRunsFn: func(_ context.Context) (int, error) {
+				return 3, nil
+			},
+		},
+		&mockECS{
+			serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+				return ecs.ServiceStatus{}, nil
+			},
+			setDesiredFn: func(_ context.Context, _ int32) error {
+				return nil
+			},
+		},
+		0, 10, time.Second, time.Minute, slog.Default(),
+	)
+	s.SetMetrics(fm)
+
+	if err := s.Reconcile(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(fm.scaleDownReasons) != 0 {
+		t.Errorf("scale-down reasons = %v, want none on scale-up", fm.scaleDownReasons)
+	}
+}
+
+func TestReconcileScaleDownIdleThreshold(t *testing.T) {
+	tests := []struct {
+		name      string
+		idle      int
+		threshold int
+		wantScale bool
+		wantCount int32
+	}{
+		{name: "idle below threshold", idle: 2, threshold: 3, wantScale: false},
+		{name: "idle equal to threshold", idle: 3, threshold: 3, wantScale: false},
+		{name: "idle just above threshold", idle: 4, threshold: 3, wantScale: true, wantCount: 2},
+		{name: "threshold disabled", idle: 2, threshold: 0, wantScale: true, wantCount: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 2 busy agents plus tt.idle idle agents, no pending work:
+			// computed desired is 2.
+			const busy = 2
+			fm := &fakeMetrics{}
+			scaled := false
+			ecsClient := &mockECS{
+				serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+					return ecs.ServiceStatus{Desired: int32(busy + tt.idle), Running: int32(busy + tt.idle)}, nil
+				},
+				setDesiredFn: func(_ context.Context, _ int32) error {
+					scaled = true
+					return nil
+				},
+			}
+
+			s := New("test",
+				&mockTFC{
+					agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+						return busy, tt.idle, busy + tt.idle, nil
+					},
+					pendingRunsFn: func(_ context.Context) (int, error) {
+						return 0, nil
+					},
+				},
+				ecsClient,
+				0, 20, time.Second, time.Minute, slog.Default(),
+				WithScaleDownIdleThreshold(tt.threshold),
+			)
+			s.SetMetrics(fm)
+
+			if err := s.Reconcile(context.Background()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if scaled != tt.wantScale {
+				t.Fatalf("scaled = %v, want %v", scaled, tt.wantScale)
+			}
+			if tt.wantScale {
+				if ecsClient.lastDesiredCount != tt.wantCount {
+					t.Errorf("scaled to %d, want %d", ecsClient.lastDesiredCount, tt.wantCount)
+				}
+				if len(fm.scaleDownSkips) != 0 {
+					t.Errorf("scale-down skips = %v, want none", fm.scaleDownSkips)
+				}
+				return
+			}
+			if len(fm.scaleDownSkips) != 1 || fm.scaleDownSkips[0] != "idle_threshold" {
+				t.Errorf("scale-down skips = %v, want [idle_threshold]", fm.scaleDownSkips)
+			}
+			if !fm.lastSuccess {
+				t.Error("expected a threshold skip to count as a successful reconcile")
+			}
+		})
+	}
+}
+
+func TestReconcileScaleUpStepLimit(t *testing.T) {
+	tests := []struct {
+		name           string
+		pending        int
+		busy           int
+		currentDesired int32
+		pendingTasks   int32
+		minAgents      int
+		maxAgents      int
+		maxScaleUpStep int
+		wantCount      int32
+	}{
+		{
+			name:           "unlimited step jumps to computed",
+			pending:        20,
+			currentDesired: 0,
+			maxAgents:      30,
+			wantCount:      20,
+		},
+		{
+			name:           "step limits +20 demand to +5",
+			pending:        20,
+			currentDesired: 0,
+			maxAgents:      30,
+			maxScaleUpStep: 5,
+			wantCount:      5,
+		},
+		{
+			name:           "step limit applies from non-zero current",
+			pending:        18,
+			busy:           2,
+			currentDesired: 2,
+			maxAgents:      30,
+			maxScaleUpStep: 5,
+			wantCount:      7,
+		},
+		{
+			name:           "step larger than delta has no effect",
+			pending:        3,
+			currentDesired: 0,
+			maxAgents:      30,
+			maxScaleUpStep: 5,
+			wantCount:      3,
+		},
+		{
+			name:           "step never blocks reaching min",
+			pending:        0,
+			currentDesired: 0,
+			minAgents:      8,
+			maxAgents:      30,
+			maxScaleUpStep: 5,
+			wantCount:      8,
+		},
+		{
+			name:           "max clamp still applies",
+			pending:        20,
+			currentDesired: 8,
+			maxAgents:      10,
+			maxScaleUpStep: 5,
+			wantCount:      10,
+		},
+		{
+			name:           "pending tasks count against step",
+			pending:        20,
+			currentDesired: 5,
+			pendingTasks:   3,
+			maxAgents:      30,
+			maxScaleUpStep: 5,
+			wantCount:      7,
+		},
+		{
+			name:           "step fully pending holds scale-up",
+			pending:        20,
+			currentDesired: 5,
+			pendingTasks:   5,
+			maxAgents:      30,
+			maxScaleUpStep: 5,
+			wantCount:      0,
+		},
+		{
+			name:           "pending tasks ignored without step",
+			pending:        20,
+			currentDesired: 5,
+			pendingTasks:   5,
+			maxAgents:      30,
+			wantCount:      20,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ecsClient := &mockECS{
+				serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+					return ecs.ServiceStatus{
+						Desired: tt.currentDesired,
+						Running: tt.currentDesired - tt.pendingTasks,
+						Pending: tt.pendingTasks,
+					}, nil
+				},
+				setDesiredFn: func(_ context.Context, _ int32) error {
+					return nil
+				},
+			}
+
+			s := New("test",
+				&mockTFC{
+					agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+						return tt.busy, 0, tt.busy, nil
+					},
+					pendingRunsFn: func(_ context.Context) (int, error) {
+						return tt.pending, nil
+					},
+				},
+				ecsClient,
+				tt.minAgents, tt.maxAgents, time.Second, time.Minute, slog.Default(),
+				WithMaxScaleUpStep(tt.maxScaleUpStep),
+			)
+
+			if err := s.Reconcile(context.Background()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if ecsClient.lastDesiredCount != tt.wantCount {
+				t.Errorf("scaled to %d, want %d", ecsClient.lastDesiredCount, tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestReconcileDryRun(t *testing.T) {
+	tests := []struct {
+		name           string
+		busy           int
+		idle           int
+		pending        int
+		currentDesired int32
+		wantDirection  string
+	}{
+		{
+			name:           "scale up",
+			pending:        4,
+			currentDesired: 1,
+			wantDirection:  "up",
+		},
+		{
+			name:           "scale down",
+			busy:           1,
+			idle:           3,
+			currentDesired: 4,
+			wantDirection:  "down",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fm := &fakeMetrics{}
+			ecsClient := &mockECS{
+				serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+					return ecs.ServiceStatus{Desired: tt.currentDesired, Running: tt.currentDesired}, nil
+				},
+				setDesiredFn: func(_ context.Context, _ int32) error {
+					t.Fatal("SetDesiredCount should not be called in dry-run mode")
+					return nil
+				},
+				getTaskIPsFn: func(_ context.Context) ([]ecs.TaskInfo, error) {
+					return []ecs.TaskInfo{{TaskArn: "arn:task/1", PrivateIP: "10.0.0.1"}}, nil
+				},
+			}
+
+			s := New("test",
+				&mockTFC{
+					agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+						return tt.busy, tt.idle, tt.busy + tt.idle, nil
+					},
+					pendingRunsFn: func(_ context.Context) (int, error) {
+						return tt.pending, nil
+					},
+					agentDetailsFn: func(_ context.Context) ([]tfc.AgentInfo, error) {
+						return []tfc.AgentInfo{{ID: "a1", IP: "10.0.0.1", Status: "busy"}}, nil
+					},
+				},
+				ecsClient,
+				0, 10, time.Second, time.Minute, slog.Default(),
+				WithDryRun(true),
+			)
+			s.SetMetrics(fm)
+
+			if err := s.Reconcile(context.Background()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(ecsClient.protectCalls) != 0 {
+				t.Errorf("expected no protection calls in dry-run mode, got %d", len(ecsClient.protectCalls))
+			}
+			if len(fm.scaleEvents) != 0 {
+				t.Errorf("scale events = %v, want none", fm.scaleEvents)
+			}
+			if len(fm.dryRunScaleEvents) != 1 || fm.dryRunScaleEvents[0] != tt.wantDirection {
+				t.Errorf("dry-run scale events = %v, want [%s]", fm.dryRunScaleEvents, tt.wantDirection)
+			}
+			if !fm.lastSuccess {
+				t.Error("expected success result")
+			}
+		})
+	}
+}
+
+func TestRunDryRunSignalsReady(t *testing.T) {
+	s := New("test",
+		&mockTFC{
+			agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+				return 0, 0, 0, nil
+			},
+			pendingRunsFn: func(_ context.Context) (int, error) {
+				return 3, nil
+			},
+		},
+		&mockECS{
+			serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+				return ecs.ServiceStatus{}, nil
+			},
+			setDesiredFn: func(_ context.Context, _ int32) error {
+				return errors.New("SetDesiredCount should not be called in dry-run mode")
+			},
+		},
+		0, 10, 50*time.Millisecond, time.Minute, slog.Default(),
+		WithDryRun(true),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = s.Run(ctx) }()
+
+	select {
+	case <-s.Ready():
+	case <-time.After(2 * time.Second):
+		t.Fatal("Ready channel was not closed in dry-run mode")
+	}
+}
+
+func TestReconcileTaskProtectionExpiry(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        []Option
+		wantMinutes int32
+	}{
+		{
+			name:        "default expiry",
+			wantMinutes: 120,
+		},
+		{
+			name:        "configured expiry",
+			opts:        []Option{WithTaskProtectionExpiry(6 * time.Hour)},
+			wantMinutes: 360,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ecsClient := &mockECS{
+				serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+					return ecs.ServiceStatus{Desired: 2, Running: 2}, nil
+				},
+				setDesiredFn: func(_ context.Context, _ int32) error {
+					return nil
+				},
+				getTaskIPsFn: func(_ context.Context) ([]ecs.TaskInfo, error) {
+					return []ecs.TaskInfo{
+						{TaskArn: "arn:task/1", PrivateIP: "10.0.0.1"},
+						{TaskArn: "arn:task/2", PrivateIP: "10.0.0.2"},
+					}, nil
+				},
+			}
+
+			s := New("test",
+				&mockTFC{
+					agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+						return 1, 1, 2, nil
+					},
+					pendingRunsFn: func(_ context.Context) (int, error) {
+						return 0, nil
+					},
+					agentDetailsFn: func(_ context.Context) ([]tfc.AgentInfo, error) {
+						return []tfc.AgentInfo{
+							{ID: "a1", IP: "10.0.0.1", Status: "busy"},
+							{ID: "a2", IP: "10.0.0.2", Status: "idle"},
+						}, nil
+					},
+				},
+				ecsClient,
+				0, 10, time.Second, time.Minute, slog.Default(),
+				tt.opts...,
+			)
+
+			if err := s.Reconcile(context.Background()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var enableCall *protectCall
+			for i := range ecsClient.protectCalls {
+				if ecsClient.protectCalls[i].enabled {
+					enableCall = &ecsClient.protectCalls[i]
+				}
+			}
+			if enableCall == nil {
+				t.Fatal("expected a protect-enable call for busy tasks")
+			}
+			if enableCall.expiresInMinutes != tt.wantMinutes {
+				t.Errorf("expiresInMinutes: got %d, want %d", enableCall.expiresInMinutes, tt.wantMinutes)
+			}
+		})
+	}
+}
+
+// recordingHandler is a slog.Handler that captures records for assertions.
+type recordingHandler struct {
+	mu      sync.Mutex
+	records []slog.Record
+}
+
+func (h *recordingHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *recordingHandler) Handle(_ context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records = append(h.records, r.Clone())
+	return nil
+}
+
+func (h *recordingHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+
+func (h *recordingHandler) WithGroup(string) slog.Handler { return h }
+
+// find returns all captured records with the given message.
+func (h *recordingHandler) find(msg string) []slog.Record {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var out []slog.Record
+	for _, r := range h.records {
+		if r.Message == msg {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// recordAttrs flattens a record's attributes into a map.
+func recordAttrs(r slog.Record) map[string]slog.Value {
+	attrs := make(map[string]slog.Value)
+	r.Attrs(func(a slog.Attr) bool {
+		attrs[a.Key] = a.Value
+		return true
+	})
+	return attrs
+}
+
+// mockWorkspaceTFC is a mockTFC that also reports per-workspace pending runs.
+type mockWorkspaceTFC struct {
+	mockTFC
+	workspaces []tfc.WorkspacePendingRuns
+	calls      int
+}
+
+func (m *mockWorkspaceTFC) GetPendingRunsByWorkspace(_ context.Context) ([]tfc.WorkspacePendingRuns, error) {
+	m.calls++
+	return m.workspaces, nil
+}
+
+func TestReconcileLogsTopWorkspacesOnScaleUp(t *testing.T) {
+	workspaces := make([]tfc.WorkspacePendingRuns, 0, 7)
+	for i := range 7 {
+		workspaces = append(workspaces, tfc.WorkspacePendingRuns{
+			WorkspaceID:   "ws-" + string(rune('a'+i)),
+			WorkspaceName: "name-" + string(rune('a'+i)),
+			PlanPending:   i,
+		})
+	}
+
+	tfcClient := &mockWorkspaceTFC{
+		mockTFC: mockTFC{
+			agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+				return 0, 0, 0, nil
+			},
+			pendingRunsFn: func(_ context.Context) (int, error) {
+				return 21, nil
+			},
+		},
+		workspaces: workspaces,
+	}
+
+	handler := &recordingHandler{}
+	s := New("test",
+		tfcClient,
+		&mockECS{
+			serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+				return ecs.ServiceStatus{}, nil
+			},
+			setDesiredFn: func(_ context.Context, _ int32) error {
+				return nil
+			},
+		},
+		0, 30, time.Second, time.Minute, slog.New(handler),
+	)
+
+	if err := s.Reconcile(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	records := handler.find("pending runs by workspace")
+	if len(records) != maxLoggedWorkspaces {
+		t.Fatalf("got %d workspace log lines, want %d", len(records), maxLoggedWorkspaces)
+	}
+	first := recordAttrs(records[0])
+	if got := first["workspace_id"].String(); got != "ws-g" {
+		t.Errorf("top workspace: got %s, want ws-g", got)
+	}
+	if got := first["plan_pending"].Int64(); got != 6 {
+		t.Errorf("top workspace plan_pending: got %d, want 6", got)
+	}
+	for _, r := range records {
+		if r.Level != slog.LevelDebug {
+			t.Errorf("workspace log level: got %v, want debug", r.Level)
+		}
+	}
+}
+
+func TestReconcileSkipsWorkspaceLookupWhenDebugDisabled(t *testing.T) {
+	tfcClient := &mockWorkspaceTFC{
+		mockTFC: mockTFC{
+			agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+				return 0, 0, 0, nil
+			},
+			pendingRunsFn: func(_ context.Context) (int, error) {
+				return 3, nil
+			},
+		},
+	}
+
+	s := New("test",
+		tfcClient,
+		&mockECS{
+			serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+				return ecs.ServiceStatus{}, nil
+			},
+			setDesiredFn: func(_ context.Context, _ int32) error {
+				return nil
+			},
+		},
+		0, 10, time.Second, time.Minute, slog.Default(),
+	)
+
+	if err := s.Reconcile(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tfcClient.calls != 0 {
+		t.Errorf("GetPendingRunsByWorkspace calls: got %d, want 0 at info level", tfcClient.calls)
+	}
+}
+
+func TestReconcileLogsScaleEvent(t *testing.T) {
+	tests := []struct {
+		name          string
+		busy, idle    int
+		pending       int
+		desired       int32
+		dryRun        bool
+		wantEvents    int
+		wantTo        int64
+		wantDirection string
+		wantReason    string
+	}{
+		{
+			name:          "scale up",
+			busy:          1,
+			idle:          0,
+			pending:       3,
+			desired:       1,
+			wantEvents:    1,
+			wantTo:        4,
+			wantDirection: "up",
+		},
+		{
+			name:          "scale down",
+			busy:          1,
+			idle:          3,
+			pending:       0,
+			desired:       4,
+			wantEvents:    1,
+			wantTo:        1,
+			wantDirection: "down",
+			wantReason:    reasonReducedDemand,
+		},
+		{
+			name:    "no change",
+			busy:    2,
+			pending: 0,
+			desired: 2,
+		},
+		{
+			name:    "dry run",
+			busy:    1,
+			pending: 3,
+			desired: 1,
+			dryRun:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := &recordingHandler{}
+			s := New("spot",
+				&mockTFC{
+					agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+						return tt.busy, tt.idle, tt.busy + tt.idle, nil
+					},
+					pendingRunsFn: func(_ context.Context) (int, error) {
+						return tt.pending, nil
+					},
+				},
+				&mockECS{
+					serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+						return ecs.ServiceStatus{Desired: tt.desired, Running: tt.desired}, nil
+					},
+					setDesiredFn: func(_ context.Context, _ int32) error {
+						return nil
+					},
+				},
+				0, 10, time.Second, 0, slog.New(handler),
+				WithDryRun(tt.dryRun),
+			)
+
+			if err := s.Reconcile(context.Background()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := len(handler.find("reconcile")); got != 1 {
+				t.Errorf("reconcile log lines: got %d, want 1", got)
+			}
+
+			events := handler.find("scale")
+			if len(events) != tt.wantEvents {
+				t.Fatalf("scale events: got %d, want %d", len(events), tt.wantEvents)
+			}
+			if tt.wantEvents == 0 {
+				return
+			}
+
+			attrs := recordAttrs(events[0])
+			for _, key := range []string{"event", "service", "from", "to", "direction", "reason", "pending", "busy", "idle", "total"} {
+				if _, ok := attrs[key]; !ok {
+					t.Errorf("missing attribute %q", key)
+				}
+			}
+			if got := attrs["event"].String(); got != "scale" {
+				t.Errorf("event: got %s, want scale", got)
+			}
+			if got := attrs["service"].String(); got != "spot" {
+				t.Errorf("service: got %s, want spot", got)
+			}
+			if got := attrs["from"].Int64(); got != int64(tt.desired) {
+				t.Errorf("from: got %d, want %d", got, tt.desired)
+			}
+			if got := attrs["to"].Int64(); got != tt.wantTo {
+				t.Errorf("to: got %d, want %d", got, tt.wantTo)
+			}
+			if got := attrs["direction"].String(); got != tt.wantDirection {
+				t.Errorf("direction: got %s, want %s", got, tt.wantDirection)
+			}
+			if got := attrs["reason"].String(); got != tt.wantReason {
+				t.Errorf("reason: got %q, want %q", got, tt.wantReason)
+			}
+			if got := attrs["pending"].Int64(); got != int64(tt.pending) {
+				t.Errorf("pending: got %d, want %d", got, tt.pending)
+			}
+			if got := attrs["busy"].Int64(); got != int64(tt.busy) {
+				t.Errorf("busy: got %d, want %d", got, tt.busy)
+			}
+			if got := attrs["idle"].Int64(); got != int64(tt.idle) {
+				t.Errorf("idle: got %d, want %d", got, tt.idle)
+			}
+			if got := attrs["total"].Int64(); got != int64(tt.busy+tt.idle) {
+				t.Errorf("total: got %d, want %d", got, tt.busy+tt.idle)
+			}
+		})
+	}
+}
+
+func TestRunReconcileTrigger(t *testing.T) {
+	var mu sync.Mutex
+	var calls int
+	reconciled := make(chan struct{}, 10)
+	trigger := make(chan struct{}, 1)
+
+	s := New("test",
+		&mockTFC{
+			agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+				mu.Lock()
+				calls++
+				mu.Unlock()
+				reconciled <- struct{}{}
+				return 0, 0, 0, nil
+			},
+			pendingRunsFn: func(_ context.Context) (int, error) {
+				return 0, nil
+			},
+		},
+		&mockECS{
+			serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+				return ecs.ServiceStatus{}, nil
+			},
+		},
+		0, 10, time.Hour, time.Minute, slog.Default(),
+		WithReconcileTrigger(trigger),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- s.Run(ctx) }()
+
+	waitReconcile := func() {
+		t.Helper()
+		select {
+		case <-reconciled:
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for reconcile")
+		}
+	}
+
+	// Initial reconcile on start, then one per trigger despite the hour-long interval.
+	waitReconcile()
+	trigger <- struct{}{}
+	waitReconcile()
+	trigger <- struct{}{}
+	waitReconcile()
+
+	cancel()
+	<-done
+
+	mu.Lock()
+	defer mu.Unlock()
+	if calls != 3 {
+		t.Errorf("reconciles: got %d, want 3", calls)
+	}
+}
+
+func TestSelectIdleForRemoval(t *testing.T) {
+	now := time.Date(2025, 1, 2, 12, 0, 0, 0, time.UTC)
+	old := ecs.TaskInfo{TaskArn: "old", StartedAt: now.Add(-5 * time.Hour)}
+	older := ecs.TaskInfo{TaskArn: "older", StartedAt: now.Add(-8 * time.Hour)}
+	young := ecs.TaskInfo{TaskArn: "young", StartedAt: now.Add(-10 * time.Minute)}
+	pending := ecs.TaskInfo{TaskArn: "pending"}
+
+	tests := []struct {
+		name          string
+		maxTaskAge    time.Duration
+		protectNewest bool
+		idle          []ecs.TaskInfo
+		scaleDownBy   int
+		wantRemovable []string
+		wantKept      []string
+	}{
+		{
+			name:          "disabled leaves every idle task removable",
+			idle:          []ecs.TaskInfo{young, old},
+			scaleDownBy:   1,
+			wantRemovable: []string{"young", "old"},
+		},
+		{
+			name:          "no expired task leaves choice to ECS",
+			maxTaskAge:    6 * time.Hour,
+			idle:          []ecs.TaskInfo{young, old},
+			scaleDownBy:   1,
+			wantRemovable: []string{"young", "old"},
+		},
+		{
+			name:          "expired task chosen over young",
+			maxTaskAge:    time.Hour,
+			idle:          []ecs.TaskInfo{young, old, pending},
+			scaleDownBy:   1,
+			wantRemovable: []string{"old"},
+			wantKept:      []string{"young", "pending"},
+		},
+		{
+			name:          "oldest expired first",
+			maxTaskAge:    time.Hour,
+			idle:          []ecs.TaskInfo{old, young, older},
+			scaleDownBy:   2,
+			wantRemovable: []string{"older", "old"},
+			wantKept:      []string{"young"},
+		},
+		{
+			name:          "removing every idle task",
+			maxTaskAge:    time.Hour,
+			idle:          []ecs.TaskInfo{young, old},
+			scaleDownBy:   2,
+			wantRemovable: []string{"young", "old"},
+		},
+		{
+			name:          "newest kept",
+			protectNewest: true,
+			idle:          []ecs.TaskInfo{old, young, older},
+			scaleDownBy:   1,
+			wantRemovable: []string{"old", "older"},
+			wantKept:      []string{"young"},
+		},
+		{
+			name:          "newest ignores tasks not started",
+			protectNewest: true,
+			idle:          []ecs.TaskInfo{pending, old},
+			scaleDownBy:   1,
+			wantRemovable: []string{"pending"},
+			wantKept:      []string{"old"},
+		},
+		{
+			name:          "newest removed with every idle task",
+			protectNewest: true,
+			idle:          []ecs.TaskInfo{young, old},
+			scaleDownBy:   2,
+			wantRemovable: []string{"young", "old"},
+		},
+		{
+			name:          "expired tasks take precedence over newest",
+			maxTaskAge:    time.Hour,
+			protectNewest: true,
+			idle:          []ecs.TaskInfo{young, old},
+			scaleDownBy:   1,
+			wantRemovable: []string{"old"},
+			wantKept:      []string{"young"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Scaler{maxTaskAge: tt.maxTaskAge, protectNewestIdle: tt.protectNewest}
+			removable, kept := s.selectIdleForRemoval(tt.idle, tt.scaleDownBy, now)
+			if got := taskArns(removable); !slices.Equal(got, tt.wantRemovable) {
+				t.Errorf("removable: got %v, want %v", got, tt.wantRemovable)
+			}
+			if got := taskArns(kept); !slices.Equal(got, tt.wantKept) {
+				t.Errorf("kept: got %v, want %v", got, tt.wantKept)
+			}
+		})
+	}
+}
+
+func TestReconcileMaxTaskAgePrefersOldIdleTasks(t *testing.T) {
+	now := time.Now()
+	ecsClient := &mockECS{
+		serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+			return ecs.ServiceStatus{Desired: 4, Running: 4}, nil
+		},
+		setDesiredFn: func(_ context.Context, _ int32) error {
+			return nil
+		},
+		getTaskIPsFn: func(_ context.Context) ([]ecs.TaskInfo, error) {
+			return []ecs.TaskInfo{
+				{TaskArn: "arn:task/busy", PrivateIP: "10.0.0.1", StartedAt: now.Add(-48 * time.Hour)},
+				{TaskArn: "arn:task/young-1", PrivateIP: "10.0.0.2", StartedAt: now.Add(-5 * time.Minute)},
+				{TaskArn: "arn:task/old", PrivateIP: "10.0.0.3", StartedAt: now.Add(-26 * time.Hour)},
+				{TaskArn: "arn:task/young-2", PrivateIP: "10.0.0.4", StartedAt: now.Add(-time.Hour)},
+			}, nil
+		},
+	}
+
+	s := New("test",
+		&mockTFC{
+			agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+				return 1, 3, 4, nil
+			},
+			pendingRunsFn: func(_ context.Context) (int, error) {
+				return 0, nil
+			},
+			agentDetailsFn: func(_ context.Context) ([]tfc.AgentInfo, error) {
+				return []tfc.AgentInfo{
+					{ID: "a1", IP: "10.0.0.1", Status: "busy"},
+					{ID: "a2", IP: "10.0.0.2", Status: "idle"},
+					{ID: "a3", IP: "10.0.0.3", Status: "idle"},
+					{ID: "a4", IP: "10.0.0.4", Status: "idle"},
+				}, nil
+			},
+		},
+		ecsClient,
+		0, 10, time.Second, 0, slog.Default(),
+		WithMaxScaleDownStep(1),
+		WithMaxTaskAge(24*time.Hour),
+	)
+
+	if err := s.Reconcile(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ecsClient.lastDesiredCount != 3 {
+		t.Errorf("desired count: got %d, want 3", ecsClient.lastDesiredCount)
+	}
+
+	var protected, unprotected []string
+	for _, c := range ecsClient.protectCalls {
+		if c.enabled {
+			protected = append(protected, c.taskArns...)
+		} else {
+			unprotected = append(unprotected, c.taskArns...)
+		}
+	}
+	if want := []string{"arn:task/old"}; !slices.Equal(unprotected, want) {
+		t.Errorf("unprotected: got %v, want %v", unprotected, want)
+	}
+	slices.Sort(protected)
+	if want := []string{"arn:task/busy", "arn:task/young-1", "arn:task/young-2"}; !slices.Equal(protected, want) {
+		t.Errorf("protected: got %v, want %v", protected, want)
+	}
+	if len(ecsClient.stoppedTasks) != 0 {
+		t.Errorf("stopped tasks during scale-down: got %v, want none", ecsClient.stoppedTasks)
+	}
+}
+
+func TestReconcileProtectsNewestIdleTask(t *testing.T) {
+	now := time.Now()
+	ecsClient := &mockECS{
+		serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+			return ecs.ServiceStatus{Desired: 4, Running: 4}, nil
+		},
+		setDesiredFn: func(_ context.Context, _ int32) error {
+			return nil
+		},
+		getTaskIPsFn: func(_ context.Context) ([]ecs.TaskInfo, error) {
+			return []ecs.TaskInfo{
+				{TaskArn: "arn:task/busy", PrivateIP: "10.0.0.1", StartedAt: now.Add(-time.Minute)},
+				{TaskArn: "arn:task/old", PrivateIP: "10.0.0.2", StartedAt: now.Add(-3 * time.Hour)},
+				{TaskArn: "arn:task/newest", PrivateIP: "10.0.0.3", StartedAt: now.Add(-5 * time.Minute)},
+				{TaskArn: "arn:task/middle", PrivateIP: "10.0.0.4", StartedAt: now.Add(-time.Hour)},
+			}, nil
+		},
+	}
+
+	s := New("test",
+		&mockTFC{
+			agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+				return 1, 3, 4, nil
+			},
+			pendingRunsFn: func(_ context.Context) (int, error) {
+				return 0, nil
+			},
+			agentDetailsFn: func(_ context.Context) ([]tfc.AgentInfo, error) {
+				return []tfc.AgentInfo{
+					{ID: "a1", IP: "10.0.0.1", Status: "busy"},
+					{ID: "a2", IP: "10.0.0.2", Status: "idle"},
+					{ID: "a3", IP: "10.0.0.3", Status: "idle"},
+					{ID: "a4", IP: "10.0.0.4", Status: "idle"},
+				}, nil
+			},
+		},
+		ecsClient,
+		0, 10, time.Second, 0, slog.Default(),
+		WithMaxScaleDownStep(2),
+		WithProtectNewestIdle(true),
+	)
+
+	if err := s.Reconcile(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ecsClient.lastDesiredCount != 2 {
+		t.Errorf("desired count: got %d, want 2", ecsClient.lastDesiredCount)
+	}
+
+	var protected, unprotected []string
+	for _, c := range ecsClient.protectCalls {
+		if c.enabled {
+			protected = append(protected, c.taskArns...)
+		} else {
+			unprotected = append(unprotected, c.taskArns...)
+		}
+	}
+	slices.Sort(protected)
+	if want := []string{"arn:task/busy", "arn:task/newest"}; !slices.Equal(protected, want) {
+		t.Errorf("protected: got %v, want %v", protected, want)
+	}
+	slices.Sort(unprotected)
+	if want := []string{"arn:task/middle", "arn:task/old"}; !slices.Equal(unprotected, want) {
+		t.Errorf("unprotected: got %v, want %v", unprotected, want)
+	}
+}
+
+func TestReconcileRecyclesExpiredIdleTask(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxTaskAge  time.Duration
+		dryRun      bool
+		wantStopped []string
+	}{
+		{name: "stops oldest expired idle task", maxTaskAge: 24 * time.Hour, wantStopped: []string{"arn:task/oldest"}},
+		{name: "nothing expired", maxTaskAge: 72 * time.Hour},
+		{name: "disabled", maxTaskAge: 0},
+		{name: "dry run", maxTaskAge: 24 * time.Hour, dryRun: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Now()
+			ecsClient := &mockECS{
+				// 1 busy agent plus 2 pending runs keeps the desired count at 3.
+				serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+					return ecs.ServiceStatus{Desired: 3, Running: 3}, nil
+				},
+				setDesiredFn: func(_ context.Context, _ int32) error {
+					t.Error("unexpected SetDesiredCount")
+					return nil
+				},
+				getTaskIPsFn: func(_ context.Context) ([]ecs.TaskInfo, error) {
+					return []ecs.TaskInfo{
+						{TaskArn: "arn:task/busy", PrivateIP: "10.0.0.1", StartedAt: now.Add(-60 * time.Hour)},
+						{TaskArn: "arn:task/old", PrivateIP: "10.0.0.2", StartedAt: now.Add(-30 * time.Hour)},
+						{TaskArn: "arn:task/oldest", PrivateIP: "10.0.0.3", StartedAt: now.Add(-50 * time.Hour)},
+					}, nil
+				},
+			}
+
+			s := New("test",
+				&mockTFC{
+					agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+						return 1, 2, 3, nil
+					},
+					pendingRunsFn: func(_ context.Context) (int, error) {
+						return 2, nil
+					},
+					agentDetailsFn: func(_ context.Context) ([]tfc.AgentInfo, error) {
+						return []tfc.AgentInfo{
+							{ID: "a1", IP: "10.0.0.1", Status: "busy"},
+							{ID: "a2", IP: "10.0.0.2", Status: "idle"},
+							{ID: "a3", IP: "10.0.0.3", Status: "idle"},
+						}, nil
+					},
+				},
+				ecsClient,
+				0, 10, time.Second, time.Minute, slog.Default(),
+				WithMaxTaskAge(tt.maxTaskAge),
+				WithDryRun(tt.dryRun),
+			)
+
+			if err := s.Reconcile(context.Background()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !slices.Equal(ecsClient.stoppedTasks, tt.wantStopped) {
+				t.Errorf("stopped tasks: got %v, want %v", ecsClient.stoppedTasks, tt.wantStopped)
+			}
+		})
+	}
+}
+
+func TestReconcileRecycleFailureIsNonFatal(t *testing.T) {
+	ecsClient := &mockECS{
+		serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+			return ecs.ServiceStatus{Desired: 1, Running: 1}, nil
+		},
+		getTaskIPsFn: func(_ context.Context) ([]ecs.TaskInfo, error) {
+			return []ecs.TaskInfo{
+				{TaskArn: "arn:task/old", PrivateIP: "10.0.0.1", StartedAt: time.Now().Add(-48 * time.Hour)},
+			}, nil
+		},
+		stopTaskFn: func(_ context.Context, _, _ string) error {
+			return errors.New("stop failed")
+		},
+	}
+
+	s := New("test",
+		&mockTFC{
+			agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+				return 0, 1, 1, nil
+			},
+			pendingRunsFn: func(_ context.Context) (int, error) {
+				return 1, nil
+			},
+			agentDetailsFn: func(_ context.Context) ([]tfc.AgentInfo, error) {
+				return []tfc.AgentInfo{{ID: "a1", IP: "10.0.0.1", Status: "idle"}}, nil
+			},
+		},
+		ecsClient,
+		0, 10, time.Second, time.Minute, slog.Default(),
+		WithMaxTaskAge(time.Hour),
+	)
+
+	if err := s.Reconcile(context.Background()); err != nil {
+		t.Fatalf("recycle failure should not fail reconcile: %v", err)
+	}
+	if len(ecsClient.stoppedTasks) != 1 {
+		t.Errorf("stop attempts: got %d, want 1", len(ecsClient.stoppedTasks))
+	}
+}
+
+// mockLimitTFC adds a pool agent limit to mockTFC.
+type mockLimitTFC struct {
+	mockTFC
+	limit    int
+	limitErr error
+}
+
+func (m *mockLimitTFC) GetPoolLimit(_ context.Context) (int, error) {
+	return m.limit, m.limitErr
+}
+
+func TestReconcileClampsToPoolLimit(t *testing.T) {
+	tests := []struct {
+		name      string
+		limit     int
+		limitErr  error
+		minAgents int
+		wantCount int32
+		wantWarn  bool
+	}{
+		{name: "no limit", limit: 0, wantCount: 10},
+		{name: "limit above max", limit: 50, wantCount: 10},
+		{name: "limit below max", limit: 6, wantCount: 6, wantWarn: true},
+		{name: "limit below min keeps min", limit: 2, minAgents: 3, wantCount: 3, wantWarn: true},
+		{name: "limit error falls back to max", limitErr: errors.New("boom"), wantCount: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := &recordingHandler{}
+			ecsClient := &mockECS{
+				serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+					return ecs.ServiceStatus{Desired: 1, Running: 1}, nil
+				},
+				setDesiredFn: func(_ context.Context, _ int32) error {
+					return nil
+				},
+			}
+			s := New("test",
+				&mockLimitTFC{
+					mockTFC: mockTFC{
+						agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+							return 1, 0, 1, nil
+						},
+						pendingRunsFn: func(_ context.Context) (int, error) {
+							return 20, nil
+						},
+					},
+					limit:    tt.limit,
+					limitErr: tt.limitErr,
+				},
+				ecsClient,
+				tt.minAgents, 10, time.Second, time.Minute, slog.New(handler),
+			)
+
+			// Reconcile twice to check the warning is not repeated.
+			for range 2 {
+				if err := s.Reconcile(context.Background()); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+			if ecsClient.lastDesiredCount != tt.wantCount {
+				t.Errorf("desired count: got %d, want %d", ecsClient.lastDesiredCount, tt.wantCount)
+			}
+
+			warnings := handler.find("max agents exceeds TFC agent limit, clamping")
+			if tt.wantWarn && len(warnings) != 1 {
+				t.Errorf("limit warnings: got %d, want 1", len(warnings))
+			}
+			if !tt.wantWarn && len(warnings) != 0 {
+				t.Errorf("limit warnings: got %d, want 0", len(warnings))
+			}
+		})
+	}
+}
+
+func TestRunReconcileFailureBackoff(t *testing.T) {
+	var fail bool
+	s := New("test",
+		&mockTFC{
+			agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+				if fail {
+					return 0, 0, 0, errors.New("tfc unavailable")
+				}
+				return 0, 0, 0, nil
+			},
+			pendingRunsFn: func(_ context.Context) (int, error) {
+				return 0, nil
+			},
+		},
+		&mockECS{
+			serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+				return ecs.ServiceStatus{}, nil
+			},
+		},
+		0, 10, 10*time.Second, time.Minute, slog.Default(),
+		WithFailureBackoffMax(time.Minute),
+	)
+	// Disable jitter so the intervals are exact.
+	s.rand = nil
+
+	if got := s.nextPollInterval(); got != 10*time.Second {
+		t.Fatalf("initial interval: got %v, want 10s", got)
+	}
+
+	fail = true
+	for i, want := range []time.Duration{
+		20 * time.Second,
+		40 * time.Second,
+		time.Minute,
+		time.Minute,
+	} {
+		if err := s.runReconcile(context.Background()); err != nil {
+			t.Fatalf("unexpected fatal error: %v", err)
+		}
+		if got := s.nextPollInterval(); got != want {
+			t.Errorf("after %d failures: got %v, want %v", i+1, got, want)
+		}
+	}
+
+	fail = false
+	if err := s.runReconcile(context.Background()); err != nil {
+		t.Fatalf("unexpected fatal error: %v", err)
+	}
+	if got := s.nextPollInterval(); got != 10*time.Second {
+		t.Errorf("after success: got %v, want 10s", got)
+	}
+}
+
+func TestRunReconcileReadyAfterSuccesses(t *testing.T) {
+	tests := []struct {
+		name      string
+		required  int
+		results   []bool // true = reconcile succeeds
+		wantReady []bool // readiness after each reconcile
+	}{
+		{name: "default is ready after first success", results: []bool{false, true}, wantReady: []bool{false, true}},
+		{
+			name:      "streak of three",
+			required:  3,
+			results:   []bool{true, true, false, true, true, true},
+			wantReady: []bool{false, false, false, false, false, true},
+		},
+		{
+			name:      "stays ready after a later failure",
+			required:  2,
+			results:   []bool{true, true, false},
+			wantReady: []bool{false, true, true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fail bool
+			s := New("test",
+				&mockTFC{
+					agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+						if fail {
+							return 0, 0, 0, errors.New("tfc unavailable")
+						}
+						return 0, 0, 0, nil
+					},
+					pendingRunsFn: func(_ context.Context) (int, error) {
+						return 0, nil
+					},
+				},
+				&mockECS{
+					serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+						return ecs.ServiceStatus{}, nil
+					},
+				},
+				0, 10, 10*time.Second, time.Minute, slog.Default(),
+				WithReadyAfterSuccesses(tt.required),
+			)
+
+			for i, ok := range tt.results {
+				fail = !ok
+				if err := s.runReconcile(context.Background()); err != nil {
+					t.Fatalf("unexpected fatal error: %v", err)
+				}
+				var ready bool
+				select {
+				case <-s.Ready():
+					ready = true
+				default:
+				}
+				if ready != tt.wantReady[i] {
+					t.Errorf("after reconcile %d: ready = %v, want %v", i+1, ready, tt.wantReady[i])
+				}
+			}
+		})
+	}
+}
+
+func TestNextPollIntervalBackoff(t *testing.T) {
+	tests := []struct {
+		name       string
+		failures   int
+		backoffMax time.Duration
+		jitter     float64
+		wantBase   time.Duration
+		wantJitter float64
+	}{
+		{name: "no failures", failures: 0, backoffMax: time.Minute, wantBase: 10 * time.Second},
+		{name: "backoff disabled", failures: 3, backoffMax: 0, wantBase: 10 * time.Second},
+		{name: "backoff is jittered", failures: 2, backoffMax: time.Minute, wantBase: 40 * time.Second, wantJitter: failureBackoffJitter},
+		{name: "larger poll jitter kept", failures: 1, backoffMax: time.Minute, jitter: 0.5, wantBase: 20 * time.Second, wantJitter: 0.5},
+		{name: "capped", failures: 10, backoffMax: time.Minute, wantBase: time.Minute, wantJitter: failureBackoffJitter},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Scaler{
+				pollInterval:        10 * time.Second,
+				pollJitter:          tt.jitter,
+				failureBackoffMax:   tt.backoffMax,
+				consecutiveFailures: tt.failures,
+				rand:                rand.New(rand.NewPCG(1, 2)),
+			}
+
+			lo := time.Duration(float64(tt.wantBase) * (1 - tt.wantJitter))
+			hi := time.Duration(float64(tt.wantBase) * (1 + tt.wantJitter))
+			seen := make(map[time.Duration]bool)
+			for range 1000 {
+				d := s.nextPollInterval()
+				if d < lo || d > hi {
+					t.Fatalf("interval %v outside [%v, %v]", d, lo, hi)
+				}
+				seen[d] = true
+			}
+			if tt.wantJitter > 0 && len(seen) < 2 {
+				t.Error("expected jitter to vary the interval")
+			}
+		})
+	}
+}
+
+func TestReconcileProtectsBusyTaskByAgentName(t *testing.T) {
+	ecsClient := &mockECS{
+		serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+			return ecs.ServiceStatus{Desired: 3, Running: 3}, nil
+		},
+		setDesiredFn: func(_ context.Context, _ int32) error {
+			return nil
+		},
+		getTaskIPsFn: func(_ context.Context) ([]ecs.TaskInfo, error) {
+			return []ecs.TaskInfo{
+				// The busy agent's task has not reported its private IP yet.
+				{TaskArn: "arn:aws:ecs:us-east-1:123:task/cluster/abc123"},
+				{TaskArn: "arn:aws:ecs:us-east-1:123:task/cluster/def456", Tags: map[string]string{ecs.AgentNameTag: "tagged-busy"}},
+				{TaskArn: "arn:aws:ecs:us-east-1:123:task/cluster/idle789", PrivateIP: "10.0.0.3"},
+			}, nil
+		},
+	}
+
+	s := New("test",
+		&mockTFC{
+			agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+				return 2, 1, 3, nil
+			},
+			pendingRunsFn: func(_ context.Context) (int, error) {
+				return 0, nil
+			},
+			agentDetailsFn: func(_ context.Context) ([]tfc.AgentInfo, error) {
+				return []tfc.AgentInfo{
+					{ID: "a1", Name: "tfc-agent-abc123", IP: "10.0.0.1", Status: "busy"},
+					{ID: "a2", Name: "tagged-busy", IP: "10.0.0.2", Status: "busy"},
+					{ID: "a3", Name: "tfc-agent-other", IP: "10.0.0.3", Status: "idle"},
+				}, nil
+			},
+		},
+		ecsClient,
+		0, 10, time.Second, 0, slog.Default(),
+	)
+
+	if err := s.Reconcile(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var protected, unprotected []string
+	for _, c := range ecsClient.protectCalls {
+		if c.enabled {
+			protected = append(protected, c.taskArns...)
+		} else {
+			unprotected = append(unprotected, c.taskArns...)
+		}
+	}
+	want := []string{
+		"arn:aws:ecs:us-east-1:123:task/cluster/abc123",
+		"arn:aws:ecs:us-east-1:123:task/cluster/def456",
+	}
+	if !slices.Equal(protected, want) {
+		t.Errorf("protected: got %v, want %v", protected, want)
+	}
+	if want := []string{"arn:aws:ecs:us-east-1:123:task/cluster/idle789"}; !slices.Equal(unprotected, want) {
+		t.Errorf("unprotected: got %v, want %v", unprotected, want)
+	}
+}
+
+func TestReconcileCorrelation(t *testing.T) {
+	const (
+		taskA = "arn:aws:ecs:us-east-1:123:task/cluster/aaa111"
+		taskB = "arn:aws:ecs:us-east-1:123:task/cluster/bbb222"
+	)
+	tests := []struct {
+		name            string
+		correlation     Correlation
+		wantProtected   []string
+		wantUnprotected []string
+	}{
+		// The agents report each other's task IPs, as after an IP is reused.
+		{name: "ip", correlation: CorrelateByIP, wantProtected: []string{taskB}, wantUnprotected: []string{taskA}},
+		{name: "name", correlation: CorrelateByName, wantProtected: []string{taskA}, wantUnprotected: []string{taskB}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ecsClient := &mockECS{
+				serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+					return ecs.ServiceStatus{Desired: 2, Running: 2}, nil
+				},
+				setDesiredFn: func(_ context.Context, _ int32) error {
+					return nil
+				},
+				getTaskIPsFn: func(_ context.Context) ([]ecs.TaskInfo, error) {
+					return []ecs.TaskInfo{
+						{TaskArn: taskA, PrivateIP: "10.0.0.1"},
+						{TaskArn: taskB, PrivateIP: "10.0.0.2"},
+					}, nil
+				},
+			}
+
+			s := New("test",
+				&mockTFC{
+					agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+						return 1, 1, 2, nil
+					},
+					pendingRunsFn: func(_ context.Context) (int, error) {
+						return 0, nil
+					},
+					agentDetailsFn: func(_ context.Context) ([]tfc.AgentInfo, error) {
+						return []tfc.AgentInfo{
+							{ID: "a1", Name: "aaa111", IP: "10.0.0.2", Status: "busy"},
+							{ID: "a2", Name: "bbb222", IP: "10.0.0.1", Status: "idle"},
+						}, nil
+					},
+				},
+				ecsClient,
+				0, 10, time.Second, 0, slog.Default(),
+				WithCorrelation(tt.correlation),
+			)
+
+			if err := s.Reconcile(context.Background()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var protected, unprotected []string
+			for _, c := range ecsClient.protectCalls {
+				if c.enabled {
+					protected = append(protected, c.taskArns...)
+				} else {
+					unprotected = append(unprotected, c.taskArns...)
+				}
+			}
+			if !slices.Equal(protected, tt.wantProtected) {
+				t.Errorf("protected: got %v, want %v", protected, tt.wantProtected)
+			}
+			if !slices.Equal(unprotected, tt.wantUnprotected) {
+				t.Errorf("unprotected: got %v, want %v", unprotected, tt.wantUnprotected)
+			}
+			if ecsClient.lastDesiredCount != 1 {
+				t.Errorf("scaled to %d, want 1", ecsClient.lastDesiredCount)
+			}
+		})
+	}
+}
+
+func TestUpdateSettingsAppliesToRunningScaler(t *testing.T) {
+	var mu sync.Mutex
+	var counts []int32
+	reconciled := make(chan struct{}, 10)
+	trigger := make(chan struct{}, 1)
+
+	ecsClient := &mockECS{
+		serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			if len(counts) == 0 {
+				return ecs.ServiceStatus{Desired: 1, Running: 1}, nil
+			}
+			return ecs.ServiceStatus{Desired: counts[len(counts)-1], Running: counts[len(counts)-1]}, nil
+		},
+		setDesiredFn: func(_ context.Context, count int32) error {
+			mu.Lock()
+			defer mu.Unlock()
+			counts = append(counts, count)
+			return nil
+		},
+	}
+
+	s := New("test",
+		&mockTFC{
+			agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+				return 0, 0, 0, nil
+			},
+			pendingRunsFn: func(_ context.Context) (int, error) {
+				defer func() { reconciled <- struct{}{} }()
+				return 20, nil
+			},
+		},
+		ecsClient,
+		0, 5, time.Hour, time.Minute, slog.Default(),
+		WithReconcileTrigger(trigger),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- s.Run(ctx) }()
+
+	waitReconcile := func() {
+		t.Helper()
+		select {
+		case <-reconciled:
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for reconcile")
+		}
+	}
+
+	waitReconcile()
+	s.UpdateSettings(Settings{MinAgents: 0, MaxAgents: 8, PollInterval: time.Hour, Cooldown: time.Minute})
+	trigger <- struct{}{}
+	waitReconcile()
+
+	cancel()
+	<-done
+
+	mu.Lock()
+	defer mu.Unlock()
+	if want := []int32{5, 8}; !slices.Equal(counts, want) {
+		t.Errorf("desired counts: got %v, want %v", counts, want)
+	}
+}
+
+func TestReconcilePlacementStall(t *testing.T) {
+	// Each entry is the running count seen by one reconcile; desired stays 5.
+	tests := []struct {
+		name       string
+		threshold  int
+		running    []int32
+		wantStalls []int
+	}{
+		{
+			name:       "counts after threshold",
+			threshold:  3,
+			running:    []int32{2, 2, 2, 2, 2},
+			wantStalls: []int{0, 0, 1, 2, 3},
+		},
+		{
+			name:       "placement resets streak",
+			threshold:  2,
+			running:    []int32{2, 5, 2, 2},
+			wantStalls: []int{0, 0, 0, 1},
+		},
+		{
+			name:       "disabled",
+			threshold:  0,
+			running:    []int32{2, 2, 2},
+			wantStalls: []int{0, 0, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var reconcile int
+			fm := &fakeMetrics{}
+			s := New("test",
+				&mockTFC{
+					agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+						return 0, 0, 0, nil
+					},
+					pendingRunsFn: func(_ context.Context) (int, error) {
+						return 5, nil
+					},
+				},
+				&mockECS{
+					serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+						return ecs.ServiceStatus{Desired: 5, Running: tt.running[reconcile]}, nil
+					},
+				},
+				0, 10, time.Second, time.Minute, slog.Default(),
+				WithPlacementStallThreshold(tt.threshold),
+			)
+			s.SetMetrics(fm)
+
+			for i := range tt.running {
+				reconcile = i
+				if err := s.Reconcile(context.Background()); err != nil {
+					t.Fatalf("reconcile %d: unexpected error: %v", i, err)
+				}
+				if fm.placementStalls != tt.wantStalls[i] {
+					t.Errorf("after reconcile %d: stalls = %d, want %d", i, fm.placementStalls, tt.wantStalls[i])
+				}
+				if want := int(5 - tt.running[i]); fm.placementGaps[i] != want {
+					t.Errorf("reconcile %d: gap = %d, want %d", i, fm.placementGaps[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestReconcileBudgetLimitsScaleUp(t *testing.T) {
+	budget := NewBudget(10)
+
+	// The sibling service already runs 7 agents.
+	sibling := New("spot",
+		&mockTFC{
+			agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+				return 7, 0, 7, nil
+			},
+			pendingRunsFn: func(_ context.Context) (int, error) {
+				return 0, nil
+			},
+		},
+		&mockECS{
+			serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+				return ecs.ServiceStatus{Desired: 7, Running: 7}, nil
+			},
+		},
+		0, 10, time.Second, time.Minute, slog.Default(),
+		WithBudget(budget),
+	)
+	if err := sibling.Reconcile(context.Background()); err != nil {
+		t.Fatalf("sibling reconcile: unexpected error: %v", err)
+	}
+
+	var setTo []int32
+	s := New("regular",
+		&mockTFC{
+			agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+				return 1, 0, 1, nil
+			},
+			pendingRunsFn: func(_ context.Context) (int, error) {
+				return 8, nil
+			},
+		},
+		&mockECS{
+			serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+				return ecs.ServiceStatus{Desired: 1, Running: 1}, nil
+			},
+			setDesiredFn: func(_ context.Context, count int32) error {
+				setTo = append(setTo, count)
+				return nil
+			},
+		},
+		0, 10, time.Second, time.Minute, slog.Default(),
+		WithBudget(budget),
+	)
+
+	// Demand is 9 agents but only 3 fit alongside the sibling's 7.
+	if err := s.Reconcile(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(setTo, []int32{3}) {
+		t.Errorf("SetDesiredCount calls = %v, want [3]", setTo)
+	}
+}
+
+func TestReconcileBudgetExhaustedSkipsScaleUp(t *testing.T) {
+	budget := NewBudget(5)
+	budget.observe("spot", 5)
+
+	s := New("regular",
+		&mockTFC{
+			agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+				return 0, 0, 0, nil
+			},
+			pendingRunsFn: func(_ context.Context) (int, error) {
+				return 4, nil
+			},
+		},
+		&mockECS{
+			serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+				return ecs.ServiceStatus{}, nil
+			},
+			setDesiredFn: func(_ context.Context, count int32) error {
+				t.Errorf("SetDesiredCount(%d) called with exhausted budget", count)
+				return nil
+			},
+		},
+		0, 10, time.Second, time.Minute, slog.Default(),
+		WithBudget(budget),
+	)
+
+	if err := s.Reconcile(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestReconcileRecordsMaxClamp(t *testing.T) {
+	tests := []struct {
+		name       string
+		pending    int
+		warmIdle   int
+		wantClamps int
+	}{
+		{name: "pending far exceeds max", pending: 50, wantClamps: 1},
+		{name: "demand at max", pending: 10, wantClamps: 0},
+		{name: "warm idle pushes over max", pending: 9, warmIdle: 2, wantClamps: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fm := &fakeMetrics{}
+			handler := &recordingHandler{}
+			ecsClient := &mockECS{
+				serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+					return ecs.ServiceStatus{}, nil
+				},
+				setDesiredFn: func(_ context.Context, _ int32) error {
+					return nil
+				},
+			}
+			s := New("test",
+				&mockTFC{
+					agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+						return 0, 0, 0, nil
+					},
+					pendingRunsFn: func(_ context.Context) (int, error) {
+						return tt.pending, nil
+					},
+				},
+				ecsClient,
+				0, 10, time.Second, time.Minute, slog.New(handler),
+				WithWarmIdle(tt.warmIdle),
+			)
+			s.SetMetrics(fm)
+
+			// A second reconcile must count again but not warn again.
+			for range 2 {
+				if err := s.Reconcile(context.Background()); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+
+			if fm.maxClamps != 2*tt.wantClamps {
+				t.Errorf("max clamps = %d, want %d", fm.maxClamps, 2*tt.wantClamps)
+			}
+			if ecsClient.lastDesiredCount != 10 {
+				t.Errorf("scaled to %d, want 10", ecsClient.lastDesiredCount)
+			}
+			if got := len(handler.find("demand exceeds max agents, clamping")); got != tt.wantClamps {
+				t.Errorf("clamp warnings = %d, want %d", got, tt.wantClamps)
+			}
+		})
+	}
+}
+
+func TestStateAfterReconcile(t *testing.T) {
+	s := New("test",
+		&mockTFC{
+			agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+				return 2, 1, 3, nil
+			},
+			pendingRunsFn: func(_ context.Context) (int, error) {
+				return 4, nil
+			},
+		},
+		&mockECS{
+			serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+				return ecs.ServiceStatus{Desired: 3, Running: 2}, nil
+			},
+			setDesiredFn: func(_ context.Context, _ int32) error {
+				return nil
+			},
+		},
+		0, 10, time.Second, time.Minute, slog.Default(),
+	)
+
+	if got := s.State(); got != (State{}) {
+		t.Errorf("State before reconcile = %+v, want zero", got)
+	}
+
+	before := time.Now()
+	if err := s.Reconcile(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := s.State()
+
+	if got.LastReconcile.Before(before) {
+		t.Errorf("LastReconcile = %v, want after %v", got.LastReconcile, before)
+	}
+	// The scale-up to 6 just started the cooldown.
+	if got.CooldownRemainingSeconds <= 50 || got.CooldownRemainingSeconds > 60 {
+		t.Errorf("CooldownRemainingSeconds = %v, want just under 60", got.CooldownRemainingSeconds)
+	}
+	got.LastReconcile = time.Time{}
+	got.CooldownRemainingSeconds = 0
+	want := State{
+		ComputedDesired: 6,
+		CurrentDesired:  3,
+		CurrentRunning:  2,
+		PendingRuns:     4,
+		BusyAgents:      2,
+		IdleAgents:      1,
+		TotalAgents:     3,
+	}
+	if got != want {
+		t.Errorf("State = %+v, want %+v", got, want)
+	}
+
+	data, err := json.Marshal(s.State())
+	if err != nil {
+		t.Fatalf("marshaling state: %v", err)
+	}
+	for _, key := range []string{
+		`"last_reconcile":`, `"computed_desired":6`, `"current_desired":3`, `"current_running":2`,
+		`"pending_runs":4`, `"busy_agents":2`, `"idle_agents":1`, `"total_agents":3`, `"cooldown_remaining_seconds":`,
+	} {
+		if !strings.Contains(string(data), key) {
+			t.Errorf("state JSON %s missing %s", data, key)
+		}
+	}
+}
+
+func TestReconcileScaleDownFactor(t *testing.T) {
+	tests := []struct {
+		name       string
+		factor     float64
+		cooldown   time.Duration
+		reconciles int
+		wantCounts []int32
+	}{
+		{
+			name:       "halves the gap until the target is reached",
+			factor:     0.5,
+			reconciles: 5,
+			wantCounts: []int32{5, 2, 1, 0},
+		},
+		{
+			name:       "small fraction still removes one agent",
+			factor:     0.1,
+			reconciles: 3,
+			wantCounts: []int32{9, 8, 7},
+		},
+		{
+			name:       "factor of one removes everything",
+			factor:     1,
+			reconciles: 2,
+			wantCounts: []int32{0},
+		},
+		{
+			name:       "cooldown still blocks later steps",
+			factor:     0.5,
+			cooldown:   time.Minute,
+			reconciles: 3,
+			wantCounts: []int32{5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current := int32(10)
+			var counts []int32
+			fm := &fakeMetrics{}
+			s := New("test",
+				&mockTFC{
+					agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+						// Every agent is idle and there is no pending work.
+						return 0, int(current), int(current), nil
+					},
+					pendingRunsFn: func(_ context.Context) (int, error) {
+						return 0, nil
+					},
+				},
+				&mockECS{
+					serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+						return ecs.ServiceStatus{Desired: current, Running: current}, nil
+					},
+					setDesiredFn: func(_ context.Context, count int32) error {
+						counts = append(counts, count)
+						current = count
+						return nil
+					},
+					getTaskIPsFn: func(_ context.Context) ([]ecs.TaskInfo, error) {
+						return nil, nil
+					},
+				},
+				0, 10, time.Second, tt.cooldown, slog.Default(),
+				WithScaleDownFactor(tt.factor),
+			)
+			s.SetMetrics(fm)
+
+			for range tt.reconciles {
+				if err := s.Reconcile(context.Background()); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+
+			if !slices.Equal(counts, tt.wantCounts) {
+				t.Errorf("SetDesiredCount calls = %v, want %v", counts, tt.wantCounts)
+			}
+			if tt.factor < 1 && tt.cooldown == 0 && (len(fm.scaleDownReasons) == 0 || fm.scaleDownReasons[0] != reasonScaleDownFactor) {
+				t.Errorf("scale-down reasons = %v, want first %q", fm.scaleDownReasons, reasonScaleDownFactor)
+			}
+			if tt.cooldown > 0 && fm.cooldownSkips != tt.reconciles-1 {
+				t.Errorf("cooldown skips = %d, want %d", fm.cooldownSkips, tt.reconciles-1)
+			}
+		})
+	}
+}
+
+func TestReconcileDesiredCountMismatch(t *testing.T) {
+	tests := []struct {
+		name           string
+		setErr         error
+		wantErr        bool
+		wantMismatches int
+		wantScaled     bool
+	}{
+		{name: "applied count matches", wantScaled: true},
+		{
+			name:           "another actor changed the count",
+			setErr:         &ecs.DesiredCountMismatchError{Requested: 6, Applied: 9},
+			wantMismatches: 1,
+			wantScaled:     true,
+		},
+		{
+			name:    "update failed",
+			setErr:  errors.New("throttled"),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fm := &fakeMetrics{}
+			handler := &recordingHandler{}
+			s := New("test",
+				&mockTFC{
+					agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+						return 0, 0, 0, nil
+					},
+					pendingRunsFn: func(_ context.Context) (int, error) {
+						return 6, nil
+					},
+				},
+				&mockECS{
+					serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+						return ecs.ServiceStatus{Desired: 2, Running: 2}, nil
+					},
+					setDesiredFn: func(_ context.Context, _ int32) error {
+						return tt.setErr
+					},
+				},
+				0, 10, time.Second, time.Minute, slog.New(handler),
+			)
+			s.SetMetrics(fm)
+
+			err := s.Reconcile(context.Background())
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if fm.countMismatches != tt.wantMismatches {
+				t.Errorf("mismatches = %d, want %d", fm.countMismatches, tt.wantMismatches)
+			}
+			if got := len(handler.find("ECS desired count differs from requested, service may have been updated concurrently")); got != tt.wantMismatches {
+				t.Errorf("mismatch warnings = %d, want %d", got, tt.wantMismatches)
+			}
+			if scaled := len(fm.scaleEvents) == 1; scaled != tt.wantScaled {
+				t.Errorf("scale events = %v, want scaled=%v", fm.scaleEvents, tt.wantScaled)
+			}
+		})
+	}
+}
+
+func TestReconcileBusinessHoursMinimum(t *testing.T) {
+	hours := &BusinessHours{MinAgents: 5, Start: 9 * time.Hour, End: 17 * time.Hour, Location: time.UTC}
+	// 2024-01-08 is a Monday.
+	tests := []struct {
+		name      string
+		now       time.Time
+		minAgents int
+		maxAgents int
+		wantCount int32
+	}{
+		{name: "inside window raises minimum", now: time.Date(2024, time.January, 8, 9, 0, 0, 0, time.UTC), maxAgents: 10, wantCount: 5},
+		{name: "outside window uses base minimum", now: time.Date(2024, time.January, 8, 17, 0, 0, 0, time.UTC), minAgents: 1, maxAgents: 10, wantCount: 1},
+		{name: "weekend uses base minimum", now: time.Date(2024, time.January, 13, 12, 0, 0, 0, time.UTC), minAgents: 1, maxAgents: 10, wantCount: 1},
+		{name: "base minimum above schedule wins", now: time.Date(2024, time.January, 8, 12, 0, 0, 0, time.UTC), minAgents: 7, maxAgents: 10, wantCount: 7},
+		{name: "capped at max", now: time.Date(2024, time.January, 8, 12, 0, 0, 0, time.UTC), maxAgents: 3, wantCount: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ecsClient := &mockECS{
+				serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+					return ecs.ServiceStatus{}, nil
+				},
+				setDesiredFn: func(_ context.Context, _ int32) error {
+					return nil
+				},
+			}
+			s := New("test",
+				&mockTFC{
+					agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+						return 0, 0, 0, nil
+					},
+					pendingRunsFn: func(_ context.Context) (int, error) {
+						return 0, nil
+					},
+				},
+				ecsClient,
+				tt.minAgents, tt.maxAgents, time.Second, time.Minute, slog.Default(),
+				WithBusinessHours(hours),
+			)
+			s.now = func() time.Time { return tt.now }
+
+			if err := s.Reconcile(context.Background()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if ecsClient.lastDesiredCount != tt.wantCount {
+				t.Errorf("scaled to %d, want %d", ecsClient.lastDesiredCount, tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestReconcileOrphanTasks(t *testing.T) {
+	tests := []struct {
+		name           string
+		running        int32
+		total          int
+		threshold      int
+		reconciles     int
+		wantOrphans    int
+		wantPersistent int
+	}{
+		{name: "agents never registered", running: 5, total: 2, threshold: 3, reconciles: 4, wantOrphans: 3, wantPersistent: 2},
+		{name: "below threshold", running: 5, total: 2, threshold: 3, reconciles: 2, wantOrphans: 3, wantPersistent: 0},
+		{name: "all registered", running: 5, total: 5, threshold: 1, reconciles: 3, wantOrphans: 0, wantPersistent: 0},
+		{name: "more agents than tasks", running: 2, total: 4, threshold: 1, reconciles: 2, wantOrphans: 0, wantPersistent: 0},
+		{name: "threshold disabled", running: 5, total: 2, reconciles: 3, wantOrphans: 3, wantPersistent: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fm := &fakeMetrics{}
+			s := New("test",
+				&mockTFC{
+					agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+						return 0, tt.total, tt.total, nil
+					},
+					pendingRunsFn: func(_ context.Context) (int, error) {
+						return 0, nil
+					},
+				},
+				&mockECS{
+					serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+						return ecs.ServiceStatus{Desired: tt.running, Running: tt.running}, nil
+					},
+					setDesiredFn: func(_ context.Context, _ int32) error {
+						return nil
+					},
+				},
+				0, 10, time.Second, time.Hour, slog.Default(),
+				WithOrphanTaskThreshold(tt.threshold),
+			)
+			s.SetMetrics(fm)
+			// Keep the cooldown active so no scale-down happens.
+			s.lastScaleTime = time.Now()
+
+			for range tt.reconciles {
+				if err := s.Reconcile(context.Background()); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+
+			if got := fm.orphanTasks[len(fm.orphanTasks)-1]; got != tt.wantOrphans {
+				t.Errorf("orphan tasks = %d, want %d", got, tt.wantOrphans)
+			}
+			if fm.orphanPersistent != tt.wantPersistent {
+				t.Errorf("persistent reports = %d, want %d", fm.orphanPersistent, tt.wantPersistent)
+			}
+		})
+	}
+}
+
+func TestSmoothDemand(t *testing.T) {
+	tests := []struct {
+		name  string
+		alpha float64
+		raw   []int
+		want  []int
+	}{
+		{name: "disabled", alpha: 1, raw: []int{4, 0, 6, 0}, want: []int{4, 0, 6, 0}},
+		{name: "zero disables", alpha: 0, raw: []int{4, 0}, want: []int{4, 0}},
+		{name: "spike reacts immediately", alpha: 0.5, raw: []int{0, 8}, want: []int{0, 8}},
+		{name: "decays after spike", alpha: 0.5, raw: []int{8, 0, 0, 0, 0, 0}, want: []int{8, 4, 2, 1, 1, 0}},
+		{name: "converges to steady demand", alpha: 0.3, raw: []int{0, 5, 5, 5, 5, 5, 5, 5, 5, 5}, want: []int{0, 5, 5, 5, 5, 5, 5, 5, 5, 5}},
+		{name: "dip is held", alpha: 0.2, raw: []int{6, 6, 1, 6}, want: []int{6, 6, 5, 6}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New("test", &mockTFC{}, &mockECS{}, 0, 10, time.Second, time.Minute, slog.Default(),
+				WithSmoothingAlpha(tt.alpha))
+			for i, raw := range tt.raw {
+				if got := s.smoothDemand(raw); got != tt.want[i] {
+					t.Errorf("reconcile %d: smoothDemand(%d) = %d, want %d", i, raw, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestReconcileSmoothedScaleDown(t *testing.T) {
+	pending := []int{6, 0, 0, 0, 0, 0, 0}
+	var call int
+	ecsMock := &mockECS{
+		serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+			return ecs.ServiceStatus{Desired: 6, Running: 6}, nil
+		},
+		setDesiredFn: func(_ context.Context, _ int32) error {
+			return nil
+		},
+	}
+	s := New("test",
+		&mockTFC{
+			agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+				return 0, 6, 6, nil
+			},
+			pendingRunsFn: func(_ context.Context) (int, error) {
+				p := pending[call]
+				call++
+				return p, nil
+			},
+		},
+		ecsMock,
+		0, 10, time.Second, 0, slog.Default(),
+		WithSmoothingAlpha(0.5),
+	)
+
+	var got []int32
+	for range pending {
+		ecsMock.lastDesiredCount = 6
+		if err := s.Reconcile(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		got = append(got, ecsMock.lastDesiredCount)
+	}
+
+	// Smoothed demand after the spike: 6, 3, 2 (1.5 rounds to 2), 1 (0.75), 0.
+	want := []int32{6, 3, 2, 1, 0, 0, 0}
+	if !slices.Equal(got, want) {
+		t.Errorf("desired counts = %v, want %v", got, want)
+	}
+}
+
+func TestReconcileCooldownHeldAgents(t *testing.T) {
+	fm := &fakeMetrics{}
+	s := New("test",
+		&mockTFC{
+			agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+				return 0, 5, 5, nil
+			},
+			pendingRunsFn: func(_ context.Context) (int, error) {
+				return 0, nil
+			},
+		},
+		&mockECS{
+			serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+				return ecs.ServiceStatus{Desired: 5, Running: 5}, nil
+			},
+			setDesiredFn: func(_ context.Context, _ int32) error {
+				return nil
+			},
+		},
+		1, 10, time.Second, time.Minute, slog.Default(),
+	)
+	s.SetMetrics(fm)
+
+	// Cooldown blocks the scale-down from 5 to the minimum of 1.
+	s.lastScaleTime = time.Now()
+	if err := s.Reconcile(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Once the cooldown has passed the scale-down proceeds.
+	s.lastScaleTime = time.Time{}
+	if err := s.Reconcile(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := []int{4, 0}; !slices.Equal(fm.cooldownHeld, want) {
+		t.Errorf("cooldown held agents = %v, want %v", fm.cooldownHeld, want)
+	}
+}
+
+func TestReconcileComputedAndAppliedDesired(t *testing.T) {
+	fm := &fakeMetrics{}
+	s := New("test",
+		&mockTFC{
+			// 3 busy, 2 idle, no pending: the formula wants 3, but the idle
+			// guard allows removing only the 2 idle agents from 6 tasks.
+			agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+				return 3, 2, 5, nil
+			},
+			pendingRunsFn: func(_ context.Context) (int, error) {
+				return 0, nil
+			},
+		},
+		&mockECS{
+			serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+				return ecs.ServiceStatus{Desired: 6, Running: 6}, nil
+			},
+			setDesiredFn: func(_ context.Context, _ int32) error {
+				return nil
+			},
+		},
+		0, 10, time.Second, 0, slog.Default(),
+	)
+	s.SetMetrics(fm)
+
+	if err := s.Reconcile(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := [][2]int{{3, 4}}; !slices.Equal(fm.desired, want) {
+		t.Errorf("computed/applied desired = %v, want %v", fm.desired, want)
+	}
+}
+
+func TestReconcileDeploymentLimit(t *testing.T) {
+	tests := []struct {
+		name           string
+		maximumPercent int32
+		pending        int
+		wantExceeded   int
+	}{
+		{name: "within maximum percent", maximumPercent: 200, pending: 8, wantExceeded: 0},
+		{name: "beyond maximum percent", maximumPercent: 150, pending: 8, wantExceeded: 1},
+		{name: "no deployment configuration", maximumPercent: 0, pending: 8, wantExceeded: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fm := &fakeMetrics{}
+			ecsClient := &mockECS{
+				serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+					return ecs.ServiceStatus{Desired: 4, Running: 4, MaximumPercent: tt.maximumPercent}, nil
+				},
+				setDesiredFn: func(_ context.Context, _ int32) error {
+					return nil
+				},
+			}
+			s := New("test",
+				&mockTFC{
+					agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+						return 0, 0, 0, nil
+					},
+					pendingRunsFn: func(_ context.Context) (int, error) {
+						return tt.pending, nil
+					},
+				},
+				ecsClient,
+				0, 20, time.Second, time.Minute, slog.Default(),
+			)
+			s.SetMetrics(fm)
+
+			if err := s.Reconcile(context.Background()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			// The warning does not hold the scale-up back.
+			if ecsClient.lastDesiredCount != 8 {
+				t.Errorf("desired count = %d, want 8", ecsClient.lastDesiredCount)
+			}
+			if fm.deploymentLimit != tt.wantExceeded {
+				t.Errorf("deployment limit exceeded = %d, want %d", fm.deploymentLimit, tt.wantExceeded)
+			}
+		})
+	}
+}
+
+func TestReconcileCooldownRemaining(t *testing.T) {
+	fm := &fakeMetrics{}
+	pending := 3
+	s := New("test",
+		&mockTFC{
+			agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+				return 0, 0, 0, nil
+			},
+			pendingRunsFn: func(_ context.Context) (int, error) {
+				return pending, nil
+			},
+		},
+		&mockECS{
+			serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+				return ecs.ServiceStatus{Desired: 0, Running: 0}, nil
+			},
+			setDesiredFn: func(_ context.Context, _ int32) error {
+				return nil
+			},
+		},
+		0, 10, time.Second, time.Minute, slog.Default(),
+	)
+	s.SetMetrics(fm)
+
+	// No scale yet: nothing to wait for.
+	pending = 0
+	if err := s.Reconcile(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := fm.cooldownRemaining[0]; got != 0 {
+		t.Errorf("before any scale: cooldown remaining = %v, want 0", got)
+	}
+
+	pending = 3
+	if err := s.Reconcile(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := fm.cooldownRemaining[1]
+	if got <= 59 || got > 60 {
+		t.Errorf("after scale: cooldown remaining = %v, want just under 60", got)
+	}
+}
+
+func TestComputeTargetDesired(t *testing.T) {
+	tests := []struct {
+		name        string
+		pendingRuns int
+		busyAgents  int
+		warmIdle    int
+		ratio       float64
+		minAgents   int
+		maxAgents   int
+		want        int
+	}{
+		{name: "idle pool", ratio: 0.7, maxAgents: 20, want: 0},
+		{name: "idle pool at min", ratio: 0.7, minAgents: 2, maxAgents: 20, want: 2},
+		{name: "one busy rounds up", busyAgents: 1, ratio: 0.7, maxAgents: 20, want: 2},
+		{name: "exact multiple", busyAgents: 7, ratio: 0.7, maxAgents: 20, want: 10},
+		{name: "rounds up", busyAgents: 8, ratio: 0.7, maxAgents: 20, want: 12},
+		{name: "half busy", busyAgents: 5, ratio: 0.5, maxAgents: 20, want: 10},
+		{name: "ratio one matches busy", busyAgents: 5, ratio: 1, maxAgents: 20, want: 5},
+		{name: "pending adds headroom", pendingRuns: 3, busyAgents: 7, ratio: 0.7, maxAgents: 20, want: 13},
+		{name: "pending only", pendingRuns: 2, ratio: 0.7, maxAgents: 20, want: 2},
+		{name: "warm idle floor wins", busyAgents: 7, warmIdle: 5, ratio: 0.7, maxAgents: 20, want: 12},
+		{name: "ratio headroom covers warm idle", busyAgents: 7, warmIdle: 2, ratio: 0.7, maxAgents: 20, want: 10},
+		{name: "clamped to max", pendingRuns: 4, busyAgents: 14, ratio: 0.7, maxAgents: 20, want: 20},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := computeTargetDesired(tt.pendingRuns, tt.busyAgents, tt.warmIdle, tt.ratio, tt.minAgents, tt.maxAgents)
+			if got != tt.want {
+				t.Errorf("got %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReconcileTargetBusyRatio(t *testing.T) {
+	ecsClient := &mockECS{
+		serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+			return ecs.ServiceStatus{Desired: 7, Running: 7}, nil
+		},
+		setDesiredFn: func(_ context.Context, _ int32) error {
+			return nil
+		},
+	}
+	s := New("test",
+		&mockTFC{
+			agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+				return 7, 0, 7, nil
+			},
+			pendingRunsFn: func(_ context.Context) (int, error) {
+				return 1, nil
+			},
+		},
+		ecsClient, 0, 20, time.Second, time.Minute, slog.Default(),
+		WithTargetBusyRatio(0.7),
+	)
+
+	if err := s.Reconcile(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// ceil(7 / 0.7) + 1 pending; the additive formula would give 8.
+	if ecsClient.lastDesiredCount != 11 {
+		t.Errorf("desired = %d, want 11", ecsClient.lastDesiredCount)
+	}
+}
+
+func TestReconcileSpotInterruptions(t *testing.T) {
+	fm := &fakeMetrics{}
+	interrupted := []ecs.TaskInfo{
+		// Stopped before the scaler started; not reported.
+		{TaskArn: "arn:task/old", LastStatus: "STOPPED", StopCode: "SpotInterruption"},
+		{TaskArn: "arn:task/a", LastStatus: "RUNNING", StopCode: "SpotInterruption"},
+	}
+	var compensated []int
+	s := New("spot",
+		&mockTFC{
+			agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+				return 3, 0, 3, nil
+			},
+			pendingRunsFn: func(_ context.Context) (int, error) {
+				return 0, nil
+			},
+		},
+		&mockECS{
+			serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+				return ecs.ServiceStatus{Desired: 3, Running: 3}, nil
+			},
+			interruptedFn: func(_ context.Context) ([]ecs.TaskInfo, error) {
+				return interrupted, nil
+			},
+		},
+		0, 10, time.Second, time.Minute, slog.Default(),
+		WithSpotInterruptions(func(n int) { compensated = append(compensated, n) }),
+	)
+	s.SetMetrics(fm)
+
+	steps := []struct {
+		name            string
+		tasks           []ecs.TaskInfo
+		wantTotal       int
+		wantCompensated []int
+		wantRunning     int32
+	}{
+		{
+			name:            "first check reports only running interrupted tasks",
+			wantTotal:       1,
+			wantCompensated: []int{1},
+			wantRunning:     2,
+		},
+		{
+			name: "same interruptions are not reported again",
+			tasks: []ecs.TaskInfo{
+				{TaskArn: "arn:task/old", LastStatus: "STOPPED", StopCode: "SpotInterruption"},
+				{TaskArn: "arn:task/a", LastStatus: "STOPPED", StopCode: "SpotInterruption"},
+			},
+			wantTotal:       1,
+			wantCompensated: []int{1},
+			wantRunning:     3,
+		},
+		{
+			name: "new interruptions are reported even once stopped",
+			tasks: []ecs.TaskInfo{
+				{TaskArn: "arn:task/a", LastStatus: "STOPPED", StopCode: "SpotInterruption"},
+				{TaskArn: "arn:task/b", LastStatus: "STOPPED", StoppedReason: "Your Spot Task was interrupted."},
+				{TaskArn: "arn:task/c", LastStatus: "RUNNING", StopCode: "SpotInterruption"},
+			},
+			wantTotal:       3,
+			wantCompensated: []int{1, 2},
+			wantRunning:     2,
+		},
+	}
+
+	for _, step := range steps {
+		if step.tasks != nil {
+			interrupted = step.tasks
+		}
+		if err := s.Reconcile(context.Background()); err != nil {
+			t.Fatalf("%s: unexpected error: %v", step.name, err)
+		}
+		if fm.spotInterruptions != step.wantTotal {
+			t.Errorf("%s: spot interruptions = %d, want %d", step.name, fm.spotInterruptions, step.wantTotal)
+		}
+		if !slices.Equal(compensated, step.wantCompensated) {
+			t.Errorf("%s: compensation calls = %v, want %v", step.name, compensated, step.wantCompensated)
+		}
+		if got := s.State().CurrentRunning; got != step.wantRunning {
+			t.Errorf("%s: running = %d, want %d", step.name, got, step.wantRunning)
+		}
+	}
+}
+
+func TestReconcileCompensation(t *testing.T) {
+	ecsClient := &mockECS{
+		serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+			return ecs.ServiceStatus{Desired: 1, Running: 1}, nil
+		},
+		setDesiredFn: func(_ context.Context, _ int32) error {
+			return nil
+		},
+	}
+	s := New("regular",
+		&mockTFC{
+			agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+				return 1, 0, 1, nil
+			},
+			pendingRunsFn: func(_ context.Context) (int, error) {
+				return 0, nil
+			},
+		},
+		ecsClient,
+		0, 10, time.Second, time.Minute, slog.Default(),
+	)
+
+	s.Compensate(2)
+	if err := s.Reconcile(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ecsClient.lastDesiredCount != 3 {
+		t.Errorf("desired = %d, want 3 (1 busy + 2 compensation)", ecsClient.lastDesiredCount)
+	}
+
+	// Compensation is consumed by one reconcile.
+	if err := s.Reconcile(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := s.State().ComputedDesired; got != 1 {
+		t.Errorf("computed desired after compensation = %d, want 1", got)
+	}
+}
+
+func TestReconcileKeepsUnappliedCompensation(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []Option
+		setErr   error
+		desired  int32
+		wantErr  bool
+		wantKept int64
+	}{
+		{name: "applied", desired: 1, wantKept: 0},
+		{name: "held by stabilization", desired: 1, opts: []Option{WithScaleUpStabilization(time.Hour)}, wantKept: 2},
+		{name: "write fails", desired: 1, setErr: errors.New("throttled"), wantErr: true, wantKept: 2},
+		{name: "covered by current capacity", desired: 5, wantKept: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ecsClient := &mockECS{
+				serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+					return ecs.ServiceStatus{Desired: tt.desired, Running: tt.desired}, nil
+				},
+				setDesiredFn: func(_ context.Context, _ int32) error {
+					return tt.setErr
+				},
+			}
+			s := New("regular",
+				&mockTFC{
+					agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+						return 1, 0, 1, nil
+					},
+					pendingRunsFn: func(_ context.Context) (int, error) {
+						return 0, nil
+					},
+				},
+				ecsClient,
+				0, 10, time.Second, time.Hour, slog.Default(),
+				tt.opts...,
+			)
+
+			s.Compensate(2)
+			if err := s.Reconcile(context.Background()); (err != nil) != tt.wantErr {
+				t.Fatalf("Reconcile error = %v, want error %v", err, tt.wantErr)
+			}
+			if got := s.compensation.Load(); got != tt.wantKept {
+				t.Errorf("compensation kept = %d, want %d", got, tt.wantKept)
+			}
+		})
+	}
+}
+
+func TestReconcileIdleGuardDisabled(t *testing.T) {
+	// 6 tasks but only 3 agents (1 busy, 2 idle): agents that exited after
+	// their job left tasks behind. The computed target is 1 either way.
+	tests := []struct {
+		name        string
+		idleGuard   bool
+		protectErr  error
+		wantDesired int32
+	}{
+		{name: "guarded removes only idle agents", idleGuard: true, wantDesired: 4},
+		{name: "unguarded scales to computed target", idleGuard: false, wantDesired: 1},
+		{name: "unguarded falls back to guard when protection fails", idleGuard: false, protectErr: errors.New("AccessDeniedException"), wantDesired: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ecsClient := &mockECS{
+				serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+					return ecs.ServiceStatus{Desired: 6, Running: 6}, nil
+				},
+				setDesiredFn: func(_ context.Context, _ int32) error {
+					return nil
+				},
+				getTaskIPsFn: func(_ context.Context) ([]ecs.TaskInfo, error) {
+					return []ecs.TaskInfo{{TaskArn: "arn:task/1", PrivateIP: "10.0.0.1"}}, nil
+				},
+				setTaskProtFn: func(_ context.Context, _ []string, _ bool, _ int32) error {
+					return tt.protectErr
+				},
+			}
+			s := New("test",
+				&mockTFC{
+					agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+						return 1, 2, 3, nil
+					},
+					pendingRunsFn: func(_ context.Context) (int, error) {
+						return 0, nil
+					},
+					agentDetailsFn: func(_ context.Context) ([]tfc.AgentInfo, error) {
+						return []tfc.AgentInfo{{ID: "a1", IP: "10.0.0.1", Status: "busy"}}, nil
+					},
+				},
+				ecsClient, 0, 10, time.Second, 0, slog.Default(),
+				WithIdleGuard(tt.idleGuard),
+			)
+
+			if err := s.Reconcile(context.Background()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := s.State().ComputedDesired; got != 1 {
+				t.Errorf("computed desired = %d, want 1", got)
+			}
+			if ecsClient.lastDesiredCount != tt.wantDesired {
+				t.Errorf("desired = %d, want %d", ecsClient.lastDesiredCount, tt.wantDesired)
+			}
+			if len(ecsClient.protectCalls) == 0 {
+				t.Error("expected busy tasks to be protected before scale-down")
+			}
+		})
+	}
+}
+
+func TestReconcileSkipsScaleDownWhileConverging(t *testing.T) {
+	// A previous scale-up to 5 has only 3 tasks running, all idle.
+	tests := []struct {
+		name        string
+		pending     int
+		stallAfter  int
+		wantDesired int32
+		wantSkips   []string
+	}{
+		{name: "no pending work skips scale-down", wantSkips: []string{skipConverging}},
+		{name: "scale-up still proceeds", pending: 7, wantDesired: 7},
+		{name: "stalled placement allows scale-down", stallAfter: 1, wantDesired: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fm := &fakeMetrics{}
+			ecsClient := &mockECS{
+				serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+					return ecs.ServiceStatus{Desired: 5, Running: 3}, nil
+				},
+				setDesiredFn: func(_ context.Context, _ int32) error {
+					return nil
+				},
+			}
+			s := New("test",
+				&mockTFC{
+					agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+						return 0, 3, 3, nil
+					},
+					pendingRunsFn: func(_ context.Context) (int, error) {
+						return tt.pending, nil
+					},
+				},
+				ecsClient, 0, 10, time.Second, 0, slog.Default(),
+				WithPlacementStallThreshold(tt.stallAfter),
+				WithTaskProtection(false),
+			)
+			s.SetMetrics(fm)
+
+			if err := s.Reconcile(context.Background()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if ecsClient.lastDesiredCount != tt.wantDesired {
+				t.Errorf("desired = %d, want %d", ecsClient.lastDesiredCount, tt.wantDesired)
+			}
+			if !slices.Equal(fm.scaleDownSkips, tt.wantSkips) {
+				t.Errorf("scale-down skips = %v, want %v", fm.scaleDownSkips, tt.wantSkips)
+			}
+		})
+	}
+}
+
+func TestReconcileProtectionUnsupportedController(t *testing.T) {
+	// CODE_DEPLOY services reject UpdateTaskProtection, so two scale-downs
+	// must not call it and must warn only once.
+	tests := []struct {
+		name        string
+		idleGuard   bool
+		wantDesired int32
+	}{
+		{name: "idle guard applies as usual", idleGuard: true, wantDesired: 2},
+		{name: "unguarded falls back to the idle guard", idleGuard: false, wantDesired: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			fm := &fakeMetrics{}
+			// 4 tasks with 1 busy and 1 idle agent: the computed target is 1,
+			// but only the idle agent may be removed.
+			desired := int32(4)
+			ecsClient := &mockECS{
+				serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+					return ecs.ServiceStatus{Desired: desired, Running: desired, DeploymentController: "CODE_DEPLOY"}, nil
+				},
+				setDesiredFn: func(_ context.Context, count int32) error {
+					desired = count
+					return nil
+				},
+				setTaskProtFn: func(_ context.Context, _ []string, _ bool, _ int32) error {
+					return errors.New("unexpected task protection call")
+				},
+			}
+			s := New("test",
+				&mockTFC{
+					agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+						return 1, 1, 2, nil
+					},
+					pendingRunsFn: func(_ context.Context) (int, error) {
+						return 0, nil
+					},
+				},
+				ecsClient, 0, 10, time.Second, 0, slog.New(slog.NewTextHandler(&logs, nil)),
+				WithIdleGuard(tt.idleGuard),
+			)
+			s.SetMetrics(fm)
+
+			for range 2 {
+				if err := s.Reconcile(context.Background()); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+			if len(ecsClient.protectCalls) != 0 {
+				t.Errorf("protect calls = %d, want 0", len(ecsClient.protectCalls))
+			}
+			if fm.taskProtectionErrors != 0 {
+				t.Errorf("task protection errors = %d, want 0", fm.taskProtectionErrors)
+			}
+			if ecsClient.lastDesiredCount != tt.wantDesired {
+				t.Errorf("desired = %d, want %d", ecsClient.lastDesiredCount, tt.wantDesired)
+			}
+			if got := strings.Count(logs.String(), "does not support task protection"); got != 1 {
+				t.Errorf("unsupported controller warnings = %d, want 1", got)
+			}
+		})
+	}
+}
+
+func TestReconcilePaused(t *testing.T) {
+	var paused atomic.Bool
+	paused.Store(true)
+	fm := &fakeMetrics{}
+	var setCalls int
+	ecsClient := &mockECS{
+		serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+			return ecs.ServiceStatus{Desired: 1, Running: 1}, nil
+		},
+		setDesiredFn: func(_ context.Context, _ int32) error {
+			setCalls++
+			return nil
+		},
+	}
+	s := New("test",
+		&mockTFC{
+			agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+				return 1, 0, 1, nil
+			},
+			pendingRunsFn: func(_ context.Context) (int, error) {
+				return 3, nil
+			},
+		},
+		ecsClient, 0, 10, time.Second, 0, slog.Default(),
+		WithPause(&paused),
+	)
+	s.SetMetrics(fm)
+
+	if err := s.Reconcile(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if setCalls != 0 {
+		t.Errorf("desired count set %d times while paused, want 0", setCalls)
+	}
+	if fm.reconcileCalls != 1 {
+		t.Errorf("reconcile metrics recorded %d times while paused, want 1", fm.reconcileCalls)
+	}
+	if state := s.State(); !state.Paused || state.ComputedDesired != 4 {
+		t.Errorf("state = %+v, want paused with computed desired 4", state)
+	}
+	if err := s.drain(context.Background()); err != nil {
+		t.Fatalf("unexpected drain error: %v", err)
+	}
+	if setCalls != 0 {
+		t.Errorf("drain set the desired count while paused")
+	}
+
+	paused.Store(false)
+	if err := s.Reconcile(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ecsClient.lastDesiredCount != 4 {
+		t.Errorf("desired after resume = %d, want 4", ecsClient.lastDesiredCount)
+	}
+}
+
+func TestReconcileBusyFloor(t *testing.T) {
+	// 8 tasks with 3 busy agents, but a stale status still reports 6 idle
+	// agents, so the idle guard would allow going down to maxAgents (2) and
+	// stopping a busy agent's task.
+	tests := []struct {
+		name        string
+		busyFloor   bool
+		warmIdle    int
+		wantDesired int32
+		wantReason  string
+	}{
+		{name: "floor keeps busy agents", busyFloor: true, wantDesired: 3, wantReason: reasonBusyFloor},
+		{name: "floor includes warm idle", busyFloor: true, warmIdle: 1, wantDesired: 4, wantReason: reasonBusyFloor},
+		{name: "disabled corrects to the maximum", busyFloor: false, wantDesired: 2, wantReason: reasonBoundsCorrection},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fm := &fakeMetrics{}
+			ecsClient := &mockECS{
+				serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+					return ecs.ServiceStatus{Desired: 8, Running: 8}, nil
+				},
+				setDesiredFn: func(_ context.Context, _ int32) error {
+					return nil
+				},
+			}
+			s := New("test",
+				&mockTFC{
+					agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+						return 3, 6 + tt.warmIdle, 9 + tt.warmIdle, nil
+					},
+					pendingRunsFn: func(_ context.Context) (int, error) {
+						return 0, nil
+					},
+				},
+				ecsClient, 0, 2, time.Second, 0, slog.Default(),
+				WithBusyFloor(tt.busyFloor),
+				WithWarmIdle(tt.warmIdle),
+				WithTaskProtection(false),
+			)
+			s.SetMetrics(fm)
+
+			if err := s.Reconcile(context.Background()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if ecsClient.lastDesiredCount != tt.wantDesired {
+				t.Errorf("desired = %d, want %d", ecsClient.lastDesiredCount, tt.wantDesired)
+			}
+			if len(fm.scaleDownReasons) != 1 || fm.scaleDownReasons[0] != tt.wantReason {
+				t.Errorf("scale-down reasons = %v, want [%s]", fm.scaleDownReasons, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestReconcileOtherAgents(t *testing.T) {
+	// One busy and two idle agents, plus two agents in other statuses. With
+	// the idle guard off, nothing but the busy floor keeps the other agents'
+	// tasks from being stopped.
+	tests := []struct {
+		name        string
+		running     int32
+		wantDesired int32
+	}{
+		{name: "other agents with running tasks are kept", running: 5, wantDesired: 3},
+		{name: "only as many as running tasks allow", running: 4, wantDesired: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fm := &fakeMetrics{}
+			ecsClient := &mockECS{
+				serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+					return ecs.ServiceStatus{Desired: tt.running, Running: tt.running}, nil
+				},
+				setDesiredFn: func(_ context.Context, _ int32) error {
+					return nil
+				},
+			}
+			s := New("test",
+				&mockTFC{
+					agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+						return 1, 2, 5, nil
+					},
+					pendingRunsFn: func(_ context.Context) (int, error) {
+						return 0, nil
+					},
+				},
+				ecsClient, 0, 10, time.Second, 0, slog.Default(),
+				WithIdleGuard(false),
+				WithTaskProtection(false),
+			)
+			s.SetMetrics(fm)
+
+			if err := s.Reconcile(context.Background()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if ecsClient.lastDesiredCount != tt.wantDesired {
+				t.Errorf("desired = %d, want %d", ecsClient.lastDesiredCount, tt.wantDesired)
+			}
+			if len(fm.scaleDownReasons) != 1 || fm.scaleDownReasons[0] != reasonBusyFloor {
+				t.Errorf("scale-down reasons = %v, want [%s]", fm.scaleDownReasons, reasonBusyFloor)
+			}
+			if !slices.Equal(fm.otherAgents, []int{2}) {
+				t.Errorf("other agents = %v, want [2]", fm.otherAgents)
+			}
+		})
+	}
+}
+
+func TestReconcileMaxQueueWait(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	// One idle agent already covers the single pending run, so the count
+	// alone never scales up.
+	tests := []struct {
+		name         string
+		queuedFor    time.Duration
+		pendingTasks int32
+		maxAgents    int
+		wantDesired  int32
+		wantScale    bool
+		wantWait     float64
+	}{
+		{name: "old run forces scale-up", queuedFor: 20 * time.Minute, maxAgents: 10, wantDesired: 2, wantScale: true, wantWait: 1200},
+		{name: "recent run does not", queuedFor: 5 * time.Minute, maxAgents: 10, wantWait: 300},
+		{name: "not while tasks are starting", queuedFor: 20 * time.Minute, pendingTasks: 1, maxAgents: 10, wantWait: 1200},
+		{name: "not above max agents", queuedFor: 20 * time.Minute, maxAgents: 1, wantWait: 1200},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fm := &fakeMetrics{}
+			ecsClient := &mockECS{
+				serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+					return ecs.ServiceStatus{Desired: 1, Running: 1 - tt.pendingTasks, Pending: tt.pendingTasks}, nil
+				},
+				setDesiredFn: func(_ context.Context, _ int32) error {
+					return nil
+				},
+			}
+			tfcClient := &mockTypedTFC{
+				mockTFC: mockTFC{
+					agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+						return 0, 1, 1, nil
+					},
+				},
+				counts: tfc.PendingRunCounts{PlanPending: 1, OldestPlanAt: now.Add(-tt.queuedFor)},
+			}
+			s := New("test", tfcClient, ecsClient, 0, tt.maxAgents, time.Second, time.Minute, slog.Default(),
+				WithMaxQueueWait(15*time.Minute),
+			)
+			s.now = func() time.Time { return now }
+			s.SetMetrics(fm)
+
+			if err := s.Reconcile(context.Background()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if scaled := ecsClient.lastDesiredCount != 0; scaled != tt.wantScale {
+				t.Fatalf("scaled = %v (to %d), want %v", scaled, ecsClient.lastDesiredCount, tt.wantScale)
+			}
+			if tt.wantScale && ecsClient.lastDesiredCount != tt.wantDesired {
+				t.Errorf("desired = %d, want %d", ecsClient.lastDesiredCount, tt.wantDesired)
+			}
+			if len(fm.queueWait) != 1 || fm.queueWait[0] != tt.wantWait {
+				t.Errorf("queue wait = %v, want [%v]", fm.queueWait, tt.wantWait)
+			}
+		})
+	}
+}
+
+// mockBreakdownTFC reports service and pool-wide pending counts.
+type mockBreakdownTFC struct {
+	mockTFC
+	service tfc.PendingRunDetail
+	pool    tfc.PendingRunCounts
+}
+
+func (m *mockBreakdownTFC) GetPendingRunsBreakdown(_ context.Context) (tfc.PendingRunDetail, tfc.PendingRunCounts, error) {
+	return m.service, m.pool, nil
+}
+
+func TestReconcileRecordsPendingByType(t *testing.T) {
+	fm := &fakeMetrics{}
+	ecsClient := &mockECS{
+		serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+			return ecs.ServiceStatus{Desired: 0, Running: 0}, nil
+		},
+		setDesiredFn: func(_ context.Context, _ int32) error {
+			return nil
+		},
+	}
+	// A plan-only view: demand comes from plan runs, but both pool-wide
+	// counts are recorded.
+	tfcClient := &mockBreakdownTFC{
+		mockTFC: mockTFC{
+			agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+				return 0, 0, 0, nil
+			},
+		},
+		service: tfc.PendingRunDetail{PendingRunCounts: tfc.PendingRunCounts{PlanPending: 2}},
+		pool:    tfc.PendingRunCounts{PlanPending: 2, ApplyPending: 3},
+	}
+	s := New("spot", tfcClient, ecsClient, 0, 10, time.Second, time.Minute, slog.Default())
+	s.SetMetrics(fm)
+
+	if err := s.Reconcile(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ecsClient.lastDesiredCount != 2 {
+		t.Errorf("desired = %d, want 2", ecsClient.lastDesiredCount)
+	}
+	if want := [][2]int{{2, 3}}; !slices.Equal(fm.pendingByType, want) {
+		t.Errorf("pending by type = %v, want %v", fm.pendingByType, want)
+	}
+}
+
+func TestReconcileScaleUpStabilization(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	type step struct {
+		at        time.Duration
+		pending   int
+		wantScale bool
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "one-off spike does not scale up",
+			steps: []step{
+				{at: 0, pending: 3},
+				{at: 30 * time.Second, pending: 0},
+				{at: 3 * time.Minute, pending: 3},
+			},
+		},
+		{
+			name: "sustained demand scales up",
+			steps: []step{
+				{at: 0, pending: 3},
+				{at: time.Minute, pending: 2},
+				{at: 2 * time.Minute, pending: 3, wantScale: true},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var pending int
+			ecsClient := &mockECS{
+				serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+					return ecs.ServiceStatus{}, nil
+				},
+				setDesiredFn: func(_ context.Context, _ int32) error {
+					return nil
+				},
+			}
+			tfcClient := &mockTFC{
+				agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+					return 0, 0, 0, nil
+				},
+				pendingRunsFn: func(_ context.Context) (int, error) {
+					return pending, nil
+				},
+			}
+			s := New("test", tfcClient, ecsClient, 0, 10, time.Second, time.Minute, slog.Default(),
+				WithScaleUpStabilization(2*time.Minute),
+			)
+
+			for i, st := range tt.steps {
+				s.now = func() time.Time { return start.Add(st.at) }
+				pending = st.pending
+				ecsClient.lastDesiredCount = 0
+
+				if err := s.Reconcile(context.Background()); err != nil {
+					t.Fatalf("step %d: unexpected error: %v", i, err)
+				}
+				if scaled := ecsClient.lastDesiredCount != 0; scaled != st.wantScale {
+					t.Errorf("step %d: scaled = %v (to %d), want %v", i, scaled, ecsClient.lastDesiredCount, st.wantScale)
+				}
+			}
+		})
+	}
+}
+
+func TestReconcileScaleUpStabilizationQueueWaitBypass(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	ecsClient := &mockECS{
+		serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+			return ecs.ServiceStatus{}, nil
+		},
+		setDesiredFn: func(_ context.Context, _ int32) error {
+			return nil
+		},
+	}
+	tfcClient := &mockTypedTFC{
+		mockTFC: mockTFC{
+			agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+				return 0, 0, 0, nil
+			},
+		},
+		counts: tfc.PendingRunCounts{PlanPending: 1, OldestPlanAt: now.Add(-20 * time.Minute)},
+	}
+	s := New("test", tfcClient, ecsClient, 0, 10, time.Second, time.Minute, slog.Default(),
+		WithScaleUpStabilization(5*time.Minute),
+		WithMaxQueueWait(15*time.Minute),
+	)
+	s.now = func() time.Time { return now }
+
+	if err := s.Reconcile(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ecsClient.lastDesiredCount != 1 {
+		t.Errorf("desired = %d, want 1", ecsClient.lastDesiredCount)
+	}
+}
+
+// mockStatusTFC reports agents in every status.
+type mockStatusTFC struct {
+	mockTFC
+	statuses map[string]int
+}
+
+func (m *mockStatusTFC) GetAgentStatusCounts(_ context.Context) (map[string]int, error) {
+	return m.statuses, nil
+}
+
+func TestReconcileRecordsAgentsByStatus(t *testing.T) {
+	fm := &fakeMetrics{}
+	statuses := map[string]int{"busy": 2, "idle": 1, "unknown": 0, "errored": 3, "exited": 1}
+	ecsClient := &mockECS{
+		serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+			return ecs.ServiceStatus{Desired: 3, Running: 3}, nil
+		},
+		setDesiredFn: func(_ context.Context, _ int32) error {
+			return nil
+		},
+	}
+	tfcClient := &mockStatusTFC{
+		mockTFC: mockTFC{
+			agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+				t.Error("GetAgentPoolStatus called despite status counts")
+				return 0, 0, 0, nil
+			},
+			pendingRunsFn: func(_ context.Context) (int, error) {
+				return 0, nil
+			},
+		},
+		statuses: statuses,
+	}
+	s := New("test", tfcClient, ecsClient, 0, 10, time.Second, time.Minute, slog.Default())
+	s.SetMetrics(fm)
+
+	if err := s.Reconcile(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(fm.agentsByStatus) != 1 || !maps.Equal(fm.agentsByStatus[0], statuses) {
+		t.Errorf("agents by status = %v, want [%v]", fm.agentsByStatus, statuses)
+	}
+	if fm.lastBusy != 2 || fm.lastIdle != 1 || fm.lastTotal != 7 {
+		t.Errorf("busy/idle/total = %d/%d/%d, want 2/1/7", fm.lastBusy, fm.lastIdle, fm.lastTotal)
+	}
+}
+
+func TestRunMaxConsecutiveFailures(t *testing.T) {
+	tests := []struct {
+		name      string
+		max       int
+		wantCalls int
+	}{
+		{name: "stops after one failure", max: 1, wantCalls: 1},
+		{name: "stops after three failures", max: 3, wantCalls: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int
+			s := New("test",
+				&mockTFC{
+					agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+						calls++
+						return 0, 0, 0, errors.New("tfc unavailable")
+					},
+				},
+				&mockECS{},
+				0, 10, time.Millisecond, time.Minute, slog.Default(),
+				WithMaxConsecutiveFailures(tt.max),
+				WithFailureBackoffMax(time.Millisecond),
+			)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			err := s.Run(ctx)
+			if !errors.Is(err, ErrTooManyFailures) {
+				t.Fatalf("Run() error = %v, want ErrTooManyFailures", err)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("reconciles = %d, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestRunMaxConsecutiveFailuresResetBySuccess(t *testing.T) {
+	var calls int
+	s := New("test",
+		&mockTFC{
+			agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+				calls++
+				// Fail, fail, succeed, then fail until Run gives up.
+				if calls == 3 {
+					return 0, 0, 0, nil
+				}
+				return 0, 0, 0, errors.New("tfc unavailable")
+			},
+			pendingRunsFn: func(_ context.Context) (int, error) {
+				return 0, nil
+			},
+		},
+		&mockECS{
+			serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+				return ecs.ServiceStatus{}, nil
+			},
+		},
+		0, 10, time.Millisecond, time.Minute, slog.Default(),
+		WithMaxConsecutiveFailures(3),
+		WithFailureBackoffMax(time.Millisecond),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := s.Run(ctx); !errors.Is(err, ErrTooManyFailures) {
+		t.Fatalf("Run() error = %v, want ErrTooManyFailures", err)
+	}
+	if calls != 6 {
+		t.Errorf("reconciles = %d, want 6", calls)
+	}
+}
+
+func TestReconcileRunPollInterval(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	var statusCalls, runCalls int
+	pending := 2
+	ecsClient := &mockECS{
+		serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+			return ecs.ServiceStatus{Desired: 2, Running: 2}, nil
+		},
+		setDesiredFn: func(_ context.Context, _ int32) error {
+			return nil
+		},
+	}
+	tfcClient := &mockTFC{
+		agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+			statusCalls++
+			return 2, 0, 2, nil
+		},
+		pendingRunsFn: func(_ context.Context) (int, error) {
+			runCalls++
+			return pending, nil
+		},
+	}
+	s := New("test", tfcClient, ecsClient, 0, 10, 10*time.Second, time.Minute, slog.Default(),
+		WithRunPollInterval(30*time.Second),
+	)
+
+	// Reconcile every 10s for a minute; runs are listed at 0s, 30s, and 60s.
+	for i := range 7 {
+		s.now = func() time.Time { return start.Add(time.Duration(i) * 10 * time.Second) }
+		if i == 1 {
+			// A change between listings is not seen until the next one.
+			pending = 5
+		}
+		if err := s.Reconcile(context.Background()); err != nil {
+			t.Fatalf("reconcile %d: unexpected error: %v", i, err)
+		}
+		if i == 1 && s.State().PendingRuns != 2 {
+			t.Errorf("reconcile 1: pending runs = %d, want cached 2", s.State().PendingRuns)
+		}
+	}
+
+	if statusCalls != 7 {
+		t.Errorf("GetAgentPoolStatus calls = %d, want 7", statusCalls)
+	}
+	if runCalls != 3 {
+		t.Errorf("GetPendingRuns calls = %d, want 3", runCalls)
+	}
+	if got := s.State().PendingRuns; got != 5 {
+		t.Errorf("final pending runs = %d, want 5", got)
+	}
+}
+
+func TestReconcileScaleToZeroGrace(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	type step struct {
+		at          time.Duration
+		busy        int
+		wantDesired int32
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "last agent survives until grace elapses",
+			steps: []step{
+				{at: 0, wantDesired: 1},
+				{at: 5 * time.Minute, wantDesired: 1},
+				{at: 10 * time.Minute, wantDesired: 0},
+			},
+		},
+		{
+			name: "busy agent restarts the grace period",
+			steps: []step{
+				{at: 0, wantDesired: 1},
+				{at: 8 * time.Minute, busy: 1, wantDesired: 1},
+				{at: 12 * time.Minute, wantDesired: 1},
+				{at: 22 * time.Minute, wantDesired: 0},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var busy int
+			current := int32(3)
+			ecsClient := &mockECS{
+				serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+					return ecs.ServiceStatus{Desired: current, Running: current}, nil
+				},
+				setDesiredFn: func(_ context.Context, count int32) error {
+					current = count
+					return nil
+				},
+			}
+			tfcClient := &mockTFC{
+				agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+					return busy, int(current) - busy, int(current), nil
+				},
+				pendingRunsFn: func(_ context.Context) (int, error) {
+					return 0, nil
+				},
+			}
+			s := New("test", tfcClient, ecsClient, 0, 10, time.Second, 0, slog.Default(),
+				WithScaleToZeroGrace(10*time.Minute),
+				WithTaskProtection(false),
+			)
+
+			for i, st := range tt.steps {
+				s.now = func() time.Time { return start.Add(st.at) }
+				busy = st.busy
+				if err := s.Reconcile(context.Background()); err != nil {
+					t.Fatalf("step %d: unexpected error: %v", i, err)
+				}
+				if current != st.wantDesired {
+					t.Errorf("step %d: desired = %d, want %d", i, current, st.wantDesired)
+				}
+			}
+		})
+	}
+}
+
+func TestReconcileNotifiesScaleEvent(t *testing.T) {
+	received := make(chan notify.Event, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		var ev notify.Event
+		if err := json.NewDecoder(r.Body).Decode(&ev); err != nil {
+			t.Errorf("decoding body: %v", err)
+		}
+		received <- ev
+	}))
+	defer srv.Close()
+
+	webhook := notify.NewWebhook(srv.URL, time.Second, slog.Default())
+	s := New("test",
+		&mockTFC{
+			agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+				return 2, 0, 2, nil
+			},
+			pendingRunsFn: func(_ context.Context) (int, error) {
+				return 3, nil
+			},
+		},
+		&mockECS{
+			serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+				return ecs.ServiceStatus{Desired: 2, Running: 2}, nil
+			},
+			setDesiredFn: func(_ context.Context, _ int32) error {
+				return nil
+			},
+		},
+		0, 10, time.Second, 0, slog.Default(),
+		WithNotifier(webhook),
+	)
+
+	if err := s.Reconcile(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	webhook.Wait()
+
+	select {
+	case got := <-received:
+		want := notify.Event{Service: "test", From: 2, To: 5, Direction: "up"}
+		if got != want {
+			t.Errorf("event = %+v, want %+v", got, want)
+		}
+	default:
+		t.Fatal("webhook received no scale event")
+	}
+}
+
+func TestReconcileDryRunDoesNotNotify(t *testing.T) {
+	var notified []notify.Event
+	s := New("test",
+		&mockTFC{
+			agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+				return 2, 0, 2, nil
+			},
+			pendingRunsFn: func(_ context.Context) (int, error) {
+				return 3, nil
+			},
+		},
+		&mockECS{
+			serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+				return ecs.ServiceStatus{Desired: 2, Running: 2}, nil
+			},
+		},
+		0, 10, time.Second, 0, slog.Default(),
+		WithDryRun(true),
+		WithNotifier(notifierFunc(func(ev notify.Event) { notified = append(notified, ev) })),
+	)
+
+	if err := s.Reconcile(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(notified) != 0 {
+		t.Errorf("notified %v in dry-run mode, want nothing", notified)
+	}
+}
+
+// notifierFunc adapts a function to the Notifier interface.
+type notifierFunc func(ev notify.Event)
+
+func (f notifierFunc) Notify(ev notify.Event) { f(ev) }
+
+func TestReconcileBoundsCorrection(t *testing.T) {
+	tests := []struct {
+		name        string
+		desired     int32
+		busy, idle  int
+		wantDesired int32
+		wantReasons []string
+	}{
+		// Someone set the service to 50 by hand; with 2 busy agents, no idle
+		// ones, and cooldown active, only the correction can bring it down.
+		{name: "above max", desired: 50, busy: 2, wantDesired: 10, wantReasons: []string{reasonBoundsCorrection}},
+		{name: "below min", desired: 0, wantDesired: 3},
+		{name: "busy floor above max", desired: 50, busy: 12, wantDesired: 12, wantReasons: []string{reasonBusyFloor}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fm := &fakeMetrics{}
+			ecsClient := &mockECS{
+				serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+					return ecs.ServiceStatus{Desired: tt.desired, Running: tt.desired}, nil
+				},
+				setDesiredFn: func(_ context.Context, _ int32) error {
+					return nil
+				},
+				getTaskIPsFn: func(_ context.Context) ([]ecs.TaskInfo, error) {
+					return []ecs.TaskInfo{
+						{TaskArn: "arn:task/1", PrivateIP: "10.0.0.1"},
+						{TaskArn: "arn:task/2", PrivateIP: "10.0.0.2"},
+					}, nil
+				},
+			}
+			s := New("test",
+				&mockTFC{
+					agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+						return tt.busy, tt.idle, tt.busy + tt.idle, nil
+					},
+					pendingRunsFn: func(_ context.Context) (int, error) {
+						return 0, nil
+					},
+					agentDetailsFn: func(_ context.Context) ([]tfc.AgentInfo, error) {
+						return []tfc.AgentInfo{
+							{ID: "a1", IP: "10.0.0.1", Status: "busy"},
+							{ID: "a2", IP: "10.0.0.2", Status: "busy"},
+						}, nil
+					},
+				},
+				ecsClient, 3, 10, time.Second, time.Hour, slog.Default(),
+				WithScaleUpStabilization(time.Hour),
+			)
+			s.SetMetrics(fm)
+			s.lastScaleTime = time.Now()
+
+			if err := s.Reconcile(context.Background()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if ecsClient.lastDesiredCount != tt.wantDesired {
+				t.Errorf("desired = %d, want %d", ecsClient.lastDesiredCount, tt.wantDesired)
+			}
+			if !slices.Equal(fm.scaleDownReasons, tt.wantReasons) {
+				t.Errorf("scale-down reasons = %v, want %v", fm.scaleDownReasons, tt.wantReasons)
+			}
+			if tt.desired > tt.wantDesired {
+				protected := slices.ContainsFunc(ecsClient.protectCalls, func(c protectCall) bool {
+					return c.enabled && len(c.taskArns) == 2
+				})
+				if !protected {
+					t.Errorf("busy tasks were not protected before the correction: %+v", ecsClient.protectCalls)
+				}
+			}
+		})
+	}
+}
+
+func TestReconcileWithinBoundsUsesGuards(t *testing.T) {
+	// A desired count within the bounds is left to the scale-down guards:
+	// cooldown blocks the scale-down.
+	ecsClient := &mockECS{
+		serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+			return ecs.ServiceStatus{Desired: 10, Running: 10}, nil
+		},
+	}
+	s := New("test",
+		&mockTFC{
+			agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+				return 0, 10, 10, nil
+			},
+			pendingRunsFn: func(_ context.Context) (int, error) {
+				return 0, nil
+			},
+		},
+		ecsClient, 0, 10, time.Second, time.Hour, slog.Default(),
+	)
+	s.lastScaleTime = time.Now()
+
+	if err := s.Reconcile(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ecsClient.lastDesiredCount != 0 {
+		t.Errorf("SetDesiredCount called with %d, want no call", ecsClient.lastDesiredCount)
+	}
+}
+
+func TestReconcileScaleDeadband(t *testing.T) {
+	tests := []struct {
+		name           string
+		count          int
+		percent        float64
+		currentDesired int32
+		busy, idle     int
+		pending        int
+		wantSet        bool
+		wantDesired    int32
+		wantDeadband   int
+	}{
+		{name: "disabled", currentDesired: 5, busy: 5, pending: 1, wantSet: true, wantDesired: 6},
+		{name: "small scale-up skipped", count: 2, currentDesired: 5, busy: 5, pending: 1, wantDeadband: 1},
+		{name: "scale-up at threshold skipped", count: 2, currentDesired: 5, busy: 5, pending: 2, wantDeadband: 1},
+		{name: "large scale-up applied", count: 2, currentDesired: 5, busy: 5, pending: 3, wantSet: true, wantDesired: 8},
+		{name: "small scale-down skipped", count: 2, currentDesired: 10, busy: 9, idle: 1, wantDeadband: 1},
+		{name: "small percentage skipped", percent: 50, currentDesired: 5, busy: 5, pending: 2, wantDeadband: 1},
+		{name: "large percentage applied", percent: 50, currentDesired: 5, busy: 5, pending: 3, wantSet: true, wantDesired: 8},
+		{name: "exact max applied", count: 5, currentDesired: 18, busy: 18, pending: 2, wantSet: true, wantDesired: 20},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var setCalls int
+			ecsClient := &mockECS{
+				serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+					return ecs.ServiceStatus{Desired: tt.currentDesired, Running: tt.currentDesired}, nil
+				},
+				setDesiredFn: func(_ context.Context, _ int32) error {
+					setCalls++
+					return nil
+				},
+			}
+			s := New("test",
+				&mockTFC{
+					agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+						return tt.busy, tt.idle, tt.busy + tt.idle, nil
+					},
+					pendingRunsFn: func(_ context.Context) (int, error) {
+						return tt.pending, nil
+					},
+				},
+				ecsClient, 0, 20, time.Second, time.Hour, slog.Default(),
+				WithScaleDeadband(tt.count, tt.percent),
+			)
+			fm := &fakeMetrics{}
+			s.SetMetrics(fm)
+
+			if err := s.Reconcile(context.Background()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := setCalls > 0; got != tt.wantSet {
+				t.Fatalf("SetDesiredCount called = %v, want %v", got, tt.wantSet)
+			}
+			if tt.wantSet && ecsClient.lastDesiredCount != tt.wantDesired {
+				t.Errorf("desired = %d, want %d", ecsClient.lastDesiredCount, tt.wantDesired)
+			}
+			if fm.withinDeadband != tt.wantDeadband {
+				t.Errorf("within deadband count = %d, want %d", fm.withinDeadband, tt.wantDeadband)
+			}
+		})
+	}
+}
+
+// mockConcurrencyTFC adds an organization run concurrency limit to
+// mockLimitTFC.
+type mockConcurrencyTFC struct {
+	mockLimitTFC
+	concurrency    int
+	concurrencyErr error
+}
+
+func (m *mockConcurrencyTFC) GetRunConcurrency(_ context.Context) (int, error) {
+	return m.concurrency, m.concurrencyErr
+}
+
+func TestReconcileClampsToRunConcurrency(t *testing.T) {
+	tests := []struct {
+		name           string
+		poolLimit      int
+		concurrency    int
+		concurrencyErr error
+		wantCount      int32
+		wantWarn       bool
+	}{
+		{name: "no limit", wantCount: 10},
+		{name: "concurrency above max", concurrency: 25, wantCount: 10},
+		{name: "concurrency below max", concurrency: 4, wantCount: 4, wantWarn: true},
+		{name: "pool limit binds first", poolLimit: 3, concurrency: 4, wantCount: 3},
+		{name: "concurrency below pool limit", poolLimit: 8, concurrency: 4, wantCount: 4, wantWarn: true},
+		{name: "concurrency error falls back to max", concurrencyErr: errors.New("boom"), wantCount: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := &recordingHandler{}
+			ecsClient := &mockECS{
+				serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+					return ecs.ServiceStatus{Desired: 1, Running: 1}, nil
+				},
+				setDesiredFn: func(_ context.Context, _ int32) error {
+					return nil
+				},
+			}
+			s := New("test",
+				&mockConcurrencyTFC{
+					mockLimitTFC: mockLimitTFC{
+						mockTFC: mockTFC{
+							agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+								return 1, 0, 1, nil
+							},
+							pendingRunsFn: func(_ context.Context) (int, error) {
+								return 20, nil
+							},
+						},
+						limit: tt.poolLimit,
+					},
+					concurrency:    tt.concurrency,
+					concurrencyErr: tt.concurrencyErr,
+				},
+				ecsClient,
+				0, 10, time.Second, time.Minute, slog.New(handler),
+			)
+
+			// Reconcile twice to check the warning is not repeated.
+			for range 2 {
+				if err := s.Reconcile(context.Background()); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+			if ecsClient.lastDesiredCount != tt.wantCount {
+				t.Errorf("desired count: got %d, want %d", ecsClient.lastDesiredCount, tt.wantCount)
+			}
+
+			warnings := handler.find("max agents exceeds organization run concurrency, clamping")
+			if tt.wantWarn && len(warnings) != 1 {
+				t.Errorf("concurrency warnings: got %d, want 1", len(warnings))
+			}
+			if !tt.wantWarn && len(warnings) != 0 {
+				t.Errorf("concurrency warnings: got %d, want 0", len(warnings))
+			}
+		})
+	}
+}
+
+func TestReconcileOutcome(t *testing.T) {
+	tests := []struct {
+		name        string
+		desired     int32
+		busy, idle  int
+		pending     int
+		statusErr   error
+		inCooldown  bool
+		wantOutcome string
+		wantSuccess bool
+	}{
+		{name: "scaled up", desired: 1, busy: 1, pending: 3, wantOutcome: outcomeScaledUp, wantSuccess: true},
+		{name: "scaled down", desired: 5, idle: 5, wantOutcome: outcomeScaledDown, wantSuccess: true},
+		{name: "no change", desired: 2, busy: 2, wantOutcome: outcomeNoChange, wantSuccess: true},
+		{name: "cooldown skip", desired: 5, idle: 5, inCooldown: true, wantOutcome: outcomeCooldownSkip, wantSuccess: true},
+		{name: "error", statusErr: errors.New("tfc unavailable"), wantOutcome: outcomeError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New("test",
+				&mockTFC{
+					agentPoolStatusFn: func(_ context.Context) (int, int, int, error) {
+						return tt.busy, tt.idle, tt.busy + tt.idle, tt.statusErr
+					},
+					pendingRunsFn: func(_ context.Context) (int, error) {
+						return tt.pending, nil
+					},
+				},
+				&mockECS{
+					serviceStatusFn: func(_ context.Context) (ecs.ServiceStatus, error) {
+						return ecs.ServiceStatus{Desired: tt.desired, Running: tt.desired}, nil
+					},
+					setDesiredFn: func(_ context.Context, _ int32) error {
+						return nil
+					},
+				},
+				0, 10, time.Second, time.Minute, slog.Default(),
+				WithIdleGuard(false),
+				WithTaskProtection(false),
+			)
+			fm := &fakeMetrics{}
+			s.SetMetrics(fm)
+			if tt.inCooldown {
+				s.lastScaleTime = time.Now()
+			}
+
+			err := s.Reconcile(context.Background())
+			if (err != nil) != (tt.statusErr != nil) {
+				t.Fatalf("Reconcile error = %v, want error %v", err, tt.statusErr != nil)
+			}
+			if !slices.Equal(fm.outcomes, []string{tt.wantOutcome}) {
+				t.Errorf("outcomes = %v, want [%s]", fm.outcomes, tt.wantOutcome)
+			}
+			if fm.resultCalls != 1 || fm.lastSuccess != tt.wantSuccess {
+				t.Errorf("results = %d (last success %v), want 1 (success %v)", fm.resultCalls, fm.lastSuccess, tt.wantSuccess)
+			}
+		})
+	}
+}
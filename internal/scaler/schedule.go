@@ -0,0 +1,41 @@
+package scaler
+
+import "time"
+
+// BusinessHours is a weekday window during which a higher minimum agent
+// count applies. Start and End are wall-clock offsets from midnight in
+// Location. When End is not after Start the window wraps past midnight and
+// belongs to the day it opens, so a Friday 22:00–06:00 window runs into
+// Saturday morning but no window opens on Saturday or Sunday.
+type BusinessHours struct {
+	MinAgents int
+	Start     time.Duration
+	End       time.Duration
+	Location  *time.Location
+}
+
+// Active reports whether t falls within the window.
+func (b *BusinessHours) Active(t time.Time) bool {
+	local := t.In(b.Location)
+	offset := time.Duration(local.Hour())*time.Hour +
+		time.Duration(local.Minute())*time.Minute +
+		time.Duration(local.Second())*time.Second
+
+	if b.Start < b.End {
+		return isWeekday(local.Weekday()) && offset >= b.Start && offset < b.End
+	}
+
+	// The window wraps midnight: the evening part belongs to today and the
+	// early-morning part to the window that opened yesterday.
+	if offset >= b.Start {
+		return isWeekday(local.Weekday())
+	}
+	if offset < b.End {
+		return isWeekday((local.Weekday() + 6) % 7)
+	}
+	return false
+}
+
+func isWeekday(d time.Weekday) bool {
+	return d != time.Saturday && d != time.Sunday
+}
@@ -0,0 +1,55 @@
+package scaler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBusinessHoursActive(t *testing.T) {
+	nyc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("loading location: %v", err)
+	}
+	day := &BusinessHours{Start: 8 * time.Hour, End: 18 * time.Hour, Location: nyc}
+	night := &BusinessHours{Start: 22 * time.Hour, End: 6 * time.Hour, Location: nyc}
+
+	// 2024-01-08 is a Monday; 2024-01-12 a Friday.
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2024, time.January, day, hour, minute, 0, 0, nyc)
+	}
+
+	tests := []struct {
+		name  string
+		hours *BusinessHours
+		t     time.Time
+		want  bool
+	}{
+		{name: "before start", hours: day, t: at(8, 7, 59), want: false},
+		{name: "at start", hours: day, t: at(8, 8, 0), want: true},
+		{name: "midday", hours: day, t: at(10, 12, 30), want: true},
+		{name: "just before end", hours: day, t: at(8, 17, 59), want: true},
+		{name: "at end", hours: day, t: at(8, 18, 0), want: false},
+		{name: "saturday", hours: day, t: at(13, 12, 0), want: false},
+		{name: "sunday", hours: day, t: at(14, 12, 0), want: false},
+		{name: "other zone converted", hours: day, t: time.Date(2024, time.January, 8, 13, 0, 0, 0, time.UTC), want: true},
+		{name: "other zone before start", hours: day, t: time.Date(2024, time.January, 8, 12, 59, 0, 0, time.UTC), want: false},
+
+		{name: "wrap evening", hours: night, t: at(8, 22, 0), want: true},
+		{name: "wrap after midnight", hours: night, t: at(9, 5, 59), want: true},
+		{name: "wrap at end", hours: night, t: at(9, 6, 0), want: false},
+		{name: "wrap gap", hours: night, t: at(9, 12, 0), want: false},
+		{name: "wrap friday night into saturday", hours: night, t: at(13, 2, 0), want: true},
+		{name: "wrap saturday night", hours: night, t: at(13, 23, 0), want: false},
+		{name: "wrap sunday night into monday", hours: night, t: at(15, 2, 0), want: false},
+		{name: "wrap sunday evening", hours: night, t: at(14, 22, 0), want: false},
+		{name: "wrap monday evening", hours: night, t: at(15, 22, 0), want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.hours.Active(tt.t); got != tt.want {
+				t.Errorf("Active(%v) = %v, want %v", tt.t, got, tt.want)
+			}
+		})
+	}
+}
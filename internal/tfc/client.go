@@ -3,11 +3,22 @@
 package tfc
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"maps"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/hashicorp/go-tfe"
+	"golang.org/x/sync/errgroup"
 )
 
 // AgentPoolReader reads agent pool details including related workspaces.
@@ -15,6 +26,12 @@ type AgentPoolReader interface {
 	ReadWithOptions(ctx context.Context, agentPoolID string, options *tfe.AgentPoolReadOptions) (*tfe.AgentPool, error)
 }
 
+// AgentPoolLister lists an organization's agent pools, for resolving a pool
+// by name.
+type AgentPoolLister interface {
+	List(ctx context.Context, organization string, options *tfe.AgentPoolListOptions) (*tfe.AgentPoolList, error)
+}
+
 // AgentLister lists agents within an agent pool.
 type AgentLister interface {
 	List(ctx context.Context, agentPoolID string, options *tfe.AgentListOptions) (*tfe.AgentList, error)
@@ -25,32 +42,508 @@ type RunLister interface {
 	List(ctx context.Context, workspaceID string, options *tfe.RunListOptions) (*tfe.RunList, error)
 }
 
+// OrganizationRunLister lists runs across an organization.
+type OrganizationRunLister interface {
+	ListForOrganization(ctx context.Context, organization string, options *tfe.RunListForOrganizationOptions) (*tfe.OrganizationRunList, error)
+}
+
+// ErrPoolNotFound is returned when TFC reports that the agent pool does not
+// exist or the token cannot see it. Retrying will not help.
+var ErrPoolNotFound = errors.New("agent pool not found")
+
 // Client wraps TFC/TFE API access for the autoscaler.
 type Client struct {
-	agentPoolID string
-	agentPools  AgentPoolReader
-	agents      AgentLister
-	runs        RunLister
+	organization string
+	agentPoolID  string
+	// agentPoolName is resolved to agentPoolID by New when no ID is given.
+	agentPoolName string
+	agentPools    AgentPoolReader
+	agents        AgentLister
+	runs          RunLister
+	orgRuns       OrganizationRunLister
+
+	// maxRetries is the number of additional attempts for transient API errors.
+	maxRetries int
+	// retryBaseDelay is the initial backoff delay, doubled on each retry.
+	retryBaseDelay time.Duration
+	// workspaceTags, when non-empty, limits pending run counts to workspaces
+	// carrying at least one of these tags (lowercased).
+	workspaceTags map[string]bool
+	// workspaceIDs, when non-empty, limits pending run counts to these pool
+	// workspaces.
+	workspaceIDs map[string]bool
+	// logger receives warnings about the configuration, such as allowlisted
+	// workspaces the pool does not have. Nil uses slog.Default.
+	logger *slog.Logger
+	// warnMu guards unknownWorkspaces, the allowlisted workspace IDs missing
+	// from the pool when last warned about, so each change is logged once.
+	warnMu            sync.Mutex
+	unknownWorkspaces string
+	// agentLimit is the organization's agent limit. Zero means unknown.
+	agentLimit int
+	// runConcurrency is the organization's run concurrency limit. Zero
+	// means unknown.
+	runConcurrency int
+	// planStatuses and applyStatuses are comma-separated run status filters
+	// counted as pending plan and apply demand. Empty uses the defaults.
+	planStatuses  string
+	applyStatuses string
+	// excludeSpeculative skips speculative (plan-only) runs when counting
+	// pending runs.
+	excludeSpeculative bool
+	// workspaceConcurrency is how many workspaces have their runs counted at
+	// once. Values below 1 count one workspace at a time.
+	workspaceConcurrency int
+	// userAgent is sent as the User-Agent header on every TFC API request.
+	userAgent string
+	// usePoolQueue counts pending runs with organization-wide run listings
+	// filtered to the agent pool instead of listing runs per workspace.
+	usePoolQueue bool
+	// pageSize is the page size of agent listings. Zero uses DefaultPageSize.
+	pageSize int
+	// onRateLimited, when set, is called for each API attempt TFC rate-limits.
+	onRateLimited func()
+
+	// rateLimitMu guards rateLimitedUntil, which holds back every call until
+	// the Retry-After of the last rate-limited response has passed.
+	rateLimitMu      sync.Mutex
+	rateLimitedUntil time.Time
+}
+
+// DefaultUserAgent identifies the autoscaler to TFC when WithUserAgent is
+// not set.
+const DefaultUserAgent = "tfc-agent-autoscaler"
+
+// DefaultPageSize is the page size of agent listings when WithPageSize is not
+// set. MaxPageSize is the largest page size the TFC API accepts.
+const (
+	DefaultPageSize = 100
+	MaxPageSize     = 100
+)
+
+// newTFEClient creates the underlying go-tfe client. Tests replace it to
+// inspect the config.
+var newTFEClient = tfe.NewClient
+
+// Option configures optional behavior for Client.
+type Option func(*Client)
+
+// WithRetry retries transient API errors up to maxRetries times with
+// exponential backoff starting at baseDelay.
+func WithRetry(maxRetries int, baseDelay time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		c.retryBaseDelay = baseDelay
+	}
+}
+
+// WithWorkspaceTags limits pending run counts to workspaces in the pool that
+// carry at least one of the given tags. Matching is case-insensitive. An empty
+// list counts every workspace.
+func WithWorkspaceTags(tags []string) Option {
+	return func(c *Client) {
+		if len(tags) == 0 {
+			c.workspaceTags = nil
+			return
+		}
+		c.workspaceTags = make(map[string]bool, len(tags))
+		for _, tag := range tags {
+			c.workspaceTags[strings.ToLower(tag)] = true
+		}
+	}
+}
+
+// WithWorkspaceIDs limits pending run counts to the pool workspaces with the
+// given IDs. It composes with WithWorkspaceTags: a workspace must match both.
+// IDs the pool does not have are logged as a warning and ignored. An empty
+// list counts every workspace.
+func WithWorkspaceIDs(ids []string) Option {
+	return func(c *Client) {
+		if len(ids) == 0 {
+			c.workspaceIDs = nil
+			return
+		}
+		c.workspaceIDs = make(map[string]bool, len(ids))
+		for _, id := range ids {
+			c.workspaceIDs[id] = true
+		}
+	}
+}
+
+// WithLogger sets the logger for configuration warnings. It defaults to
+// slog.Default.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
+
+// WithOrganization scopes the client to the named TFC organization, for
+// calls that address the organization rather than the agent pool.
+func WithOrganization(name string) Option {
+	return func(c *Client) {
+		c.organization = name
+	}
+}
+
+// WithAgentPoolName makes New look the agent pool up by name in the
+// organization set by WithOrganization when no agent pool ID is given.
+func WithAgentPoolName(name string) Option {
+	return func(c *Client) {
+		c.agentPoolName = name
+	}
+}
+
+// WithAgentLimit sets the organization's agent limit reported by
+// GetPoolLimit. Zero means no known limit.
+func WithAgentLimit(limit int) Option {
+	return func(c *Client) {
+		c.agentLimit = limit
+	}
+}
+
+// WithRunConcurrency sets the organization's run concurrency limit reported
+// by GetRunConcurrency. Zero means no known limit.
+func WithRunConcurrency(limit int) Option {
+	return func(c *Client) {
+		c.runConcurrency = limit
+	}
+}
+
+// WithPendingStatuses sets which run statuses count as pending plan and
+// apply demand. An empty list keeps the default for that run type. Statuses
+// should be validated with IsRunStatus first.
+func WithPendingStatuses(plan, apply []string) Option {
+	return func(c *Client) {
+		c.planStatuses = strings.Join(plan, ",")
+		c.applyStatuses = strings.Join(apply, ",")
+	}
+}
+
+// WithSpeculativeRuns sets whether speculative (plan-only) runs count as
+// pending demand. They are included by default; exclude them when such runs
+// do not execute on this pool's agents.
+func WithSpeculativeRuns(include bool) Option {
+	return func(c *Client) {
+		c.excludeSpeculative = !include
+	}
+}
+
+// WithWorkspaceConcurrency counts pending runs for up to n workspaces at once,
+// so pools with many workspaces reconcile within the poll interval. Values
+// below 1 count one workspace at a time.
+func WithWorkspaceConcurrency(n int) Option {
+	return func(c *Client) {
+		c.workspaceConcurrency = n
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent on TFC API requests, so they
+// can be attributed in audit logs and rate limits. An empty value uses
+// DefaultUserAgent.
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
+
+// WithPoolQueue counts pending runs by listing the organization's runs
+// filtered to the agent pool, which takes a few paginated calls regardless of
+// how many workspaces use the pool, instead of listing runs per workspace.
+// The organization comes from WithOrganization or, failing that, the pool.
+func WithPoolQueue(enabled bool) Option {
+	return func(c *Client) {
+		c.usePoolQueue = enabled
+	}
+}
+
+// WithPageSize sets the page size of agent listings. Zero uses
+// DefaultPageSize; values above MaxPageSize are rejected by the TFC API.
+func WithPageSize(n int) Option {
+	return func(c *Client) {
+		c.pageSize = n
+	}
+}
+
+// WithRateLimitHook calls fn each time TFC rate-limits an API attempt, for
+// example to count it in a metric.
+func WithRateLimitHook(fn func()) Option {
+	return func(c *Client) {
+		c.onRateLimited = fn
+	}
 }
 
 // New creates a new TFC client.
-func New(token, address, agentPoolID string) (*Client, error) {
+func New(ctx context.Context, token, address, agentPoolID string, opts ...Option) (*Client, error) {
+	c := &Client{agentPoolID: agentPoolID}
+	for _, opt := range opts {
+		opt(c)
+	}
+
 	cfg := &tfe.Config{
 		Token:   token,
 		Address: address,
+		Headers: http.Header{"User-Agent": []string{cmp.Or(c.userAgent, DefaultUserAgent)}},
+		HTTPClient: &http.Client{
+			Transport: statusTransport{base: http.DefaultTransport.(*http.Transport).Clone()},
+		},
 	}
 
-	client, err := tfe.NewClient(cfg)
+	client, err := newTFEClient(cfg)
 	if err != nil {
 		return nil, fmt.Errorf("creating TFE client: %w", err)
 	}
 
-	return &Client{
-		agentPoolID: agentPoolID,
-		agentPools:  client.AgentPools,
-		agents:      client.Agents,
-		runs:        client.Runs,
-	}, nil
+	c.agentPools = client.AgentPools
+	c.agents = client.Agents
+	c.runs = client.Runs
+	c.orgRuns = client.Runs
+
+	if c.agentPoolID == "" && c.agentPoolName != "" {
+		id, err := c.resolveAgentPoolID(ctx, client.AgentPools)
+		if err != nil {
+			return nil, err
+		}
+		c.agentPoolID = id
+	}
+
+	return c, nil
+}
+
+// resolveAgentPoolID returns the ID of the agent pool named agentPoolName in
+// the client's organization. It wraps ErrPoolNotFound when no pool has that
+// exact name.
+func (c *Client) resolveAgentPoolID(ctx context.Context, pools AgentPoolLister) (string, error) {
+	if c.organization == "" {
+		return "", fmt.Errorf("resolving agent pool %q: no organization configured", c.agentPoolName)
+	}
+
+	// The query matches names by substring, so the exact name is checked
+	// below.
+	opts := &tfe.AgentPoolListOptions{
+		ListOptions: tfe.ListOptions{PageSize: MaxPageSize},
+		Query:       c.agentPoolName,
+	}
+	for {
+		list, err := withRetry(ctx, c, func(ctx context.Context) (*tfe.AgentPoolList, error) {
+			return pools.List(ctx, c.organization, opts)
+		})
+		if err != nil {
+			return "", fmt.Errorf("listing agent pools in organization %s: %w", c.organization, err)
+		}
+
+		for _, pool := range list.Items {
+			if pool.Name == c.agentPoolName {
+				return pool.ID, nil
+			}
+		}
+
+		if list.Pagination == nil || list.CurrentPage >= list.TotalPages {
+			break
+		}
+		opts.PageNumber = list.NextPage
+	}
+
+	return "", fmt.Errorf("agent pool %q in organization %s: %w", c.agentPoolName, c.organization, ErrPoolNotFound)
+}
+
+// AgentPoolID returns the ID of the agent pool the client reads, resolved
+// from WithAgentPoolName if no ID was given.
+func (c *Client) AgentPoolID() string {
+	return c.agentPoolID
+}
+
+// Validate reads the agent pool once to check that the token can reach it,
+// and that the pool belongs to the configured organization, if any. It is
+// meant as a startup preflight so bad credentials fail fast.
+func (c *Client) Validate(ctx context.Context) error {
+	pool, err := withRetry(ctx, c, func(ctx context.Context) (*tfe.AgentPool, error) {
+		return c.agentPools.ReadWithOptions(ctx, c.agentPoolID, &tfe.AgentPoolReadOptions{})
+	})
+	if err != nil {
+		return fmt.Errorf("reading agent pool %s: %w", c.agentPoolID, c.poolError(err))
+	}
+	if c.organization != "" && pool.Organization != nil && pool.Organization.Name != c.organization {
+		return fmt.Errorf("agent pool %s belongs to organization %s, not %s", c.agentPoolID, pool.Organization.Name, c.organization)
+	}
+	return nil
+}
+
+// Organization returns the organization the client is scoped to, or an empty
+// string if none was configured.
+func (c *Client) Organization() string {
+	return c.organization
+}
+
+// poolError converts a not-found response for the agent pool into
+// ErrPoolNotFound, keeping the original error in the chain.
+func (c *Client) poolError(err error) error {
+	if errors.Is(err, tfe.ErrResourceNotFound) {
+		return fmt.Errorf("%w: %w", ErrPoolNotFound, err)
+	}
+	return err
+}
+
+// statusKey is the context key for the statusRecorder of an API attempt.
+type statusKey struct{}
+
+// statusRecorder holds the HTTP status of the last response received during
+// one API attempt, or zero if the request failed without a response. go-tfe
+// does not expose the status on the errors it returns. It also notes whether
+// any response in the attempt was rate-limited, since go-tfe retries those
+// itself, and the longest Retry-After they asked for.
+type statusRecorder struct {
+	status      int
+	rateLimited bool
+	retryAfter  time.Duration
+}
+
+// recordStatus stores status in ctx's statusRecorder, if it has one.
+func recordStatus(ctx context.Context, status int) {
+	if rec, ok := ctx.Value(statusKey{}).(*statusRecorder); ok {
+		rec.status = status
+	}
+}
+
+// recordRateLimit notes a rate-limited response asking to wait retryAfter in
+// ctx's statusRecorder, if it has one.
+func recordRateLimit(ctx context.Context, retryAfter time.Duration) {
+	if rec, ok := ctx.Value(statusKey{}).(*statusRecorder); ok {
+		rec.rateLimited = true
+		rec.retryAfter = max(rec.retryAfter, retryAfter)
+	}
+}
+
+// parseRetryAfter returns the wait a Retry-After header value asks for,
+// given either as seconds or as an HTTP date, relative to now. It returns
+// zero for a missing or malformed value.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0)
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(at.Sub(now), 0)
+	}
+	return 0
+}
+
+// statusTransport records each response's HTTP status for withRetry.
+type statusTransport struct {
+	base http.RoundTripper
+}
+
+func (t statusTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	status := 0
+	if err == nil {
+		status = resp.StatusCode
+	}
+	recordStatus(req.Context(), status)
+	if status == http.StatusTooManyRequests {
+		recordRateLimit(req.Context(), parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()))
+	}
+	return resp, err
+}
+
+// noteRateLimit counts a rate-limited attempt and holds back later calls
+// until retryAfter has passed.
+func (c *Client) noteRateLimit(retryAfter time.Duration) {
+	if c.onRateLimited != nil {
+		c.onRateLimited()
+	}
+	if retryAfter <= 0 {
+		return
+	}
+	until := time.Now().Add(retryAfter)
+	c.rateLimitMu.Lock()
+	defer c.rateLimitMu.Unlock()
+	if until.After(c.rateLimitedUntil) {
+		c.rateLimitedUntil = until
+	}
+}
+
+// waitRateLimit blocks until the Retry-After of the last rate-limited
+// response has passed, or ctx is done.
+func (c *Client) waitRateLimit(ctx context.Context) error {
+	c.rateLimitMu.Lock()
+	wait := time.Until(c.rateLimitedUntil)
+	c.rateLimitMu.Unlock()
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// isRetryable reports whether a call that failed with err after a response
+// with the given HTTP status (zero if none) may succeed on a later attempt.
+// Only rate limiting (429), server errors (5xx), and network failures are
+// transient; other client errors and context errors are permanent.
+func isRetryable(err error, status int) bool {
+	switch {
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return false
+	case status == http.StatusTooManyRequests, status >= http.StatusInternalServerError:
+		return true
+	case status != 0:
+		return false
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// withRetry calls fn, retrying transient errors with exponential backoff.
+// It gives up early when the context is canceled or its deadline would
+// expire before the next attempt. Each attempt gets a context that records
+// the HTTP status of its response, and waits out the Retry-After of any
+// rate-limited response, from this call or a concurrent one, first.
+func withRetry[T any](ctx context.Context, c *Client, fn func(ctx context.Context) (T, error)) (T, error) {
+	call := func() (T, int, error) {
+		if err := c.waitRateLimit(ctx); err != nil {
+			var zero T
+			return zero, 0, err
+		}
+		rec := &statusRecorder{}
+		result, err := fn(context.WithValue(ctx, statusKey{}, rec))
+		if rec.rateLimited {
+			c.noteRateLimit(rec.retryAfter)
+		}
+		return result, rec.status, err
+	}
+
+	result, status, err := call()
+	delay := c.retryBaseDelay
+	for attempt := 0; attempt < c.maxRetries && err != nil && isRetryable(err, status); attempt++ {
+		wait := delay
+		c.rateLimitMu.Lock()
+		wait = max(wait, time.Until(c.rateLimitedUntil))
+		c.rateLimitMu.Unlock()
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			return result, err
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return result, errors.Join(err, ctx.Err())
+		case <-timer.C:
+		}
+
+		result, status, err = call()
+		delay *= 2
+	}
+	return result, err
 }
 
 // AgentInfo holds details about a single TFC agent.
@@ -64,14 +557,16 @@ type AgentInfo struct {
 // GetAgentDetails returns detailed information about all agents in the pool.
 func (c *Client) GetAgentDetails(ctx context.Context) ([]AgentInfo, error) {
 	opts := &tfe.AgentListOptions{
-		ListOptions: tfe.ListOptions{PageSize: 100},
+		ListOptions: tfe.ListOptions{PageSize: cmp.Or(c.pageSize, DefaultPageSize)},
 	}
 
 	var agents []AgentInfo
 	for {
-		list, err := c.agents.List(ctx, c.agentPoolID, opts)
+		list, err := withRetry(ctx, c, func(ctx context.Context) (*tfe.AgentList, error) {
+			return c.agents.List(ctx, c.agentPoolID, opts)
+		})
 		if err != nil {
-			return nil, fmt.Errorf("listing agents: %w", err)
+			return nil, fmt.Errorf("listing agents: %w", c.poolError(err))
 		}
 
 		for _, agent := range list.Items {
@@ -95,13 +590,15 @@ func (c *Client) GetAgentDetails(ctx context.Context) ([]AgentInfo, error) {
 // GetAgentPoolStatus returns the count of busy, idle, and total agents in the pool.
 func (c *Client) GetAgentPoolStatus(ctx context.Context) (busy, idle, total int, err error) {
 	opts := &tfe.AgentListOptions{
-		ListOptions: tfe.ListOptions{PageSize: 100},
+		ListOptions: tfe.ListOptions{PageSize: cmp.Or(c.pageSize, DefaultPageSize)},
 	}
 
 	for {
-		agents, listErr := c.agents.List(ctx, c.agentPoolID, opts)
+		agents, listErr := withRetry(ctx, c, func(ctx context.Context) (*tfe.AgentList, error) {
+			return c.agents.List(ctx, c.agentPoolID, opts)
+		})
 		if listErr != nil {
-			return 0, 0, 0, fmt.Errorf("listing agents: %w", listErr)
+			return 0, 0, 0, fmt.Errorf("listing agents: %w", c.poolError(listErr))
 		}
 
 		for _, agent := range agents.Items {
@@ -123,6 +620,87 @@ func (c *Client) GetAgentPoolStatus(ctx context.Context) (busy, idle, total int,
 	return busy, idle, total, nil
 }
 
+// AgentStatuses are the agent statuses reported by TFC.
+var AgentStatuses = []string{"busy", "idle", "unknown", "errored", "exited"}
+
+// GetAgentStatusCounts returns the number of agents in the pool in each
+// status. Every status in AgentStatuses is present, zero when no agent has
+// it; any other status TFC reports is included as well.
+func (c *Client) GetAgentStatusCounts(ctx context.Context) (map[string]int, error) {
+	agents, err := c.GetAgentDetails(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return countAgentStatuses(agents), nil
+}
+
+// countAgentStatuses builds the status histogram for agents.
+func countAgentStatuses(agents []AgentInfo) map[string]int {
+	counts := make(map[string]int, len(AgentStatuses))
+	for _, status := range AgentStatuses {
+		counts[status] = 0
+	}
+	for _, agent := range agents {
+		counts[agent.Status]++
+	}
+	return counts
+}
+
+// GetPoolLimit returns the maximum number of agents that can connect to this
+// pool, or zero when there is no known limit. The TFC API does not expose the
+// organization's agent limit (the pool's agent-count attribute is the number
+// of registered agents, not a cap), so the limit comes from WithAgentLimit.
+func (c *Client) GetPoolLimit(_ context.Context) (int, error) {
+	return c.agentLimit, nil
+}
+
+// GetRunConcurrency returns how many runs the organization executes at once,
+// or zero when there is no known limit. Neither the TFC API nor go-tfe
+// exposes the organization's run concurrency, so the limit comes from
+// WithRunConcurrency.
+func (c *Client) GetRunConcurrency(_ context.Context) (int, error) {
+	return c.runConcurrency, nil
+}
+
+// runStatuses is the set of run statuses known to go-tfe.
+var runStatuses = map[tfe.RunStatus]bool{
+	tfe.RunApplied:                  true,
+	tfe.RunApplying:                 true,
+	tfe.RunApplyQueued:              true,
+	tfe.RunCanceled:                 true,
+	tfe.RunConfirmed:                true,
+	tfe.RunCostEstimated:            true,
+	tfe.RunCostEstimating:           true,
+	tfe.RunDiscarded:                true,
+	tfe.RunErrored:                  true,
+	tfe.RunFetching:                 true,
+	tfe.RunFetchingCompleted:        true,
+	tfe.RunPending:                  true,
+	tfe.RunPlanned:                  true,
+	tfe.RunPlannedAndFinished:       true,
+	tfe.RunPlannedAndSaved:          true,
+	tfe.RunPlanning:                 true,
+	tfe.RunPlanQueued:               true,
+	tfe.RunPolicyChecked:            true,
+	tfe.RunPolicyChecking:           true,
+	tfe.RunPolicyOverride:           true,
+	tfe.RunPolicySoftFailed:         true,
+	tfe.RunPostPlanAwaitingDecision: true,
+	tfe.RunPostPlanCompleted:        true,
+	tfe.RunPostPlanRunning:          true,
+	tfe.RunPreApplyRunning:          true,
+	tfe.RunPreApplyCompleted:        true,
+	tfe.RunPrePlanCompleted:         true,
+	tfe.RunPrePlanRunning:           true,
+	tfe.RunQueuing:                  true,
+	tfe.RunQueuingApply:             true,
+}
+
+// IsRunStatus reports whether status is a run status known to TFC.
+func IsRunStatus(status string) bool {
+	return runStatuses[tfe.RunStatus(status)]
+}
+
 // planPendingStatuses filters runs waiting for plan capacity.
 var planPendingStatuses = strings.Join([]string{
 	string(tfe.RunPending),
@@ -138,6 +716,10 @@ var applyPendingStatuses = strings.Join([]string{
 type PendingRunCounts struct {
 	PlanPending  int
 	ApplyPending int
+	// OldestPlanAt and OldestApplyAt are when the longest-waiting pending run
+	// of each type was queued; zero when none are pending.
+	OldestPlanAt  time.Time
+	OldestApplyAt time.Time
 }
 
 // Total returns the sum of plan and apply pending runs.
@@ -145,32 +727,339 @@ func (p PendingRunCounts) Total() int {
 	return p.PlanPending + p.ApplyPending
 }
 
-// GetPendingRunsByType returns pending run counts split by plan vs apply type
-// across all workspaces assigned to this agent pool.
-func (c *Client) GetPendingRunsByType(ctx context.Context) (PendingRunCounts, error) {
-	pool, err := c.agentPools.ReadWithOptions(ctx, c.agentPoolID, &tfe.AgentPoolReadOptions{
-		Include: []tfe.AgentPoolIncludeOpt{tfe.AgentPoolWorkspaces},
+// Oldest returns when the longest-waiting pending run of either type was
+// queued, or zero when none are pending.
+func (p PendingRunCounts) Oldest() time.Time {
+	return earliest(p.OldestPlanAt, p.OldestApplyAt)
+}
+
+// PendingRunDetail holds pending run counts split by type, with each type
+// further broken down by run status, e.g. plan_queued runs waiting for an
+// agent vs pending runs not yet ready to start.
+type PendingRunDetail struct {
+	PendingRunCounts
+	// PlanByStatus and ApplyByStatus count pending runs of each type by run
+	// status. Statuses with no runs are omitted.
+	PlanByStatus  map[string]int
+	ApplyByStatus map[string]int
+}
+
+// earliest returns the earlier of a and b, ignoring zero times.
+func earliest(a, b time.Time) time.Time {
+	if a.IsZero() || (!b.IsZero() && b.Before(a)) {
+		return b
+	}
+	return a
+}
+
+// queuedAt returns when run entered its current queue: the status timestamp
+// for plan_queued and apply_queued runs, otherwise when it was created.
+func queuedAt(run *tfe.Run) time.Time {
+	if ts := run.StatusTimestamps; ts != nil {
+		switch run.Status {
+		case tfe.RunPlanQueued:
+			if !ts.PlanQueuedAt.IsZero() {
+				return ts.PlanQueuedAt
+			}
+		case tfe.RunApplyQueued:
+			if !ts.ApplyQueuedAt.IsZero() {
+				return ts.ApplyQueuedAt
+			}
+		}
+	}
+	return run.CreatedAt
+}
+
+// WorkspacePendingRuns holds pending run counts for a single workspace.
+type WorkspacePendingRuns struct {
+	WorkspaceID   string
+	WorkspaceName string
+	PlanPending   int
+	ApplyPending  int
+	// OldestPlanAt and OldestApplyAt are when the workspace's longest-waiting
+	// pending run of each type was queued; zero when none are pending.
+	OldestPlanAt  time.Time
+	OldestApplyAt time.Time
+}
+
+// Total returns the sum of plan and apply pending runs for the workspace.
+func (w WorkspacePendingRuns) Total() int {
+	return w.PlanPending + w.ApplyPending
+}
+
+// GetPendingRunsByWorkspace returns pending run counts for each workspace
+// assigned to this agent pool, in pool order, skipping workspaces excluded by
+// WithWorkspaceTags. Run listings are paginated per workspace, and workspaces
+// are counted concurrently up to WithWorkspaceConcurrency. The first error
+// cancels the remaining counts. With WithPoolQueue, the counts come from
+// organization-wide run listings instead.
+func (c *Client) GetPendingRunsByWorkspace(ctx context.Context) ([]WorkspacePendingRuns, error) {
+	workspaces, _, err := c.pendingRuns(ctx)
+	return workspaces, err
+}
+
+// pendingRuns returns the per-workspace pending run counts, as
+// GetPendingRunsByWorkspace does, along with pool-wide counts of the plan and
+// apply runs by status.
+func (c *Client) pendingRuns(ctx context.Context) ([]WorkspacePendingRuns, statusCounts, error) {
+	pool, err := withRetry(ctx, c, func(ctx context.Context) (*tfe.AgentPool, error) {
+		return c.agentPools.ReadWithOptions(ctx, c.agentPoolID, &tfe.AgentPoolReadOptions{
+			Include: []tfe.AgentPoolIncludeOpt{tfe.AgentPoolWorkspaces},
+		})
 	})
 	if err != nil {
-		return PendingRunCounts{}, fmt.Errorf("reading agent pool: %w", err)
+		return nil, statusCounts{}, fmt.Errorf("reading agent pool: %w", c.poolError(err))
 	}
 
-	var counts PendingRunCounts
-	for _, ws := range pool.Workspaces {
-		planCount, err := c.countRunsForWorkspace(ctx, ws.ID, planPendingStatuses)
-		if err != nil {
-			return PendingRunCounts{}, fmt.Errorf("counting plan runs for workspace %s: %w", ws.ID, err)
+	c.warnUnknownWorkspaces(pool.Workspaces)
+	workspaces := slices.DeleteFunc(slices.Clone(pool.Workspaces), func(ws *tfe.Workspace) bool {
+		return !c.matchesWorkspaceTags(ws) || (len(c.workspaceIDs) > 0 && !c.workspaceIDs[ws.ID])
+	})
+	if c.usePoolQueue {
+		return c.pendingRunsFromPoolQueue(ctx, pool, workspaces)
+	}
+
+	// Each goroutine writes only its own index, keeping pool order.
+	result := make([]WorkspacePendingRuns, len(workspaces))
+	byStatus := make([]statusCounts, len(workspaces))
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(max(c.workspaceConcurrency, 1))
+	for i, ws := range workspaces {
+		g.Go(func() error {
+			if err := gctx.Err(); err != nil {
+				return err
+			}
+
+			byStatus[i] = newStatusCounts()
+			planCount, oldestPlan, err := c.countRunsForWorkspace(gctx, ws.ID, cmp.Or(c.planStatuses, planPendingStatuses), byStatus[i].plan)
+			if err != nil {
+				return fmt.Errorf("counting plan runs for workspace %s: %w", ws.ID, err)
+			}
+
+			applyCount, oldestApply, err := c.countRunsForWorkspace(gctx, ws.ID, cmp.Or(c.applyStatuses, applyPendingStatuses), byStatus[i].apply)
+			if err != nil {
+				return fmt.Errorf("counting apply runs for workspace %s: %w", ws.ID, err)
+			}
+
+			result[i] = WorkspacePendingRuns{
+				WorkspaceID:   ws.ID,
+				WorkspaceName: ws.Name,
+				PlanPending:   planCount,
+				ApplyPending:  applyCount,
+				OldestPlanAt:  oldestPlan,
+				OldestApplyAt: oldestApply,
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, statusCounts{}, err
+	}
+
+	total := newStatusCounts()
+	for _, counts := range byStatus {
+		total.add(counts)
+	}
+	return result, total, nil
+}
+
+// statusCounts counts pending plan and apply runs by run status.
+type statusCounts struct {
+	plan, apply map[string]int
+}
+
+func newStatusCounts() statusCounts {
+	return statusCounts{plan: map[string]int{}, apply: map[string]int{}}
+}
+
+// add adds other's counts to sc.
+func (sc statusCounts) add(other statusCounts) {
+	for status, n := range other.plan {
+		sc.plan[status] += n
+	}
+	for status, n := range other.apply {
+		sc.apply[status] += n
+	}
+}
+
+// pendingRunsFromPoolQueue counts pending runs for workspaces by listing the
+// organization's runs on the agent pool once per run type, rather than once
+// per workspace. Runs in other workspaces are ignored.
+func (c *Client) pendingRunsFromPoolQueue(ctx context.Context, pool *tfe.AgentPool, workspaces []*tfe.Workspace) ([]WorkspacePendingRuns, statusCounts, error) {
+	org := c.organization
+	if org == "" && pool.Organization != nil {
+		org = pool.Organization.Name
+	}
+	if org == "" {
+		return nil, statusCounts{}, fmt.Errorf("agent pool %s has no organization to list runs in", c.agentPoolID)
+	}
+
+	result := make([]WorkspacePendingRuns, len(workspaces))
+	index := make(map[string]int, len(workspaces))
+	for i, ws := range workspaces {
+		result[i] = WorkspacePendingRuns{WorkspaceID: ws.ID, WorkspaceName: ws.Name}
+		index[ws.ID] = i
+	}
+	byStatus := newStatusCounts()
+
+	planRuns, err := c.listPoolRuns(ctx, org, pool.Name, cmp.Or(c.planStatuses, planPendingStatuses))
+	if err != nil {
+		return nil, statusCounts{}, fmt.Errorf("listing plan runs for agent pool %s: %w", pool.Name, err)
+	}
+	for _, run := range planRuns {
+		if i, ok := index[run.Workspace.ID]; ok {
+			byStatus.plan[string(run.Status)]++
+			result[i].PlanPending++
+			result[i].OldestPlanAt = earliest(result[i].OldestPlanAt, queuedAt(run))
+		}
+	}
+
+	applyRuns, err := c.listPoolRuns(ctx, org, pool.Name, cmp.Or(c.applyStatuses, applyPendingStatuses))
+	if err != nil {
+		return nil, statusCounts{}, fmt.Errorf("listing apply runs for agent pool %s: %w", pool.Name, err)
+	}
+	for _, run := range applyRuns {
+		if i, ok := index[run.Workspace.ID]; ok {
+			byStatus.apply[string(run.Status)]++
+			result[i].ApplyPending++
+			result[i].OldestApplyAt = earliest(result[i].OldestApplyAt, queuedAt(run))
 		}
-		counts.PlanPending += planCount
+	}
+
+	return result, byStatus, nil
+}
+
+// listPoolRuns lists the organization's runs on the named agent pool in the
+// given statuses, skipping speculative runs when they are excluded and runs
+// without a workspace.
+func (c *Client) listPoolRuns(ctx context.Context, org, poolName, statuses string) ([]*tfe.Run, error) {
+	opts := &tfe.RunListForOrganizationOptions{
+		Status:         statuses,
+		AgentPoolNames: poolName,
+		ListOptions:    tfe.ListOptions{PageSize: 100},
+	}
 
-		applyCount, err := c.countRunsForWorkspace(ctx, ws.ID, applyPendingStatuses)
+	var runs []*tfe.Run
+	for {
+		page, err := withRetry(ctx, c, func(ctx context.Context) (*tfe.OrganizationRunList, error) {
+			return c.orgRuns.ListForOrganization(ctx, org, opts)
+		})
 		if err != nil {
-			return PendingRunCounts{}, fmt.Errorf("counting apply runs for workspace %s: %w", ws.ID, err)
+			return nil, err
+		}
+
+		for _, run := range page.Items {
+			if run == nil || run.Workspace == nil || (c.excludeSpeculative && run.PlanOnly) {
+				continue
+			}
+			runs = append(runs, run)
+		}
+
+		if page.PaginationNextPrev == nil || page.NextPage == 0 {
+			break
+		}
+		opts.PageNumber = page.NextPage
+	}
+
+	return runs, nil
+}
+
+// matchesWorkspaceTags reports whether ws carries any configured workspace
+// tag. Tags are read from the tag-names attribute and, when present, the
+// tags relationship.
+func (c *Client) matchesWorkspaceTags(ws *tfe.Workspace) bool {
+	if len(c.workspaceTags) == 0 {
+		return true
+	}
+	for _, name := range ws.TagNames {
+		if c.workspaceTags[strings.ToLower(name)] {
+			return true
+		}
+	}
+	for _, tag := range ws.Tags {
+		if tag != nil && c.workspaceTags[strings.ToLower(tag.Name)] {
+			return true
+		}
+	}
+	return false
+}
+
+// warnUnknownWorkspaces logs a warning naming the allowlisted workspace IDs
+// that are not among the pool's workspaces, once each time that set changes.
+func (c *Client) warnUnknownWorkspaces(workspaces []*tfe.Workspace) {
+	if len(c.workspaceIDs) == 0 {
+		return
+	}
+	inPool := make(map[string]bool, len(workspaces))
+	for _, ws := range workspaces {
+		inPool[ws.ID] = true
+	}
+	var unknown []string
+	for id := range c.workspaceIDs {
+		if !inPool[id] {
+			unknown = append(unknown, id)
 		}
-		counts.ApplyPending += applyCount
 	}
+	slices.Sort(unknown)
+	key := strings.Join(unknown, ",")
+
+	c.warnMu.Lock()
+	defer c.warnMu.Unlock()
+	if key == c.unknownWorkspaces {
+		return
+	}
+	c.unknownWorkspaces = key
+	if len(unknown) > 0 {
+		cmp.Or(c.logger, slog.Default()).Warn("allowlisted workspaces are not in the agent pool, ignoring them",
+			"agent_pool_id", c.agentPoolID,
+			"workspace_ids", unknown,
+		)
+	}
+}
+
+// GetPendingRunsByType returns pending run counts split by plan vs apply type
+// across all workspaces assigned to this agent pool.
+func (c *Client) GetPendingRunsByType(ctx context.Context) (PendingRunCounts, error) {
+	detail, err := c.GetPendingRunsDetailed(ctx)
+	return detail.PendingRunCounts, err
+}
+
+// GetPendingRunsDetailed returns pending run counts split by plan vs apply
+// type and by run status across all workspaces assigned to this agent pool.
+func (c *Client) GetPendingRunsDetailed(ctx context.Context) (PendingRunDetail, error) {
+	workspaces, byStatus, err := c.pendingRuns(ctx)
+	if err != nil {
+		return PendingRunDetail{}, err
+	}
+
+	detail := PendingRunDetail{
+		PlanByStatus:  omitZero(byStatus.plan),
+		ApplyByStatus: omitZero(byStatus.apply),
+	}
+	for _, ws := range workspaces {
+		detail.PlanPending += ws.PlanPending
+		detail.ApplyPending += ws.ApplyPending
+		detail.OldestPlanAt = earliest(detail.OldestPlanAt, ws.OldestPlanAt)
+		detail.OldestApplyAt = earliest(detail.OldestApplyAt, ws.OldestApplyAt)
+	}
+
+	return detail, nil
+}
 
-	return counts, nil
+// omitZero returns counts without its zero entries.
+func omitZero(counts map[string]int) map[string]int {
+	maps.DeleteFunc(counts, func(_ string, n int) bool { return n == 0 })
+	return counts
+}
+
+// GetPendingRunsBreakdown returns the pool's pending run counts. The client
+// serves the whole pool, so the service and pool counts are the same.
+func (c *Client) GetPendingRunsBreakdown(ctx context.Context) (service PendingRunDetail, pool PendingRunCounts, err error) {
+	detail, err := c.GetPendingRunsDetailed(ctx)
+	if err != nil {
+		return PendingRunDetail{}, PendingRunCounts{}, err
+	}
+	return detail, detail.PendingRunCounts, nil
 }
 
 // GetPendingRuns returns the total count of pending/queued runs across all
@@ -183,20 +1072,35 @@ func (c *Client) GetPendingRuns(ctx context.Context) (int, error) {
 	return counts.Total(), nil
 }
 
-func (c *Client) countRunsForWorkspace(ctx context.Context, workspaceID, statuses string) (int, error) {
+// countRunsForWorkspace counts the workspace's runs in the given statuses and
+// returns when the longest-waiting of them was queued. Each counted run is
+// also added to byStatus under its status.
+func (c *Client) countRunsForWorkspace(ctx context.Context, workspaceID, statuses string, byStatus map[string]int) (int, time.Time, error) {
 	opts := &tfe.RunListOptions{
 		Status:      statuses,
 		ListOptions: tfe.ListOptions{PageSize: 100},
 	}
 
 	var total int
+	var oldest time.Time
 	for {
-		runs, err := c.runs.List(ctx, workspaceID, opts)
+		runs, err := withRetry(ctx, c, func(ctx context.Context) (*tfe.RunList, error) {
+			return c.runs.List(ctx, workspaceID, opts)
+		})
 		if err != nil {
-			return 0, err
+			return 0, time.Time{}, err
 		}
 
-		total += len(runs.Items)
+		for _, run := range runs.Items {
+			if c.excludeSpeculative && run != nil && run.PlanOnly {
+				continue
+			}
+			total++
+			if run != nil {
+				oldest = earliest(oldest, queuedAt(run))
+				byStatus[string(run.Status)]++
+			}
+		}
 
 		if runs.Pagination == nil || runs.CurrentPage >= runs.TotalPages {
 			break
@@ -204,5 +1108,5 @@ func (c *Client) countRunsForWorkspace(ctx context.Context, workspaceID, statuse
 		opts.PageNumber = runs.NextPage
 	}
 
-	return total, nil
+	return total, oldest, nil
 }
@@ -1,9 +1,22 @@
 package tfc
 
 import (
+	"bytes"
+	"cmp"
 	"context"
 	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/go-tfe"
 )
@@ -17,6 +30,15 @@ func (m *mockAgentPools) ReadWithOptions(ctx context.Context, agentPoolID string
 	return m.readWithOptionsFn(ctx, agentPoolID, options)
 }
 
+// mockAgentPoolLister implements the agent pool listing we use.
+type mockAgentPoolLister struct {
+	listFn func(ctx context.Context, organization string, options *tfe.AgentPoolListOptions) (*tfe.AgentPoolList, error)
+}
+
+func (m *mockAgentPoolLister) List(ctx context.Context, organization string, options *tfe.AgentPoolListOptions) (*tfe.AgentPoolList, error) {
+	return m.listFn(ctx, organization, options)
+}
+
 // mockAgents implements the subset of tfe.Agents we use.
 type mockAgents struct {
 	listFn func(ctx context.Context, agentPoolID string, options *tfe.AgentListOptions) (*tfe.AgentList, error)
@@ -35,6 +57,15 @@ func (m *mockRuns) List(ctx context.Context, workspaceID string, options *tfe.Ru
 	return m.listFn(ctx, workspaceID, options)
 }
 
+// mockOrgRuns implements the organization-wide run listing we use.
+type mockOrgRuns struct {
+	listForOrganizationFn func(ctx context.Context, organization string, options *tfe.RunListForOrganizationOptions) (*tfe.OrganizationRunList, error)
+}
+
+func (m *mockOrgRuns) ListForOrganization(ctx context.Context, organization string, options *tfe.RunListForOrganizationOptions) (*tfe.OrganizationRunList, error) {
+	return m.listForOrganizationFn(ctx, organization, options)
+}
+
 func TestGetAgentPoolStatus(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -113,6 +144,64 @@ func TestGetAgentPoolStatus(t *testing.T) {
 	}
 }
 
+func TestGetAgentStatusCounts(t *testing.T) {
+	tests := []struct {
+		name   string
+		agents []*tfe.Agent
+		want   map[string]int
+	}{
+		{
+			name: "mixed statuses",
+			agents: []*tfe.Agent{
+				{ID: "agent-1", Status: "idle"},
+				{ID: "agent-2", Status: "busy"},
+				{ID: "agent-3", Status: "errored"},
+				{ID: "agent-4", Status: "errored"},
+				{ID: "agent-5", Status: "exited"},
+				{ID: "agent-6", Status: "unknown"},
+			},
+			want: map[string]int{"busy": 1, "idle": 1, "unknown": 1, "errored": 2, "exited": 1},
+		},
+		{
+			name:   "no agents reports every status as zero",
+			agents: []*tfe.Agent{},
+			want:   map[string]int{"busy": 0, "idle": 0, "unknown": 0, "errored": 0, "exited": 0},
+		},
+		{
+			name: "unrecognized status is kept",
+			agents: []*tfe.Agent{
+				{ID: "agent-1", Status: "busy"},
+				{ID: "agent-2", Status: "draining"},
+			},
+			want: map[string]int{"busy": 1, "idle": 0, "unknown": 0, "errored": 0, "exited": 0, "draining": 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Client{
+				agentPoolID: "apool-123",
+				agents: &mockAgents{
+					listFn: func(_ context.Context, _ string, _ *tfe.AgentListOptions) (*tfe.AgentList, error) {
+						return &tfe.AgentList{
+							Items:      tt.agents,
+							Pagination: &tfe.Pagination{TotalPages: 1, CurrentPage: 1},
+						}, nil
+					},
+				},
+			}
+
+			got, err := c.GetAgentStatusCounts(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestGetAgentDetails(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -309,6 +398,381 @@ func TestGetPendingRunsByType(t *testing.T) {
 	}
 }
 
+func TestGetPendingRunsByWorkspace(t *testing.T) {
+	c := &Client{
+		agentPoolID: "apool-123",
+		agentPools: &mockAgentPools{
+			readWithOptionsFn: func(_ context.Context, _ string, _ *tfe.AgentPoolReadOptions) (*tfe.AgentPool, error) {
+				return &tfe.AgentPool{
+					ID: "apool-123",
+					Workspaces: []*tfe.Workspace{
+						{ID: "ws-1", Name: "network"},
+						{ID: "ws-2", Name: "compute"},
+					},
+				}, nil
+			},
+		},
+		runs: &mockRuns{
+			listFn: func(_ context.Context, wsID string, opts *tfe.RunListOptions) (*tfe.RunList, error) {
+				// ws-1 has two pages of plan-pending runs.
+				if wsID == "ws-1" && opts.Status == planPendingStatuses {
+					if opts.PageNumber == 0 || opts.PageNumber == 1 {
+						return &tfe.RunList{
+							Items:      []*tfe.Run{{ID: "run-1"}, {ID: "run-2"}},
+							Pagination: &tfe.Pagination{TotalPages: 2, CurrentPage: 1, NextPage: 2},
+						}, nil
+					}
+					return &tfe.RunList{
+						Items:      []*tfe.Run{{ID: "run-3"}},
+						Pagination: &tfe.Pagination{TotalPages: 2, CurrentPage: 2},
+					}, nil
+				}
+				if wsID == "ws-2" && opts.Status == applyPendingStatuses {
+					return &tfe.RunList{
+						Items:      []*tfe.Run{{ID: "run-4"}},
+						Pagination: &tfe.Pagination{TotalPages: 1, CurrentPage: 1},
+					}, nil
+				}
+				return &tfe.RunList{
+					Items:      []*tfe.Run{},
+					Pagination: &tfe.Pagination{TotalPages: 1, CurrentPage: 1},
+				}, nil
+			},
+		},
+	}
+
+	got, err := c.GetPendingRunsByWorkspace(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []WorkspacePendingRuns{
+		{WorkspaceID: "ws-1", WorkspaceName: "network", PlanPending: 3, ApplyPending: 0},
+		{WorkspaceID: "ws-2", WorkspaceName: "compute", PlanPending: 0, ApplyPending: 1},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d workspaces, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("workspace[%d]: got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestGetPendingRunsWorkspaceTagFilter(t *testing.T) {
+	workspaces := []*tfe.Workspace{
+		{ID: "ws-1", Name: "agents-spot", TagNames: []string{"team-a", "autoscale"}},
+		{ID: "ws-2", Name: "legacy", TagNames: []string{"team-b"}},
+	}
+
+	tests := []struct {
+		name      string
+		tags      []string
+		wantPlan  int
+		wantApply int
+		wantWS    []string
+	}{
+		{
+			name:      "no filter counts every workspace",
+			wantPlan:  2,
+			wantApply: 1,
+			wantWS:    []string{"ws-1", "ws-2"},
+		},
+		{
+			name:      "tag excludes the other workspace",
+			tags:      []string{"autoscale"},
+			wantPlan:  2,
+			wantApply: 0,
+			wantWS:    []string{"ws-1"},
+		},
+		{
+			name:      "matches any tag case-insensitively",
+			tags:      []string{"nope", "TEAM-B"},
+			wantPlan:  0,
+			wantApply: 1,
+			wantWS:    []string{"ws-2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var listed []string
+			c := &Client{
+				agentPoolID: "apool-123",
+				agentPools: &mockAgentPools{
+					readWithOptionsFn: func(_ context.Context, _ string, opts *tfe.AgentPoolReadOptions) (*tfe.AgentPool, error) {
+						if len(opts.Include) != 1 || opts.Include[0] != tfe.AgentPoolWorkspaces {
+							t.Errorf("include = %v, want [workspaces]", opts.Include)
+						}
+						return &tfe.AgentPool{ID: "apool-123", Workspaces: workspaces}, nil
+					},
+				},
+				runs: &mockRuns{
+					listFn: func(_ context.Context, wsID string, opts *tfe.RunListOptions) (*tfe.RunList, error) {
+						items := []*tfe.Run{}
+						switch {
+						case wsID == "ws-1" && opts.Status == planPendingStatuses:
+							listed = append(listed, wsID)
+							items = []*tfe.Run{{ID: "run-1"}, {ID: "run-2"}}
+						case wsID == "ws-2" && opts.Status == planPendingStatuses:
+							listed = append(listed, wsID)
+						case wsID == "ws-2" && opts.Status == applyPendingStatuses:
+							items = []*tfe.Run{{ID: "run-3"}}
+						}
+						return &tfe.RunList{
+							Items:      items,
+							Pagination: &tfe.Pagination{TotalPages: 1, CurrentPage: 1},
+						}, nil
+					},
+				},
+			}
+			WithWorkspaceTags(tt.tags)(c)
+
+			got, err := c.GetPendingRunsByType(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.PlanPending != tt.wantPlan || got.ApplyPending != tt.wantApply {
+				t.Errorf("got %+v, want plan=%d apply=%d", got, tt.wantPlan, tt.wantApply)
+			}
+			if len(listed) != len(tt.wantWS) {
+				t.Fatalf("listed runs for %v, want %v", listed, tt.wantWS)
+			}
+			for i := range tt.wantWS {
+				if listed[i] != tt.wantWS[i] {
+					t.Errorf("listed[%d] = %s, want %s", i, listed[i], tt.wantWS[i])
+				}
+			}
+		})
+	}
+}
+
+func TestGetPendingRunsWorkspaceTagRelationship(t *testing.T) {
+	c := &Client{
+		agentPoolID: "apool-123",
+		agentPools: &mockAgentPools{
+			readWithOptionsFn: func(_ context.Context, _ string, _ *tfe.AgentPoolReadOptions) (*tfe.AgentPool, error) {
+				return &tfe.AgentPool{ID: "apool-123", Workspaces: []*tfe.Workspace{
+					{ID: "ws-1", Tags: []*tfe.Tag{{Name: "autoscale"}}},
+					{ID: "ws-2"},
+				}}, nil
+			},
+		},
+		runs: &mockRuns{
+			listFn: func(_ context.Context, _ string, _ *tfe.RunListOptions) (*tfe.RunList, error) {
+				return &tfe.RunList{
+					Items:      []*tfe.Run{{ID: "run-1"}},
+					Pagination: &tfe.Pagination{TotalPages: 1, CurrentPage: 1},
+				}, nil
+			},
+		},
+	}
+	WithWorkspaceTags([]string{"autoscale"})(c)
+
+	got, err := c.GetPendingRunsByWorkspace(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 1 || got[0].WorkspaceID != "ws-1" {
+		t.Errorf("got %+v, want only ws-1", got)
+	}
+}
+
+func TestGetPendingRunsWorkspaceIDs(t *testing.T) {
+	workspaces := []*tfe.Workspace{
+		{ID: "ws-1", Tags: []*tfe.Tag{{Name: "autoscale"}}},
+		{ID: "ws-2", Tags: []*tfe.Tag{{Name: "autoscale"}}},
+		{ID: "ws-3"},
+	}
+
+	tests := []struct {
+		name        string
+		ids         []string
+		tags        []string
+		wantWS      []string
+		wantUnknown string
+	}{
+		{name: "no allowlist", wantWS: []string{"ws-1", "ws-2", "ws-3"}},
+		{name: "allowlist", ids: []string{"ws-3", "ws-1"}, wantWS: []string{"ws-1", "ws-3"}},
+		{name: "composes with tags", ids: []string{"ws-2", "ws-3"}, tags: []string{"autoscale"}, wantWS: []string{"ws-2"}},
+		{name: "unknown ID", ids: []string{"ws-1", "ws-gone"}, wantWS: []string{"ws-1"}, wantUnknown: "ws-gone"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			c := &Client{
+				agentPoolID: "apool-123",
+				agentPools: &mockAgentPools{
+					readWithOptionsFn: func(_ context.Context, _ string, _ *tfe.AgentPoolReadOptions) (*tfe.AgentPool, error) {
+						return &tfe.AgentPool{ID: "apool-123", Workspaces: workspaces}, nil
+					},
+				},
+				runs: &mockRuns{
+					listFn: func(_ context.Context, _ string, _ *tfe.RunListOptions) (*tfe.RunList, error) {
+						return &tfe.RunList{Pagination: &tfe.Pagination{TotalPages: 1, CurrentPage: 1}}, nil
+					},
+				},
+			}
+			WithWorkspaceIDs(tt.ids)(c)
+			WithWorkspaceTags(tt.tags)(c)
+			WithLogger(slog.New(slog.NewTextHandler(&logs, nil)))(c)
+
+			// The second call checks that an unchanged set is warned about once.
+			for range 2 {
+				got, err := c.GetPendingRunsByWorkspace(context.Background())
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				var ids []string
+				for _, ws := range got {
+					ids = append(ids, ws.WorkspaceID)
+				}
+				if !slices.Equal(ids, tt.wantWS) {
+					t.Errorf("counted workspaces %v, want %v", ids, tt.wantWS)
+				}
+			}
+
+			warnings := strings.Count(logs.String(), "allowlisted workspaces are not in the agent pool")
+			if tt.wantUnknown == "" {
+				if warnings != 0 {
+					t.Errorf("unexpected warning: %s", logs.String())
+				}
+				return
+			}
+			if warnings != 1 || !strings.Contains(logs.String(), tt.wantUnknown) {
+				t.Errorf("want one warning naming %s, got %q", tt.wantUnknown, logs.String())
+			}
+		})
+	}
+}
+
+func TestGetPendingRunsByWorkspaceError(t *testing.T) {
+	c := &Client{
+		agentPoolID: "apool-123",
+		agentPools: &mockAgentPools{
+			readWithOptionsFn: func(_ context.Context, _ string, _ *tfe.AgentPoolReadOptions) (*tfe.AgentPool, error) {
+				return &tfe.AgentPool{ID: "apool-123", Workspaces: []*tfe.Workspace{{ID: "ws-1"}}}, nil
+			},
+		},
+		runs: &mockRuns{
+			listFn: func(_ context.Context, _ string, _ *tfe.RunListOptions) (*tfe.RunList, error) {
+				return nil, errors.New("api failure")
+			},
+		},
+	}
+
+	if _, err := c.GetPendingRunsByWorkspace(context.Background()); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestGetPendingRunsByWorkspaceConcurrency(t *testing.T) {
+	const (
+		numWorkspaces = 20
+		concurrency   = 3
+	)
+	workspaces := make([]*tfe.Workspace, numWorkspaces)
+	for i := range workspaces {
+		workspaces[i] = &tfe.Workspace{ID: fmt.Sprintf("ws-%d", i), Name: fmt.Sprintf("name-%d", i)}
+	}
+
+	var inFlight, maxInFlight atomic.Int32
+	c := &Client{
+		agentPoolID: "apool-123",
+		agentPools: &mockAgentPools{
+			readWithOptionsFn: func(_ context.Context, _ string, _ *tfe.AgentPoolReadOptions) (*tfe.AgentPool, error) {
+				return &tfe.AgentPool{ID: "apool-123", Workspaces: workspaces}, nil
+			},
+		},
+		runs: &mockRuns{
+			listFn: func(_ context.Context, wsID string, opts *tfe.RunListOptions) (*tfe.RunList, error) {
+				n := inFlight.Add(1)
+				defer inFlight.Add(-1)
+				for {
+					peak := maxInFlight.Load()
+					if n <= peak || maxInFlight.CompareAndSwap(peak, n) {
+						break
+					}
+				}
+				time.Sleep(2 * time.Millisecond)
+
+				// ws-N has N pending plans and no pending applies.
+				var items []*tfe.Run
+				if opts.Status == planPendingStatuses {
+					var i int
+					_, _ = fmt.Sscanf(wsID, "ws-%d", &i)
+					items = make([]*tfe.Run, i)
+				}
+				return &tfe.RunList{Items: items, Pagination: &tfe.Pagination{TotalPages: 1, CurrentPage: 1}}, nil
+			},
+		},
+		workspaceConcurrency: concurrency,
+	}
+
+	got, err := c.GetPendingRunsByWorkspace(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != numWorkspaces {
+		t.Fatalf("got %d workspaces, want %d", len(got), numWorkspaces)
+	}
+	for i, ws := range got {
+		want := WorkspacePendingRuns{WorkspaceID: fmt.Sprintf("ws-%d", i), WorkspaceName: fmt.Sprintf("name-%d", i), PlanPending: i}
+		if ws != want {
+			t.Errorf("workspace[%d]: got %+v, want %+v", i, ws, want)
+		}
+	}
+	if peak := maxInFlight.Load(); peak > concurrency || peak < 2 {
+		t.Errorf("peak concurrent run lists = %d, want between 2 and %d", peak, concurrency)
+	}
+}
+
+func TestGetPendingRunsByWorkspaceConcurrentErrorCancels(t *testing.T) {
+	workspaces := []*tfe.Workspace{{ID: "ws-fail"}, {ID: "ws-1"}, {ID: "ws-2"}, {ID: "ws-3"}}
+	apiErr := errors.New("api failure")
+
+	var canceled atomic.Int32
+	var started sync.WaitGroup
+	started.Add(len(workspaces) - 1)
+	c := &Client{
+		agentPoolID: "apool-123",
+		agentPools: &mockAgentPools{
+			readWithOptionsFn: func(_ context.Context, _ string, _ *tfe.AgentPoolReadOptions) (*tfe.AgentPool, error) {
+				return &tfe.AgentPool{ID: "apool-123", Workspaces: workspaces}, nil
+			},
+		},
+		runs: &mockRuns{
+			listFn: func(ctx context.Context, wsID string, _ *tfe.RunListOptions) (*tfe.RunList, error) {
+				if wsID == "ws-fail" {
+					// Fail once the others are in flight.
+					started.Wait()
+					return nil, apiErr
+				}
+				// The others block until the failure cancels them.
+				started.Done()
+				select {
+				case <-ctx.Done():
+					canceled.Add(1)
+					return nil, ctx.Err()
+				case <-time.After(5 * time.Second):
+					return &tfe.RunList{}, nil
+				}
+			},
+		},
+		workspaceConcurrency: len(workspaces),
+	}
+
+	_, err := c.GetPendingRunsByWorkspace(context.Background())
+	if !errors.Is(err, apiErr) {
+		t.Fatalf("error = %v, want %v", err, apiErr)
+	}
+	if got := canceled.Load(); got != 3 {
+		t.Errorf("canceled run lists = %d, want 3", got)
+	}
+}
+
 func TestGetPendingRuns(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -391,3 +855,1229 @@ func TestGetPendingRuns(t *testing.T) {
 		})
 	}
 }
+
+func TestAgentListPageSize(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		want int
+	}{
+		{name: "default", want: DefaultPageSize},
+		{name: "configured", opts: []Option{WithPageSize(25)}, want: 25},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sizes []int
+			c := &Client{
+				agentPoolID: "apool-123",
+				agents: &mockAgents{
+					listFn: func(_ context.Context, _ string, opts *tfe.AgentListOptions) (*tfe.AgentList, error) {
+						sizes = append(sizes, opts.PageSize)
+						return &tfe.AgentList{Pagination: &tfe.Pagination{TotalPages: 1, CurrentPage: 1}}, nil
+					},
+				},
+			}
+			for _, opt := range tt.opts {
+				opt(c)
+			}
+
+			if _, err := c.GetAgentDetails(context.Background()); err != nil {
+				t.Fatalf("GetAgentDetails: unexpected error: %v", err)
+			}
+			if _, _, _, err := c.GetAgentPoolStatus(context.Background()); err != nil {
+				t.Fatalf("GetAgentPoolStatus: unexpected error: %v", err)
+			}
+			if want := []int{tt.want, tt.want}; !slices.Equal(sizes, want) {
+				t.Errorf("page sizes = %v, want %v", sizes, want)
+			}
+		})
+	}
+}
+
+func TestGetAgentPoolStatusRetriesTransientErrors(t *testing.T) {
+	calls := 0
+	c := &Client{
+		agentPoolID:    "apool-123",
+		maxRetries:     3,
+		retryBaseDelay: time.Millisecond,
+		agents: &mockAgents{
+			listFn: func(ctx context.Context, _ string, _ *tfe.AgentListOptions) (*tfe.AgentList, error) {
+				calls++
+				if calls <= 2 {
+					return nil, httpError(ctx, http.StatusServiceUnavailable)
+				}
+				return &tfe.AgentList{
+					Items: []*tfe.Agent{
+						{ID: "agent-1", Status: "busy"},
+						{ID: "agent-2", Status: "idle"},
+					},
+					Pagination: &tfe.Pagination{TotalPages: 1, CurrentPage: 1},
+				}, nil
+			},
+		},
+	}
+
+	busy, idle, total, err := c.GetAgentPoolStatus(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("List calls: got %d, want 3", calls)
+	}
+	if busy != 1 || idle != 1 || total != 2 {
+		t.Errorf("got busy=%d idle=%d total=%d, want 1/1/2", busy, idle, total)
+	}
+}
+
+func TestGetPendingRunsRetriesTransientErrors(t *testing.T) {
+	readCalls := 0
+	runCalls := 0
+	c := &Client{
+		agentPoolID:    "apool-123",
+		maxRetries:     2,
+		retryBaseDelay: time.Millisecond,
+		agentPools: &mockAgentPools{
+			readWithOptionsFn: func(ctx context.Context, _ string, _ *tfe.AgentPoolReadOptions) (*tfe.AgentPool, error) {
+				readCalls++
+				if readCalls <= 2 {
+					return nil, httpError(ctx, http.StatusTooManyRequests)
+				}
+				return &tfe.AgentPool{ID: "apool-123", Workspaces: []*tfe.Workspace{{ID: "ws-1"}}}, nil
+			},
+		},
+		runs: &mockRuns{
+			listFn: func(ctx context.Context, _ string, opts *tfe.RunListOptions) (*tfe.RunList, error) {
+				runCalls++
+				if runCalls == 1 {
+					return nil, httpError(ctx, http.StatusBadGateway)
+				}
+				items := []*tfe.Run{}
+				if opts.Status == planPendingStatuses {
+					items = append(items, &tfe.Run{ID: "run-1"}, &tfe.Run{ID: "run-2"})
+				}
+				return &tfe.RunList{
+					Items:      items,
+					Pagination: &tfe.Pagination{TotalPages: 1, CurrentPage: 1},
+				}, nil
+			},
+		},
+	}
+
+	count, err := c.GetPendingRuns(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if readCalls != 3 {
+		t.Errorf("ReadWithOptions calls: got %d, want 3", readCalls)
+	}
+	if count != 2 {
+		t.Errorf("got %d pending runs, want 2", count)
+	}
+}
+
+// httpError records status as the HTTP status of the current API attempt and
+// returns the error go-tfe reports for it.
+func httpError(ctx context.Context, status int) error {
+	recordStatus(ctx, status)
+	return fmt.Errorf("%d %s", status, http.StatusText(status))
+}
+
+func TestRetryExhausted(t *testing.T) {
+	calls := 0
+	c := &Client{
+		agentPoolID:    "apool-123",
+		maxRetries:     2,
+		retryBaseDelay: time.Millisecond,
+		agents: &mockAgents{
+			listFn: func(ctx context.Context, _ string, _ *tfe.AgentListOptions) (*tfe.AgentList, error) {
+				calls++
+				return nil, httpError(ctx, http.StatusInternalServerError)
+			},
+		},
+	}
+
+	_, _, _, err := c.GetAgentPoolStatus(context.Background())
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if calls != 3 {
+		t.Errorf("List calls: got %d, want 3 (1 attempt + 2 retries)", calls)
+	}
+}
+
+func TestRetrySkipsPermanentErrors(t *testing.T) {
+	calls := 0
+	c := &Client{
+		agentPoolID:    "apool-123",
+		maxRetries:     3,
+		retryBaseDelay: time.Millisecond,
+		agents: &mockAgents{
+			listFn: func(_ context.Context, _ string, _ *tfe.AgentListOptions) (*tfe.AgentList, error) {
+				calls++
+				return nil, tfe.ErrUnauthorized
+			},
+		},
+	}
+
+	_, _, _, err := c.GetAgentPoolStatus(context.Background())
+	if !errors.Is(err, tfe.ErrUnauthorized) {
+		t.Fatalf("expected ErrUnauthorized, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("List calls: got %d, want 1", calls)
+	}
+}
+
+func TestRetryByStatus(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       func(ctx context.Context) error
+		wantCalls int
+	}{
+		{name: "rate limited", err: func(ctx context.Context) error { return httpError(ctx, http.StatusTooManyRequests) }, wantCalls: 3},
+		{name: "server error", err: func(ctx context.Context) error { return httpError(ctx, http.StatusServiceUnavailable) }, wantCalls: 3},
+		{name: "network error", err: func(context.Context) error { return &net.OpError{Op: "dial", Err: errors.New("connection refused")} }, wantCalls: 3},
+		{name: "bad request", err: func(ctx context.Context) error { return httpError(ctx, http.StatusBadRequest) }, wantCalls: 1},
+		{name: "forbidden", err: func(ctx context.Context) error { return httpError(ctx, http.StatusForbidden) }, wantCalls: 1},
+		{name: "conflict", err: func(ctx context.Context) error { return httpError(ctx, http.StatusConflict) }, wantCalls: 1},
+		{name: "unprocessable", err: func(ctx context.Context) error { return httpError(ctx, http.StatusUnprocessableEntity) }, wantCalls: 1},
+		{name: "no response", err: func(context.Context) error { return errors.New("decoding response") }, wantCalls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			c := &Client{
+				agentPoolID:    "apool-123",
+				maxRetries:     2,
+				retryBaseDelay: time.Millisecond,
+				agents: &mockAgents{
+					listFn: func(ctx context.Context, _ string, _ *tfe.AgentListOptions) (*tfe.AgentList, error) {
+						calls++
+						return nil, tt.err(ctx)
+					},
+				},
+			}
+
+			if _, _, _, err := c.GetAgentPoolStatus(context.Background()); err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("List calls: got %d, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestStatusTransportRecordsStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	rec := &statusRecorder{}
+	req, err := http.NewRequestWithContext(context.WithValue(context.Background(), statusKey{}, rec), http.MethodGet, srv.URL, nil)
+	if err != nil {
+		t.Fatalf("creating request: %v", err)
+	}
+	resp, err := (&http.Client{Transport: statusTransport{base: http.DefaultTransport}}).Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+
+	if rec.status != http.StatusTooManyRequests {
+		t.Errorf("recorded status = %d, want %d", rec.status, http.StatusTooManyRequests)
+	}
+}
+
+func TestStatusTransportRecordsRetryAfter(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Retry-After", "3")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	rec := &statusRecorder{}
+	req, err := http.NewRequestWithContext(context.WithValue(context.Background(), statusKey{}, rec), http.MethodGet, srv.URL, nil)
+	if err != nil {
+		t.Fatalf("creating request: %v", err)
+	}
+	resp, err := (&http.Client{Transport: statusTransport{base: http.DefaultTransport}}).Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+
+	if !rec.rateLimited || rec.retryAfter != 3*time.Second {
+		t.Errorf("recorded rateLimited=%v retryAfter=%v, want true 3s", rec.rateLimited, rec.retryAfter)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		value string
+		want  time.Duration
+	}{
+		{name: "missing", value: "", want: 0},
+		{name: "seconds", value: "30", want: 30 * time.Second},
+		{name: "negative seconds", value: "-5", want: 0},
+		{name: "http date", value: now.Add(time.Minute).Format(http.TimeFormat), want: time.Minute},
+		{name: "past http date", value: now.Add(-time.Minute).Format(http.TimeFormat), want: 0},
+		{name: "malformed", value: "soon", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseRetryAfter(tt.value, now); got != tt.want {
+				t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+// rateLimitError records a rate-limited response asking to wait retryAfter
+// for the current API attempt and returns the error go-tfe reports for it.
+func rateLimitError(ctx context.Context, retryAfter time.Duration) error {
+	recordRateLimit(ctx, retryAfter)
+	return httpError(ctx, http.StatusTooManyRequests)
+}
+
+func TestRateLimitWaitsRetryAfter(t *testing.T) {
+	const retryAfter = 50 * time.Millisecond
+	calls := 0
+	rateLimited := 0
+	c := &Client{
+		agentPoolID:    "apool-123",
+		maxRetries:     1,
+		retryBaseDelay: time.Millisecond,
+		onRateLimited:  func() { rateLimited++ },
+		agents: &mockAgents{
+			listFn: func(ctx context.Context, _ string, _ *tfe.AgentListOptions) (*tfe.AgentList, error) {
+				calls++
+				if calls == 1 {
+					return nil, rateLimitError(ctx, retryAfter)
+				}
+				return &tfe.AgentList{Pagination: &tfe.Pagination{TotalPages: 1, CurrentPage: 1}}, nil
+			},
+		},
+	}
+
+	start := time.Now()
+	if _, _, _, err := c.GetAgentPoolStatus(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < retryAfter {
+		t.Errorf("retried after %v, want at least the Retry-After of %v", elapsed, retryAfter)
+	}
+	if calls != 2 {
+		t.Errorf("List calls: got %d, want 2", calls)
+	}
+	if rateLimited != 1 {
+		t.Errorf("rate limit hook calls: got %d, want 1", rateLimited)
+	}
+}
+
+func TestRateLimitHoldsBackLaterCalls(t *testing.T) {
+	const retryAfter = 50 * time.Millisecond
+	calls := 0
+	c := &Client{
+		agentPoolID: "apool-123",
+		agents: &mockAgents{
+			listFn: func(ctx context.Context, _ string, _ *tfe.AgentListOptions) (*tfe.AgentList, error) {
+				calls++
+				if calls == 1 {
+					return nil, rateLimitError(ctx, retryAfter)
+				}
+				return &tfe.AgentList{Pagination: &tfe.Pagination{TotalPages: 1, CurrentPage: 1}}, nil
+			},
+		},
+	}
+
+	start := time.Now()
+	if _, _, _, err := c.GetAgentPoolStatus(context.Background()); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if _, _, _, err := c.GetAgentPoolStatus(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < retryAfter {
+		t.Errorf("next call after %v, want at least the Retry-After of %v", elapsed, retryAfter)
+	}
+
+	// A canceled context stops the wait.
+	c.noteRateLimit(time.Hour)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, _, err := c.GetAgentPoolStatus(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("List calls: got %d, want 2", calls)
+	}
+}
+
+func TestRetryStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	c := &Client{
+		agentPoolID:    "apool-123",
+		maxRetries:     5,
+		retryBaseDelay: time.Hour,
+		agents: &mockAgents{
+			listFn: func(ctx context.Context, _ string, _ *tfe.AgentListOptions) (*tfe.AgentList, error) {
+				calls++
+				cancel()
+				return nil, httpError(ctx, http.StatusServiceUnavailable)
+			},
+		},
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, _, _, err := c.GetAgentPoolStatus(ctx)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("retry did not stop after context cancellation")
+	}
+	if calls != 1 {
+		t.Errorf("List calls: got %d, want 1", calls)
+	}
+}
+
+func TestRetryRespectsDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	calls := 0
+	c := &Client{
+		agentPoolID:    "apool-123",
+		maxRetries:     5,
+		retryBaseDelay: time.Second,
+		agents: &mockAgents{
+			listFn: func(ctx context.Context, _ string, _ *tfe.AgentListOptions) (*tfe.AgentList, error) {
+				calls++
+				return nil, httpError(ctx, http.StatusServiceUnavailable)
+			},
+		},
+	}
+
+	start := time.Now()
+	_, _, _, err := c.GetAgentPoolStatus(ctx)
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("retry waited %v despite deadline", elapsed)
+	}
+	if calls != 1 {
+		t.Errorf("List calls: got %d, want 1", calls)
+	}
+}
+
+func TestGetPoolLimit(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		want int
+	}{
+		{name: "no limit configured", want: 0},
+		{name: "configured limit", opts: []Option{WithAgentLimit(50)}, want: 50},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Client{agentPoolID: "apool-123"}
+			for _, opt := range tt.opts {
+				opt(c)
+			}
+
+			got, err := c.GetPoolLimit(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("GetPoolLimit: got %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetRunConcurrency(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		want int
+	}{
+		{name: "no limit configured", want: 0},
+		{name: "configured limit", opts: []Option{WithRunConcurrency(5)}, want: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Client{agentPoolID: "apool-123"}
+			for _, opt := range tt.opts {
+				opt(c)
+			}
+
+			got, err := c.GetRunConcurrency(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("GetRunConcurrency: got %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithOrganization(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{name: "not configured", want: ""},
+		{name: "configured", opts: []Option{WithOrganization("my-org")}, want: "my-org"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Client{agentPoolID: "apool-123"}
+			for _, opt := range tt.opts {
+				opt(c)
+			}
+
+			if got := c.Organization(); got != tt.want {
+				t.Errorf("Organization: got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		org     string
+		pool    *tfe.AgentPool
+		err     error
+		wantErr bool
+		wantIs  error
+	}{
+		{name: "ok", pool: &tfe.AgentPool{ID: "apool-123"}},
+		{
+			name: "matching organization",
+			org:  "my-org",
+			pool: &tfe.AgentPool{ID: "apool-123", Organization: &tfe.Organization{Name: "my-org"}},
+		},
+		{name: "unauthorized", err: tfe.ErrUnauthorized, wantErr: true, wantIs: tfe.ErrUnauthorized},
+		{name: "pool not found", err: tfe.ErrResourceNotFound, wantErr: true, wantIs: tfe.ErrResourceNotFound},
+		{
+			name:    "other organization",
+			org:     "my-org",
+			pool:    &tfe.AgentPool{ID: "apool-123", Organization: &tfe.Organization{Name: "other-org"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Client{
+				agentPoolID:  "apool-123",
+				organization: tt.org,
+				agentPools: &mockAgentPools{
+					readWithOptionsFn: func(_ context.Context, _ string, _ *tfe.AgentPoolReadOptions) (*tfe.AgentPool, error) {
+						return tt.pool, tt.err
+					},
+				},
+			}
+
+			err := c.Validate(context.Background())
+			if !tt.wantErr {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if tt.wantIs != nil && !errors.Is(err, tt.wantIs) {
+				t.Errorf("error: got %v, want %v", err, tt.wantIs)
+			}
+		})
+	}
+}
+
+func TestResolveAgentPoolID(t *testing.T) {
+	// Two pages of pools whose names all contain "ci": the query matches by
+	// substring, so only the exact name resolves.
+	pages := map[int]*tfe.AgentPoolList{
+		1: {
+			Pagination: &tfe.Pagination{CurrentPage: 1, NextPage: 2, TotalPages: 2},
+			Items: []*tfe.AgentPool{
+				{ID: "apool-1", Name: "ci-large"},
+				{ID: "apool-2", Name: "ci-small"},
+			},
+		},
+		2: {
+			Pagination: &tfe.Pagination{CurrentPage: 2, TotalPages: 2},
+			Items:      []*tfe.AgentPool{{ID: "apool-3", Name: "ci"}},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		org     string
+		pool    string
+		listErr error
+		want    string
+		wantErr bool
+		wantIs  error
+	}{
+		{name: "first page", org: "my-org", pool: "ci-small", want: "apool-2"},
+		{name: "later page", org: "my-org", pool: "ci", want: "apool-3"},
+		{name: "not found", org: "my-org", pool: "ci-medium", wantErr: true, wantIs: ErrPoolNotFound},
+		{name: "list error", org: "my-org", pool: "ci", listErr: tfe.ErrUnauthorized, wantErr: true, wantIs: tfe.ErrUnauthorized},
+		{name: "no organization", pool: "ci", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var queries []string
+			lister := &mockAgentPoolLister{
+				listFn: func(_ context.Context, organization string, options *tfe.AgentPoolListOptions) (*tfe.AgentPoolList, error) {
+					if organization != "my-org" {
+						t.Errorf("organization = %q, want my-org", organization)
+					}
+					queries = append(queries, options.Query)
+					if tt.listErr != nil {
+						return nil, tt.listErr
+					}
+					return pages[cmp.Or(options.PageNumber, 1)], nil
+				},
+			}
+			c := &Client{organization: tt.org, agentPoolName: tt.pool}
+
+			got, err := c.resolveAgentPoolID(context.Background(), lister)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got ID %q", got)
+				}
+				if tt.wantIs != nil && !errors.Is(err, tt.wantIs) {
+					t.Errorf("error: got %v, want %v", err, tt.wantIs)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ID = %q, want %q", got, tt.want)
+			}
+			for _, q := range queries {
+				if q != tt.pool {
+					t.Errorf("query = %q, want %q", q, tt.pool)
+				}
+			}
+		})
+	}
+}
+
+func TestPoolNotFound(t *testing.T) {
+	notFound := func() *Client {
+		return &Client{
+			agentPoolID: "apool-missing",
+			maxRetries:  2,
+			agentPools: &mockAgentPools{
+				readWithOptionsFn: func(_ context.Context, _ string, _ *tfe.AgentPoolReadOptions) (*tfe.AgentPool, error) {
+					return nil, tfe.ErrResourceNotFound
+				},
+			},
+			agents: &mockAgents{
+				listFn: func(_ context.Context, _ string, _ *tfe.AgentListOptions) (*tfe.AgentList, error) {
+					return nil, tfe.ErrResourceNotFound
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name string
+		call func(c *Client) error
+	}{
+		{name: "GetAgentPoolStatus", call: func(c *Client) error {
+			_, _, _, err := c.GetAgentPoolStatus(context.Background())
+			return err
+		}},
+		{name: "GetAgentDetails", call: func(c *Client) error {
+			_, err := c.GetAgentDetails(context.Background())
+			return err
+		}},
+		{name: "GetPendingRuns", call: func(c *Client) error {
+			_, err := c.GetPendingRuns(context.Background())
+			return err
+		}},
+		{name: "Validate", call: func(c *Client) error {
+			return c.Validate(context.Background())
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call(notFound())
+			if !errors.Is(err, ErrPoolNotFound) {
+				t.Errorf("error: got %v, want ErrPoolNotFound", err)
+			}
+			if !errors.Is(err, tfe.ErrResourceNotFound) {
+				t.Errorf("error: got %v, want original 404 kept in chain", err)
+			}
+		})
+	}
+
+	t.Run("other errors are not mapped", func(t *testing.T) {
+		c := &Client{
+			agentPoolID: "apool-123",
+			agents: &mockAgents{
+				listFn: func(_ context.Context, _ string, _ *tfe.AgentListOptions) (*tfe.AgentList, error) {
+					return nil, tfe.ErrUnauthorized
+				},
+			},
+		}
+		_, _, _, err := c.GetAgentPoolStatus(context.Background())
+		if err == nil || errors.Is(err, ErrPoolNotFound) {
+			t.Errorf("error: got %v, want a non-ErrPoolNotFound error", err)
+		}
+	})
+
+	t.Run("missing workspace runs are not a missing pool", func(t *testing.T) {
+		c := &Client{
+			agentPoolID: "apool-123",
+			agentPools: &mockAgentPools{
+				readWithOptionsFn: func(_ context.Context, _ string, _ *tfe.AgentPoolReadOptions) (*tfe.AgentPool, error) {
+					return &tfe.AgentPool{ID: "apool-123", Workspaces: []*tfe.Workspace{{ID: "ws-1"}}}, nil
+				},
+			},
+			runs: &mockRuns{
+				listFn: func(_ context.Context, _ string, _ *tfe.RunListOptions) (*tfe.RunList, error) {
+					return nil, tfe.ErrResourceNotFound
+				},
+			},
+		}
+		_, err := c.GetPendingRuns(context.Background())
+		if err == nil || errors.Is(err, ErrPoolNotFound) {
+			t.Errorf("error: got %v, want a non-ErrPoolNotFound error", err)
+		}
+	})
+}
+
+func TestGetPendingRunsCustomStatuses(t *testing.T) {
+	tests := []struct {
+		name             string
+		plan             []string
+		apply            []string
+		wantPlanFilter   string
+		wantApplyFilter  string
+		wantPlanPending  int
+		wantApplyPending int
+	}{
+		{
+			name:             "defaults",
+			wantPlanFilter:   "pending,plan_queued",
+			wantApplyFilter:  "apply_queued",
+			wantPlanPending:  2,
+			wantApplyPending: 1,
+		},
+		{
+			name:             "awaiting confirmation counts as apply demand",
+			apply:            []string{"apply_queued", "cost_estimated", "policy_checked"},
+			wantPlanFilter:   "pending,plan_queued",
+			wantApplyFilter:  "apply_queued,cost_estimated,policy_checked",
+			wantPlanPending:  2,
+			wantApplyPending: 4,
+		},
+		{
+			name:             "custom plan statuses",
+			plan:             []string{"plan_queued"},
+			wantPlanFilter:   "plan_queued",
+			wantApplyFilter:  "apply_queued",
+			wantPlanPending:  1,
+			wantApplyPending: 1,
+		},
+	}
+
+	// Runs returned for each status filter.
+	runsPerFilter := map[string]int{
+		"pending,plan_queued": 2,
+		"plan_queued":         1,
+		"apply_queued":        1,
+		"apply_queued,cost_estimated,policy_checked": 4,
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var filters []string
+			c := &Client{
+				agentPoolID: "apool-123",
+				agentPools: &mockAgentPools{
+					readWithOptionsFn: func(_ context.Context, _ string, _ *tfe.AgentPoolReadOptions) (*tfe.AgentPool, error) {
+						return &tfe.AgentPool{ID: "apool-123", Workspaces: []*tfe.Workspace{{ID: "ws-1"}}}, nil
+					},
+				},
+				runs: &mockRuns{
+					listFn: func(_ context.Context, _ string, opts *tfe.RunListOptions) (*tfe.RunList, error) {
+						filters = append(filters, opts.Status)
+						items := make([]*tfe.Run, runsPerFilter[opts.Status])
+						return &tfe.RunList{
+							Items:      items,
+							Pagination: &tfe.Pagination{TotalPages: 1, CurrentPage: 1},
+						}, nil
+					},
+				},
+			}
+			WithPendingStatuses(tt.plan, tt.apply)(c)
+
+			counts, err := c.GetPendingRunsByType(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if want := []string{tt.wantPlanFilter, tt.wantApplyFilter}; !slices.Equal(filters, want) {
+				t.Errorf("status filters = %q, want %q", filters, want)
+			}
+			if counts.PlanPending != tt.wantPlanPending || counts.ApplyPending != tt.wantApplyPending {
+				t.Errorf("counts = %+v, want plan=%d apply=%d", counts, tt.wantPlanPending, tt.wantApplyPending)
+			}
+		})
+	}
+}
+
+func TestGetPendingRunsSpeculative(t *testing.T) {
+	runs := []*tfe.Run{
+		{ID: "run-1", PlanOnly: true},
+		{ID: "run-2"},
+		{ID: "run-3", PlanOnly: true},
+		{ID: "run-4", IsDestroy: true},
+	}
+
+	tests := []struct {
+		name string
+		opts []Option
+		want int
+	}{
+		{name: "included by default", want: 4},
+		{name: "included", opts: []Option{WithSpeculativeRuns(true)}, want: 4},
+		{name: "excluded", opts: []Option{WithSpeculativeRuns(false)}, want: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Client{
+				agentPoolID: "apool-123",
+				agentPools: &mockAgentPools{
+					readWithOptionsFn: func(_ context.Context, _ string, _ *tfe.AgentPoolReadOptions) (*tfe.AgentPool, error) {
+						return &tfe.AgentPool{ID: "apool-123", Workspaces: []*tfe.Workspace{{ID: "ws-1"}}}, nil
+					},
+				},
+				runs: &mockRuns{
+					listFn: func(_ context.Context, _ string, opts *tfe.RunListOptions) (*tfe.RunList, error) {
+						items := runs
+						if opts.Status != planPendingStatuses {
+							items = nil
+						}
+						return &tfe.RunList{
+							Items:      items,
+							Pagination: &tfe.Pagination{TotalPages: 1, CurrentPage: 1},
+						}, nil
+					},
+				},
+			}
+			for _, opt := range tt.opts {
+				opt(c)
+			}
+
+			got, err := c.GetPendingRuns(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("GetPendingRuns: got %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsRunStatus(t *testing.T) {
+	tests := []struct {
+		status string
+		want   bool
+	}{
+		{status: "pending", want: true},
+		{status: "cost_estimated", want: true},
+		{status: "policy_checked", want: true},
+		{status: "apply_queued", want: true},
+		{status: "Pending", want: false},
+		{status: "queued", want: false},
+		{status: "", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := IsRunStatus(tt.status); got != tt.want {
+			t.Errorf("IsRunStatus(%q) = %v, want %v", tt.status, got, tt.want)
+		}
+	}
+}
+
+func TestNewUserAgent(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{name: "default", want: DefaultUserAgent},
+		{name: "empty override", opts: []Option{WithUserAgent("")}, want: DefaultUserAgent},
+		{name: "override", opts: []Option{WithUserAgent("tfc-agent-autoscaler/v1.2.3")}, want: "tfc-agent-autoscaler/v1.2.3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *tfe.Config
+			orig := newTFEClient
+			newTFEClient = func(cfg *tfe.Config) (*tfe.Client, error) {
+				got = cfg
+				return &tfe.Client{}, nil
+			}
+			t.Cleanup(func() { newTFEClient = orig })
+
+			if _, err := New(context.Background(), "token", "https://tfe.example.com", "apool-123", tt.opts...); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if ua := got.Headers.Get("User-Agent"); ua != tt.want {
+				t.Errorf("User-Agent = %q, want %q", ua, tt.want)
+			}
+			if got.Token != "token" || got.Address != "https://tfe.example.com" {
+				t.Errorf("config = {Token: %q, Address: %q}, want token and address passed through", got.Token, got.Address)
+			}
+		})
+	}
+}
+
+func TestGetPendingRunsByTypeOldest(t *testing.T) {
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	c := &Client{
+		agentPoolID: "apool-123",
+		agentPools: &mockAgentPools{
+			readWithOptionsFn: func(_ context.Context, _ string, _ *tfe.AgentPoolReadOptions) (*tfe.AgentPool, error) {
+				return &tfe.AgentPool{ID: "apool-123", Workspaces: []*tfe.Workspace{{ID: "ws-1"}, {ID: "ws-2"}}}, nil
+			},
+		},
+		runs: &mockRuns{
+			listFn: func(_ context.Context, wsID string, opts *tfe.RunListOptions) (*tfe.RunList, error) {
+				var items []*tfe.Run
+				switch {
+				case wsID == "ws-1" && opts.Status == planPendingStatuses:
+					items = []*tfe.Run{
+						// Plan queued 10m ago, though created 30m ago.
+						{Status: tfe.RunPlanQueued, CreatedAt: base.Add(-30 * time.Minute), StatusTimestamps: &tfe.RunStatusTimestamps{PlanQueuedAt: base.Add(-10 * time.Minute)}},
+						{Status: tfe.RunPending, CreatedAt: base.Add(-5 * time.Minute)},
+					}
+				case wsID == "ws-2" && opts.Status == planPendingStatuses:
+					items = []*tfe.Run{{Status: tfe.RunPending, CreatedAt: base.Add(-20 * time.Minute)}}
+				case wsID == "ws-2" && opts.Status == applyPendingStatuses:
+					// Without a status timestamp, the creation time is used.
+					items = []*tfe.Run{{Status: tfe.RunApplyQueued, CreatedAt: base.Add(-40 * time.Minute)}}
+				}
+				return &tfe.RunList{Items: items, Pagination: &tfe.Pagination{TotalPages: 1, CurrentPage: 1}}, nil
+			},
+		},
+	}
+
+	got, err := c.GetPendingRunsByType(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := PendingRunCounts{
+		PlanPending:   3,
+		ApplyPending:  1,
+		OldestPlanAt:  base.Add(-20 * time.Minute),
+		OldestApplyAt: base.Add(-40 * time.Minute),
+	}
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	if oldest := got.Oldest(); !oldest.Equal(want.OldestApplyAt) {
+		t.Errorf("Oldest() = %v, want %v", oldest, want.OldestApplyAt)
+	}
+	if oldest := (PendingRunCounts{}).Oldest(); !oldest.IsZero() {
+		t.Errorf("Oldest() with nothing pending = %v, want zero", oldest)
+	}
+}
+
+func TestGetPendingRunsPoolQueue(t *testing.T) {
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	pool := &tfe.AgentPool{
+		ID:           "apool-123",
+		Name:         "ecs-agents",
+		Organization: &tfe.Organization{Name: "acme"},
+		Workspaces: []*tfe.Workspace{
+			{ID: "ws-1", Name: "network", TagNames: []string{"prod"}},
+			{ID: "ws-2", Name: "app"},
+			{ID: "ws-3", Name: "idle"},
+		},
+	}
+	// Runs on the pool, including one in a workspace since moved off it.
+	runs := []*tfe.Run{
+		{Status: tfe.RunPending, CreatedAt: base.Add(-5 * time.Minute), Workspace: &tfe.Workspace{ID: "ws-1"}},
+		{Status: tfe.RunPlanQueued, CreatedAt: base.Add(-30 * time.Minute), StatusTimestamps: &tfe.RunStatusTimestamps{PlanQueuedAt: base.Add(-10 * time.Minute)}, Workspace: &tfe.Workspace{ID: "ws-1"}},
+		{Status: tfe.RunPending, PlanOnly: true, CreatedAt: base.Add(-2 * time.Minute), Workspace: &tfe.Workspace{ID: "ws-2"}},
+		{Status: tfe.RunApplyQueued, CreatedAt: base.Add(-40 * time.Minute), Workspace: &tfe.Workspace{ID: "ws-2"}},
+		{Status: tfe.RunApplyQueued, CreatedAt: base.Add(-50 * time.Minute), Workspace: &tfe.Workspace{ID: "ws-1"}},
+		{Status: tfe.RunPending, CreatedAt: base.Add(-time.Hour), Workspace: &tfe.Workspace{ID: "ws-other"}},
+	}
+	inStatuses := func(run *tfe.Run, statuses string) bool {
+		return slices.Contains(strings.Split(statuses, ","), string(run.Status))
+	}
+
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{name: "all workspaces"},
+		{name: "workspace tags", opts: []Option{WithWorkspaceTags([]string{"prod"})}},
+		{name: "speculative excluded", opts: []Option{WithSpeculativeRuns(false)}},
+		{name: "custom statuses", opts: []Option{WithPendingStatuses([]string{"pending"}, []string{"apply_queued"})}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var workspaceCalls, orgCalls int
+			newClient := func(opts ...Option) *Client {
+				c := &Client{
+					agentPoolID: "apool-123",
+					agentPools: &mockAgentPools{
+						readWithOptionsFn: func(_ context.Context, _ string, _ *tfe.AgentPoolReadOptions) (*tfe.AgentPool, error) {
+							return pool, nil
+						},
+					},
+					runs: &mockRuns{
+						listFn: func(_ context.Context, wsID string, opts *tfe.RunListOptions) (*tfe.RunList, error) {
+							workspaceCalls++
+							var items []*tfe.Run
+							for _, run := range runs {
+								if run.Workspace.ID == wsID && inStatuses(run, opts.Status) {
+									items = append(items, run)
+								}
+							}
+							return &tfe.RunList{Items: items, Pagination: &tfe.Pagination{TotalPages: 1, CurrentPage: 1}}, nil
+						},
+					},
+					orgRuns: &mockOrgRuns{
+						listForOrganizationFn: func(_ context.Context, org string, opts *tfe.RunListForOrganizationOptions) (*tfe.OrganizationRunList, error) {
+							orgCalls++
+							if org != "acme" || opts.AgentPoolNames != "ecs-agents" {
+								return nil, fmt.Errorf("unexpected listing for org %q pool %q", org, opts.AgentPoolNames)
+							}
+							var items []*tfe.Run
+							for _, run := range runs {
+								if inStatuses(run, opts.Status) {
+									items = append(items, run)
+								}
+							}
+							// Serve one run per page to exercise pagination.
+							page := max(opts.PageNumber, 1)
+							if page > len(items) {
+								return &tfe.OrganizationRunList{PaginationNextPrev: &tfe.PaginationNextPrev{CurrentPage: page}}, nil
+							}
+							next := 0
+							if page < len(items) {
+								next = page + 1
+							}
+							return &tfe.OrganizationRunList{
+								Items:              items[page-1 : page],
+								PaginationNextPrev: &tfe.PaginationNextPrev{CurrentPage: page, NextPage: next},
+							}, nil
+						},
+					},
+				}
+				for _, opt := range opts {
+					opt(c)
+				}
+				return c
+			}
+
+			want, err := newClient(tt.opts...).GetPendingRunsByWorkspace(context.Background())
+			if err != nil {
+				t.Fatalf("per-workspace: unexpected error: %v", err)
+			}
+			got, err := newClient(append(tt.opts, WithPoolQueue(true))...).GetPendingRunsByWorkspace(context.Background())
+			if err != nil {
+				t.Fatalf("pool queue: unexpected error: %v", err)
+			}
+			if !slices.Equal(got, want) {
+				t.Errorf("pool queue counts = %+v, want %+v", got, want)
+			}
+			if workspaceCalls != 2*len(want) {
+				t.Errorf("per-workspace run listings = %d, want %d", workspaceCalls, 2*len(want))
+			}
+			if orgCalls == 0 {
+				t.Error("pool queue made no organization run listings")
+			}
+		})
+	}
+}
+
+func TestGetPendingRunsPoolQueueNeedsOrganization(t *testing.T) {
+	c := &Client{
+		agentPoolID: "apool-123",
+		agentPools: &mockAgentPools{
+			readWithOptionsFn: func(_ context.Context, _ string, _ *tfe.AgentPoolReadOptions) (*tfe.AgentPool, error) {
+				return &tfe.AgentPool{ID: "apool-123", Name: "ecs-agents"}, nil
+			},
+		},
+		usePoolQueue: true,
+	}
+
+	if _, err := c.GetPendingRuns(context.Background()); err == nil {
+		t.Fatal("expected error without an organization, got nil")
+	}
+}
+
+func TestGetPendingRunsDetailed(t *testing.T) {
+	runsByWorkspace := map[string][]*tfe.Run{
+		"ws-1": {
+			{Status: tfe.RunPending},
+			{Status: tfe.RunPlanQueued},
+			{Status: tfe.RunPlanQueued},
+			{Status: tfe.RunApplyQueued},
+		},
+		"ws-2": {
+			{Status: tfe.RunPending, PlanOnly: true},
+			{Status: tfe.RunPlanQueued},
+		},
+	}
+
+	tests := []struct {
+		name              string
+		opts              []Option
+		wantCounts        PendingRunCounts
+		wantPlanByStatus  map[string]int
+		wantApplyByStatus map[string]int
+	}{
+		{
+			name:              "all runs",
+			wantCounts:        PendingRunCounts{PlanPending: 5, ApplyPending: 1},
+			wantPlanByStatus:  map[string]int{"pending": 2, "plan_queued": 3},
+			wantApplyByStatus: map[string]int{"apply_queued": 1},
+		},
+		{
+			name:              "speculative excluded",
+			opts:              []Option{WithSpeculativeRuns(false)},
+			wantCounts:        PendingRunCounts{PlanPending: 4, ApplyPending: 1},
+			wantPlanByStatus:  map[string]int{"pending": 1, "plan_queued": 3},
+			wantApplyByStatus: map[string]int{"apply_queued": 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Client{
+				agentPoolID: "apool-123",
+				agentPools: &mockAgentPools{
+					readWithOptionsFn: func(_ context.Context, _ string, _ *tfe.AgentPoolReadOptions) (*tfe.AgentPool, error) {
+						return &tfe.AgentPool{
+							ID:         "apool-123",
+							Workspaces: []*tfe.Workspace{{ID: "ws-1"}, {ID: "ws-2"}},
+						}, nil
+					},
+				},
+				runs: &mockRuns{
+					listFn: func(_ context.Context, wsID string, opts *tfe.RunListOptions) (*tfe.RunList, error) {
+						var items []*tfe.Run
+						for _, run := range runsByWorkspace[wsID] {
+							if slices.Contains(strings.Split(opts.Status, ","), string(run.Status)) {
+								items = append(items, run)
+							}
+						}
+						return &tfe.RunList{Items: items, Pagination: &tfe.Pagination{TotalPages: 1, CurrentPage: 1}}, nil
+					},
+				},
+			}
+			for _, opt := range tt.opts {
+				opt(c)
+			}
+
+			got, err := c.GetPendingRunsDetailed(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.PendingRunCounts != tt.wantCounts {
+				t.Errorf("counts = %+v, want %+v", got.PendingRunCounts, tt.wantCounts)
+			}
+			if !maps.Equal(got.PlanByStatus, tt.wantPlanByStatus) {
+				t.Errorf("plan by status = %v, want %v", got.PlanByStatus, tt.wantPlanByStatus)
+			}
+			if !maps.Equal(got.ApplyByStatus, tt.wantApplyByStatus) {
+				t.Errorf("apply by status = %v, want %v", got.ApplyByStatus, tt.wantApplyByStatus)
+			}
+		})
+	}
+}
+
+func TestGetPendingRunsDetailedPoolQueue(t *testing.T) {
+	runs := []*tfe.Run{
+		{Status: tfe.RunPending, Workspace: &tfe.Workspace{ID: "ws-1"}},
+		{Status: tfe.RunPlanQueued, Workspace: &tfe.Workspace{ID: "ws-1"}},
+		{Status: tfe.RunPlanQueued, Workspace: &tfe.Workspace{ID: "ws-2"}},
+		{Status: tfe.RunApplyQueued, Workspace: &tfe.Workspace{ID: "ws-2"}},
+		{Status: tfe.RunPlanQueued, Workspace: &tfe.Workspace{ID: "ws-other"}},
+	}
+
+	c := &Client{
+		agentPoolID: "apool-123",
+		agentPools: &mockAgentPools{
+			readWithOptionsFn: func(_ context.Context, _ string, _ *tfe.AgentPoolReadOptions) (*tfe.AgentPool, error) {
+				return &tfe.AgentPool{
+					ID:           "apool-123",
+					Name:         "ecs-agents",
+					Organization: &tfe.Organization{Name: "acme"},
+					Workspaces:   []*tfe.Workspace{{ID: "ws-1"}, {ID: "ws-2"}},
+				}, nil
+			},
+		},
+		orgRuns: &mockOrgRuns{
+			listForOrganizationFn: func(_ context.Context, _ string, opts *tfe.RunListForOrganizationOptions) (*tfe.OrganizationRunList, error) {
+				var items []*tfe.Run
+				for _, run := range runs {
+					if slices.Contains(strings.Split(opts.Status, ","), string(run.Status)) {
+						items = append(items, run)
+					}
+				}
+				return &tfe.OrganizationRunList{Items: items}, nil
+			},
+		},
+	}
+	WithPoolQueue(true)(c)
+
+	got, err := c.GetPendingRunsDetailed(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := (PendingRunCounts{PlanPending: 3, ApplyPending: 1}); got.PendingRunCounts != want {
+		t.Errorf("counts = %+v, want %+v", got.PendingRunCounts, want)
+	}
+	if want := map[string]int{"pending": 1, "plan_queued": 2}; !maps.Equal(got.PlanByStatus, want) {
+		t.Errorf("plan by status = %v, want %v", got.PlanByStatus, want)
+	}
+	if want := map[string]int{"apply_queued": 1}; !maps.Equal(got.ApplyByStatus, want) {
+		t.Errorf("apply by status = %v, want %v", got.ApplyByStatus, want)
+	}
+}
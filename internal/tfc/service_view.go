@@ -3,21 +3,44 @@ package tfc
 import (
 	"context"
 	"fmt"
+	"strings"
+	"time"
 )
 
-// RunType identifies whether a ServiceView handles plan or apply runs.
+// RunType identifies whether a ServiceView handles plan runs, apply runs, or
+// both.
 type RunType int
 
-// RunTypePlan and RunTypeApply distinguish plan-only vs apply-only service views.
+// RunTypePlan and RunTypeApply distinguish plan-only vs apply-only service
+// views. RunTypeAll counts both, for a service that runs every job itself.
 const (
 	RunTypePlan RunType = iota
 	RunTypeApply
+	RunTypeAll
 )
 
+// runTypeNames are the names ParseRunType accepts, by RunType.
+var runTypeNames = map[string]RunType{
+	"plan":  RunTypePlan,
+	"apply": RunTypeApply,
+}
+
+// ParseRunType returns the RunType called name: "plan" or "apply".
+func ParseRunType(name string) (RunType, error) {
+	runType, ok := runTypeNames[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown run type %q", name)
+	}
+	return runType, nil
+}
+
 // ServiceViewClient is the subset of Client that ServiceView needs.
 type ServiceViewClient interface {
 	GetAgentDetails(ctx context.Context) ([]AgentInfo, error)
 	GetPendingRunsByType(ctx context.Context) (PendingRunCounts, error)
+	GetPendingRunsDetailed(ctx context.Context) (PendingRunDetail, error)
+	GetPendingRunsByWorkspace(ctx context.Context) ([]WorkspacePendingRuns, error)
+	GetPoolLimit(ctx context.Context) (int, error)
 }
 
 // TaskIPsFunc returns the set of private IPs belonging to an ECS service's tasks.
@@ -29,15 +52,50 @@ type ServiceView struct {
 	client  ServiceViewClient
 	runType RunType
 	taskIPs TaskIPsFunc
+
+	// agentNamePrefix, when set, additionally requires agent names to start
+	// with this prefix.
+	agentNamePrefix string
+
+	// preceding are the views whose matching agents this view leaves out.
+	preceding []*ServiceView
+}
+
+// ServiceViewOption configures optional behavior for ServiceView.
+type ServiceViewOption func(*ServiceView)
+
+// WithAgentNamePrefix restricts the view to agents whose name starts with
+// prefix. It is combined with the task IP filter, so an agent must match
+// both. This guards against misattribution when task IPs are reused.
+func WithAgentNamePrefix(prefix string) ServiceViewOption {
+	return func(sv *ServiceView) {
+		sv.agentNamePrefix = prefix
+	}
+}
+
+// WithPrecedence leaves out agents that any of views also matches, so the
+// views of services sharing an agent pool are mutually exclusive: an agent
+// whose IP belongs to two services' tasks for a moment, e.g. while a task is
+// recycled, counts only for the view that takes precedence.
+func WithPrecedence(views ...*ServiceView) ServiceViewOption {
+	return func(sv *ServiceView) {
+		sv.preceding = views
+	}
 }
 
 // NewServiceView creates a ServiceView that filters by run type and task IPs.
-func NewServiceView(client ServiceViewClient, runType RunType, taskIPs TaskIPsFunc) *ServiceView {
-	return &ServiceView{
+func NewServiceView(client ServiceViewClient, runType RunType, taskIPs TaskIPsFunc, opts ...ServiceViewOption) *ServiceView {
+	sv := &ServiceView{
 		client:  client,
 		runType: runType,
 		taskIPs: taskIPs,
 	}
+
+	for _, opt := range opts {
+		opt(sv)
+	}
+
+	return sv
 }
 
 // GetPendingRuns returns the pending run count for this service's run type.
@@ -52,11 +110,90 @@ func (sv *ServiceView) GetPendingRuns(ctx context.Context) (int, error) {
 		return counts.PlanPending, nil
 	case RunTypeApply:
 		return counts.ApplyPending, nil
+	case RunTypeAll:
+		return counts.Total(), nil
 	default:
 		return 0, fmt.Errorf("unknown run type: %d", sv.runType)
 	}
 }
 
+// GetPendingRunsByType returns pending run counts restricted to this
+// service's run type. The count and oldest queued time for the other run type
+// are zeroed.
+func (sv *ServiceView) GetPendingRunsByType(ctx context.Context) (PendingRunCounts, error) {
+	service, _, err := sv.GetPendingRunsBreakdown(ctx)
+	return service.PendingRunCounts, err
+}
+
+// GetPendingRunsDetailed returns pending run counts by type and status
+// restricted to this service's run type. Counts, statuses, and the oldest
+// queued time for the other run type are zeroed.
+func (sv *ServiceView) GetPendingRunsDetailed(ctx context.Context) (PendingRunDetail, error) {
+	service, _, err := sv.GetPendingRunsBreakdown(ctx)
+	return service, err
+}
+
+// GetPendingRunsBreakdown returns this service's pending run counts, as
+// GetPendingRunsDetailed does, alongside the pool-wide counts for both run
+// types, from a single query.
+func (sv *ServiceView) GetPendingRunsBreakdown(ctx context.Context) (service PendingRunDetail, pool PendingRunCounts, err error) {
+	detail, err := sv.client.GetPendingRunsDetailed(ctx)
+	if err != nil {
+		return PendingRunDetail{}, PendingRunCounts{}, fmt.Errorf("getting pending runs by type: %w", err)
+	}
+
+	service = detail
+	switch sv.runType {
+	case RunTypePlan:
+		service.ApplyPending = 0
+		service.OldestApplyAt = time.Time{}
+		service.ApplyByStatus = nil
+	case RunTypeApply:
+		service.PlanPending = 0
+		service.OldestPlanAt = time.Time{}
+		service.PlanByStatus = nil
+	case RunTypeAll:
+	default:
+		return PendingRunDetail{}, PendingRunCounts{}, fmt.Errorf("unknown run type: %d", sv.runType)
+	}
+
+	return service, detail.PendingRunCounts, nil
+}
+
+// GetPendingRunsByWorkspace returns per-workspace pending counts restricted
+// to this service's run type. Counts and oldest queued times for the other run
+// type are zeroed.
+func (sv *ServiceView) GetPendingRunsByWorkspace(ctx context.Context) ([]WorkspacePendingRuns, error) {
+	workspaces, err := sv.client.GetPendingRunsByWorkspace(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting pending runs by workspace: %w", err)
+	}
+
+	filtered := make([]WorkspacePendingRuns, 0, len(workspaces))
+	for _, ws := range workspaces {
+		switch sv.runType {
+		case RunTypePlan:
+			ws.ApplyPending = 0
+			ws.OldestApplyAt = time.Time{}
+		case RunTypeApply:
+			ws.PlanPending = 0
+			ws.OldestPlanAt = time.Time{}
+		case RunTypeAll:
+		default:
+			return nil, fmt.Errorf("unknown run type: %d", sv.runType)
+		}
+		filtered = append(filtered, ws)
+	}
+
+	return filtered, nil
+}
+
+// GetPoolLimit returns the underlying pool's agent limit. The limit applies to
+// the whole pool, so it is not divided between services.
+func (sv *ServiceView) GetPoolLimit(ctx context.Context) (int, error) {
+	return sv.client.GetPoolLimit(ctx)
+}
+
 // GetAgentPoolStatus returns busy, idle, total counts for agents whose IPs
 // match this service's ECS tasks.
 func (sv *ServiceView) GetAgentPoolStatus(ctx context.Context) (busy, idle, total int, err error) {
@@ -78,6 +215,16 @@ func (sv *ServiceView) GetAgentPoolStatus(ctx context.Context) (busy, idle, tota
 	return busy, idle, total, nil
 }
 
+// GetAgentStatusCounts returns the number of this service's agents in each
+// status, like Client.GetAgentStatusCounts.
+func (sv *ServiceView) GetAgentStatusCounts(ctx context.Context) (map[string]int, error) {
+	agents, err := sv.filteredAgents(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return countAgentStatuses(agents), nil
+}
+
 // GetAgentDetails returns agent details filtered to agents whose IPs
 // match this service's ECS tasks.
 func (sv *ServiceView) GetAgentDetails(ctx context.Context) ([]AgentInfo, error) {
@@ -94,13 +241,41 @@ func (sv *ServiceView) filteredAgents(ctx context.Context) ([]AgentInfo, error)
 	if err != nil {
 		return nil, fmt.Errorf("getting task IPs: %w", err)
 	}
+	precedingIPs := make([]map[string]bool, len(sv.preceding))
+	for i, other := range sv.preceding {
+		if precedingIPs[i], err = other.taskIPs(ctx); err != nil {
+			return nil, fmt.Errorf("getting task IPs of a preceding service: %w", err)
+		}
+	}
 
 	var filtered []AgentInfo
+	seen := make(map[string]bool, len(allAgents))
 	for _, agent := range allAgents {
-		if ips[agent.IP] {
-			filtered = append(filtered, agent)
+		if !sv.matches(agent, ips) || seen[agent.ID] {
+			continue
+		}
+		claimed := false
+		for i, other := range sv.preceding {
+			if other.matches(agent, precedingIPs[i]) {
+				claimed = true
+				break
+			}
+		}
+		if claimed {
+			continue
 		}
+		// An agent listed twice is only counted once.
+		if agent.ID != "" {
+			seen[agent.ID] = true
+		}
+		filtered = append(filtered, agent)
 	}
 
 	return filtered, nil
 }
+
+// matches reports whether agent belongs to this view's service, given the
+// service's task IPs.
+func (sv *ServiceView) matches(agent AgentInfo, ips map[string]bool) bool {
+	return ips[agent.IP] && strings.HasPrefix(agent.Name, sv.agentNamePrefix)
+}
@@ -3,7 +3,9 @@ package tfc
 import (
 	"context"
 	"errors"
+	"maps"
 	"testing"
+	"time"
 )
 
 func TestServiceViewGetPendingRuns(t *testing.T) {
@@ -25,6 +27,12 @@ func TestServiceViewGetPendingRuns(t *testing.T) {
 			counts:   PendingRunCounts{PlanPending: 5, ApplyPending: 3},
 			wantRuns: 3,
 		},
+		{
+			name:     "all type returns total pending",
+			runType:  RunTypeAll,
+			counts:   PendingRunCounts{PlanPending: 5, ApplyPending: 3},
+			wantRuns: 8,
+		},
 		{
 			name:     "zero counts",
 			runType:  RunTypePlan,
@@ -52,6 +60,130 @@ func TestServiceViewGetPendingRuns(t *testing.T) {
 	}
 }
 
+func TestParseRunType(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    RunType
+		wantErr bool
+	}{
+		{name: "plan", want: RunTypePlan},
+		{name: "apply", want: RunTypeApply},
+		{name: "destroy", wantErr: true},
+		{name: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRunType(tt.name)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServiceViewGetPendingRunsByType(t *testing.T) {
+	planAt := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	applyAt := planAt.Add(-time.Hour)
+	tests := []struct {
+		name    string
+		runType RunType
+		want    PendingRunCounts
+	}{
+		{name: "plan view zeroes apply", runType: RunTypePlan, want: PendingRunCounts{PlanPending: 5, OldestPlanAt: planAt}},
+		{name: "apply view zeroes plan", runType: RunTypeApply, want: PendingRunCounts{ApplyPending: 3, OldestApplyAt: applyAt}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sv := NewServiceView(&mockServiceViewClient{
+				pendingRunsByTypeFn: func(_ context.Context) (PendingRunCounts, error) {
+					return PendingRunCounts{PlanPending: 5, ApplyPending: 3, OldestPlanAt: planAt, OldestApplyAt: applyAt}, nil
+				},
+			}, tt.runType, nil)
+
+			got, err := sv.GetPendingRunsByType(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServiceViewGetPendingRunsBreakdown(t *testing.T) {
+	detail := PendingRunDetail{
+		PendingRunCounts: PendingRunCounts{PlanPending: 5, ApplyPending: 3},
+		PlanByStatus:     map[string]int{"pending": 2, "plan_queued": 3},
+		ApplyByStatus:    map[string]int{"apply_queued": 3},
+	}
+	tests := []struct {
+		name              string
+		runType           RunType
+		wantCounts        PendingRunCounts
+		wantPlanByStatus  map[string]int
+		wantApplyByStatus map[string]int
+	}{
+		{
+			name:             "plan view",
+			runType:          RunTypePlan,
+			wantCounts:       PendingRunCounts{PlanPending: 5},
+			wantPlanByStatus: map[string]int{"pending": 2, "plan_queued": 3},
+		},
+		{
+			name:              "apply view",
+			runType:           RunTypeApply,
+			wantCounts:        PendingRunCounts{ApplyPending: 3},
+			wantApplyByStatus: map[string]int{"apply_queued": 3},
+		},
+		{
+			name:              "all view",
+			runType:           RunTypeAll,
+			wantCounts:        PendingRunCounts{PlanPending: 5, ApplyPending: 3},
+			wantPlanByStatus:  map[string]int{"pending": 2, "plan_queued": 3},
+			wantApplyByStatus: map[string]int{"apply_queued": 3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sv := NewServiceView(&mockServiceViewClient{
+				pendingRunsDetailedFn: func(_ context.Context) (PendingRunDetail, error) {
+					return detail, nil
+				},
+			}, tt.runType, nil)
+
+			service, pool, err := sv.GetPendingRunsBreakdown(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if service.PendingRunCounts != tt.wantCounts {
+				t.Errorf("service = %+v, want %+v", service.PendingRunCounts, tt.wantCounts)
+			}
+			if !maps.Equal(service.PlanByStatus, tt.wantPlanByStatus) {
+				t.Errorf("plan by status = %v, want %v", service.PlanByStatus, tt.wantPlanByStatus)
+			}
+			if !maps.Equal(service.ApplyByStatus, tt.wantApplyByStatus) {
+				t.Errorf("apply by status = %v, want %v", service.ApplyByStatus, tt.wantApplyByStatus)
+			}
+			if pool != detail.PendingRunCounts {
+				t.Errorf("pool = %+v, want %+v", pool, detail.PendingRunCounts)
+			}
+		})
+	}
+}
+
 func TestServiceViewGetAgentPoolStatus(t *testing.T) {
 	allAgents := []AgentInfo{
 		{ID: "a1", IP: "10.0.0.1", Status: "busy"},
@@ -90,6 +222,104 @@ func TestServiceViewGetAgentPoolStatus(t *testing.T) {
 	}
 }
 
+func TestServiceViewPrecedence(t *testing.T) {
+	client := &mockServiceViewClient{
+		agentDetailsFn: func(_ context.Context) ([]AgentInfo, error) {
+			return []AgentInfo{
+				{ID: "a1", IP: "10.0.0.1", Status: "busy"},
+				// 10.0.0.2 is briefly in both services' task sets while a
+				// task is recycled.
+				{ID: "a2", IP: "10.0.0.2", Status: "idle"},
+				{ID: "a3", IP: "10.0.1.1", Status: "busy"},
+				// Listed twice by the API.
+				{ID: "a3", IP: "10.0.1.1", Status: "busy"},
+			}, nil
+		},
+	}
+	ipsFunc := func(ips ...string) TaskIPsFunc {
+		return func(_ context.Context) (map[string]bool, error) {
+			set := make(map[string]bool, len(ips))
+			for _, ip := range ips {
+				set[ip] = true
+			}
+			return set, nil
+		}
+	}
+
+	regular := NewServiceView(client, RunTypeApply, ipsFunc("10.0.0.1", "10.0.0.2"))
+	spot := NewServiceView(client, RunTypePlan, ipsFunc("10.0.0.2", "10.0.1.1"),
+		WithPrecedence(regular),
+	)
+
+	tests := []struct {
+		name      string
+		view      *ServiceView
+		wantBusy  int
+		wantIdle  int
+		wantTotal int
+	}{
+		{name: "preceding view keeps the shared agent", view: regular, wantBusy: 1, wantIdle: 1, wantTotal: 2},
+		{name: "later view leaves it out", view: spot, wantBusy: 1, wantIdle: 0, wantTotal: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			busy, idle, total, err := tt.view.GetAgentPoolStatus(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if busy != tt.wantBusy || idle != tt.wantIdle || total != tt.wantTotal {
+				t.Errorf("busy/idle/total: got %d/%d/%d, want %d/%d/%d", busy, idle, total, tt.wantBusy, tt.wantIdle, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestServiceViewPrecedenceTaskIPsError(t *testing.T) {
+	client := &mockServiceViewClient{
+		agentDetailsFn: func(_ context.Context) ([]AgentInfo, error) {
+			return []AgentInfo{{ID: "a1", IP: "10.0.0.1", Status: "busy"}}, nil
+		},
+	}
+	regular := NewServiceView(client, RunTypeApply, func(_ context.Context) (map[string]bool, error) {
+		return nil, errors.New("ecs unavailable")
+	})
+	spot := NewServiceView(client, RunTypePlan, func(_ context.Context) (map[string]bool, error) {
+		return map[string]bool{"10.0.0.1": true}, nil
+	}, WithPrecedence(regular))
+
+	if _, _, _, err := spot.GetAgentPoolStatus(context.Background()); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestServiceViewGetAgentStatusCounts(t *testing.T) {
+	allAgents := []AgentInfo{
+		{ID: "a1", IP: "10.0.0.1", Status: "busy"},
+		{ID: "a2", IP: "10.0.0.2", Status: "errored"},
+		{ID: "a3", IP: "10.0.0.3", Status: "errored"},
+		{ID: "a4", IP: "10.0.0.4", Status: "idle"},
+	}
+
+	// Only 10.0.0.1 and 10.0.0.3 belong to this service's tasks.
+	sv := NewServiceView(&mockServiceViewClient{
+		agentDetailsFn: func(_ context.Context) ([]AgentInfo, error) {
+			return allAgents, nil
+		},
+	}, RunTypePlan, func(_ context.Context) (map[string]bool, error) {
+		return map[string]bool{"10.0.0.1": true, "10.0.0.3": true}, nil
+	})
+
+	got, err := sv.GetAgentStatusCounts(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]int{"busy": 1, "idle": 0, "unknown": 0, "errored": 1, "exited": 0}
+	if !maps.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
 func TestServiceViewGetAgentPoolStatusAllIdle(t *testing.T) {
 	allAgents := []AgentInfo{
 		{ID: "a1", IP: "10.0.0.1", Status: "idle"},
@@ -193,10 +423,135 @@ func TestServiceViewGetAgentPoolStatusNoMatchingIPs(t *testing.T) {
 	}
 }
 
+func TestServiceViewAgentNamePrefix(t *testing.T) {
+	allAgents := []AgentInfo{
+		{ID: "a1", Name: "spot-1", IP: "10.0.0.1", Status: "busy"},
+		{ID: "a2", Name: "regular-1", IP: "10.0.0.2", Status: "busy"},
+		{ID: "a3", Name: "spot-2", IP: "10.0.0.3", Status: "idle"},
+		{ID: "a4", Name: "spot-3", IP: "10.0.0.9", Status: "idle"},
+	}
+
+	// 10.0.0.2 was recycled from a spot task to a regular agent; the name
+	// filter must drop it even though the IP still matches.
+	taskIPs := map[string]bool{
+		"10.0.0.1": true,
+		"10.0.0.2": true,
+		"10.0.0.3": true,
+	}
+
+	tests := []struct {
+		name    string
+		opts    []ServiceViewOption
+		wantIDs []string
+	}{
+		{
+			name:    "IP filter only",
+			wantIDs: []string{"a1", "a2", "a3"},
+		},
+		{
+			name:    "IP and name prefix combined",
+			opts:    []ServiceViewOption{WithAgentNamePrefix("spot-")},
+			wantIDs: []string{"a1", "a3"},
+		},
+		{
+			name:    "prefix matching nothing",
+			opts:    []ServiceViewOption{WithAgentNamePrefix("batch-")},
+			wantIDs: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sv := NewServiceView(&mockServiceViewClient{
+				agentDetailsFn: func(_ context.Context) ([]AgentInfo, error) {
+					return allAgents, nil
+				},
+			}, RunTypePlan, func(_ context.Context) (map[string]bool, error) {
+				return taskIPs, nil
+			}, tt.opts...)
+
+			agents, err := sv.GetAgentDetails(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(agents) != len(tt.wantIDs) {
+				t.Fatalf("got %d agents %+v, want %v", len(agents), agents, tt.wantIDs)
+			}
+			for i, id := range tt.wantIDs {
+				if agents[i].ID != id {
+					t.Errorf("agent[%d] = %s, want %s", i, agents[i].ID, id)
+				}
+			}
+		})
+	}
+}
+
+func TestServiceViewGetPendingRunsByWorkspace(t *testing.T) {
+	workspaces := []WorkspacePendingRuns{
+		{WorkspaceID: "ws-1", WorkspaceName: "network", PlanPending: 2, ApplyPending: 1},
+		{WorkspaceID: "ws-2", WorkspaceName: "compute", PlanPending: 0, ApplyPending: 3},
+	}
+
+	tests := []struct {
+		name    string
+		runType RunType
+		want    []WorkspacePendingRuns
+	}{
+		{
+			name:    "plan view zeroes apply counts",
+			runType: RunTypePlan,
+			want: []WorkspacePendingRuns{
+				{WorkspaceID: "ws-1", WorkspaceName: "network", PlanPending: 2},
+				{WorkspaceID: "ws-2", WorkspaceName: "compute"},
+			},
+		},
+		{
+			name:    "apply view zeroes plan counts",
+			runType: RunTypeApply,
+			want: []WorkspacePendingRuns{
+				{WorkspaceID: "ws-1", WorkspaceName: "network", ApplyPending: 1},
+				{WorkspaceID: "ws-2", WorkspaceName: "compute", ApplyPending: 3},
+			},
+		},
+		{
+			name:    "all view keeps both counts",
+			runType: RunTypeAll,
+			want:    workspaces,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sv := NewServiceView(&mockServiceViewClient{
+				pendingRunsByWorkspaceFn: func(_ context.Context) ([]WorkspacePendingRuns, error) {
+					return workspaces, nil
+				},
+			}, tt.runType, nil)
+
+			got, err := sv.GetPendingRunsByWorkspace(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d workspaces, want %d", len(got), len(tt.want))
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("workspace[%d]: got %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
 // mockServiceViewClient is used by ServiceView tests to mock the underlying Client methods.
 type mockServiceViewClient struct {
-	agentDetailsFn      func(ctx context.Context) ([]AgentInfo, error)
-	pendingRunsByTypeFn func(ctx context.Context) (PendingRunCounts, error)
+	agentDetailsFn           func(ctx context.Context) ([]AgentInfo, error)
+	pendingRunsByTypeFn      func(ctx context.Context) (PendingRunCounts, error)
+	pendingRunsDetailedFn    func(ctx context.Context) (PendingRunDetail, error)
+	pendingRunsByWorkspaceFn func(ctx context.Context) ([]WorkspacePendingRuns, error)
+	poolLimit                int
 }
 
 func (m *mockServiceViewClient) GetAgentDetails(ctx context.Context) ([]AgentInfo, error) {
@@ -206,3 +561,33 @@ func (m *mockServiceViewClient) GetAgentDetails(ctx context.Context) ([]AgentInf
 func (m *mockServiceViewClient) GetPendingRunsByType(ctx context.Context) (PendingRunCounts, error) {
 	return m.pendingRunsByTypeFn(ctx)
 }
+
+// GetPendingRunsDetailed falls back to pendingRunsByTypeFn, without status
+// counts, when pendingRunsDetailedFn is unset.
+func (m *mockServiceViewClient) GetPendingRunsDetailed(ctx context.Context) (PendingRunDetail, error) {
+	if m.pendingRunsDetailedFn != nil {
+		return m.pendingRunsDetailedFn(ctx)
+	}
+	counts, err := m.pendingRunsByTypeFn(ctx)
+	return PendingRunDetail{PendingRunCounts: counts}, err
+}
+
+func (m *mockServiceViewClient) GetPendingRunsByWorkspace(ctx context.Context) ([]WorkspacePendingRuns, error) {
+	return m.pendingRunsByWorkspaceFn(ctx)
+}
+
+func (m *mockServiceViewClient) GetPoolLimit(_ context.Context) (int, error) {
+	return m.poolLimit, nil
+}
+
+func TestServiceViewGetPoolLimit(t *testing.T) {
+	sv := NewServiceView(&mockServiceViewClient{poolLimit: 25}, RunTypePlan, nil)
+
+	got, err := sv.GetPoolLimit(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != 25 {
+		t.Errorf("GetPoolLimit: got %d, want 25", got)
+	}
+}
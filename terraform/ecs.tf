@@ -47,7 +47,7 @@ resource "aws_ecs_task_definition" "autoscaler" {
       { name = "TFC_AGENT_POOL_ID", value = var.tfc_agent_pool_id },
       { name = "TFC_ORG", value = var.tfc_org },
       { name = "TFE_ADDRESS", value = var.tfc_address },
-      { name = "ECS_CLUSTER", value = aws_ecs_cluster.main.name },
+      { name = "ECS_CLUSTER", value = aws_ecs_cluster.main.arn },
       { name = "ECS_SERVICE", value = aws_ecs_service.tfc_agent.name },
       { name = "MIN_AGENTS", value = tostring(var.min_agents) },
       { name = "MAX_AGENTS", value = tostring(var.max_agents) },
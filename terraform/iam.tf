@@ -65,6 +65,7 @@ resource "aws_iam_role_policy" "task_ecs" {
         "ecs:ListTasks",
         "ecs:DescribeTasks",
         "ecs:UpdateTaskProtection",
+        "ecs:StopTask",
       ]
       Resource = "*"
       Condition = {